@@ -37,6 +37,7 @@ type App struct {
 	Metrics             metrics.Metrics
 	passOPTIONS         bool
 	maxErrorsInResponse int
+	pairPath            string
 	shutdown            chan os.Signal
 	mw                  []web.Middleware
 	storedSpecs         storage.DBOpenAPILoader
@@ -44,7 +45,7 @@ type App struct {
 }
 
 // NewApp creates an App value that handle a set of routes for the set of application.
-func NewApp(lock *sync.RWMutex, passOPTIONS bool, maxErrorsInResponse int, storedSpecs storage.DBOpenAPILoader, shutdown chan os.Signal, logger zerolog.Logger, pMetrics metrics.Metrics, mw ...web.Middleware) *App {
+func NewApp(lock *sync.RWMutex, passOPTIONS bool, maxErrorsInResponse int, pairPath string, storedSpecs storage.DBOpenAPILoader, shutdown chan os.Signal, logger zerolog.Logger, pMetrics metrics.Metrics, mw ...web.Middleware) *App {
 
 	schemaIDs := storedSpecs.SchemaIDs()
 
@@ -64,6 +65,7 @@ func NewApp(lock *sync.RWMutex, passOPTIONS bool, maxErrorsInResponse int, store
 		lock:                lock,
 		passOPTIONS:         passOPTIONS,
 		maxErrorsInResponse: maxErrorsInResponse,
+		pairPath:            pairPath,
 	}
 
 	return &app
@@ -191,6 +193,34 @@ func (a *App) APIModeMainHandler(ctx *fasthttp.RequestCtx) {
 	// Delete internal header
 	ctx.Request.Header.Del(web.XWallarmSchemaIDHeader)
 
+	// Replace the request by the request/response pair to be validated
+	isPair := a.pairPath != "" && ctx.IsPost() && strconv.B2S(ctx.Path()) == a.pairPath
+	if isPair {
+		if err := setValidationPair(ctx); err != nil {
+			a.Metrics.IncErrorTypeCounter("request parsing error", 0)
+
+			a.Log.Error().
+				Err(err).
+				Bytes("host", ctx.Request.Header.Host()).
+				Bytes("path", ctx.Path()).
+				Bytes("method", ctx.Request.Header.Method()).
+				Interface("request_id", ctx.UserValue(web.RequestID)).
+				Msg("error while parsing request/response pair")
+
+			if err := web.RespondError(ctx, fasthttp.StatusBadRequest, ""); err != nil {
+				a.Log.Error().
+					Err(err).
+					Bytes("host", ctx.Request.Header.Host()).
+					Bytes("path", ctx.Path()).
+					Bytes("method", ctx.Request.Header.Method()).
+					Interface("request_id", ctx.UserValue(web.RequestID)).
+					Msg("error while sending response")
+			}
+
+			return
+		}
+	}
+
 	a.lock.RLock()
 	defer a.lock.RUnlock()
 
@@ -248,6 +278,11 @@ func (a *App) APIModeMainHandler(ctx *fasthttp.RequestCtx) {
 		}
 	}
 
+	// the validated response should not be sent back
+	if isPair {
+		ctx.Response.Reset()
+	}
+
 	responseSummary := make([]*validator.ValidationResponseSummary, 0, len(schemaIDs))
 	responseErrors := make([]*validator.ValidationError, 0)
 
@@ -256,6 +291,9 @@ func (a *App) APIModeMainHandler(ctx *fasthttp.RequestCtx) {
 		if statusCode, ok := ctx.UserValue(web.GlobalResponseStatusCodeKey).(int); ok {
 			ctx.Response.Header.Reset()
 			ctx.Response.Header.SetStatusCode(statusCode)
+			if retryAfter, ok := ctx.UserValue(web.GlobalResponseRetryAfterKey).(string); ok {
+				ctx.Response.Header.Set(fasthttp.HeaderRetryAfter, retryAfter)
+			}
 			return
 		}
 
@@ -26,7 +26,7 @@ type RequestValidator struct {
 	SchemaID      int
 }
 
-// Handler validates request and respond with 200, 403 (with error) or 500 status code
+// Handler validates request and/or response according to the validation target and respond with 200, 403 (with error) or 500 status code
 func (s *RequestValidator) Handler(ctx *fasthttp.RequestCtx) error {
 
 	// handle panic
@@ -57,18 +57,47 @@ func (s *RequestValidator) Handler(ctx *fasthttp.RequestCtx) error {
 		return nil
 	}
 
-	validationErrors, err := apiMode.APIModeValidateRequest(ctx, s.Metrics, s.SchemaID, s.ParserPool, s.CustomRoute, s.Cfg.UnknownParametersDetection)
-	if err != nil {
-		s.Log.Error().
-			Err(err).
-			Interface("request_id", ctx.UserValue(web.RequestID)).
-			Bytes("host", ctx.Request.Header.Host()).
-			Bytes("path", ctx.Path()).
-			Bytes("method", ctx.Request.Header.Method()).
-			Msg("request validation error")
+	var validationErrors []*validator.ValidationError
+	target := validator.GetValidationTarget(ctx)
+
+	if target&validator.TargetRequest != 0 {
+		// the request body exceeding the JSON limits is not parsed
+		limitsErrors, limitExceeded := s.jsonLimitsErrors(ctx)
+		validationErrors = append(validationErrors, limitsErrors...)
+
+		if !limitExceeded {
+			requestErrors, err := apiMode.APIModeValidateRequest(ctx, s.Metrics, s.SchemaID, s.ParserPool, s.CustomRoute, s.Cfg.UnknownParametersDetection)
+			if err != nil {
+				s.Log.Error().
+					Err(err).
+					Interface("request_id", ctx.UserValue(web.RequestID)).
+					Bytes("host", ctx.Request.Header.Host()).
+					Bytes("path", ctx.Path()).
+					Bytes("method", ctx.Request.Header.Method()).
+					Msg("request validation error")
+
+				ctx.SetUserValue(keyStatusCode, fasthttp.StatusInternalServerError)
+				return nil
+			}
+			validationErrors = append(validationErrors, requestErrors...)
+		}
+	}
 
-		ctx.SetUserValue(keyStatusCode, fasthttp.StatusInternalServerError)
-		return nil
+	if target&validator.TargetResponse != 0 {
+		responseErrors, err := apiMode.APIModeValidateResponse(ctx, s.Metrics, s.SchemaID, s.ParserPool, s.CustomRoute)
+		if err != nil {
+			s.Log.Error().
+				Err(err).
+				Interface("request_id", ctx.UserValue(web.RequestID)).
+				Bytes("host", ctx.Request.Header.Host()).
+				Bytes("path", ctx.Path()).
+				Bytes("method", ctx.Request.Header.Method()).
+				Msg("response validation error")
+
+			ctx.SetUserValue(keyStatusCode, fasthttp.StatusInternalServerError)
+			return nil
+		}
+		validationErrors = append(validationErrors, responseErrors...)
 	}
 
 	// Respond 403 with errors
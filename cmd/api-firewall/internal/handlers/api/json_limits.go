@@ -0,0 +1,48 @@
+package api
+
+import (
+	"io"
+	"strings"
+
+	"github.com/savsgio/gotils/strconv"
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/platform/jsonlimits"
+	"github.com/wallarm/api-firewall/internal/platform/web"
+	"github.com/wallarm/api-firewall/pkg/APIMode/validator"
+)
+
+// jsonLimitsErrors checks the structural limits of the JSON request body and
+// returns the validation errors of the exceeded limit and the duplicate keys.
+// The duplicate keys are only logged in the LOG_ONLY mode. True is returned
+// if the limit is exceeded and the request body should not be validated
+func (s *RequestValidator) jsonLimitsErrors(ctx *fasthttp.RequestCtx) ([]*validator.ValidationError, bool) {
+	if !jsonlimits.Enabled(&s.Cfg.JSONLimits) || !jsonlimits.IsJSON(strconv.B2S(ctx.Request.Header.ContentType())) {
+		return nil, false
+	}
+
+	// the decompression errors are reported by the request validation
+	bodyReader, err := web.GetDecompressedRequestBody(&ctx.Request, strconv.B2S(ctx.Request.Header.ContentEncoding()))
+	if err != nil {
+		return nil, false
+	}
+	body, err := io.ReadAll(bodyReader)
+	if err != nil {
+		return nil, false
+	}
+
+	duplicates, limitErr := jsonlimits.Check(body, &s.Cfg.JSONLimits)
+
+	if len(duplicates) > 0 && !strings.EqualFold(s.Cfg.JSONLimits.DuplicateKeys, web.ValidationBlock) {
+		s.Log.Debug().
+			Strs("duplicate_keys", duplicates).
+			Interface("request_id", ctx.UserValue(web.RequestID)).
+			Bytes("host", ctx.Request.Header.Host()).
+			Bytes("path", ctx.Path()).
+			Bytes("method", ctx.Request.Header.Method()).
+			Msg("request body contains duplicate keys")
+		duplicates = nil
+	}
+
+	return jsonlimits.ValidationErrors(limitErr, duplicates), limitErr != nil
+}
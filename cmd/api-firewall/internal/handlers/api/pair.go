@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/pkg/APIMode/validator"
+)
+
+var (
+	errPairRequestMissed = errors.New("request method and uri are required")
+	errPairNothingToDo   = errors.New("neither request nor response should be validated")
+)
+
+// validationPair is the request/response pair which is validated against the
+// specification. The response is validated only if it is set. The request is
+// validated unless the validate_request is false
+type validationPair struct {
+	Request         pairRequest   `json:"request"`
+	Response        *pairResponse `json:"response"`
+	ValidateRequest *bool         `json:"validate_request"`
+}
+
+type pairRequest struct {
+	Method  string              `json:"method"`
+	URI     string              `json:"uri"`
+	Headers map[string][]string `json:"headers"`
+	Body    string              `json:"body"`
+}
+
+type pairResponse struct {
+	StatusCode int                 `json:"status_code"`
+	Headers    map[string][]string `json:"headers"`
+	Body       string              `json:"body"`
+}
+
+// setValidationPair replaces the request of the context by the request of the
+// pair from the request body and sets the response of the pair. The
+// validation target is saved in the context
+func setValidationPair(ctx *fasthttp.RequestCtx) error {
+
+	var pair validationPair
+	if err := json.Unmarshal(ctx.PostBody(), &pair); err != nil {
+		return fmt.Errorf("request/response pair parsing: %w", err)
+	}
+
+	if pair.Request.Method == "" || pair.Request.URI == "" {
+		return errPairRequestMissed
+	}
+
+	var target validator.ValidationTarget
+	if pair.ValidateRequest == nil || *pair.ValidateRequest {
+		target |= validator.TargetRequest
+	}
+	if pair.Response != nil {
+		target |= validator.TargetResponse
+	}
+
+	if target == 0 {
+		return errPairNothingToDo
+	}
+
+	ctx.Request.Reset()
+	ctx.Request.Header.SetMethod(pair.Request.Method)
+	ctx.Request.SetRequestURI(pair.Request.URI)
+	ctx.Request.SetBodyString(pair.Request.Body)
+
+	for name, values := range pair.Request.Headers {
+		for _, v := range values {
+			ctx.Request.Header.Add(name, v)
+		}
+	}
+
+	if pair.Response != nil {
+		statusCode := pair.Response.StatusCode
+		if statusCode == 0 {
+			statusCode = fasthttp.StatusOK
+		}
+
+		ctx.Response.Reset()
+		ctx.Response.Header.SetNoDefaultContentType(true)
+		ctx.Response.SetStatusCode(statusCode)
+		ctx.Response.SetBodyString(pair.Response.Body)
+
+		for name, values := range pair.Response.Headers {
+			for _, v := range values {
+				ctx.Response.Header.Add(name, v)
+			}
+		}
+	}
+
+	ctx.SetUserValue(validator.APIModeValidationTargetKey, target)
+
+	return nil
+}
@@ -1,12 +1,15 @@
 package api
 
 import (
+	"context"
 	"net/url"
 	"os"
 	"os/signal"
 	"path"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/ardanlabs/conf"
 	"github.com/pkg/errors"
@@ -15,8 +18,11 @@ import (
 
 	"github.com/wallarm/api-firewall/internal/config"
 	"github.com/wallarm/api-firewall/internal/platform/allowiplist"
+	"github.com/wallarm/api-firewall/internal/platform/formats"
 	"github.com/wallarm/api-firewall/internal/platform/metrics"
+	"github.com/wallarm/api-firewall/internal/platform/ratelimit"
 	"github.com/wallarm/api-firewall/internal/platform/storage"
+	"github.com/wallarm/api-firewall/internal/platform/tracing"
 	"github.com/wallarm/api-firewall/internal/version"
 )
 
@@ -62,6 +68,15 @@ func Run(logger zerolog.Logger) error {
 	// buffered channel so the goroutine can exit if we don't collect this error.
 	serverErrors := make(chan error, 1)
 
+	// init the string formats before the specifications are loaded
+	if err := formats.Configure(&cfg.StringFormats); err != nil {
+		return errors.Wrap(err, "string formats init error")
+	}
+
+	if cfg.StringFormats.Validation {
+		logger.Info().Msgf("%s: String formats validation is enabled: %d custom formats", logPrefix, len(cfg.StringFormats.Custom))
+	}
+
 	// load spec from the database
 	specStorage, err := storage.NewOpenAPIDB(cfg.PathToSpecDB, cfg.DBVersion)
 	if err != nil {
@@ -101,11 +116,46 @@ func Run(logger zerolog.Logger) error {
 		logger.Info().Msgf("%s: Loaded %d Whitelisted IP's to the cache", logPrefix, allowedIPCache.ElementsNum)
 	}
 
+	// =========================================================================
+	// Init Rate Limiter
+
+	rateLimiter, err := ratelimit.NewRateLimiter(&cfg.RateLimit)
+	if err != nil {
+		return errors.Wrap(err, "rate limiter init error")
+	}
+
+	switch rateLimiter {
+	case nil:
+		logger.Info().Msgf("%s: Rate limits are not configured", logPrefix)
+	default:
+		logger.Info().Msgf("%s: Rate limits are configured: %d requests per %s by %s, %d endpoint-specific limits", logPrefix, cfg.RateLimit.Requests, cfg.RateLimit.Period, cfg.RateLimit.Key, len(cfg.RateLimit.Endpoints))
+	}
+
 	// =========================================================================
 	// Init ZeroLogger
 
 	zeroLogger := &config.ZerologAdapter{Logger: logger}
 
+	// =========================================================================
+	// Init Tracing
+
+	shutdownTracing, err := tracing.Init(&cfg.Tracing, logger)
+	if err != nil {
+		return errors.Wrap(err, "tracing init error")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error().Err(err).Msgf("%s: tracing shutdown", logPrefix)
+		}
+	}()
+
+	if cfg.Tracing.Enabled {
+		logger.Info().Msgf("%s: OpenTelemetry tracing is enabled: %s exporter", logPrefix, strings.ToUpper(cfg.Tracing.Exporter))
+	}
+
 	// =========================================================================
 	// Init Metrics
 
@@ -133,7 +183,7 @@ func Run(logger zerolog.Logger) error {
 	// =========================================================================
 	// Init Handlers
 
-	requestHandlers := Handlers(&dbLock, &cfg, shutdown, logger, metricsController, specStorage, allowedIPCache, waf)
+	requestHandlers := Handlers(&dbLock, &cfg, shutdown, logger, metricsController, specStorage, allowedIPCache, waf, rateLimiter)
 
 	// =========================================================================
 	// Start Health API Service
@@ -223,7 +273,7 @@ func Run(logger zerolog.Logger) error {
 
 	updSpecErrors := make(chan error, 1)
 
-	updOpenAPISpec := NewHandlerUpdater(&dbLock, logger, metricsController, specStorage, &cfg, &api, shutdown, &healthData, allowedIPCache, waf)
+	updOpenAPISpec := NewHandlerUpdater(&dbLock, logger, metricsController, specStorage, &cfg, &api, shutdown, &healthData, allowedIPCache, waf, rateLimiter)
 
 	// disable updater if SpecificationUpdatePeriod == 0
 	if cfg.SpecificationUpdatePeriod.Seconds() > 0 {
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"os"
 	"runtime/debug"
 	"sync"
@@ -14,6 +15,7 @@ import (
 	"github.com/wallarm/api-firewall/internal/config"
 	"github.com/wallarm/api-firewall/internal/platform/allowiplist"
 	"github.com/wallarm/api-firewall/internal/platform/metrics"
+	"github.com/wallarm/api-firewall/internal/platform/ratelimit"
 	"github.com/wallarm/api-firewall/internal/platform/router"
 	"github.com/wallarm/api-firewall/internal/platform/storage"
 	"github.com/wallarm/api-firewall/internal/platform/storage/updater"
@@ -35,12 +37,14 @@ type Specification struct {
 	shutdown       chan os.Signal
 	health         *Health
 	lock           *sync.RWMutex
+	updateLock     sync.Mutex
 	allowedIPCache *allowiplist.AllowedIPsType
 	metrics        metrics.Metrics
+	rateLimiter    *ratelimit.RateLimiter
 }
 
 // NewHandlerUpdater function defines configuration updater controller
-func NewHandlerUpdater(lock *sync.RWMutex, logger zerolog.Logger, metrics metrics.Metrics, sqlLiteStorage storage.DBOpenAPILoader, cfg *config.APIMode, api *fasthttp.Server, shutdown chan os.Signal, health *Health, allowedIPCache *allowiplist.AllowedIPsType, waf coraza.WAF) updater.Updater {
+func NewHandlerUpdater(lock *sync.RWMutex, logger zerolog.Logger, metrics metrics.Metrics, sqlLiteStorage storage.DBOpenAPILoader, cfg *config.APIMode, api *fasthttp.Server, shutdown chan os.Signal, health *Health, allowedIPCache *allowiplist.AllowedIPsType, waf coraza.WAF, rateLimiter *ratelimit.RateLimiter) updater.Updater {
 	return &Specification{
 		logger:         logger,
 		waf:            waf,
@@ -54,6 +58,7 @@ func NewHandlerUpdater(lock *sync.RWMutex, logger zerolog.Logger, metrics metric
 		lock:           lock,
 		allowedIPCache: allowedIPCache,
 		metrics:        metrics,
+		rateLimiter:    rateLimiter,
 	}
 }
 
@@ -75,46 +80,15 @@ func (s *Specification) Run() {
 	for {
 		select {
 		case <-updateTicker.C:
-
-			// load new schemes
-			newSpecDB, err := s.Load()
+			updated, err := s.Update()
 			if err != nil {
 				s.logger.Error().Err(err).Msgf("%s: loading specifications failed", logPrefix)
 				continue
 			}
 
-			// do not downgrade the db version
-			if s.sqlLiteStorage.Version() > newSpecDB.Version() {
-				s.logger.Error().Msgf("%s: version of the new DB structure is lower then current one (V2)", logPrefix)
-				continue
-			}
-
-			if s.sqlLiteStorage.ShouldUpdate(newSpecDB) {
-				s.logger.Debug().Msgf("%s: openAPI specifications with the following IDs were updated: %v", logPrefix, newSpecDB.SchemaIDs())
-
-				// find new IDs and log them
-				newScemaIDs := newSpecDB.SchemaIDs()
-				oldSchemaIDs := s.sqlLiteStorage.SchemaIDs()
-				for _, ns := range newScemaIDs {
-					if !validator.Contains(oldSchemaIDs, ns) {
-						s.logger.Info().Msgf("%s: fetched OpenAPI specification from the database with id: %d", logPrefix, ns)
-					}
-				}
-
-				s.lock.Lock()
-				s.sqlLiteStorage = newSpecDB
-				s.api.Handler = Handlers(s.lock, s.cfg, s.shutdown, s.logger, s.metrics, s.sqlLiteStorage, s.allowedIPCache, s.waf)
-				s.health.OpenAPIDB = s.sqlLiteStorage
-				if err := s.sqlLiteStorage.AfterLoad(s.cfg.PathToSpecDB); err != nil {
-					s.logger.Error().Err(err).Msgf("%s: error in after specification loading function", logPrefix)
-				}
-				s.lock.Unlock()
-
-				s.logger.Debug().Msgf("%s: OpenAPI specifications have been updated", logPrefix)
-				continue
+			if !updated {
+				s.logger.Debug().Msgf("%s: new OpenAPI specifications not found", logPrefix)
 			}
-
-			s.logger.Debug().Msgf("%s: new OpenAPI specifications not found", logPrefix)
 		case <-s.stop:
 			updateTicker.Stop()
 			return
@@ -142,6 +116,53 @@ func (s *Specification) Shutdown() error {
 	return nil
 }
 
+// Update function loads the specifications and replaces the handlers if the
+// specifications have been changed. It returns true if the specifications have
+// been updated
+func (s *Specification) Update() (bool, error) {
+	s.updateLock.Lock()
+	defer s.updateLock.Unlock()
+
+	// load new schemes
+	newSpecDB, err := s.Load()
+	if err != nil {
+		return false, err
+	}
+
+	// do not downgrade the db version
+	if s.sqlLiteStorage.Version() > newSpecDB.Version() {
+		return false, errors.New("version of the new DB structure is lower then current one (V2)")
+	}
+
+	if !s.sqlLiteStorage.ShouldUpdate(newSpecDB) {
+		return false, nil
+	}
+
+	s.logger.Debug().Msgf("%s: openAPI specifications with the following IDs were updated: %v", logPrefix, newSpecDB.SchemaIDs())
+
+	// find new IDs and log them
+	newScemaIDs := newSpecDB.SchemaIDs()
+	oldSchemaIDs := s.sqlLiteStorage.SchemaIDs()
+	for _, ns := range newScemaIDs {
+		if !validator.Contains(oldSchemaIDs, ns) {
+			s.logger.Info().Msgf("%s: fetched OpenAPI specification from the database with id: %d", logPrefix, ns)
+		}
+	}
+
+	s.lock.Lock()
+	s.sqlLiteStorage = newSpecDB
+	s.api.Handler = Handlers(s.lock, s.cfg, s.shutdown, s.logger, s.metrics, s.sqlLiteStorage, s.allowedIPCache, s.waf, s.rateLimiter)
+	s.health.OpenAPIDB = s.sqlLiteStorage
+	if err := s.sqlLiteStorage.AfterLoad(s.cfg.PathToSpecDB); err != nil {
+		s.logger.Error().Err(err).Msgf("%s: error in after specification loading function", logPrefix)
+	}
+	s.lock.Unlock()
+
+	s.logger.Debug().Msgf("%s: OpenAPI specifications have been updated", logPrefix)
+
+	return true, nil
+}
+
 // Load function reads DB file and returns it
 func (s *Specification) Load() (storage.DBOpenAPILoader, error) {
 
@@ -15,6 +15,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/events"
 	"github.com/wallarm/api-firewall/internal/platform/proxy"
 	"github.com/wallarm/api-firewall/internal/platform/validator"
 	"github.com/wallarm/api-firewall/internal/platform/web"
@@ -57,6 +58,7 @@ func (h *Handler) GraphQLHandle(ctx *fasthttp.RequestCtx) error {
 			Interface("request_id", ctx.UserValue(web.RequestID)).
 			Msg("POST request without application/json content-type is received")
 
+		ctx.SetUserValue(web.RequestBlocked, true)
 		return web.RespondError(ctx, fasthttp.StatusForbidden, "")
 	}
 
@@ -68,6 +70,7 @@ func (h *Handler) GraphQLHandle(ctx *fasthttp.RequestCtx) error {
 			Interface("request_id", ctx.UserValue(web.RequestID)).
 			Msg("GET request without \"query\" query parameter is received")
 
+		ctx.SetUserValue(web.RequestBlocked, true)
 		ctx.Response.SetStatusCode(fasthttp.StatusBadRequest)
 		return web.RespondGraphQLErrors(&ctx.Response, ErrInvalidQuery)
 	}
@@ -95,7 +98,13 @@ func (h *Handler) GraphQLHandle(ctx *fasthttp.RequestCtx) error {
 			Interface("request_id", ctx.UserValue(web.RequestID)).
 			Msg("GraphQL request unmarshal")
 
+		ctx.SetUserValue(web.RequestValidationFailed, true)
+		events.Record(ctx, events.TypeRequestValidation,
+			events.Action(strings.EqualFold(h.cfg.Graphql.RequestValidation, web.ValidationBlock)),
+			events.Violation{Code: events.CodeGraphQLInvalidRequest, Message: err.Error()})
+
 		if strings.EqualFold(h.cfg.Graphql.RequestValidation, web.ValidationBlock) {
+			ctx.SetUserValue(web.RequestBlocked, true)
 			return web.RespondGraphQLErrors(&ctx.Response, ErrInvalidQuery)
 		}
 	}
@@ -108,7 +117,13 @@ func (h *Handler) GraphQLHandle(ctx *fasthttp.RequestCtx) error {
 			Interface("request_id", ctx.UserValue(web.RequestID)).
 			Msg("GraphQL query validation")
 
+		ctx.SetUserValue(web.RequestValidationFailed, true)
+		events.Record(ctx, events.TypeRequestValidation,
+			events.Action(strings.EqualFold(h.cfg.Graphql.RequestValidation, web.ValidationBlock)),
+			events.Violation{Code: events.CodeGraphQLInvalidRequest, Message: fmt.Sprintf("the batch query limit has been exceeded: %d queries", len(gqlRequest))})
+
 		if strings.EqualFold(h.cfg.Graphql.RequestValidation, web.ValidationBlock) {
+			ctx.SetUserValue(web.RequestBlocked, true)
 			return web.RespondGraphQLErrors(&ctx.Response, ErrInvalidQuery)
 		}
 	}
@@ -146,7 +161,13 @@ func (h *Handler) GraphQLHandle(ctx *fasthttp.RequestCtx) error {
 	}
 
 	if err := eg.Wait(); err != nil {
+		ctx.SetUserValue(web.RequestValidationFailed, true)
+		events.Record(ctx, events.TypeRequestValidation,
+			events.Action(strings.EqualFold(h.cfg.Graphql.RequestValidation, web.ValidationBlock)),
+			events.Violation{Code: events.CodeGraphQLInvalidRequest, Message: err.Error()})
+
 		if strings.EqualFold(h.cfg.Graphql.RequestValidation, web.ValidationBlock) {
+			ctx.SetUserValue(web.RequestBlocked, true)
 			return web.RespondGraphQLErrors(&ctx.Response, ErrInvalidQuery)
 		}
 	}
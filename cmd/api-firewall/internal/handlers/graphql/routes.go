@@ -19,10 +19,12 @@ import (
 	"github.com/wallarm/api-firewall/internal/platform/allowiplist"
 	"github.com/wallarm/api-firewall/internal/platform/denylist"
 	"github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/ratelimit"
+	"github.com/wallarm/api-firewall/internal/platform/tracing"
 	"github.com/wallarm/api-firewall/internal/platform/web"
 )
 
-func Handlers(cfg *config.GraphQLMode, schema *graphql.Schema, serverURL *url.URL, shutdown chan os.Signal, logger zerolog.Logger, proxy proxy.Pool, wsClient proxy.WebSocketClient, deniedTokens *denylist.DeniedTokens, AllowedIPCache *allowiplist.AllowedIPsType) fasthttp.RequestHandler {
+func Handlers(cfg *config.GraphQLMode, schema *graphql.Schema, serverURL *url.URL, shutdown chan os.Signal, logger zerolog.Logger, proxy proxy.Pool, wsClient proxy.WebSocketClient, deniedTokens *denylist.DeniedTokens, AllowedIPCache *allowiplist.AllowedIPsType, rateLimiter *ratelimit.RateLimiter) fasthttp.RequestHandler {
 
 	// Construct the web.App which holds all routes as well as common Middleware.
 	appOptions := web.AppAdditionalOptions{
@@ -53,7 +55,14 @@ func Handlers(cfg *config.GraphQLMode, schema *graphql.Schema, serverURL *url.UR
 		Logger:                logger,
 	}
 
-	app := web.NewApp(&appOptions, shutdown, logger, mid.Logger(logger), mid.Errors(logger), mid.Panics(logger), mid.Proxy(&proxyOptions), mid.IPAllowlist(&ipAllowlistOptions), mid.Denylist(&denylistOptions))
+	rateLimitOptions := mid.RateLimitOptions{
+		Mode:        web.GraphQLMode,
+		Config:      &cfg.RateLimit,
+		RateLimiter: rateLimiter,
+		Logger:      logger,
+	}
+
+	app := web.NewApp(&appOptions, shutdown, logger, mid.Logger(logger), mid.Errors(logger), mid.Panics(logger), mid.Proxy(&proxyOptions), mid.IPAllowlist(&ipAllowlistOptions), mid.Denylist(&denylistOptions), mid.RateLimit(&rateLimitOptions))
 
 	// define FastJSON parsers pool
 	var parserPool fastjson.ParserPool
@@ -121,5 +130,5 @@ func Handlers(cfg *config.GraphQLMode, schema *graphql.Schema, serverURL *url.UR
 		}
 	}
 
-	return app.MainHandler
+	return tracing.Handler(app.MainHandler)
 }
@@ -1,11 +1,14 @@
 package graphql
 
 import (
+	"context"
 	"net/url"
 	"os"
 	"os/signal"
 	"path"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/ardanlabs/conf"
 	"github.com/pkg/errors"
@@ -17,7 +20,12 @@ import (
 	"github.com/wallarm/api-firewall/internal/config"
 	"github.com/wallarm/api-firewall/internal/platform/allowiplist"
 	"github.com/wallarm/api-firewall/internal/platform/denylist"
+	"github.com/wallarm/api-firewall/internal/platform/events"
+	"github.com/wallarm/api-firewall/internal/platform/metrics"
 	"github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/ratelimit"
+	"github.com/wallarm/api-firewall/internal/platform/tracing"
+	"github.com/wallarm/api-firewall/internal/platform/web"
 	"github.com/wallarm/api-firewall/internal/version"
 )
 
@@ -112,6 +120,11 @@ func Run(logger zerolog.Logger) error {
 		}
 	}
 
+	httpHealthCheck, err := handlersProxy.NewHTTPHealthCheck(serverURL, &cfg.Server)
+	if err != nil {
+		return errors.Wrap(err, "proxy pool init")
+	}
+
 	pool, err := proxy.NewPoolV2(host, &proxy.PoolV2Options{
 		MaxConnsPerHost:     cfg.Server.MaxConnsPerHost,
 		MaxIdleConnDuration: cfg.Server.MaxIdleConnDuration,
@@ -124,6 +137,13 @@ func Run(logger zerolog.Logger) error {
 		InsecureConnection:  cfg.Server.InsecureConnection,
 		RootCA:              cfg.Server.RootCA,
 		HealthCheckInterval: cfg.Server.HealthCheckInterval,
+		HTTPHealthCheck:     httpHealthCheck,
+		HealthCheckTimeout:  cfg.Server.HealthCheck.Timeout,
+		HealthyThreshold:    cfg.Server.HealthCheck.HealthyThreshold,
+		UnhealthyThreshold:  cfg.Server.HealthCheck.UnhealthyThreshold,
+		OutlierDetection:    handlersProxy.NewOutlierDetection(&cfg.Server),
+		CircuitBreaker:      handlersProxy.NewCircuitBreaker(&cfg.Server),
+		Retry:               handlersProxy.NewRetry(&cfg.Server),
 		Logger:              logger,
 	})
 	if err != nil {
@@ -185,15 +205,94 @@ func Run(logger zerolog.Logger) error {
 		logger.Info().Msgf("%s: Loaded %d Whitelisted IP's to the cache", logPrefix, allowedIPCache.ElementsNum)
 	}
 
+	// =========================================================================
+	// Init Rate Limiter
+
+	rateLimiter, err := ratelimit.NewRateLimiter(&cfg.RateLimit)
+	if err != nil {
+		return errors.Wrap(err, "rate limiter init error")
+	}
+
+	switch rateLimiter {
+	case nil:
+		logger.Info().Msgf("%s: Rate limits are not configured", logPrefix)
+	default:
+		logger.Info().Msgf("%s: Rate limits are configured: %d requests per %s by %s, %d endpoint-specific limits", logPrefix, cfg.RateLimit.Requests, cfg.RateLimit.Period, cfg.RateLimit.Key, len(cfg.RateLimit.Endpoints))
+	}
+
 	// =========================================================================
 	// Init ZeroLogger
 
 	zeroLogger := &config.ZerologAdapter{Logger: logger}
 
+	// =========================================================================
+	// Init Tracing
+
+	shutdownTracing, err := tracing.Init(&cfg.Tracing, logger)
+	if err != nil {
+		return errors.Wrap(err, "tracing init error")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error().Err(err).Msgf("%s: tracing shutdown", logPrefix)
+		}
+	}()
+
+	if cfg.Tracing.Enabled {
+		logger.Info().Msgf("%s: OpenTelemetry tracing is enabled: %s exporter", logPrefix, strings.ToUpper(cfg.Tracing.Exporter))
+	}
+
+	// =========================================================================
+	// Init Security Events
+
+	eventsSink, err := events.New(&cfg.Events, logger)
+	if err != nil {
+		return errors.Wrap(err, "security events init error")
+	}
+	if eventsSink != nil {
+		defer func() {
+			if err := eventsSink.Close(); err != nil {
+				logger.Error().Err(err).Msgf("%s: security events sink shutdown", logPrefix)
+			}
+		}()
+
+		logger.Info().Msgf("%s: security events are sent to the configured sinks", logPrefix)
+	}
+
+	// =========================================================================
+	// Init Metrics
+
+	// make a channel to listen for errors coming from the metrics listener. Use a
+	// buffered channel so the goroutine can exit if we don't collect this error.
+	metricsErrors := make(chan error, 1)
+
+	metricsOptions := metrics.Options{
+		EndpointName: cfg.Metrics.EndpointName,
+		Host:         cfg.Metrics.Host,
+		ReadTimeout:  cfg.Metrics.ReadTimeout,
+		WriteTimeout: cfg.Metrics.WriteTimeout,
+	}
+
+	metricsController := metrics.NewPrometheusMetrics(cfg.Metrics.Enabled)
+	if backendPool, ok := pool.(metrics.BackendPool); ok {
+		metricsController.AddBackendPool("", backendPool)
+	}
+
+	if cfg.Metrics.Enabled {
+		go func() {
+			// Start the service listening for requests.
+			logger.Info().Msgf("%s: Prometheus metrics: API listening on %s/%s", logPrefix, metricsOptions.Host, metricsOptions.EndpointName)
+			metricsErrors <- metricsController.StartService(&logger, &metricsOptions)
+		}()
+	}
+
 	// =========================================================================
 	// Init Handlers
 
-	requestHandlers := Handlers(&cfg, schema, serverURL, shutdown, logger, pool, wsPool, deniedTokens, allowedIPCache)
+	requestHandlers := events.Handler(eventsSink, web.GraphQLMode, metricsController.RequestHandler(web.GraphQLMode, Handlers(&cfg, schema, serverURL, shutdown, logger, pool, wsPool, deniedTokens, allowedIPCache, rateLimiter)))
 
 	// =========================================================================
 	// Start Health API Service
@@ -293,6 +392,9 @@ func Run(logger zerolog.Logger) error {
 	case err := <-serverErrors:
 		return errors.Wrap(err, "server error")
 
+	case err := <-metricsErrors:
+		return errors.Wrap(err, "metrics error")
+
 	case sig := <-shutdown:
 		logger.Info().Msgf("%s: %v: Start shutdown", logPrefix, sig)
 
@@ -0,0 +1,384 @@
+package proxy
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/savsgio/gotils/strconv"
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/loader"
+	"github.com/wallarm/api-firewall/internal/platform/router"
+	"github.com/wallarm/api-firewall/internal/platform/storage/updater"
+	"github.com/wallarm/api-firewall/internal/platform/web"
+)
+
+const (
+	adminLogPrefix = "Admin API"
+
+	adminSpecsEndpoint    = "/v1/specs"
+	adminReloadEndpoint   = "/v1/specs/reload"
+	adminActionsEndpoint  = "/v1/actions"
+	adminLogLevelEndpoint = "/v1/log-level"
+	adminBackendsEndpoint = "/v1/backends"
+)
+
+var (
+	errAdminSpecNotFound    = errors.New("specification not found")
+	errAdminInvalidMode     = errors.New("invalid validation mode: DISABLE, BLOCK or LOG_ONLY expected")
+	errAdminInvalidLogLevel = errors.New("invalid log level: trace, debug, info, warning or error expected")
+	errAdminPathRequired    = errors.New("path is required")
+)
+
+// Admin is the runtime API for inspecting and controlling the firewall. All
+// requests should be authorized by the bearer token
+type Admin struct {
+	Logger   zerolog.Logger
+	Lock     *sync.RWMutex
+	Token    string
+	Specs    *SpecDispatcher
+	Updaters map[string]updater.Updater
+	Health   *Health
+
+	// Handlers builds the request handler of the specification
+	Handlers func(target *SpecTarget) fasthttp.RequestHandler
+}
+
+type adminError struct {
+	Error string `json:"error"`
+}
+
+type adminSchema struct {
+	ID      int    `json:"id"`
+	Version string `json:"version"`
+}
+
+type adminRoute struct {
+	Method             string `json:"method"`
+	Path               string `json:"path"`
+	RequestValidation  string `json:"request_validation"`
+	ResponseValidation string `json:"response_validation"`
+	Override           bool   `json:"override"`
+	Endpoint           string `json:"endpoint,omitempty"`
+}
+
+type adminSpec struct {
+	Name        string        `json:"name"`
+	Host        string        `json:"host,omitempty"`
+	PathPrefix  string        `json:"path_prefix,omitempty"`
+	SpecVersion string        `json:"spec_version"`
+	Schemas     []adminSchema `json:"schemas,omitempty"`
+	Routes      []adminRoute  `json:"routes"`
+}
+
+type adminReloadResult struct {
+	Spec    string `json:"spec"`
+	Updated bool   `json:"updated"`
+	Error   string `json:"error,omitempty"`
+}
+
+// adminActions is the request to change the validation modes of the endpoint.
+// The empty method matches all methods of the path
+type adminActions struct {
+	Spec               string `json:"spec"`
+	Method             string `json:"method"`
+	Path               string `json:"path"`
+	RequestValidation  string `json:"request_validation"`
+	ResponseValidation string `json:"response_validation"`
+}
+
+type adminLogLevel struct {
+	Level string `json:"level"`
+}
+
+// Handler routes the admin API requests
+func (a *Admin) Handler(ctx *fasthttp.RequestCtx) {
+
+	if !a.authorized(ctx) {
+		ctx.Response.Header.Set(fasthttp.HeaderWWWAuthenticate, "Bearer")
+		a.respond(ctx, adminError{Error: "unauthorized"}, fasthttp.StatusUnauthorized)
+		return
+	}
+
+	method := strconv.B2S(ctx.Method())
+
+	var err error
+	switch path := strconv.B2S(ctx.Path()); {
+	case path == adminSpecsEndpoint && method == fasthttp.MethodGet:
+		err = a.listSpecs(ctx)
+	case path == adminReloadEndpoint && method == fasthttp.MethodPost:
+		err = a.reload(ctx)
+	case path == adminActionsEndpoint && (method == fasthttp.MethodPut || method == fasthttp.MethodDelete):
+		err = a.setActions(ctx, method == fasthttp.MethodDelete)
+	case path == adminLogLevelEndpoint && method == fasthttp.MethodGet:
+		a.respond(ctx, adminLogLevel{Level: zerolog.GlobalLevel().String()}, fasthttp.StatusOK)
+	case path == adminLogLevelEndpoint && method == fasthttp.MethodPut:
+		err = a.setLogLevel(ctx)
+	case path == adminBackendsEndpoint && method == fasthttp.MethodGet:
+		_, backends := a.Health.backends()
+		a.respond(ctx, backends, fasthttp.StatusOK)
+	case slices.Contains([]string{adminSpecsEndpoint, adminReloadEndpoint, adminActionsEndpoint, adminLogLevelEndpoint, adminBackendsEndpoint}, path):
+		a.respond(ctx, adminError{Error: "method not allowed"}, fasthttp.StatusMethodNotAllowed)
+	default:
+		a.respond(ctx, adminError{Error: "unsupported path"}, fasthttp.StatusNotFound)
+	}
+
+	if err != nil {
+		a.respond(ctx, adminError{Error: err.Error()}, fasthttp.StatusBadRequest)
+	}
+}
+
+// authorized checks the bearer token of the request
+func (a *Admin) authorized(ctx *fasthttp.RequestCtx) bool {
+	token, found := strings.CutPrefix(strconv.B2S(ctx.Request.Header.Peek(fasthttp.HeaderAuthorization)), "Bearer ")
+	if !found || a.Token == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.Token)) == 1
+}
+
+func (a *Admin) respond(ctx *fasthttp.RequestCtx, data any, statusCode int) {
+	if err := web.Respond(ctx, data, statusCode); err != nil {
+		a.Logger.Error().Err(err).Msgf("%s: response", adminLogPrefix)
+	}
+}
+
+// target returns the specification by name. The default specification is
+// returned if the name is empty
+func (a *Admin) target(name string) (*SpecTarget, error) {
+	if name == "" {
+		return a.Specs.Default, nil
+	}
+
+	for _, t := range a.Specs.All() {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+
+	return nil, errors.Wrapf(errAdminSpecNotFound, "%q", name)
+}
+
+// listSpecs responds with the loaded specifications and their routes with the
+// validation modes
+func (a *Admin) listSpecs(ctx *fasthttp.RequestCtx) error {
+	a.Lock.RLock()
+	defer a.Lock.RUnlock()
+
+	var specs []adminSpec
+	for _, t := range a.Specs.All() {
+		spec := adminSpec{
+			Name:       t.Name,
+			Host:       t.Host,
+			PathPrefix: t.PathPrefix,
+			Routes:     []adminRoute{},
+		}
+
+		for _, id := range t.Storage.SchemaIDs() {
+			spec.Schemas = append(spec.Schemas, adminSchema{ID: id, Version: t.Storage.SpecificationVersion(id)})
+		}
+
+		doc := t.Storage.Specification(0)
+		if doc == nil {
+			specs = append(specs, spec)
+			continue
+		}
+
+		if doc.Info != nil {
+			spec.SpecVersion = doc.Info.Version
+		}
+
+		swagRouter, err := loader.NewRouter(doc, false)
+		if err != nil {
+			return err
+		}
+
+		endpointRules, err := newEndpointRules(t.Cfg.Endpoints)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range swagRouter.Routes {
+			path, err := routePath(t.ServerURL, r.Path)
+			if err != nil {
+				continue
+			}
+
+			route := adminRoute{
+				Method:             r.Method,
+				Path:               path,
+				RequestValidation:  t.Cfg.RequestValidation,
+				ResponseValidation: t.Cfg.ResponseValidation,
+			}
+
+			if rule := findEndpointRule(endpointRules, &r, path); rule != nil {
+				route.Endpoint = rule.String()
+				if actions := endpointActions(rule.endpoint); actions != nil {
+					route.RequestValidation = actions.Request
+					route.ResponseValidation = actions.Response
+					route.Override = true
+				}
+			}
+
+			spec.Routes = append(spec.Routes, route)
+		}
+
+		specs = append(specs, spec)
+	}
+
+	a.respond(ctx, specs, fasthttp.StatusOK)
+	return nil
+}
+
+// reload loads the specification and updates the handler if the
+// specification has been changed. All specifications are reloaded if the spec
+// query parameter is not set
+func (a *Admin) reload(ctx *fasthttp.RequestCtx) error {
+	targets := a.Specs.All()
+
+	if name := strconv.B2S(ctx.QueryArgs().Peek("spec")); name != "" {
+		t, err := a.target(name)
+		if err != nil {
+			return err
+		}
+		targets = []*SpecTarget{t}
+	}
+
+	results := make([]adminReloadResult, 0, len(targets))
+	for _, t := range targets {
+		result := adminReloadResult{Spec: t.Name}
+
+		upd, ok := a.Updaters[t.Name]
+		if !ok {
+			result.Error = errAdminSpecNotFound.Error()
+			results = append(results, result)
+			continue
+		}
+
+		updated, err := upd.Update()
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.Updated = updated
+
+		a.Logger.Info().Msgf("%s: specification %s reload: updated %t", adminLogPrefix, t.Name, updated)
+
+		results = append(results, result)
+	}
+
+	a.respond(ctx, results, fasthttp.StatusOK)
+	return nil
+}
+
+// setActions changes the validation modes of the endpoint and rebuilds the
+// handler of the specification. The custom validation modes of the endpoint
+// are removed if remove is true
+func (a *Admin) setActions(ctx *fasthttp.RequestCtx, remove bool) error {
+	var req adminActions
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		return errors.Wrap(err, "request body parsing")
+	}
+
+	if req.Path == "" {
+		return errAdminPathRequired
+	}
+
+	t, err := a.target(req.Spec)
+	if err != nil {
+		return err
+	}
+
+	var actions *router.Actions
+	if !remove {
+		actions = &router.Actions{
+			Request:  strings.ToUpper(req.RequestValidation),
+			Response: strings.ToUpper(req.ResponseValidation),
+		}
+
+		if !isValidationMode(actions.Request) || !isValidationMode(actions.Response) {
+			return errAdminInvalidMode
+		}
+	}
+
+	a.Lock.Lock()
+
+	// the slice could be shared with the configuration of other specifications
+	endpoints := slices.DeleteFunc(slices.Clone(t.Cfg.Endpoints), func(e config.Endpoint) bool {
+		return strings.EqualFold(e.Path, req.Path) && strings.EqualFold(e.Method, req.Method) && e.Tag == "" && e.OperationID == ""
+	})
+
+	// the override is the first endpoint to be applied before the patterns
+	if actions != nil {
+		endpoints = append([]config.Endpoint{{
+			Path:   req.Path,
+			Method: req.Method,
+			ValidationMode: config.ValidationMode{
+				RequestValidation:  actions.Request,
+				ResponseValidation: actions.Response,
+			},
+		}}, endpoints...)
+	}
+
+	t.Cfg.Endpoints = endpoints
+	t.Handler = a.Handlers(t)
+
+	a.Lock.Unlock()
+
+	a.Logger.Info().
+		Str("spec", t.Name).
+		Str("method", req.Method).
+		Str("path", req.Path).
+		Str("request_validation_mode", req.RequestValidation).
+		Str("response_validation_mode", req.ResponseValidation).
+		Bool("removed", remove).
+		Msgf("%s: endpoint validation modes changed", adminLogPrefix)
+
+	a.respond(ctx, nil, fasthttp.StatusNoContent)
+	return nil
+}
+
+// setLogLevel changes the global log level
+func (a *Admin) setLogLevel(ctx *fasthttp.RequestCtx) error {
+	var req adminLogLevel
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		return errors.Wrap(err, "request body parsing")
+	}
+
+	var level zerolog.Level
+	switch strings.ToLower(req.Level) {
+	case "trace":
+		level = zerolog.TraceLevel
+	case "debug":
+		level = zerolog.DebugLevel
+	case "info":
+		level = zerolog.InfoLevel
+	case "warn", "warning":
+		level = zerolog.WarnLevel
+	case "error":
+		level = zerolog.ErrorLevel
+	default:
+		return errAdminInvalidLogLevel
+	}
+
+	zerolog.SetGlobalLevel(level)
+
+	a.Logger.Info().Msgf("%s: log level changed to %s", adminLogPrefix, level)
+
+	a.respond(ctx, adminLogLevel{Level: level.String()}, fasthttp.StatusOK)
+	return nil
+}
+
+func isValidationMode(mode string) bool {
+	switch strings.ToLower(mode) {
+	case web.ValidationDisable, web.ValidationBlock, web.ValidationLog:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/platform/events"
+	"github.com/wallarm/api-firewall/internal/platform/web"
+)
+
+const requestBodyTooLargeDetail = "request body is too large"
+
+// checkRequestBodySize checks the size of the decompressed request body by
+// the operation settings. The request with the larger body is blocked with the
+// 413 status code. The violation is only logged if the request validation mode
+// is LOG_ONLY. True is returned if the request has been blocked
+func (s *openapiWaf) checkRequestBodySize(ctx *fasthttp.RequestCtx, bodySize int, requestValidationMode string) (bool, error) {
+	if bodySize <= s.maxRequestBodySize {
+		return false, nil
+	}
+
+	isBlocked := strings.EqualFold(requestValidationMode, web.ValidationBlock)
+
+	ctx.SetUserValue(web.RequestValidationFailed, true)
+	events.Record(ctx, events.TypeRequestValidation, events.Action(isBlocked), events.Violation{Code: events.CodeRequestBodyTooLarge})
+
+	if !isBlocked {
+		s.logger.Error().
+			Interface("request_id", ctx.UserValue(web.RequestID)).
+			Bytes("host", ctx.Request.Header.Host()).
+			Bytes("path", ctx.Path()).
+			Bytes("method", ctx.Request.Header.Method()).
+			Int("body_size", bodySize).
+			Int("max_body_size", s.maxRequestBodySize).
+			Msg("Request body is too large")
+		return false, nil
+	}
+
+	s.logger.Error().
+		Interface("request_id", ctx.UserValue(web.RequestID)).
+		Bytes("host", ctx.Request.Header.Host()).
+		Bytes("path", ctx.Path()).
+		Bytes("method", ctx.Request.Header.Method()).
+		Int("body_size", bodySize).
+		Int("max_body_size", s.maxRequestBodySize).
+		Msg("Request body is too large: request blocked")
+
+	ctx.SetUserValue(web.RequestBlocked, true)
+
+	return true, web.RespondBlocked(ctx, s.blockResponse.WithStatusCode(fasthttp.StatusRequestEntityTooLarge), fasthttp.StatusRequestEntityTooLarge, requestBodyTooLargeDetail)
+}
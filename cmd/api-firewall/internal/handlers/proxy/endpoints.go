@@ -0,0 +1,158 @@
+package proxy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/loader"
+)
+
+// endpointRegexPrefix is the prefix of the endpoint path which is the regular
+// expression
+const endpointRegexPrefix = "~"
+
+// endpointRule selects the routes the endpoint settings are applied to
+type endpointRule struct {
+	endpoint *config.Endpoint
+	// number of the endpoint in the configuration starting from 1
+	number int
+	// pattern is nil if the endpoint path is the templated path of the route
+	pattern *regexp.Regexp
+}
+
+// newEndpointRules returns the rules of the configured endpoints in the same
+// order
+func newEndpointRules(endpoints config.EndpointList) ([]endpointRule, error) {
+	rules := make([]endpointRule, 0, len(endpoints))
+
+	for i := range endpoints {
+		rule := endpointRule{
+			endpoint: &endpoints[i],
+			number:   i + 1,
+		}
+
+		if rule.endpoint.Selector() == "" {
+			return nil, fmt.Errorf("endpoint #%d: Path, Tag or OperationID should be set", rule.number)
+		}
+
+		pattern, err := pathPattern(rule.endpoint.Path)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint #%d: %w", rule.number, err)
+		}
+		rule.pattern = pattern
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// pathPattern compiles the endpoint path which is the regular expression or
+// the glob pattern. Nil is returned if the path is the templated path of the
+// route. In the glob pattern * matches any characters within the path segment
+// and ** matches any number of the path segments. Both the regular expression
+// and the glob pattern should match the whole path case-insensitively the same
+// way as the templated path
+func pathPattern(path string) (*regexp.Regexp, error) {
+	if expr, ok := strings.CutPrefix(path, endpointRegexPrefix); ok {
+		return regexp.Compile("(?i)^(?:" + expr + ")$")
+	}
+
+	if !strings.Contains(path, "*") {
+		return nil, nil
+	}
+
+	var expr strings.Builder
+	expr.WriteString("(?i)^")
+
+	for i := 0; i < len(path); i++ {
+		switch {
+		case strings.HasPrefix(path[i:], "/**"):
+			// the trailing segments are optional
+			expr.WriteString("(?:/.*)?")
+			i += 2
+		case strings.HasPrefix(path[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case path[i] == '*':
+			expr.WriteString("[^/]*")
+		default:
+			expr.WriteString(regexp.QuoteMeta(path[i : i+1]))
+		}
+	}
+
+	expr.WriteString("$")
+
+	return regexp.Compile(expr.String())
+}
+
+// match method checks if the route with the path matches all selectors of the
+// endpoint
+func (r *endpointRule) match(route *loader.CustomRoute, path string) bool {
+	e := r.endpoint
+
+	if e.Method != "" && !strings.EqualFold(e.Method, route.Method) {
+		return false
+	}
+
+	if e.Path != "" {
+		if r.pattern != nil && !r.pattern.MatchString(path) {
+			return false
+		}
+		if r.pattern == nil && !strings.EqualFold(e.Path, path) {
+			return false
+		}
+	}
+
+	if e.Tag == "" && e.OperationID == "" {
+		return true
+	}
+
+	if route.Route == nil || route.Route.Operation == nil {
+		return false
+	}
+
+	if e.OperationID != "" && e.OperationID != route.Route.Operation.OperationID {
+		return false
+	}
+
+	if e.Tag != "" && !containsFold(route.Route.Operation.Tags, e.Tag) {
+		return false
+	}
+
+	return true
+}
+
+// String method returns the description of the rule to be logged
+func (r *endpointRule) String() string {
+	method := r.endpoint.Method
+	if method == "" {
+		method = "*"
+	}
+
+	return fmt.Sprintf("#%d %s:%s", r.number, method, r.endpoint.Selector())
+}
+
+// findEndpointRule returns the first rule which matches the route with the
+// path. Nil is returned if the rule is not found
+func findEndpointRule(rules []endpointRule, route *loader.CustomRoute, path string) *endpointRule {
+	for i := range rules {
+		if rules[i].match(route, path) {
+			return &rules[i]
+		}
+	}
+
+	return nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,283 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/events"
+	"github.com/wallarm/api-firewall/internal/platform/loader"
+	"github.com/wallarm/api-firewall/internal/platform/validator"
+	"github.com/wallarm/api-firewall/internal/platform/web"
+	apiModeValidator "github.com/wallarm/api-firewall/pkg/APIMode/validator"
+)
+
+// formats of the validation errors in the response to the blocked request
+const (
+	errorsFormatJSON        = "JSON"
+	errorsFormatProblemJSON = "PROBLEM_JSON"
+
+	problemJSONContentType = "application/problem+json"
+	problemTypeDefault     = "about:blank"
+
+	requestBlockedDetail  = "request does not match the API specification"
+	responseBlockedDetail = "response does not match the API specification"
+)
+
+// blockResponseExtension configures the response to the blocked request of
+// the operation
+const blockResponseExtension = "x-apifw-block-response"
+
+// validationErrorEntry is the validation error with the API mode error code
+type validationErrorEntry struct {
+	Message       string                            `json:"message"`
+	Code          string                            `json:"code"`
+	Fields        []string                          `json:"related_fields,omitempty"`
+	FieldsDetails []apiModeValidator.FieldTypeError `json:"related_fields_details,omitempty"`
+}
+
+// validationErrorsBody is the body of the response to the blocked request in
+// the JSON format
+type validationErrorsBody struct {
+	Errors []validationErrorEntry `json:"errors"`
+}
+
+// problemDetails is the body of the response to the blocked request in the
+// RFC 7807 format. The validation errors are added as the extension member
+type problemDetails struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail,omitempty"`
+	Instance  string                 `json:"instance,omitempty"`
+	RequestID any                    `json:"request_id,omitempty"`
+	Errors    []validationErrorEntry `json:"errors"`
+}
+
+// validationErrors returns the list of the validation errors with the API
+// mode error codes. All errors of the openapi3.MultiError are converted
+func validationErrors(err error) []*apiModeValidator.ValidationError {
+	var result []*apiModeValidator.ValidationError
+
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		for _, multiErr := range e {
+			result = append(result, validationErrors(multiErr)...)
+		}
+	default:
+		validationErrs, _ := validator.GetErrorResponse(err)
+		result = append(result, validationErrs...)
+	}
+
+	return result
+}
+
+// unknownParametersErrors returns the validation errors of the unknown
+// parameters found in the request
+func unknownParametersErrors(upResults []validator.RequestUnknownParameterError) []*apiModeValidator.ValidationError {
+	result := make([]*apiModeValidator.ValidationError, 0, len(upResults))
+	for _, up := range upResults {
+		fields := make([]string, 0, len(up.Parameters))
+		for _, p := range up.Parameters {
+			fields = append(fields, p.Name)
+		}
+
+		result = append(result, &apiModeValidator.ValidationError{
+			Code:    apiModeValidator.ErrCodeUnknownParameterFound,
+			Message: up.Message,
+			Fields:  fields,
+		})
+	}
+
+	return result
+}
+
+// routeNotFoundErrors returns the validation errors of the request with the
+// method and path which are not found in the specification
+func routeNotFoundErrors() []*apiModeValidator.ValidationError {
+	return []*apiModeValidator.ValidationError{{
+		Code:    apiModeValidator.ErrCodeMethodAndPathNotFound,
+		Message: apiModeValidator.ErrMethodAndPathNotFound.Error(),
+	}}
+}
+
+// violations returns the security event violations of the validation errors
+func violations(validationErrs []*apiModeValidator.ValidationError) []events.Violation {
+	result := make([]events.Violation, 0, len(validationErrs))
+	for _, ve := range validationErrs {
+		result = append(result, events.Violation{
+			Code:    ve.Code,
+			Message: ve.Message,
+			Fields:  ve.Fields,
+		})
+	}
+
+	return result
+}
+
+// errorEntries returns the validation errors without the API mode schema
+// fields to be logged and sent in the response
+func errorEntries(validationErrs []*apiModeValidator.ValidationError) []validationErrorEntry {
+	result := make([]validationErrorEntry, 0, len(validationErrs))
+	for _, ve := range validationErrs {
+		result = append(result, validationErrorEntry{
+			Message:       ve.Message,
+			Code:          ve.Code,
+			Fields:        ve.Fields,
+			FieldsDetails: ve.FieldsDetails,
+		})
+	}
+
+	return result
+}
+
+// blockViolations returns the block response template violations of the
+// validation errors
+func blockViolations(validationErrs []*apiModeValidator.ValidationError) []web.BlockViolation {
+	result := make([]web.BlockViolation, 0, len(validationErrs))
+	for _, ve := range validationErrs {
+		result = append(result, web.BlockViolation{
+			Code:    ve.Code,
+			Message: ve.Message,
+			Fields:  ve.Fields,
+		})
+	}
+
+	return result
+}
+
+// operationBlockResponse returns the block response configured by the
+// OpenAPI extension of the route operation
+func operationBlockResponse(route *loader.CustomRoute) (*config.BlockResponse, error) {
+	if route == nil || route.Route == nil || route.Route.Operation == nil {
+		return nil, nil
+	}
+
+	ext, ok := route.Route.Operation.Extensions[blockResponseExtension]
+	if !ok {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(ext)
+	if err != nil {
+		return nil, err
+	}
+
+	var blockResponse config.BlockResponse
+	if err := json.Unmarshal(data, &blockResponse); err != nil {
+		return nil, fmt.Errorf("%s: %w", blockResponseExtension, err)
+	}
+
+	if blockResponse.StatusCode != 0 && fasthttp.StatusMessage(blockResponse.StatusCode) == "Unknown Status Code" {
+		return nil, fmt.Errorf("%s: invalid status code %d", blockResponseExtension, blockResponse.StatusCode)
+	}
+
+	return &blockResponse, nil
+}
+
+// newBlockResponse returns the response to the blocked request. The global
+// block response is overridden by the endpoint and then by the operation
+// block responses. The status code is used if the block responses do not set
+// it. Nil is returned if no block response is configured
+func newBlockResponse(cfg *config.ProxyMode, endpoint *config.Endpoint, route *loader.CustomRoute, statusCode int) (*web.BlockResponse, error) {
+	blockResponse := cfg.BlockResponse
+
+	if endpoint != nil {
+		blockResponse = blockResponse.Merge(endpoint.BlockResponse)
+	}
+
+	opBlockResponse, err := operationBlockResponse(route)
+	if err != nil {
+		return nil, err
+	}
+	blockResponse = blockResponse.Merge(opBlockResponse)
+
+	if blockResponse.IsEmpty() {
+		return nil, nil
+	}
+
+	if blockResponse.StatusCode == 0 {
+		blockResponse.StatusCode = statusCode
+	}
+
+	return web.NewBlockResponse(blockResponse.StatusCode, blockResponse.ContentType, blockResponse.Headers, blockResponse.Body)
+}
+
+// isUnsupportedContentType checks if the request validation failed only
+// because the request body parser was not found
+func isUnsupportedContentType(err error) bool {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		for _, multiErr := range e {
+			if !isUnsupportedContentType(multiErr) {
+				return false
+			}
+		}
+		return len(e) > 0
+	case *openapi3filter.RequestError:
+		return strings.HasPrefix(e.Error(), "request body has an error: failed to decode request body: unsupported content type")
+	}
+
+	return false
+}
+
+// respondValidationErrors responds to the blocked request by the configured
+// block response. Otherwise, the custom block status code is used and the
+// validation errors are added to the response body in the configured format
+func (s *openapiWaf) respondValidationErrors(ctx *fasthttp.RequestCtx, statusHeader, detail string, validationErrs []*apiModeValidator.ValidationError) error {
+
+	if s.blockResponse != nil {
+		return s.blockResponse.Respond(ctx, statusHeader, &web.BlockResponseData{
+			RequestID:  ctx.UserValue(web.RequestID),
+			Method:     string(ctx.Method()),
+			Path:       string(ctx.Path()),
+			Detail:     detail,
+			Violations: blockViolations(validationErrs),
+		})
+	}
+
+	statusCode := s.blockStatusCode
+
+	if err := web.RespondError(ctx, statusCode, statusHeader); err != nil {
+		return err
+	}
+
+	var (
+		body        any
+		contentType string
+	)
+
+	switch strings.ToUpper(s.cfg.ValidationErrors.Format) {
+	case errorsFormatJSON:
+		body = validationErrorsBody{Errors: errorEntries(validationErrs)}
+		contentType = "application/json"
+	case errorsFormatProblemJSON:
+		body = problemDetails{
+			Type:      problemTypeDefault,
+			Title:     fasthttp.StatusMessage(statusCode),
+			Status:    statusCode,
+			Detail:    detail,
+			Instance:  string(ctx.Path()),
+			RequestID: ctx.UserValue(web.RequestID),
+			Errors:    errorEntries(validationErrs),
+		}
+		contentType = problemJSONContentType
+	default:
+		return nil
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	ctx.SetContentType(contentType)
+	ctx.SetBody(data)
+
+	return nil
+}
@@ -0,0 +1,286 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/jsonlimits"
+	"github.com/wallarm/api-firewall/internal/platform/loader"
+	"github.com/wallarm/api-firewall/internal/platform/router"
+	"github.com/wallarm/api-firewall/internal/platform/web"
+)
+
+// extensions of the operations and path items which configure the firewall
+// settings of the operation. The operation extensions override the path item
+// extensions
+const (
+	requestValidationExtension          = "x-apifw-request-validation"
+	responseValidationExtension         = "x-apifw-response-validation"
+	unknownParametersDetectionExtension = "x-apifw-unknown-parameters-detection"
+	blockStatusCodeExtension            = "x-apifw-block-status-code"
+	rateLimitExtension                  = "x-apifw-rate-limit"
+	maxRequestBodySizeExtension         = "x-apifw-max-request-body-size"
+	jsonLimitsExtension                 = "x-apifw-json-limits"
+)
+
+// operationSettingsExtensions is the list of the operation settings extensions
+var operationSettingsExtensions = []string{
+	requestValidationExtension,
+	responseValidationExtension,
+	unknownParametersDetectionExtension,
+	blockStatusCodeExtension,
+	rateLimitExtension,
+	maxRequestBodySizeExtension,
+	jsonLimitsExtension,
+}
+
+// operationRateLimit is the rate limit of the operation
+type operationRateLimit struct {
+	Requests int    `json:"requests"`
+	Period   string `json:"period"`
+	Burst    int    `json:"burst"`
+}
+
+// operationJSONLimits is the structural limits of the JSON request bodies of
+// the operation. Nil fields are set by the global limits
+type operationJSONLimits struct {
+	MaxDepth        *int    `json:"maxDepth"`
+	MaxKeys         *int    `json:"maxKeys"`
+	MaxArrayLength  *int    `json:"maxArrayLength"`
+	MaxStringLength *int    `json:"maxStringLength"`
+	MaxTokens       *int    `json:"maxTokens"`
+	DuplicateKeys   *string `json:"duplicateKeys"`
+}
+
+// operationSettings holds the firewall settings of the operation set by the
+// specification extensions. Nil fields are not set by the extensions
+type operationSettings struct {
+	RequestValidation          *string              `json:"x-apifw-request-validation"`
+	ResponseValidation         *string              `json:"x-apifw-response-validation"`
+	UnknownParametersDetection *bool                `json:"x-apifw-unknown-parameters-detection"`
+	BlockStatusCode            *int                 `json:"x-apifw-block-status-code"`
+	RateLimit                  *operationRateLimit  `json:"x-apifw-rate-limit"`
+	MaxRequestBodySize         *int                 `json:"x-apifw-max-request-body-size"`
+	JSONLimits                 *operationJSONLimits `json:"x-apifw-json-limits"`
+
+	// ratePeriod is the parsed period of the rate limit
+	ratePeriod time.Duration
+}
+
+// newOperationSettings returns the firewall settings of the route operation.
+// The path item extensions are overridden by the operation extensions. The
+// invalid extensions are not applied and returned as the error, the valid
+// extensions of the operation are applied anyway
+func newOperationSettings(route *loader.CustomRoute) (*operationSettings, error) {
+	var settings operationSettings
+	var errs error
+
+	if route == nil || route.Route == nil {
+		return &settings, nil
+	}
+
+	for _, name := range operationSettingsExtensions {
+		var value any
+		var ok bool
+
+		if route.Route.Operation != nil {
+			value, ok = route.Route.Operation.Extensions[name]
+		}
+		if !ok && route.Route.PathItem != nil {
+			value, ok = route.Route.PathItem.Extensions[name]
+		}
+		if !ok {
+			continue
+		}
+
+		data, err := json.Marshal(map[string]any{name: value})
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		// the extension is applied to the copy of the settings to skip it if
+		// the value is invalid
+		ext := settings
+		if err := json.Unmarshal(data, &ext); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		if err := ext.validate(); err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+
+		settings = ext
+	}
+
+	return &settings, errs
+}
+
+// validate method checks the values of the extensions
+func (o *operationSettings) validate() error {
+	if err := validateMode(requestValidationExtension, o.RequestValidation); err != nil {
+		return err
+	}
+	if err := validateMode(responseValidationExtension, o.ResponseValidation); err != nil {
+		return err
+	}
+
+	if o.BlockStatusCode != nil && fasthttp.StatusMessage(*o.BlockStatusCode) == "Unknown Status Code" {
+		return fmt.Errorf("%s: invalid status code %d", blockStatusCodeExtension, *o.BlockStatusCode)
+	}
+
+	if o.RateLimit != nil {
+		if o.RateLimit.Requests <= 0 {
+			return fmt.Errorf("%s: the number of requests should be greater than 0", rateLimitExtension)
+		}
+		if o.RateLimit.Burst < 0 {
+			return fmt.Errorf("%s: the burst should not be negative", rateLimitExtension)
+		}
+
+		o.ratePeriod = time.Second
+		if o.RateLimit.Period != "" {
+			period, err := time.ParseDuration(o.RateLimit.Period)
+			if err != nil {
+				return fmt.Errorf("%s: %w", rateLimitExtension, err)
+			}
+			if period <= 0 {
+				return fmt.Errorf("%s: the period should be greater than 0", rateLimitExtension)
+			}
+			o.ratePeriod = period
+		}
+	}
+
+	if o.MaxRequestBodySize != nil && *o.MaxRequestBodySize <= 0 {
+		return fmt.Errorf("%s: the size should be greater than 0", maxRequestBodySizeExtension)
+	}
+
+	if o.JSONLimits != nil {
+		for _, limit := range []*int{o.JSONLimits.MaxDepth, o.JSONLimits.MaxKeys, o.JSONLimits.MaxArrayLength,
+			o.JSONLimits.MaxStringLength, o.JSONLimits.MaxTokens} {
+			if limit != nil && *limit < 0 {
+				return fmt.Errorf("%s: the limit should not be negative", jsonLimitsExtension)
+			}
+		}
+		if err := validateMode(jsonLimitsExtension, o.JSONLimits.DuplicateKeys); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateMode checks the validation mode set by the extension
+func validateMode(name string, mode *string) error {
+	if mode == nil {
+		return nil
+	}
+
+	*mode = strings.TrimSpace(*mode)
+	switch strings.ToLower(*mode) {
+	case web.ValidationDisable, web.ValidationBlock, web.ValidationLog:
+		return nil
+	}
+
+	return fmt.Errorf("%s: invalid validation mode %q", name, *mode)
+}
+
+// actions method returns the validation modes of the operation. The modes of
+// the endpoint configured by the environment variables are used first, then
+// the modes set by the extensions and then the global modes. Nil is returned
+// if the operation uses the global modes
+func (o *operationSettings) actions(cfg *config.ProxyMode, endpoint *config.Endpoint) *router.Actions {
+	if actions := endpointActions(endpoint); actions != nil {
+		return actions
+	}
+
+	if o.RequestValidation == nil && o.ResponseValidation == nil {
+		return nil
+	}
+
+	actions := router.Actions{
+		Request:  cfg.RequestValidation,
+		Response: cfg.ResponseValidation,
+	}
+
+	if o.RequestValidation != nil {
+		actions.Request = *o.RequestValidation
+	}
+	if o.ResponseValidation != nil {
+		actions.Response = *o.ResponseValidation
+	}
+
+	return &actions
+}
+
+// unknownParametersDetection method returns true if the unknown parameters of
+// the operation requests should be detected
+func (o *operationSettings) unknownParametersDetection(cfg *config.ProxyMode) bool {
+	if o.UnknownParametersDetection != nil {
+		return *o.UnknownParametersDetection
+	}
+
+	return cfg.ShadowAPI.UnknownParametersDetection
+}
+
+// blockStatusCode method returns the status code of the response to the
+// blocked request of the operation. The status code of the endpoint block
+// response overrides the extension
+func (o *operationSettings) blockStatusCode(cfg *config.ProxyMode, endpoint *config.Endpoint) int {
+	if endpoint != nil && endpoint.BlockResponse != nil && endpoint.BlockResponse.StatusCode != 0 {
+		return endpoint.BlockResponse.StatusCode
+	}
+
+	if o.BlockStatusCode != nil {
+		return *o.BlockStatusCode
+	}
+
+	return cfg.CustomBlockStatusCode
+}
+
+// maxRequestBodySize method returns the max size of the operation request
+// body. Zero is returned if the size is limited by the server only
+func (o *operationSettings) maxRequestBodySize() int {
+	if o.MaxRequestBodySize != nil {
+		return *o.MaxRequestBodySize
+	}
+
+	return 0
+}
+
+// jsonLimits method returns the structural limits of the JSON request bodies
+// of the operation. The global limits are overridden by the extension. Nil is
+// returned if no limit is configured
+func (o *operationSettings) jsonLimits(cfg *config.ProxyMode) *config.JSONLimits {
+	limits := cfg.JSONLimits
+
+	if ext := o.JSONLimits; ext != nil {
+		setLimit(&limits.MaxDepth, ext.MaxDepth)
+		setLimit(&limits.MaxKeys, ext.MaxKeys)
+		setLimit(&limits.MaxArrayLength, ext.MaxArrayLength)
+		setLimit(&limits.MaxStringLength, ext.MaxStringLength)
+		setLimit(&limits.MaxTokens, ext.MaxTokens)
+		if ext.DuplicateKeys != nil {
+			limits.DuplicateKeys = *ext.DuplicateKeys
+		}
+	}
+
+	if !jsonlimits.Enabled(&limits) {
+		return nil
+	}
+
+	return &limits
+}
+
+func setLimit(limit *int, value *int) {
+	if value != nil {
+		*limit = *value
+	}
+}
@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"net"
 	"os"
 
 	"github.com/rs/zerolog"
@@ -13,36 +14,96 @@ import (
 type Health struct {
 	Logger zerolog.Logger
 	Pool   proxy.Pool
+	Specs  []*SpecTarget
 }
 
-// Readiness checks if the Fasthttp connection pool is ready to handle new requests.
+// poolStats is implemented by the pools which provide the backends health state
+type poolStats interface {
+	Stats() proxy.PoolV2Stats
+}
+
+// poolStatus contains the health state of the backends of the pool
+type poolStatus struct {
+	Spec        string                `json:"spec,omitempty"`
+	Host        string                `json:"host"`
+	CircuitOpen bool                  `json:"circuit_open,omitempty"`
+	Backends    []proxy.BackendStatus `json:"backends"`
+}
+
+// Readiness checks if the Fasthttp connection pools are ready to handle new requests.
 func (h *Health) Readiness(ctx *fasthttp.RequestCtx) error {
 
 	status := "ok"
 	statusCode := fasthttp.StatusOK
 
-	reverseProxy, ip, err := h.Pool.Get()
-	if err != nil {
+	ready, backends := h.backends()
+	if !ready {
 		status = "not ready"
 		statusCode = fasthttp.StatusInternalServerError
 	}
 
-	if reverseProxy != nil {
-		if err := h.Pool.Put(ip, reverseProxy); err != nil {
-			status = "not ready"
-			statusCode = fasthttp.StatusInternalServerError
-		}
-	}
-
 	data := struct {
-		Status string `json:"status"`
+		Status   string       `json:"status"`
+		Backends []poolStatus `json:"backends,omitempty"`
 	}{
-		Status: status,
+		Status:   status,
+		Backends: backends,
 	}
 
 	return web.Respond(ctx, data, statusCode)
 }
 
+// backends returns the health state of the backends of all specifications and
+// true if all the pools are ready
+func (h *Health) backends() (bool, []poolStatus) {
+	ready := true
+
+	pools := []proxy.Pool{h.Pool}
+	names := []string{""}
+	if len(h.Specs) > 0 {
+		names[0] = defaultSpecName
+	}
+	for _, spec := range h.Specs {
+		pools = append(pools, spec.Pool)
+		names = append(names, spec.Name)
+	}
+
+	var backends []poolStatus
+	for i, pool := range pools {
+		if !isPoolReady(pool) {
+			ready = false
+		}
+
+		if ps, ok := pool.(poolStats); ok {
+			stats := ps.Stats()
+			backends = append(backends, poolStatus{
+				Spec:        names[i],
+				Host:        net.JoinHostPort(stats.Host, stats.Port),
+				CircuitOpen: stats.CircuitOpen,
+				Backends:    stats.BackendsStatus,
+			})
+		}
+	}
+
+	return ready, backends
+}
+
+// isPoolReady checks if the connection could be taken from the pool
+func isPoolReady(pool proxy.Pool) bool {
+	reverseProxy, ip, err := pool.Get()
+	if err != nil {
+		return false
+	}
+
+	if reverseProxy != nil {
+		if err := pool.Put(ip, reverseProxy); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Liveness returns simple status info if the service is alive. If the
 // app is deployed to a Kubernetes cluster, it will also return pod, node, and
 // namespace details via the Downward API. The Kubernetes environment variables
@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/platform/events"
+	"github.com/wallarm/api-firewall/internal/platform/jsonlimits"
+	"github.com/wallarm/api-firewall/internal/platform/web"
+)
+
+// checkJSONLimits checks the structural limits of the JSON request body. The
+// request exceeding the limit is blocked. The request with the duplicate keys
+// is blocked or logged according to the duplicate keys mode. The violations are
+// only logged if the request validation mode is LOG_ONLY. True is returned if
+// the request has been blocked
+func (s *openapiWaf) checkJSONLimits(ctx *fasthttp.RequestCtx, body []byte, requestValidationMode string) (bool, error) {
+	duplicates, limitErr := jsonlimits.Check(body, s.jsonLimits)
+	if limitErr == nil && len(duplicates) == 0 {
+		return false, nil
+	}
+
+	validationErrs := jsonlimits.ValidationErrors(limitErr, duplicates)
+	isBlocked := strings.EqualFold(requestValidationMode, web.ValidationBlock) &&
+		(limitErr != nil || strings.EqualFold(s.jsonLimits.DuplicateKeys, web.ValidationBlock))
+
+	ctx.SetUserValue(web.RequestValidationFailed, true)
+	events.Record(ctx, events.TypeRequestValidation, events.Action(isBlocked), violations(validationErrs)...)
+
+	if !isBlocked {
+		s.logger.Error().
+			Interface("validation_errors", errorEntries(validationErrs)).
+			Interface("request_id", ctx.UserValue(web.RequestID)).
+			Bytes("host", ctx.Request.Header.Host()).
+			Bytes("path", ctx.Path()).
+			Bytes("method", ctx.Request.Header.Method()).
+			Msg("Request body JSON limits violation")
+		return false, nil
+	}
+
+	s.logger.Error().
+		Interface("validation_errors", errorEntries(validationErrs)).
+		Interface("request_id", ctx.UserValue(web.RequestID)).
+		Bytes("host", ctx.Request.Header.Host()).
+		Bytes("path", ctx.Path()).
+		Bytes("method", ctx.Request.Header.Method()).
+		Msg("Request body JSON limits violation: request blocked")
+
+	ctx.SetUserValue(web.RequestBlocked, true)
+
+	return true, s.respondValidationErrors(ctx, "", requestBlockedDetail, validationErrs)
+}
@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"crypto"
+	"os"
+	"strings"
+
+	"github.com/karlseguin/ccache/v2"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/wallarm/api-firewall/internal/config"
+	woauth2 "github.com/wallarm/api-firewall/internal/platform/oauth2"
+)
+
+// OAuthValidators holds the validators of the OAuth tokens. The validators are
+// created once and shared by the handlers, so the fetched keys, the OpenID
+// Connect provider metadata and the cached introspection results are kept on
+// the specification reloads
+type OAuthValidators struct {
+	// Validator validates the tokens of the oauth2 security schemes. Nil is
+	// set if the OAuth validation is not configured
+	Validator woauth2.OAuth2
+	// OIDC validates the tokens of the openIdConnect security schemes
+	OIDC *woauth2.Providers
+}
+
+// NewOAuthValidators creates the token validators by the OAuth settings
+func NewOAuthValidators(cfg *config.Oauth, logger zerolog.Logger) (*OAuthValidators, error) {
+
+	// the openIdConnect tokens are validated by the JWT algorithms setting. The
+	// tokens are rejected if the algorithms are not set
+	var algorithms []string
+	if cfg.JWT.SignatureAlgorithm != "" || strings.EqualFold(cfg.ValidationType, "jwt") {
+		var err error
+		if algorithms, err = woauth2.ParseAlgorithms(cfg.JWT.SignatureAlgorithm); err != nil {
+			return nil, errors.Wrap(err, "signature algorithms parsing error")
+		}
+	}
+
+	validators := OAuthValidators{
+		OIDC: woauth2.NewProviders(cfg, algorithms, logger),
+	}
+
+	switch strings.ToLower(cfg.ValidationType) {
+	case "jwt":
+		var key crypto.PublicKey
+		var keySet *woauth2.KeySet
+		if cfg.JWT.JWKS.Source != "" {
+			keySet = woauth2.NewKeySet(&cfg.JWT.JWKS, logger)
+			logger.Info().Msgf("OAuth2: JWKS source %s is used", cfg.JWT.JWKS.Source)
+		} else if cfg.JWT.PubCertFile != "" {
+			verifyBytes, err := os.ReadFile(cfg.JWT.PubCertFile)
+			if err != nil {
+				return nil, errors.Wrap(err, "public key reading error")
+			}
+
+			key, err = woauth2.ParsePublicKeyFromPEM(verifyBytes)
+			if err != nil {
+				return nil, errors.Wrap(err, "public key parsing error")
+			}
+
+			logger.Info().Msgf("OAuth2: public certificate successfully loaded")
+		}
+
+		validators.Validator = &woauth2.JWT{
+			Cfg:        cfg,
+			Logger:     logger,
+			Algorithms: algorithms,
+			PubKey:     key,
+			SecretKey:  []byte(cfg.JWT.SecretKey),
+			KeySet:     keySet,
+		}
+
+	case "introspection":
+		validators.Validator = &woauth2.Introspection{
+			Cfg:    cfg,
+			Logger: logger,
+			Cache:  ccache.New(ccache.Configure()),
+		}
+	}
+
+	return &validators, nil
+}
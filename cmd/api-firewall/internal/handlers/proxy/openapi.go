@@ -1,10 +1,12 @@
 package proxy
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
@@ -17,12 +19,15 @@ import (
 	"github.com/valyala/fastjson"
 
 	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/events"
+	"github.com/wallarm/api-firewall/internal/platform/jsonlimits"
 	"github.com/wallarm/api-firewall/internal/platform/loader"
 	"github.com/wallarm/api-firewall/internal/platform/oauth2"
 	"github.com/wallarm/api-firewall/internal/platform/proxy"
 	"github.com/wallarm/api-firewall/internal/platform/router"
 	"github.com/wallarm/api-firewall/internal/platform/validator"
 	"github.com/wallarm/api-firewall/internal/platform/web"
+	apiModeValidator "github.com/wallarm/api-firewall/pkg/APIMode/validator"
 )
 
 type openapiWaf struct {
@@ -32,6 +37,29 @@ type openapiWaf struct {
 	cfg            *config.ProxyMode
 	parserPool     *fastjson.ParserPool
 	oauthValidator oauth2.OAuth2
+	oidcProviders  *oauth2.Providers
+	retrySafe      bool
+	blockResponse  *web.BlockResponse
+
+	// firewall settings of the operation
+	unknownParametersDetection bool
+	blockStatusCode            int
+	maxRequestBodySize         int
+	jsonLimits                 *config.JSONLimits
+}
+
+// retrySafeExtension marks the operation with non-idempotent method which
+// could be safely retried by the proxy
+const retrySafeExtension = "x-apifw-retry-safe"
+
+// isRetrySafe checks if the operation of the route is marked as retry-safe
+func isRetrySafe(route *loader.CustomRoute) bool {
+	if route == nil || route.Route == nil || route.Route.Operation == nil {
+		return false
+	}
+
+	retrySafe, ok := route.Route.Operation.Extensions[retrySafeExtension].(bool)
+	return ok && retrySafe
 }
 
 // EXPERIMENTAL feature
@@ -39,6 +67,11 @@ type openapiWaf struct {
 func getValidationHeader(ctx *fasthttp.RequestCtx, err error) *string {
 	var reason = "unknown"
 
+	// the first error is used if all errors are collected
+	if multiErr, ok := err.(openapi3.MultiError); ok && len(multiErr) > 0 {
+		err = multiErr[0]
+	}
+
 	switch err := err.(type) {
 	case *openapi3filter.ResponseError:
 		if err.Reason != "" {
@@ -99,6 +132,11 @@ func getValidationHeader(ctx *fasthttp.RequestCtx, err error) *string {
 
 func (s *openapiWaf) openapiWafHandler(ctx *fasthttp.RequestCtx) error {
 
+	// the operation could be retried by the proxy regardless of the method
+	if s.retrySafe {
+		ctx.SetUserValue(web.RequestRetrySafe, true)
+	}
+
 	// pass OPTIONS if the feature is enabled
 	var isOptionsReq, ok bool
 	if isOptionsReq, ok = ctx.UserValue(web.PassRequestOPTIONS).(bool); !ok {
@@ -133,12 +171,17 @@ func (s *openapiWaf) openapiWafHandler(ctx *fasthttp.RequestCtx) error {
 		// route for the request not found
 		ctx.SetUserValue(web.RequestProxyNoRoute, true)
 
-		if strings.EqualFold(RequestValidationMode, web.ValidationBlock) || strings.EqualFold(ResponseValidationMode, web.ValidationBlock) {
+		isBlocked := strings.EqualFold(RequestValidationMode, web.ValidationBlock) || strings.EqualFold(ResponseValidationMode, web.ValidationBlock)
+		if isBlocked || strings.EqualFold(RequestValidationMode, web.ValidationLog) || strings.EqualFold(ResponseValidationMode, web.ValidationLog) {
+			events.Record(ctx, events.TypeShadowAPI, events.Action(isBlocked), violations(routeNotFoundErrors())...)
+		}
+
+		if isBlocked {
 			if s.cfg.AddValidationStatusHeader {
 				vh := "request: customRoute not found"
-				return web.RespondError(ctx, s.cfg.CustomBlockStatusCode, vh)
+				return s.respondValidationErrors(ctx, vh, requestBlockedDetail, routeNotFoundErrors())
 			}
-			return web.RespondError(ctx, s.cfg.CustomBlockStatusCode, "")
+			return s.respondValidationErrors(ctx, "", requestBlockedDetail, routeNotFoundErrors())
 		}
 
 		if err := proxy.Perform(ctx, s.proxyPool, s.cfg.Server.RequestHostHeader); err != nil {
@@ -189,6 +232,42 @@ func (s *openapiWaf) openapiWafHandler(ctx *fasthttp.RequestCtx) error {
 		}
 	}
 
+	// the decompressed request body is checked by the operation body size
+	// limit and by the structural limits of JSON before it is parsed
+	isRequestValidated := !strings.EqualFold(RequestValidationMode, web.ValidationDisable)
+	checkBodySize := isRequestValidated && s.maxRequestBodySize > 0
+	checkJSONLimits := isRequestValidated && s.jsonLimits != nil && jsonlimits.IsJSON(strconv.B2S(ctx.Request.Header.ContentType()))
+
+	if checkBodySize || checkJSONLimits {
+		body := ctx.Request.Body()
+		if requestContentEncoding != "" {
+			var err error
+			if body, err = io.ReadAll(req.Body); err != nil {
+				s.logger.Error().
+					Err(err).
+					Interface("request_id", ctx.UserValue(web.RequestID)).
+					Bytes("host", ctx.Request.Header.Host()).
+					Bytes("path", ctx.Path()).
+					Bytes("method", ctx.Request.Header.Method()).
+					Msg("Request body decompression error")
+				return err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		if checkBodySize {
+			if blocked, err := s.checkRequestBodySize(ctx, len(body), RequestValidationMode); blocked || err != nil {
+				return err
+			}
+		}
+
+		if checkJSONLimits {
+			if blocked, err := s.checkJSONLimits(ctx, body, RequestValidationMode); blocked || err != nil {
+				return err
+			}
+		}
+	}
+
 	// validate request
 	requestValidationInput := &openapi3filter.RequestValidationInput{
 		Request:     &req,
@@ -196,6 +275,7 @@ func (s *openapiWaf) openapiWafHandler(ctx *fasthttp.RequestCtx) error {
 		Route:       s.customRoute.Route,
 		QueryParams: req.URL.Query(),
 		Options: &openapi3filter.Options{
+			MultiError: s.cfg.ValidationErrors.CollectAll,
 			AuthenticationFunc: func(ctx context.Context, input *openapi3filter.AuthenticationInput) error {
 				switch input.SecurityScheme.Type {
 				case "http":
@@ -211,7 +291,25 @@ func (s *openapiWaf) openapiWafHandler(ctx *fasthttp.RequestCtx) error {
 							return errors.New("missing bearer authorization header")
 						}
 					}
-				case "oauth2", "openIdConnect":
+				case "openIdConnect":
+					// the scheme without the discovery URL is validated as oauth2
+					if input.SecurityScheme.OpenIdConnectUrl == "" {
+						if s.oauthValidator == nil {
+							return errors.New("oauth2 validator not configured")
+						}
+						if err := s.oauthValidator.Validate(ctx, input.RequestValidationInput.Request.Header.Get("Authorization"), input.Scopes); err != nil {
+							return fmt.Errorf("oauth2 error: %s", err)
+						}
+						break
+					}
+					if s.oidcProviders == nil {
+						return errors.New("openIdConnect validator not configured")
+					}
+					provider := s.oidcProviders.Provider(input.SecurityScheme.OpenIdConnectUrl)
+					if err := provider.Validate(ctx, input.RequestValidationInput.Request.Header.Get("Authorization"), input.Scopes); err != nil {
+						return fmt.Errorf("openIdConnect error: %s", err)
+					}
+				case "oauth2":
 					if s.oauthValidator == nil {
 						return errors.New("oauth2 validator not configured")
 					}
@@ -247,52 +345,39 @@ func (s *openapiWaf) openapiWafHandler(ctx *fasthttp.RequestCtx) error {
 
 	switch strings.ToLower(RequestValidationMode) {
 	case web.ValidationBlock:
-		if err := validator.ValidateRequest(ctx, requestValidationInput, jsonParser); err != nil {
+		var requestErr error
+		var validationErrs []*apiModeValidator.ValidationError
 
-			isRequestBlocked := true
-			if requestErr, ok := err.(*openapi3filter.RequestError); ok {
-
-				// body parser not found
-				if strings.HasPrefix(requestErr.Error(), "request body has an error: failed to decode request body: unsupported content type") {
-					s.logger.Error().
-						Err(err).
-						Interface("request_id", ctx.UserValue(web.RequestID)).
-						Bytes("host", ctx.Request.Header.Host()).
-						Bytes("path", ctx.Path()).
-						Bytes("method", ctx.Request.Header.Method()).
-						Msg("Request body parsing error: request passed")
-					isRequestBlocked = false
-				}
-			}
+		if err := validator.ValidateRequest(ctx, requestValidationInput, jsonParser); err != nil {
 
-			if isRequestBlocked {
-				// request has been blocked
-				ctx.SetUserValue(web.RequestBlocked, true)
+			// body parser not found
+			if isUnsupportedContentType(err) {
+				s.logger.Error().
+					Err(err).
+					Interface("request_id", ctx.UserValue(web.RequestID)).
+					Bytes("host", ctx.Request.Header.Host()).
+					Bytes("path", ctx.Path()).
+					Bytes("method", ctx.Request.Header.Method()).
+					Msg("Request body parsing error: request passed")
+			} else {
+				requestErr = err
+				validationErrs = validationErrors(err)
+				events.Record(ctx, events.TypeRequestValidation, events.ActionBlocked, violations(validationErrs)...)
 
 				s.logger.Error().
 					Err(err).
+					Interface("validation_errors", errorEntries(validationErrs)).
 					Interface("request_id", ctx.UserValue(web.RequestID)).
 					Bytes("host", ctx.Request.Header.Host()).
 					Bytes("path", ctx.Path()).
 					Bytes("method", ctx.Request.Header.Method()).
 					Msg("Request validation error: request blocked")
-
-				if s.cfg.AddValidationStatusHeader {
-					if vh := getValidationHeader(ctx, err); vh != nil {
-						s.logger.Error().
-							Err(err).
-							Interface("request_id", ctx.UserValue(web.RequestID)).
-							Msgf("add header %s: %s", web.ValidationStatus, *vh)
-						ctx.Request.Header.Add(web.ValidationStatus, *vh)
-						return web.RespondError(ctx, s.cfg.CustomBlockStatusCode, *vh)
-					}
-				}
-
-				return web.RespondError(ctx, s.cfg.CustomBlockStatusCode, "")
 			}
 		}
 
-		if s.cfg.ShadowAPI.UnknownParametersDetection {
+		// the unknown parameters are searched after the request validation
+		// error only if all errors should be collected
+		if s.unknownParametersDetection && (requestErr == nil || s.cfg.ValidationErrors.CollectAll) {
 			upResults, valUPReqErrors := validator.ValidateUnknownRequestParameters(ctx, requestValidationInput.Route, req.Header, jsonParser)
 			// log only error and pass request if unknown params module can't parse it
 			if valUPReqErrors != nil {
@@ -312,15 +397,40 @@ func (s *openapiWaf) openapiWafHandler(ctx *fasthttp.RequestCtx) error {
 					Bytes("method", ctx.Request.Header.Method()).
 					Msg("Shadow API: undefined parameters found")
 
-				// request has been blocked
-				ctx.SetUserValue(web.RequestBlocked, true)
-				return web.RespondError(ctx, s.cfg.CustomBlockStatusCode, "")
+				upErrs := unknownParametersErrors(upResults)
+				events.Record(ctx, events.TypeShadowAPI, events.ActionBlocked, violations(upErrs)...)
+				validationErrs = append(validationErrs, upErrs...)
+			}
+		}
+
+		if len(validationErrs) > 0 {
+			// request has been blocked
+			ctx.SetUserValue(web.RequestBlocked, true)
+			ctx.SetUserValue(web.RequestValidationFailed, true)
+
+			if s.cfg.AddValidationStatusHeader && requestErr != nil {
+				if vh := getValidationHeader(ctx, requestErr); vh != nil {
+					s.logger.Error().
+						Err(requestErr).
+						Interface("request_id", ctx.UserValue(web.RequestID)).
+						Msgf("add header %s: %s", web.ValidationStatus, *vh)
+					ctx.Request.Header.Add(web.ValidationStatus, *vh)
+					return s.respondValidationErrors(ctx, *vh, requestBlockedDetail, validationErrs)
+				}
 			}
+
+			return s.respondValidationErrors(ctx, "", requestBlockedDetail, validationErrs)
 		}
 	case web.ValidationLog:
 		if err := validator.ValidateRequest(ctx, requestValidationInput, jsonParser); err != nil {
+			validationErrs := validationErrors(err)
+
+			ctx.SetUserValue(web.RequestValidationFailed, true)
+			events.Record(ctx, events.TypeRequestValidation, events.ActionLogged, violations(validationErrs)...)
+
 			s.logger.Error().
 				Err(err).
+				Interface("validation_errors", errorEntries(validationErrs)).
 				Interface("request_id", ctx.UserValue(web.RequestID)).
 				Bytes("host", ctx.Request.Header.Host()).
 				Bytes("path", ctx.Path()).
@@ -328,7 +438,7 @@ func (s *openapiWaf) openapiWafHandler(ctx *fasthttp.RequestCtx) error {
 				Msg("Request validation error")
 		}
 
-		if s.cfg.ShadowAPI.UnknownParametersDetection {
+		if s.unknownParametersDetection {
 			upResults, valUPReqErrors := validator.ValidateUnknownRequestParameters(ctx, requestValidationInput.Route, req.Header, jsonParser)
 			// log only error and pass request if unknown params module can't parse it
 			if valUPReqErrors != nil {
@@ -339,6 +449,9 @@ func (s *openapiWaf) openapiWafHandler(ctx *fasthttp.RequestCtx) error {
 			}
 
 			if len(upResults) > 0 {
+				ctx.SetUserValue(web.RequestValidationFailed, true)
+				events.Record(ctx, events.TypeShadowAPI, events.ActionLogged, violations(unknownParametersErrors(upResults))...)
+
 				unknownParameters, _ := json.Marshal(upResults)
 				s.logger.Error().
 					Bytes("errors", unknownParameters).
@@ -393,7 +506,7 @@ func (s *openapiWaf) openapiWafHandler(ctx *fasthttp.RequestCtx) error {
 			ExcludeRequestBody:    false,
 			ExcludeResponseBody:   false,
 			IncludeResponseStatus: true,
-			MultiError:            false,
+			MultiError:            s.cfg.ValidationErrors.CollectAll,
 			AuthenticationFunc:    nil,
 		},
 	}
@@ -402,10 +515,16 @@ func (s *openapiWaf) openapiWafHandler(ctx *fasthttp.RequestCtx) error {
 	switch strings.ToLower(ResponseValidationMode) {
 	case web.ValidationBlock:
 		if err := validator.ValidateResponse(ctx, responseValidationInput, jsonParser); err != nil {
+			validationErrs := validationErrors(err)
+
 			// response has been blocked
 			ctx.SetUserValue(web.ResponseBlocked, true)
+			ctx.SetUserValue(web.ResponseValidationFailed, true)
+			events.Record(ctx, events.TypeResponseValidation, events.ActionBlocked, violations(validationErrs)...)
+
 			s.logger.Error().
 				Err(err).
+				Interface("validation_errors", errorEntries(validationErrs)).
 				Interface("request_id", ctx.UserValue(web.RequestID)).
 				Bytes("host", ctx.Request.Header.Host()).
 				Bytes("path", ctx.Path()).
@@ -419,10 +538,10 @@ func (s *openapiWaf) openapiWafHandler(ctx *fasthttp.RequestCtx) error {
 						Interface("request_id", ctx.UserValue(web.RequestID)).
 						Msgf("Add header %s: %s", web.ValidationStatus, *vh)
 					ctx.Response.Header.Add(web.ValidationStatus, *vh)
-					return web.RespondError(ctx, s.cfg.CustomBlockStatusCode, *vh)
+					return s.respondValidationErrors(ctx, *vh, responseBlockedDetail, validationErrs)
 				}
 			}
-			return web.RespondError(ctx, s.cfg.CustomBlockStatusCode, "")
+			return s.respondValidationErrors(ctx, "", responseBlockedDetail, validationErrs)
 		}
 	case web.ValidationLog:
 		if err := validator.ValidateResponse(ctx, responseValidationInput, jsonParser); err != nil {
@@ -435,8 +554,14 @@ func (s *openapiWaf) openapiWafHandler(ctx *fasthttp.RequestCtx) error {
 				return nil
 			}
 
+			validationErrs := validationErrors(err)
+
+			ctx.SetUserValue(web.ResponseValidationFailed, true)
+			events.Record(ctx, events.TypeResponseValidation, events.ActionLogged, violations(validationErrs)...)
+
 			s.logger.Error().
 				Err(err).
+				Interface("validation_errors", errorEntries(validationErrs)).
 				Interface("request_id", ctx.UserValue(web.RequestID)).
 				Bytes("host", ctx.Request.Header.Host()).
 				Bytes("path", ctx.Path()).
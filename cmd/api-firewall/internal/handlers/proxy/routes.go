@@ -1,16 +1,13 @@
 package proxy
 
 import (
-	"crypto/rsa"
+	"fmt"
 	"net/url"
 	"os"
-	"strings"
 	"sync"
 
 	"github.com/corazawaf/coraza/v3"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"github.com/karlseguin/ccache/v2"
 	"github.com/rs/zerolog"
 	"github.com/valyala/fasthttp"
 	"github.com/valyala/fastjson"
@@ -22,51 +19,26 @@ import (
 	"github.com/wallarm/api-firewall/internal/platform/loader"
 	woauth2 "github.com/wallarm/api-firewall/internal/platform/oauth2"
 	"github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/ratelimit"
 	"github.com/wallarm/api-firewall/internal/platform/router"
 	"github.com/wallarm/api-firewall/internal/platform/storage"
+	"github.com/wallarm/api-firewall/internal/platform/tracing"
 	"github.com/wallarm/api-firewall/internal/platform/web"
 )
 
-func Handlers(lock *sync.RWMutex, cfg *config.ProxyMode, serverURL *url.URL, shutdown chan os.Signal, logger zerolog.Logger, httpClientsPool proxy.Pool, specStorage storage.DBOpenAPILoader, deniedTokens *denylist.DeniedTokens, allowedIPCache *allowiplist.AllowedIPsType, waf coraza.WAF) fasthttp.RequestHandler {
+func Handlers(lock *sync.RWMutex, cfg *config.ProxyMode, serverURL *url.URL, shutdown chan os.Signal, logger zerolog.Logger, httpClientsPool proxy.Pool, specStorage storage.DBOpenAPILoader, deniedTokens *denylist.DeniedTokens, allowedIPCache *allowiplist.AllowedIPsType, waf coraza.WAF, rateLimiter *ratelimit.RateLimiter, oauthValidators *OAuthValidators) fasthttp.RequestHandler {
 
 	// define FastJSON parsers pool
 	var parserPool fastjson.ParserPool
 
-	// init OAuth validator
-	var oauthValidator woauth2.OAuth2
-
-	switch strings.ToLower(cfg.Server.Oauth.ValidationType) {
-	case "jwt":
-		var key *rsa.PublicKey
-		if strings.HasPrefix(strings.ToLower(cfg.Server.Oauth.JWT.SignatureAlgorithm), "rs") && cfg.Server.Oauth.JWT.PubCertFile != "" {
-			verifyBytes, err := os.ReadFile(cfg.Server.Oauth.JWT.PubCertFile)
-			if err != nil {
-				logger.Error().Msgf("Error reading public key from file: %v", err)
-				break
-			}
-
-			key, err = jwt.ParseRSAPublicKeyFromPEM(verifyBytes)
-			if err != nil {
-				logger.Error().Msgf("Error parsing public key: %v", err)
-				break
-			}
-
-			logger.Info().Msgf("OAuth2: public certificate successfully loaded")
-		}
-
-		oauthValidator = &woauth2.JWT{
-			Cfg:       &cfg.Server.Oauth,
-			Logger:    logger,
-			PubKey:    key,
-			SecretKey: []byte(cfg.Server.Oauth.JWT.SecretKey),
-		}
-
-	case "introspection":
-		oauthValidator = &woauth2.Introspection{
-			Cfg:    &cfg.Server.Oauth,
-			Logger: logger,
-			Cache:  ccache.New(ccache.Configure()),
+	// the validators are created by the cfg if they are not shared by the caller
+	if oauthValidators == nil {
+		validators, err := NewOAuthValidators(&cfg.Server.Oauth, logger)
+		if err != nil {
+			logger.Error().Msgf("Error initializing OAuth validators: %v", err)
+			validators = &OAuthValidators{OIDC: woauth2.NewProviders(&cfg.Server.Oauth, nil, logger)}
 		}
+		oauthValidators = validators
 	}
 
 	// define options Handler to handle requests with Options method
@@ -96,13 +68,23 @@ func Handlers(lock *sync.RWMutex, cfg *config.ProxyMode, serverURL *url.URL, shu
 		}
 	}
 
+	// init the global response to the blocked requests
+	defaultBlockResponse, err := newBlockResponse(cfg, nil, nil, cfg.CustomBlockStatusCode)
+	if err != nil {
+		logger.Error().Msgf("Error parsing block response: %v", err)
+		return nil
+	}
+
 	// set handler for default behavior (404, 405)
 	defaultOpenAPIWaf := openapiWaf{
-		customRoute: nil,
-		proxyPool:   httpClientsPool,
-		logger:      logger,
-		cfg:         cfg,
-		parserPool:  &parserPool,
+		customRoute:                nil,
+		proxyPool:                  httpClientsPool,
+		logger:                     logger,
+		cfg:                        cfg,
+		parserPool:                 &parserPool,
+		blockResponse:              defaultBlockResponse,
+		unknownParametersDetection: cfg.ShadowAPI.UnknownParametersDetection,
+		blockStatusCode:            cfg.CustomBlockStatusCode,
 	}
 
 	// construct the web.App which holds all routes as well as common Middleware.
@@ -130,6 +112,7 @@ func Handlers(lock *sync.RWMutex, cfg *config.ProxyMode, serverURL *url.URL, shu
 		CustomBlockStatusCode: cfg.CustomBlockStatusCode,
 		DeniedTokens:          deniedTokens,
 		Logger:                logger,
+		BlockResponse:         defaultBlockResponse,
 	}
 
 	ipAllowlistOptions := mid.IPAllowListOptions{
@@ -138,6 +121,16 @@ func Handlers(lock *sync.RWMutex, cfg *config.ProxyMode, serverURL *url.URL, shu
 		CustomBlockStatusCode: cfg.CustomBlockStatusCode,
 		AllowedIPs:            allowedIPCache,
 		Logger:                logger,
+		BlockResponse:         defaultBlockResponse,
+	}
+
+	rateLimitOptions := mid.RateLimitOptions{
+		Mode:          web.ProxyMode,
+		Config:        &cfg.RateLimit,
+		RateLimiter:   rateLimiter,
+		Logger:        logger,
+		Operations:    make(map[string]ratelimit.Limiter),
+		BlockResponse: defaultBlockResponse.WithStatusCode(fasthttp.StatusTooManyRequests),
 	}
 
 	// Use ModSecurity-specific validation settings if defined, otherwise fall back to global settings
@@ -157,6 +150,14 @@ func Handlers(lock *sync.RWMutex, cfg *config.ProxyMode, serverURL *url.URL, shu
 		RequestValidation:     modSecRequestValidation,
 		ResponseValidation:    modSecResponseValidation,
 		CustomBlockStatusCode: cfg.CustomBlockStatusCode,
+		BlockResponse:         defaultBlockResponse,
+	}
+
+	// init the rules which select the routes with the endpoint settings
+	endpointRules, err := newEndpointRules(cfg.Endpoints)
+	if err != nil {
+		logger.Error().Msgf("Error parsing endpoints: %v", err)
+		return nil
 	}
 
 	swagRouter, err := loader.NewRouter(specStorage.Specification(0), true)
@@ -165,12 +166,7 @@ func Handlers(lock *sync.RWMutex, cfg *config.ProxyMode, serverURL *url.URL, shu
 		return nil
 	}
 
-	app := web.NewApp(&options, shutdown, logger, mid.Logger(logger), mid.Errors(logger), mid.Panics(logger), mid.Proxy(&proxyOptions), mid.IPAllowlist(&ipAllowlistOptions), mid.Denylist(&denylistOptions), mid.WAFModSecurity(&modSecOptions), mid.ShadowAPIMonitor(logger, &cfg.ShadowAPI))
-
-	serverPath := "/"
-	if serverURL.Path != "" {
-		serverPath = serverURL.Path
-	}
+	app := web.NewApp(&options, shutdown, logger, mid.Logger(logger), mid.Errors(logger), mid.Panics(logger), mid.Proxy(&proxyOptions), mid.IPAllowlist(&ipAllowlistOptions), mid.Denylist(&denylistOptions), mid.RateLimit(&rateLimitOptions), mid.WAFModSecurity(&modSecOptions), mid.ShadowAPIMonitor(logger, &cfg.ShadowAPI))
 
 	for i := 0; i < len(swagRouter.Routes); i++ {
 		s := openapiWaf{
@@ -179,44 +175,119 @@ func Handlers(lock *sync.RWMutex, cfg *config.ProxyMode, serverURL *url.URL, shu
 			logger:         logger,
 			cfg:            cfg,
 			parserPool:     &parserPool,
-			oauthValidator: oauthValidator,
+			oauthValidator: oauthValidators.Validator,
+			oidcProviders:  oauthValidators.OIDC,
+			retrySafe:      isRetrySafe(&swagRouter.Routes[i]),
+			blockResponse:  defaultBlockResponse,
 		}
 
-		updRoutePathEsc, err := url.JoinPath(serverPath, swagRouter.Routes[i].Path)
+		updRoutePath, err := routePath(serverURL, swagRouter.Routes[i].Path)
 		if err != nil {
-			s.logger.Error().Msgf("url parse error: Loaded path %s - %v", swagRouter.Routes[i].Path, err)
+			s.logger.Error().Msgf("%v: Loaded path %s", err, swagRouter.Routes[i].Path)
 			continue
 		}
 
-		updRoutePath, err := url.PathUnescape(updRoutePathEsc)
+		s.logger.Debug().Msgf("handler: Loaded path %s - %s", swagRouter.Routes[i].Method, updRoutePath)
+
+		// the first matched endpoint rule is applied
+		var endpoint *config.Endpoint
+		if rule := findEndpointRule(endpointRules, &swagRouter.Routes[i], updRoutePath); rule != nil {
+			endpoint = rule.endpoint
+			logger.Info().
+				Str("method", swagRouter.Routes[i].Method).
+				Str("path", updRoutePath).
+				Str("endpoint", rule.String()).
+				Msgf("handler: endpoint %s applied to %s - %s", rule, swagRouter.Routes[i].Method, updRoutePath)
+		}
+
+		// set operation firewall settings of the specification extensions. The
+		// invalid extensions are skipped
+		settings, err := newOperationSettings(&swagRouter.Routes[i])
 		if err != nil {
-			s.logger.Error().Msgf("url unescape error: Loaded path %s - %v", swagRouter.Routes[i].Path, err)
-			continue
+			logger.Error().Err(err).Msgf("handler: invalid operation settings of %s - %s not applied", swagRouter.Routes[i].Method, updRoutePath)
 		}
 
-		s.logger.Debug().Msgf("handler: Loaded path %s - %s", swagRouter.Routes[i].Method, updRoutePath)
+		s.unknownParametersDetection = settings.unknownParametersDetection(cfg)
+		s.blockStatusCode = settings.blockStatusCode(cfg, endpoint)
+		s.maxRequestBodySize = settings.maxRequestBodySize()
+		s.jsonLimits = settings.jsonLimits(cfg)
+
+		// set endpoint and operation custom block response
+		blockResponse, err := newBlockResponse(cfg, endpoint, &swagRouter.Routes[i], s.blockStatusCode)
+		if err != nil {
+			logger.Error().Err(err).Msgf("handler: block response of %s - %s not applied", swagRouter.Routes[i].Method, updRoutePath)
+		} else {
+			s.blockResponse = blockResponse
+		}
+
+		// set operation rate limit of the specification extension
+		if settings.RateLimit != nil {
+			if rateLimitOptions.RateLimiter == nil {
+				rateLimitOptions.RateLimiter = ratelimit.NewOperationRateLimiter(&cfg.RateLimit)
+			}
 
-		// set endpoint custom validation modes
-		var actions *router.Actions
-		for _, endpoint := range cfg.Endpoints {
-			if strings.EqualFold(endpoint.Path, updRoutePath) && (endpoint.Method == "" || endpoint.Method != "" && strings.EqualFold(swagRouter.Routes[i].Method, endpoint.Method)) {
-				actions = new(router.Actions)
-				actions.Request = endpoint.RequestValidation
-				actions.Response = endpoint.ResponseValidation
-
-				logger.Debug().
-					Str("method", swagRouter.Routes[i].Method).
-					Str("path", updRoutePath).
-					Str("request_validation_mode", actions.Request).
-					Str("response_validation_mode", actions.Response).
-					Msgf("handler: custom validation mode applied for %s - %s: request %s, response %s", swagRouter.Routes[i].Method, updRoutePath, actions.Request, actions.Response)
+			limiter, err := rateLimitOptions.RateLimiter.Operation(swagRouter.Routes[i].Method, updRoutePath, settings.RateLimit.Requests, settings.ratePeriod, settings.RateLimit.Burst)
+			if err != nil {
+				logger.Error().Err(err).Msgf("handler: rate limit of %s - %s not applied", swagRouter.Routes[i].Method, updRoutePath)
+			} else {
+				rateLimitOptions.Operations[ratelimit.OperationKey(swagRouter.Routes[i].Method, updRoutePath)] = limiter
 			}
 		}
 
+		// set endpoint and operation custom validation modes
+		actions := settings.actions(cfg, endpoint)
+		if actions != nil {
+			logger.Debug().
+				Str("method", swagRouter.Routes[i].Method).
+				Str("path", updRoutePath).
+				Str("request_validation_mode", actions.Request).
+				Str("response_validation_mode", actions.Response).
+				Msgf("handler: custom validation mode applied for %s - %s: request %s, response %s", swagRouter.Routes[i].Method, updRoutePath, actions.Request, actions.Response)
+		}
+
 		if err := app.Handle(swagRouter.Routes[i].Method, updRoutePath, actions, s.openapiWafHandler); err != nil {
 			logger.Error().Err(err).Msgf("The OAS endpoint registration failed: method %s, path %s", swagRouter.Routes[i].Method, updRoutePath)
 		}
 	}
 
-	return app.MainHandler
+	// drop the operation limiters of the previous specification
+	if rateLimitOptions.RateLimiter != nil {
+		rateLimitOptions.RateLimiter.RetainOperations(rateLimitOptions.Operations)
+	}
+
+	return tracing.Handler(app.MainHandler)
+}
+
+// routePath returns the path of the specification route prefixed by the path of
+// the server URL
+func routePath(serverURL *url.URL, specPath string) (string, error) {
+	serverPath := "/"
+	if serverURL.Path != "" {
+		serverPath = serverURL.Path
+	}
+
+	pathEsc, err := url.JoinPath(serverPath, specPath)
+	if err != nil {
+		return "", fmt.Errorf("url parse error: %w", err)
+	}
+
+	path, err := url.PathUnescape(pathEsc)
+	if err != nil {
+		return "", fmt.Errorf("url unescape error: %w", err)
+	}
+
+	return path, nil
+}
+
+// endpointActions returns the custom validation modes of the endpoint. Nil is
+// returned if the endpoint has no custom validation modes
+func endpointActions(endpoint *config.Endpoint) *router.Actions {
+	if endpoint == nil || (endpoint.RequestValidation == "" && endpoint.ResponseValidation == "") {
+		return nil
+	}
+
+	return &router.Actions{
+		Request:  endpoint.RequestValidation,
+		Response: endpoint.ResponseValidation,
+	}
 }
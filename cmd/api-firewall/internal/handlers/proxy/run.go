@@ -1,7 +1,10 @@
 package proxy
 
 import (
+	"context"
+	"fmt"
 	"mime"
+	"net"
 	"net/url"
 	"os"
 	"os/signal"
@@ -9,6 +12,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/ardanlabs/conf"
 	"github.com/go-playground/validator"
@@ -21,8 +25,15 @@ import (
 	"github.com/wallarm/api-firewall/internal/config"
 	"github.com/wallarm/api-firewall/internal/platform/allowiplist"
 	"github.com/wallarm/api-firewall/internal/platform/denylist"
+	"github.com/wallarm/api-firewall/internal/platform/events"
+	"github.com/wallarm/api-firewall/internal/platform/formats"
+	"github.com/wallarm/api-firewall/internal/platform/metrics"
+	woauth2 "github.com/wallarm/api-firewall/internal/platform/oauth2"
 	"github.com/wallarm/api-firewall/internal/platform/proxy"
-	"github.com/wallarm/api-firewall/internal/platform/storage"
+	"github.com/wallarm/api-firewall/internal/platform/ratelimit"
+	"github.com/wallarm/api-firewall/internal/platform/storage/updater"
+	"github.com/wallarm/api-firewall/internal/platform/tracing"
+	"github.com/wallarm/api-firewall/internal/platform/web"
 	"github.com/wallarm/api-firewall/internal/version"
 )
 
@@ -69,14 +80,6 @@ func Run(logger zerolog.Logger) error {
 		return errors.Wrap(err, "configuration validator error")
 	}
 
-	// oauth introspection endpoint: validate format of configured content-type
-	if cfg.Server.Oauth.Introspection.ContentType != "" {
-		_, _, err := mime.ParseMediaType(cfg.Server.Oauth.Introspection.ContentType)
-		if err != nil {
-			return errors.Wrap(err, "configuration validator error")
-		}
-	}
-
 	// load apifw.yaml configuration file
 	viper.SetConfigName("apifw") // name of config file (without extension)
 	viper.SetConfigType("yaml")
@@ -91,6 +94,64 @@ func Run(logger zerolog.Logger) error {
 		log.Debug().Msgf("%s: yaml config file reading error: %v", logPrefix, err)
 	}
 
+	// validate the additional specifications (they could be set in the yaml config file)
+	for i, spec := range cfg.Specs {
+		if err := validate.Struct(spec); err != nil {
+			return errors.Wrapf(err, "configuration validator error: spec #%d", i+1)
+		}
+		if spec.Host == "" && spec.PathPrefix == "" {
+			return errors.Errorf("configuration validator error: spec #%d should have Host or PathPrefix parameter", i+1)
+		}
+	}
+
+	// oauth introspection endpoint: validate format of configured content-type
+	// (the OAuth settings could be set in the yaml config file)
+	if cfg.Server.Oauth.Introspection.ContentType != "" {
+		_, _, err := mime.ParseMediaType(cfg.Server.Oauth.Introspection.ContentType)
+		if err != nil {
+			return errors.Wrap(err, "configuration validator error")
+		}
+	}
+
+	// oauth introspection endpoint: validate the client authentication settings
+	if strings.EqualFold(cfg.Server.Oauth.ValidationType, "introspection") {
+		if err := woauth2.ValidateClientAuth(&cfg.Server.Oauth.Introspection); err != nil {
+			return errors.Wrap(err, "configuration validator error")
+		}
+	}
+
+	// oauth JWT and openIdConnect: validate the accepted signature algorithms
+	if strings.EqualFold(cfg.Server.Oauth.ValidationType, "jwt") || cfg.Server.Oauth.JWT.SignatureAlgorithm != "" {
+		if _, err := woauth2.ParseAlgorithms(cfg.Server.Oauth.JWT.SignatureAlgorithm); err != nil {
+			return errors.Wrap(err, "configuration validator error")
+		}
+	}
+
+	// validate the block responses (the endpoints could be set in the yaml config file)
+	if _, err := newBlockResponse(&cfg, nil, nil, cfg.CustomBlockStatusCode); err != nil {
+		return errors.Wrap(err, "configuration validator error: block response")
+	}
+
+	if _, err := newEndpointRules(cfg.Endpoints); err != nil {
+		return errors.Wrap(err, "configuration validator error")
+	}
+
+	for i, endpoint := range cfg.Endpoints {
+		if endpoint.BlockResponse == nil {
+			continue
+		}
+		if err := validate.Struct(endpoint.BlockResponse); err != nil {
+			return errors.Wrapf(err, "configuration validator error: endpoint #%d block response", i+1)
+		}
+		if _, err := newBlockResponse(&cfg, &cfg.Endpoints[i], nil, cfg.CustomBlockStatusCode); err != nil {
+			return errors.Wrapf(err, "configuration validator error: endpoint #%d block response", i+1)
+		}
+	}
+
+	if cfg.Admin.Enabled && cfg.Admin.Token == "" {
+		return errors.New("configuration validator error: parameter Admin.Token is required if the admin API is enabled")
+	}
+
 	// =========================================================================
 	// App Starting
 
@@ -118,48 +179,77 @@ func Run(logger zerolog.Logger) error {
 	var lock sync.RWMutex
 
 	// =========================================================================
-	// Init Swagger
+	// Init DNS Resolver
 
-	specStorage, err := storage.NewOpenAPIFromFileOrURL(cfg.APISpecs, &cfg.APISpecsCustomHeader)
-	if err != nil {
-		return errors.Wrap(err, "loading OpenAPI specification from File or URL")
+	// default DNS resolver
+	resolver := &net.Resolver{
+		PreferGo:     true,
+		StrictErrors: false,
 	}
 
-	// =========================================================================
-	// Init Proxy Client
+	// configuration of the custom DNS server
+	if cfg.DNS.Nameserver.Host != "" {
+		nameserver := net.JoinHostPort(cfg.DNS.Nameserver.Host, cfg.DNS.Nameserver.Port)
 
-	serverURL, err := url.ParseRequestURI(cfg.Server.URL)
-	if err != nil {
-		return errors.Wrap(err, "parsing proxy URL")
-	}
-	host := serverURL.Host
-	if serverURL.Port() == "" {
-		switch serverURL.Scheme {
-		case "https":
-			host += ":443"
-		case "http":
-			host += ":80"
+		resolver.Dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+			d := net.Dialer{
+				Timeout: cfg.DNS.LookupTimeout,
+			}
+			return d.DialContext(ctx, cfg.DNS.Nameserver.Proto, nameserver)
 		}
 	}
 
-	pool, err := proxy.NewPoolV2(host, &proxy.PoolV2Options{
-		MaxConnsPerHost:     cfg.Server.MaxConnsPerHost,
-		MaxIdleConnDuration: cfg.Server.MaxIdleConnDuration,
-		ReadTimeout:         cfg.Server.ReadTimeout,
-		WriteTimeout:        cfg.Server.WriteTimeout,
-		DialTimeout:         cfg.Server.DialTimeout,
-		ReadBufferSize:      cfg.Server.ReadBufferSize,
-		WriteBufferSize:     cfg.Server.WriteBufferSize,
-		MaxResponseBodySize: cfg.Server.MaxResponseBodySize,
-		InsecureConnection:  cfg.Server.InsecureConnection,
-		RootCA:              cfg.Server.RootCA,
-		HealthCheckInterval: cfg.Server.HealthCheckInterval,
-		Logger:              logger,
+	logger.Info().Msgf("%s: Initializing DNS Resolver", logPrefix)
+
+	dnsCacheResolver, err := proxy.NewDNSResolver(resolver, &proxy.DNSCacheOptions{
+		UseCache:      cfg.DNS.Cache,
+		Logger:        logger,
+		FetchTimeout:  cfg.DNS.FetchTimeout,
+		LookupTimeout: cfg.DNS.LookupTimeout,
 	})
 	if err != nil {
-		return errors.Wrap(err, "proxy pool init")
+		return errors.Wrap(err, "DNS cache resolver init")
+	}
+	defer dnsCacheResolver.Stop()
+
+	// =========================================================================
+	// Init String Formats
+
+	if err := formats.Configure(&cfg.StringFormats); err != nil {
+		return errors.Wrap(err, "string formats init error")
+	}
+
+	if cfg.StringFormats.Validation {
+		logger.Info().Msgf("%s: String formats validation is enabled: %d custom formats", logPrefix, len(cfg.StringFormats.Custom))
 	}
 
+	// =========================================================================
+	// Init Swagger and Proxy Clients
+
+	defaultTarget, err := NewSpecTarget(defaultSpecName, "", "", &cfg, dnsCacheResolver, logger)
+	if err != nil {
+		return err
+	}
+
+	specTargets := make([]*SpecTarget, 0, len(cfg.Specs))
+	for i := range cfg.Specs {
+		name := cfg.Specs[i].Name
+		if name == "" {
+			name = fmt.Sprintf("spec_%d", i+1)
+		}
+
+		target, err := NewSpecTarget(name, cfg.Specs[i].Host, cfg.Specs[i].PathPrefix, NewSpecConfig(&cfg, &cfg.Specs[i]), dnsCacheResolver, logger)
+		if err != nil {
+			return err
+		}
+
+		logger.Info().Msgf("%s: OpenAPI specification %s loaded: host %q, path prefix %q, backend %s", logPrefix, name, target.Host, target.PathPrefix, target.Cfg.Server.URL)
+
+		specTargets = append(specTargets, target)
+	}
+
+	specDispatcher := NewSpecDispatcher(&lock, defaultTarget, specTargets)
+
 	// =========================================================================
 	// Init Deny List Cache
 
@@ -194,6 +284,23 @@ func Run(logger zerolog.Logger) error {
 		logger.Info().Msgf("%s: Loaded %d Whitelisted IP's to the cache", logPrefix, allowedIPCache.ElementsNum)
 	}
 
+	// =========================================================================
+	// Init Rate Limiter
+
+	rateLimiter, err := ratelimit.NewRateLimiter(&cfg.RateLimit)
+	if err != nil {
+		return errors.Wrap(err, "rate limiter init error")
+	}
+
+	switch rateLimiter {
+	case nil:
+		logger.Info().Msgf("%s: Rate limits are not configured", logPrefix)
+		// the operations could set the rate limits in the specifications
+		rateLimiter = ratelimit.NewOperationRateLimiter(&cfg.RateLimit)
+	default:
+		logger.Info().Msgf("%s: Rate limits are configured: %d requests per %s by %s, %d endpoint-specific limits", logPrefix, cfg.RateLimit.Requests, cfg.RateLimit.Period, cfg.RateLimit.Key, len(cfg.RateLimit.Endpoints))
+	}
+
 	// =========================================================================
 	// Init ModSecurity Core
 
@@ -212,17 +319,100 @@ func Run(logger zerolog.Logger) error {
 
 	zeroLogger := &config.ZerologAdapter{Logger: logger}
 
+	// =========================================================================
+	// Init Tracing
+
+	shutdownTracing, err := tracing.Init(&cfg.Tracing, logger)
+	if err != nil {
+		return errors.Wrap(err, "tracing init error")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error().Err(err).Msgf("%s: tracing shutdown", logPrefix)
+		}
+	}()
+
+	if cfg.Tracing.Enabled {
+		logger.Info().Msgf("%s: OpenTelemetry tracing is enabled: %s exporter", logPrefix, strings.ToUpper(cfg.Tracing.Exporter))
+	}
+
+	// =========================================================================
+	// Init Security Events
+
+	eventsSink, err := events.New(&cfg.Events, logger)
+	if err != nil {
+		return errors.Wrap(err, "security events init error")
+	}
+	if eventsSink != nil {
+		defer func() {
+			if err := eventsSink.Close(); err != nil {
+				logger.Error().Err(err).Msgf("%s: security events sink shutdown", logPrefix)
+			}
+		}()
+
+		logger.Info().Msgf("%s: security events are sent to the configured sinks", logPrefix)
+	}
+
+	// =========================================================================
+	// Init Metrics
+
+	// make a channel to listen for errors coming from the metrics listener. Use a
+	// buffered channel so the goroutine can exit if we don't collect this error.
+	metricsErrors := make(chan error, 1)
+
+	metricsOptions := metrics.Options{
+		EndpointName: cfg.Metrics.EndpointName,
+		Host:         cfg.Metrics.Host,
+		ReadTimeout:  cfg.Metrics.ReadTimeout,
+		WriteTimeout: cfg.Metrics.WriteTimeout,
+	}
+
+	metricsController := metrics.NewPrometheusMetrics(cfg.Metrics.Enabled)
+
+	// export the backends health state of all specifications
+	for _, target := range specDispatcher.All() {
+		if pool, ok := target.Pool.(metrics.BackendPool); ok {
+			metricsController.AddBackendPool(target.Name, pool)
+		}
+	}
+
+	if cfg.Metrics.Enabled {
+		go func() {
+			// Start the service listening for requests.
+			logger.Info().Msgf("%s: Prometheus metrics: API listening on %s/%s", logPrefix, metricsOptions.Host, metricsOptions.EndpointName)
+			metricsErrors <- metricsController.StartService(&logger, &metricsOptions)
+		}()
+	}
+
+	// =========================================================================
+	// Init OAuth Validators
+
+	// the validators are shared by the handlers of all specifications and kept
+	// on the specification reloads
+	oauthValidators, err := NewOAuthValidators(&cfg.Server.Oauth, logger)
+	if err != nil {
+		return errors.Wrap(err, "OAuth validators init error")
+	}
+
 	// =========================================================================
 	// Init Handlers
 
-	requestHandlers = Handlers(&lock, &cfg, serverURL, shutdown, logger, pool, specStorage, deniedTokens, allowedIPCache, waf)
+	for _, target := range specDispatcher.All() {
+		target.Handler = Handlers(&lock, target.Cfg, target.ServerURL, shutdown, logger, target.Pool, target.Storage, deniedTokens, allowedIPCache, waf, rateLimiter, oauthValidators)
+	}
+
+	requestHandlers = events.Handler(eventsSink, web.ProxyMode, metricsController.RequestHandler(web.ProxyMode, specDispatcher.Handler))
 
 	// =========================================================================
 	// Start Health API Service
 
 	healthData := Health{
 		Logger: logger,
-		Pool:   pool,
+		Pool:   defaultTarget.Pool,
+		Specs:  specTargets,
 	}
 
 	// health service handler
@@ -298,13 +488,52 @@ func Run(logger zerolog.Logger) error {
 
 	updSpecErrors := make(chan error, 1)
 
-	updOpenAPISpec := NewHandlerUpdater(&lock, logger, specStorage, &cfg, serverURL, &api, shutdown, pool, deniedTokens, allowedIPCache, waf)
+	// the updaters of all specifications are used by the admin API
+	updaters := make(map[string]updater.Updater, len(specDispatcher.All()))
 
 	// disable updater if SpecificationUpdatePeriod == 0
-	if cfg.SpecificationUpdatePeriod.Seconds() > 0 {
+	for _, target := range specDispatcher.All() {
+		updOpenAPISpec := NewHandlerUpdater(&lock, logger, target, shutdown, deniedTokens, allowedIPCache, waf, rateLimiter, oauthValidators)
+		updaters[target.Name] = updOpenAPISpec
+
+		if target.Cfg.SpecificationUpdatePeriod.Seconds() > 0 {
+			go func() {
+				logger.Info().Msgf("%s: starting specification %s regular update process every %.0f seconds", logPrefix, target.Name, target.Cfg.SpecificationUpdatePeriod.Seconds())
+				updSpecErrors <- updOpenAPISpec.Start()
+			}()
+		}
+	}
+
+	// =========================================================================
+	// Start Admin API Service
+
+	// the admin API server is stopped with the main API server
+	var adminAPI *fasthttp.Server
+
+	if cfg.Admin.Enabled {
+		adminData := Admin{
+			Logger:   logger,
+			Lock:     &lock,
+			Token:    cfg.Admin.Token,
+			Specs:    specDispatcher,
+			Updaters: updaters,
+			Health:   &healthData,
+			Handlers: func(target *SpecTarget) fasthttp.RequestHandler {
+				return Handlers(&lock, target.Cfg, target.ServerURL, shutdown, logger, target.Pool, target.Storage, deniedTokens, allowedIPCache, waf, rateLimiter, oauthValidators)
+			},
+		}
+
+		adminAPI = &fasthttp.Server{
+			Handler:               adminData.Handler,
+			ReadTimeout:           cfg.ReadTimeout,
+			WriteTimeout:          cfg.WriteTimeout,
+			Logger:                zeroLogger,
+			NoDefaultServerHeader: true,
+		}
+
 		go func() {
-			logger.Info().Msgf("%s: starting specification regular update process every %.0f seconds", logPrefix, cfg.SpecificationUpdatePeriod.Seconds())
-			updSpecErrors <- updOpenAPISpec.Start()
+			logger.Info().Msgf("%s: Admin API listening on %s", logPrefix, cfg.Admin.Host)
+			serverErrors <- adminAPI.ListenAndServe(cfg.Admin.Host)
 		}()
 	}
 
@@ -328,6 +557,9 @@ func Run(logger zerolog.Logger) error {
 	case err := <-serverErrors:
 		return errors.Wrap(err, "server error")
 
+	case err := <-metricsErrors:
+		return errors.Wrap(err, "metrics error")
+
 	case sig := <-shutdown:
 		logger.Info().Msgf("%s: %v: Start shutdown", logPrefix, sig)
 
@@ -335,10 +567,17 @@ func Run(logger zerolog.Logger) error {
 		if err := api.Shutdown(); err != nil {
 			return errors.Wrap(err, "could not stop server gracefully")
 		}
+		if adminAPI != nil {
+			if err := adminAPI.Shutdown(); err != nil {
+				return errors.Wrap(err, "could not stop admin API server gracefully")
+			}
+		}
 		logger.Info().Msgf("%s: %v: Completed shutdown", logPrefix, sig)
 
-		// Close proxy pool
-		pool.Close()
+		// Close proxy pools
+		for _, target := range specDispatcher.All() {
+			target.Pool.Close()
+		}
 	}
 
 	return nil
@@ -0,0 +1,269 @@
+package proxy
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/savsgio/gotils/strconv"
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/storage"
+	"github.com/wallarm/api-firewall/internal/platform/web"
+)
+
+const defaultSpecName = "default"
+
+// SpecTarget holds the OpenAPI specification, the backend connections pool and
+// the request handler of one specification served in the PROXY mode
+type SpecTarget struct {
+	Name       string
+	Host       string
+	PathPrefix string
+	Cfg        *config.ProxyMode
+	ServerURL  *url.URL
+	Pool       proxy.Pool
+	Storage    storage.DBOpenAPILoader
+	Handler    fasthttp.RequestHandler
+}
+
+// Match checks whether the request host and path belong to the specification
+func (t *SpecTarget) Match(host, path string) bool {
+	if t.Host != "" {
+		if !strings.EqualFold(t.Host, host) {
+			// the configured host without port matches the Host header with port
+			h, _, err := net.SplitHostPort(host)
+			if err != nil || strings.Contains(t.Host, ":") || !strings.EqualFold(t.Host, h) {
+				return false
+			}
+		}
+	}
+
+	if t.PathPrefix != "" {
+		prefix := strings.TrimSuffix(t.PathPrefix, "/")
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SpecDispatcher routes requests to the handler of the first specification
+// which matches the request Host header and path. Requests which do not match
+// any additional specification are handled by the default one
+type SpecDispatcher struct {
+	lock    *sync.RWMutex
+	Targets []*SpecTarget
+	Default *SpecTarget
+}
+
+// NewSpecDispatcher function creates dispatcher of the requests between the specifications
+func NewSpecDispatcher(lock *sync.RWMutex, defaultTarget *SpecTarget, targets []*SpecTarget) *SpecDispatcher {
+	return &SpecDispatcher{
+		lock:    lock,
+		Targets: targets,
+		Default: defaultTarget,
+	}
+}
+
+// Find returns the specification that should handle the request
+func (d *SpecDispatcher) Find(host, path string) *SpecTarget {
+	for _, t := range d.Targets {
+		if t.Match(host, path) {
+			return t
+		}
+	}
+
+	return d.Default
+}
+
+// Handler passes the request to the handler of the matched specification
+func (d *SpecDispatcher) Handler(ctx *fasthttp.RequestCtx) {
+	target := d.Find(strconv.B2S(ctx.Request.Header.Host()), strconv.B2S(ctx.Path()))
+	ctx.SetUserValue(web.RequestSpecName, target.Name)
+
+	d.lock.RLock()
+	handler := target.Handler
+	d.lock.RUnlock()
+
+	if handler == nil {
+		ctx.Error("", target.Cfg.CustomBlockStatusCode)
+		return
+	}
+
+	handler(ctx)
+}
+
+// All returns the default and all additional specifications
+func (d *SpecDispatcher) All() []*SpecTarget {
+	return append([]*SpecTarget{d.Default}, d.Targets...)
+}
+
+// NewSpecConfig function returns the copy of the global configuration with the
+// specification-related parameters overridden by the spec values
+func NewSpecConfig(cfg *config.ProxyMode, spec *config.APISpec) *config.ProxyMode {
+	specCfg := *cfg
+
+	specCfg.APISpecs = spec.APISpecs
+	specCfg.APISpecsCustomHeader = spec.APISpecsCustomHeader
+
+	if spec.APISpecsRefs.BaseDir != "" || len(spec.APISpecsRefs.AllowedHosts) > 0 {
+		specCfg.APISpecsRefs = spec.APISpecsRefs
+	}
+	specCfg.Server.URL = spec.URL
+	specCfg.Server.RequestHostHeader = spec.RequestHostHeader
+
+	if spec.RequestValidation != "" {
+		specCfg.RequestValidation = spec.RequestValidation
+	}
+
+	if spec.ResponseValidation != "" {
+		specCfg.ResponseValidation = spec.ResponseValidation
+	}
+
+	if spec.SpecificationUpdatePeriod > 0 {
+		specCfg.SpecificationUpdatePeriod = spec.SpecificationUpdatePeriod
+	}
+
+	return &specCfg
+}
+
+// NewSpecTarget function loads the specification and initializes the backend
+// connections pool using the passed configuration
+func NewSpecTarget(name, host, pathPrefix string, cfg *config.ProxyMode, dnsResolver proxy.DNSCache, logger zerolog.Logger) (*SpecTarget, error) {
+
+	specStorage, err := storage.NewOpenAPIFromFileOrURL(cfg.APISpecs, &cfg.APISpecsCustomHeader, &cfg.APISpecsRefs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: loading OpenAPI specification from File or URL", name)
+	}
+
+	serverURL, err := url.ParseRequestURI(cfg.Server.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: parsing proxy URL", name)
+	}
+
+	pool, err := newPool(serverURL, cfg, dnsResolver, logger)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: proxy pool init", name)
+	}
+
+	return &SpecTarget{
+		Name:       name,
+		Host:       host,
+		PathPrefix: pathPrefix,
+		Cfg:        cfg,
+		ServerURL:  serverURL,
+		Pool:       pool,
+		Storage:    specStorage,
+	}, nil
+}
+
+// newPool function initializes the backend connections pool. The backend host
+// name is re-resolved every DNS fetch timeout whether the DNS cache is enabled
+// or not
+func newPool(serverURL *url.URL, cfg *config.ProxyMode, dnsResolver proxy.DNSCache, logger zerolog.Logger) (proxy.Pool, error) {
+	host := serverURL.Host
+	if serverURL.Port() == "" {
+		switch serverURL.Scheme {
+		case "https":
+			host += ":443"
+		case "http":
+			host += ":80"
+		}
+	}
+
+	httpHealthCheck, err := NewHTTPHealthCheck(serverURL, &cfg.Server.ProtectedAPI)
+	if err != nil {
+		return nil, err
+	}
+
+	return proxy.NewPoolV2(host, &proxy.PoolV2Options{
+		MaxConnsPerHost:     cfg.Server.MaxConnsPerHost,
+		MaxIdleConnDuration: cfg.Server.MaxIdleConnDuration,
+		ReadTimeout:         cfg.Server.ReadTimeout,
+		WriteTimeout:        cfg.Server.WriteTimeout,
+		DialTimeout:         cfg.Server.DialTimeout,
+		ReadBufferSize:      cfg.Server.ReadBufferSize,
+		WriteBufferSize:     cfg.Server.WriteBufferSize,
+		MaxResponseBodySize: cfg.Server.MaxResponseBodySize,
+		InsecureConnection:  cfg.Server.InsecureConnection,
+		RootCA:              cfg.Server.RootCA,
+		HealthCheckInterval: cfg.Server.HealthCheckInterval,
+		HTTPHealthCheck:     httpHealthCheck,
+		HealthCheckTimeout:  cfg.Server.HealthCheck.Timeout,
+		HealthyThreshold:    cfg.Server.HealthCheck.HealthyThreshold,
+		UnhealthyThreshold:  cfg.Server.HealthCheck.UnhealthyThreshold,
+		OutlierDetection:    NewOutlierDetection(&cfg.Server.ProtectedAPI),
+		CircuitBreaker:      NewCircuitBreaker(&cfg.Server.ProtectedAPI),
+		Retry:               NewRetry(&cfg.Server.ProtectedAPI),
+		DNSResolver:         dnsResolver,
+		DNSRefreshInterval:  cfg.DNS.FetchTimeout,
+		DNSLookupTimeout:    cfg.DNS.LookupTimeout,
+		Logger:              logger,
+	})
+}
+
+// NewHTTPHealthCheck function returns the options of the backend HTTP health
+// checks. Nil is returned if the health check path is not configured
+func NewHTTPHealthCheck(serverURL *url.URL, cfg *config.ProtectedAPI) (*proxy.HTTPHealthCheck, error) {
+	if cfg.HealthCheck.Path == "" {
+		return nil, nil
+	}
+
+	statusMin, statusMax, err := cfg.HealthCheck.StatusRange()
+	if err != nil {
+		return nil, err
+	}
+
+	host := serverURL.Host
+	if cfg.RequestHostHeader != "" {
+		host = cfg.RequestHostHeader
+	}
+
+	return &proxy.HTTPHealthCheck{
+		Scheme:    serverURL.Scheme,
+		Path:      cfg.HealthCheck.Path,
+		Method:    strings.ToUpper(cfg.HealthCheck.Method),
+		Host:      host,
+		StatusMin: statusMin,
+		StatusMax: statusMax,
+		Body:      cfg.HealthCheck.ExpectedBody,
+	}, nil
+}
+
+// NewOutlierDetection function returns the options of the passive backend
+// health checks based on the results of the proxied requests
+func NewOutlierDetection(cfg *config.ProtectedAPI) proxy.OutlierDetectionOptions {
+	return proxy.OutlierDetectionOptions{
+		ConsecutiveErrors: cfg.OutlierDetection.ConsecutiveErrors,
+		BaseEjectionTime:  cfg.OutlierDetection.BaseEjectionTime,
+		MaxEjectionTime:   cfg.OutlierDetection.MaxEjectionTime,
+	}
+}
+
+// NewRetry function returns the options of the retries of the failed requests
+func NewRetry(cfg *config.ProtectedAPI) *proxy.RetryOptions {
+	return &proxy.RetryOptions{
+		MaxRetries:           cfg.Retry.MaxRetries,
+		PerTryTimeout:        cfg.Retry.PerTryTimeout,
+		BackoffBase:          cfg.Retry.BackoffBase,
+		BackoffMax:           cfg.Retry.BackoffMax,
+		BudgetPercent:        cfg.Retry.BudgetPercent,
+		BudgetMinConcurrency: cfg.Retry.BudgetMinConcurrency,
+	}
+}
+
+// NewCircuitBreaker function returns the options of the backend circuit breaker
+func NewCircuitBreaker(cfg *config.ProtectedAPI) *proxy.CircuitBreakerOptions {
+	return &proxy.CircuitBreakerOptions{
+		FailureThreshold:    cfg.CircuitBreaker.FailureThreshold,
+		OpenTimeout:         cfg.CircuitBreaker.OpenTimeout,
+		HalfOpenMaxRequests: cfg.CircuitBreaker.HalfOpenMaxRequests,
+	}
+}
@@ -1,7 +1,6 @@
 package proxy
 
 import (
-	"net/url"
 	"os"
 	"runtime/debug"
 	"sync"
@@ -9,12 +8,10 @@ import (
 
 	"github.com/corazawaf/coraza/v3"
 	"github.com/rs/zerolog"
-	"github.com/valyala/fasthttp"
 
-	"github.com/wallarm/api-firewall/internal/config"
 	"github.com/wallarm/api-firewall/internal/platform/allowiplist"
 	"github.com/wallarm/api-firewall/internal/platform/denylist"
-	"github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/ratelimit"
 	"github.com/wallarm/api-firewall/internal/platform/router"
 	"github.com/wallarm/api-firewall/internal/platform/storage"
 	"github.com/wallarm/api-firewall/internal/platform/storage/updater"
@@ -27,35 +24,32 @@ const (
 type Specification struct {
 	logger         zerolog.Logger
 	waf            coraza.WAF
-	oasStorage     storage.DBOpenAPILoader
+	target         *SpecTarget
 	stop           chan struct{}
 	updateTime     time.Duration
-	cfg            *config.ProxyMode
-	api            *fasthttp.Server
 	shutdown       chan os.Signal
 	lock           *sync.RWMutex
-	pool           proxy.Pool
-	serverURL      *url.URL
+	updateLock     sync.Mutex
 	deniedTokens   *denylist.DeniedTokens
 	allowedIPCache *allowiplist.AllowedIPsType
+	rateLimiter    *ratelimit.RateLimiter
+	oauth          *OAuthValidators
 }
 
 // NewHandlerUpdater function defines configuration updater controller
-func NewHandlerUpdater(lock *sync.RWMutex, logger zerolog.Logger, oasStorage storage.DBOpenAPILoader, cfg *config.ProxyMode, serverURL *url.URL, api *fasthttp.Server, shutdown chan os.Signal, pool proxy.Pool, deniedTokens *denylist.DeniedTokens, allowedIPCache *allowiplist.AllowedIPsType, waf coraza.WAF) updater.Updater {
+func NewHandlerUpdater(lock *sync.RWMutex, logger zerolog.Logger, target *SpecTarget, shutdown chan os.Signal, deniedTokens *denylist.DeniedTokens, allowedIPCache *allowiplist.AllowedIPsType, waf coraza.WAF, rateLimiter *ratelimit.RateLimiter, oauth *OAuthValidators) updater.Updater {
 	return &Specification{
 		logger:         logger,
 		waf:            waf,
-		oasStorage:     oasStorage,
+		target:         target,
 		stop:           make(chan struct{}),
-		updateTime:     cfg.SpecificationUpdatePeriod,
-		cfg:            cfg,
-		api:            api,
+		updateTime:     target.Cfg.SpecificationUpdatePeriod,
 		shutdown:       shutdown,
 		lock:           lock,
-		pool:           pool,
-		serverURL:      serverURL,
 		deniedTokens:   deniedTokens,
 		allowedIPCache: allowedIPCache,
+		rateLimiter:    rateLimiter,
+		oauth:          oauth,
 	}
 }
 
@@ -77,27 +71,8 @@ func (s *Specification) Run() {
 	for {
 		select {
 		case <-updateTicker.C:
-
-			// load new schemes
-			newSpecDB, err := s.Load()
-			if err != nil {
-				s.logger.Error().Err(err).Msgf("%s: loading specifications", logPrefix)
-				continue
-			}
-
-			if s.oasStorage.ShouldUpdate(newSpecDB) {
-
-				s.lock.Lock()
-				s.oasStorage = newSpecDB
-				s.api.Handler = Handlers(s.lock, s.cfg, s.serverURL, s.shutdown, s.logger, s.pool, s.oasStorage, s.deniedTokens, s.allowedIPCache, s.waf)
-				if err := s.oasStorage.AfterLoad(s.cfg.APISpecs); err != nil {
-					s.logger.Error().Err(err).Msgf("%s: error in after specification loading function", logPrefix)
-				}
-				s.lock.Unlock()
-
-				s.logger.Debug().Msgf("%s: OpenAPI specification has been updated", logPrefix)
-
-				continue
+			if _, err := s.Update(); err != nil {
+				s.logger.Error().Err(err).Msgf("%s: %s: loading specifications", logPrefix, s.target.Name)
 			}
 		case <-s.stop:
 			updateTicker.Stop()
@@ -126,9 +101,39 @@ func (s *Specification) Shutdown() error {
 	return nil
 }
 
+// Update function loads the specification and replaces the handler of the
+// target if the specification has been changed. It returns true if the
+// specification has been updated
+func (s *Specification) Update() (bool, error) {
+	s.updateLock.Lock()
+	defer s.updateLock.Unlock()
+
+	// load new schemes
+	newSpecDB, err := s.Load()
+	if err != nil {
+		return false, err
+	}
+
+	if !s.target.Storage.ShouldUpdate(newSpecDB) {
+		return false, nil
+	}
+
+	s.lock.Lock()
+	s.target.Storage = newSpecDB
+	s.target.Handler = Handlers(s.lock, s.target.Cfg, s.target.ServerURL, s.shutdown, s.logger, s.target.Pool, s.target.Storage, s.deniedTokens, s.allowedIPCache, s.waf, s.rateLimiter, s.oauth)
+	if err := s.target.Storage.AfterLoad(s.target.Cfg.APISpecs); err != nil {
+		s.logger.Error().Err(err).Msgf("%s: %s: error in after specification loading function", logPrefix, s.target.Name)
+	}
+	s.lock.Unlock()
+
+	s.logger.Debug().Msgf("%s: %s: OpenAPI specification has been updated", logPrefix, s.target.Name)
+
+	return true, nil
+}
+
 // Load function reads DB file and returns it
 func (s *Specification) Load() (storage.DBOpenAPILoader, error) {
-	return storage.NewOpenAPIFromFileOrURL(s.cfg.APISpecs, &s.cfg.APISpecsCustomHeader)
+	return storage.NewOpenAPIFromFileOrURL(s.target.Cfg.APISpecs, &s.target.Cfg.APISpecsCustomHeader, &s.target.Cfg.APISpecsRefs)
 }
 
 // Find function searches for the handler by path and method
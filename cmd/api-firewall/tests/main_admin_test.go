@@ -0,0 +1,235 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	proxyMode "github.com/wallarm/api-firewall/cmd/api-firewall/internal/handlers/proxy"
+	"github.com/wallarm/api-firewall/internal/config"
+	proxyPool "github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/storage"
+	"github.com/wallarm/api-firewall/internal/platform/storage/updater"
+)
+
+const testAdminToken = "admin-secret"
+
+func TestAdminAPI(t *testing.T) {
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var lock sync.RWMutex
+
+	serverUrl, err := url.ParseRequestURI("http://127.0.0.1:80")
+	if err != nil {
+		t.Fatalf("parsing API Host URL: %s", err.Error())
+	}
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	logger = logger.Level(zerolog.ErrorLevel)
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(openAPISpecUsersTest))
+	if err != nil {
+		t.Fatalf("loading OpenAPI specification file: %s", err.Error())
+	}
+
+	dbSpec := storage.NewMockDBOpenAPILoader(mockCtrl)
+	dbSpec.EXPECT().Specification(gomock.Any()).Return(swagger).AnyTimes()
+	dbSpec.EXPECT().SchemaIDs().Return([]int{}).AnyTimes()
+
+	pool := proxyPool.NewMockPool(mockCtrl)
+	client := proxyPool.NewMockHTTPClient(mockCtrl)
+
+	pool.EXPECT().Get().Return(client, resolvedIP, nil).AnyTimes()
+	pool.EXPECT().Put(resolvedIP, client).Return(nil).AnyTimes()
+	client.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(req *fasthttp.Request, resp *fasthttp.Response) error {
+		resp.SetStatusCode(fasthttp.StatusOK)
+		return nil
+	}).AnyTimes()
+
+	cfg := config.ProxyMode{
+		RequestValidation:     "BLOCK",
+		ResponseValidation:    "BLOCK",
+		CustomBlockStatusCode: 403,
+	}
+
+	target := &proxyMode.SpecTarget{
+		Name:      "default",
+		Cfg:       &cfg,
+		ServerURL: serverUrl,
+		Pool:      pool,
+		Storage:   dbSpec,
+	}
+
+	handlers := func(target *proxyMode.SpecTarget) fasthttp.RequestHandler {
+		return proxyMode.Handlers(&lock, target.Cfg, target.ServerURL, shutdown, logger, target.Pool, target.Storage, nil, nil, nil, nil, nil)
+	}
+	target.Handler = handlers(target)
+
+	dispatcher := proxyMode.NewSpecDispatcher(&lock, target, nil)
+
+	specUpdater := updater.NewMockUpdater(mockCtrl)
+	specUpdater.EXPECT().Update().Return(true, nil).Times(1)
+
+	admin := proxyMode.Admin{
+		Logger:   logger,
+		Lock:     &lock,
+		Token:    testAdminToken,
+		Specs:    dispatcher,
+		Updaters: map[string]updater.Updater{"default": specUpdater},
+		Health:   &proxyMode.Health{Logger: logger, Pool: pool},
+		Handlers: handlers,
+	}
+
+	adminRequest := func(method, uri, token, body string) *fasthttp.RequestCtx {
+		var reqCtx fasthttp.RequestCtx
+		reqCtx.Request.SetRequestURI(uri)
+		reqCtx.Request.Header.SetMethod(method)
+		if token != "" {
+			reqCtx.Request.Header.Set(fasthttp.HeaderAuthorization, "Bearer "+token)
+		}
+		reqCtx.Request.SetBodyString(body)
+
+		admin.Handler(&reqCtx)
+		return &reqCtx
+	}
+
+	proxyRequest := func(uri string) int {
+		var reqCtx fasthttp.RequestCtx
+		reqCtx.Request.SetRequestURI(uri)
+		reqCtx.Request.Header.SetMethod(fasthttp.MethodGet)
+
+		dispatcher.Handler(&reqCtx)
+		return reqCtx.Response.StatusCode()
+	}
+
+	type route struct {
+		Method             string `json:"method"`
+		Path               string `json:"path"`
+		RequestValidation  string `json:"request_validation"`
+		ResponseValidation string `json:"response_validation"`
+		Override           bool   `json:"override"`
+	}
+
+	listRoutes := func() []route {
+		reqCtx := adminRequest(fasthttp.MethodGet, "/v1/specs", testAdminToken, "")
+		if reqCtx.Response.StatusCode() != fasthttp.StatusOK {
+			t.Fatalf("Incorrect response status code. Expected: %d and got %d", fasthttp.StatusOK, reqCtx.Response.StatusCode())
+		}
+
+		var specs []struct {
+			Name        string  `json:"name"`
+			SpecVersion string  `json:"spec_version"`
+			Routes      []route `json:"routes"`
+		}
+		if err := json.Unmarshal(reqCtx.Response.Body(), &specs); err != nil {
+			t.Fatalf("decoding specs: %v", err)
+		}
+
+		if len(specs) != 1 || specs[0].Name != "default" || specs[0].SpecVersion != "1.0.0" || len(specs[0].Routes) != 1 {
+			t.Fatalf("incorrect specs: %s", reqCtx.Response.Body())
+		}
+
+		return specs[0].Routes
+	}
+
+	// authorization
+	for _, token := range []string{"", "wrong"} {
+		if reqCtx := adminRequest(fasthttp.MethodGet, "/v1/specs", token, ""); reqCtx.Response.StatusCode() != fasthttp.StatusUnauthorized {
+			t.Errorf("Incorrect response status code. Expected: %d and got %d", fasthttp.StatusUnauthorized, reqCtx.Response.StatusCode())
+		}
+	}
+
+	// routes
+	routes := listRoutes()
+	if routes[0] != (route{Method: "GET", Path: "/users/{id}", RequestValidation: "BLOCK", ResponseValidation: "BLOCK"}) {
+		t.Errorf("incorrect route: %+v", routes[0])
+	}
+
+	if code := proxyRequest("/users/abc"); code != fasthttp.StatusForbidden {
+		t.Errorf("Incorrect response status code. Expected: %d and got %d", fasthttp.StatusForbidden, code)
+	}
+
+	// change the validation modes of the endpoint
+	reqCtx := adminRequest(fasthttp.MethodPut, "/v1/actions", testAdminToken,
+		`{"method":"GET","path":"/users/{id}","request_validation":"log_only","response_validation":"DISABLE"}`)
+	if reqCtx.Response.StatusCode() != fasthttp.StatusNoContent {
+		t.Errorf("Incorrect response status code. Expected: %d and got %d: %s", fasthttp.StatusNoContent, reqCtx.Response.StatusCode(), reqCtx.Response.Body())
+	}
+
+	routes = listRoutes()
+	if routes[0] != (route{Method: "GET", Path: "/users/{id}", RequestValidation: "LOG_ONLY", ResponseValidation: "DISABLE", Override: true}) {
+		t.Errorf("incorrect route: %+v", routes[0])
+	}
+
+	if code := proxyRequest("/users/abc"); code != fasthttp.StatusOK {
+		t.Errorf("Incorrect response status code. Expected: %d and got %d", fasthttp.StatusOK, code)
+	}
+
+	// invalid mode
+	reqCtx = adminRequest(fasthttp.MethodPut, "/v1/actions", testAdminToken,
+		`{"path":"/users/{id}","request_validation":"ALLOW","response_validation":"DISABLE"}`)
+	if reqCtx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("Incorrect response status code. Expected: %d and got %d", fasthttp.StatusBadRequest, reqCtx.Response.StatusCode())
+	}
+
+	// remove the custom validation modes
+	reqCtx = adminRequest(fasthttp.MethodDelete, "/v1/actions", testAdminToken, `{"method":"GET","path":"/users/{id}"}`)
+	if reqCtx.Response.StatusCode() != fasthttp.StatusNoContent {
+		t.Errorf("Incorrect response status code. Expected: %d and got %d", fasthttp.StatusNoContent, reqCtx.Response.StatusCode())
+	}
+
+	if code := proxyRequest("/users/abc"); code != fasthttp.StatusForbidden {
+		t.Errorf("Incorrect response status code. Expected: %d and got %d", fasthttp.StatusForbidden, code)
+	}
+
+	// reload
+	reqCtx = adminRequest(fasthttp.MethodPost, "/v1/specs/reload", testAdminToken, "")
+	if reqCtx.Response.StatusCode() != fasthttp.StatusOK || string(reqCtx.Response.Body()) != `[{"spec":"default","updated":true}]` {
+		t.Errorf("Incorrect reload response: %d %s", reqCtx.Response.StatusCode(), reqCtx.Response.Body())
+	}
+
+	reqCtx = adminRequest(fasthttp.MethodPost, "/v1/specs/reload?spec=unknown", testAdminToken, "")
+	if reqCtx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("Incorrect response status code. Expected: %d and got %d", fasthttp.StatusBadRequest, reqCtx.Response.StatusCode())
+	}
+
+	// log level
+	currentLevel := zerolog.GlobalLevel()
+	defer zerolog.SetGlobalLevel(currentLevel)
+
+	reqCtx = adminRequest(fasthttp.MethodPut, "/v1/log-level", testAdminToken, `{"level":"debug"}`)
+	if reqCtx.Response.StatusCode() != fasthttp.StatusOK || zerolog.GlobalLevel() != zerolog.DebugLevel {
+		t.Errorf("Incorrect log level response: %d %s", reqCtx.Response.StatusCode(), reqCtx.Response.Body())
+	}
+
+	reqCtx = adminRequest(fasthttp.MethodGet, "/v1/log-level", testAdminToken, "")
+	if string(reqCtx.Response.Body()) != `{"level":"debug"}` {
+		t.Errorf("Incorrect log level: %s", reqCtx.Response.Body())
+	}
+
+	// backends
+	reqCtx = adminRequest(fasthttp.MethodGet, "/v1/backends", testAdminToken, "")
+	if reqCtx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("Incorrect response status code. Expected: %d and got %d", fasthttp.StatusOK, reqCtx.Response.StatusCode())
+	}
+
+	// unsupported method
+	reqCtx = adminRequest(fasthttp.MethodDelete, "/v1/specs", testAdminToken, "")
+	if reqCtx.Response.StatusCode() != fasthttp.StatusMethodNotAllowed {
+		t.Errorf("Incorrect response status code. Expected: %d and got %d", fasthttp.StatusMethodNotAllowed, reqCtx.Response.StatusCode())
+	}
+}
@@ -617,6 +617,9 @@ func TestAPIModeBasic(t *testing.T) {
 
 	// check limited response (maxErrorsInResponse param)
 	t.Run("testAPIModeMissedMultipleReqParamsLimitedResponse", apifwTests.testAPIModeMissedMultipleReqParamsLimitedResponse)
+
+	// check request/response pair validation
+	t.Run("testAPIModeRequestResponsePair", apifwTests.testAPIModeRequestResponsePair)
 }
 
 func createForm(form map[string]string) (string, io.Reader, error) {
@@ -748,7 +751,7 @@ func (s *APIModeServiceTests) testAPIRunBasic(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeSuccess(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	p, err := json.Marshal(map[string]any{
 		"firstname": "test",
@@ -811,7 +814,7 @@ func (s *APIModeServiceTests) testAPIModeSuccess(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeMissedMultipleReqParams(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	p, err := json.Marshal(map[string]any{
 		"firstname": "test",
@@ -905,7 +908,7 @@ func (s *APIModeServiceTests) testAPIModeMissedMultipleReqParams(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeSuccessEmptyPathParameter(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI(fmt.Sprintf("/absolute-redirect/%d", rand.Uint32()))
@@ -942,7 +945,7 @@ func (s *APIModeServiceTests) testAPIModeSuccessEmptyPathParameter(t *testing.T)
 
 func (s *APIModeServiceTests) testAPIModeSuccessMultipartStringParameter(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/redirect-to")
@@ -1010,7 +1013,7 @@ func (s *APIModeServiceTests) testAPIModeSuccessMultipartStringParameter(t *test
 
 func (s *APIModeServiceTests) testAPIModeOneSchemeMultipleIDs(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	// one schema
 	p, err := json.Marshal(map[string]any{
@@ -1094,7 +1097,7 @@ func (s *APIModeServiceTests) testAPIModeOneSchemeMultipleIDs(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeTwoDifferentSchemesMultipleIDs(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	// one schema
 	p, err := json.Marshal(map[string]any{
@@ -1201,7 +1204,7 @@ func (s *APIModeServiceTests) testAPIModeTwoDifferentSchemesMultipleIDs(t *testi
 
 func (s *APIModeServiceTests) testAPIModeTwoSchemesMultipleIDs(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	p, err := json.Marshal(map[string]any{
 		"firstname": "test",
@@ -1309,7 +1312,7 @@ func (s *APIModeServiceTests) testAPIModeTwoSchemesMultipleIDs(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeJSONParseError(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/signup")
@@ -1333,7 +1336,7 @@ func (s *APIModeServiceTests) testAPIModeJSONParseError(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeInvalidCTParseError(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	p, err := json.Marshal(map[string]any{
 		"firstname": "test",
@@ -1369,7 +1372,7 @@ func (s *APIModeServiceTests) testAPIModeInvalidCTParseError(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeCTNotInSpec(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	p, err := json.Marshal(map[string]any{
 		"firstname": "test",
@@ -1405,7 +1408,7 @@ func (s *APIModeServiceTests) testAPIModeCTNotInSpec(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeEmptyBody(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/signup")
@@ -1429,7 +1432,7 @@ func (s *APIModeServiceTests) testAPIModeEmptyBody(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeNoXWallarmSchemaIDHeader(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	p, err := json.Marshal(map[string]any{
 		"firstname": "test",
@@ -1531,7 +1534,7 @@ func (s *APIModeServiceTests) testAPIModeNoXWallarmSchemaIDHeader(t *testing.T)
 
 func (s *APIModeServiceTests) testAPIModeMethodAndPathNotFound(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	p, err := json.Marshal(map[string]any{
 		"firstname": "test",
@@ -1622,7 +1625,7 @@ func (s *APIModeServiceTests) testAPIModeMethodAndPathNotFound(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeRequiredQueryParameterMissed(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/query?id=" + uuid.New().String())
@@ -1658,7 +1661,7 @@ func (s *APIModeServiceTests) testAPIModeRequiredQueryParameterMissed(t *testing
 
 func (s *APIModeServiceTests) testAPIModeRequiredHeaderParameterMissed(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	xReqTestValue := uuid.New()
 
@@ -1697,7 +1700,7 @@ func (s *APIModeServiceTests) testAPIModeRequiredHeaderParameterMissed(t *testin
 
 func (s *APIModeServiceTests) testAPIModeRequiredCookieParameterMissed(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/cookies/request")
@@ -1734,7 +1737,7 @@ func (s *APIModeServiceTests) testAPIModeRequiredCookieParameterMissed(t *testin
 
 func (s *APIModeServiceTests) testAPIModeRequiredBodyMissed(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	p, err := json.Marshal(map[string]any{
 		"status":  uuid.New().String(),
@@ -1785,7 +1788,7 @@ func (s *APIModeServiceTests) testAPIModeRequiredBodyMissed(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeRequiredBodyParameterMissed(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	p, err := json.Marshal(map[string]any{
 		"status":  uuid.New().String(),
@@ -1848,7 +1851,7 @@ func (s *APIModeServiceTests) testAPIModeRequiredBodyParameterMissed(t *testing.
 // Invalid parameters errors
 func (s *APIModeServiceTests) testAPIModeRequiredQueryParameterInvalidValue(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/query?id=" + uuid.New().String())
@@ -1898,7 +1901,7 @@ func (s *APIModeServiceTests) testAPIModeRequiredQueryParameterInvalidValue(t *t
 
 func (s *APIModeServiceTests) testAPIModeRequiredHeaderParameterInvalidValue(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	xReqTestValue := uuid.New()
 
@@ -1954,7 +1957,7 @@ func (s *APIModeServiceTests) testAPIModeRequiredHeaderParameterInvalidValue(t *
 
 func (s *APIModeServiceTests) testAPIModeRequiredCookieParameterInvalidValue(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/cookies/request")
@@ -2006,7 +2009,7 @@ func (s *APIModeServiceTests) testAPIModeRequiredCookieParameterInvalidValue(t *
 
 func (s *APIModeServiceTests) testAPIModeRequiredBodyParameterInvalidValue(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	p, err := json.Marshal(map[string]any{
 		"status":  uuid.New().String(),
@@ -2161,7 +2164,7 @@ func (s *APIModeServiceTests) testAPIModeRequiredBodyParameterInvalidValue(t *te
 // security requirements
 func (s *APIModeServiceTests) testAPIModeBasicAuthFailed(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/security/basic")
@@ -2230,7 +2233,7 @@ func (s *APIModeServiceTests) testAPIModeBasicAuthFailed(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeBearerTokenFailed(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/security/bearer")
@@ -2299,7 +2302,7 @@ func (s *APIModeServiceTests) testAPIModeBearerTokenFailed(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeAPITokenCookieFailed(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/security/cookie")
@@ -2368,7 +2371,7 @@ func (s *APIModeServiceTests) testAPIModeAPITokenCookieFailed(t *testing.T) {
 // unknown parameters
 func (s *APIModeServiceTests) testAPIModeUnknownParameterBodyJSON(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	p, err := json.Marshal(map[string]any{
 		"firstname":     "test",
@@ -2438,7 +2441,7 @@ func (s *APIModeServiceTests) testAPIModeUnknownParameterBodyJSON(t *testing.T)
 
 func (s *APIModeServiceTests) testAPIModeUnknownParameterBodyPost(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/signup")
@@ -2492,7 +2495,7 @@ func (s *APIModeServiceTests) testAPIModeUnknownParameterBodyPost(t *testing.T)
 
 func (s *APIModeServiceTests) testAPIModeUnknownParameterQuery(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/query?uparam=test&id=" + uuid.New().String())
@@ -2531,7 +2534,7 @@ func (s *APIModeServiceTests) testAPIModeUnknownParameterQuery(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeUnknownParameterTextPlainCT(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/plain")
@@ -2555,7 +2558,7 @@ func (s *APIModeServiceTests) testAPIModeUnknownParameterTextPlainCT(t *testing.
 
 func (s *APIModeServiceTests) testAPIModeUnknownParameterInvalidCT(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/unknownCT")
@@ -2604,7 +2607,7 @@ func (s *APIModeServiceTests) testAPIModePassOptionsRequest(t *testing.T) {
 		PassOptionsRequests:        true,
 	}
 
-	handler := handlersAPI.Handlers(s.lock, &cfgPassOptions, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfgPassOptions, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/signup")
@@ -2626,7 +2629,7 @@ func (s *APIModeServiceTests) testAPIModePassOptionsRequest(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeMultipartOptionalParams(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/multipart")
@@ -2693,7 +2696,7 @@ func (s *APIModeServiceTests) testAPIModeMultipartOptionalParams(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeInvalidRouteInRequest(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	p, err := json.Marshal(map[string]any{
 		"firstname": "test",
@@ -2749,7 +2752,7 @@ func (s *APIModeServiceTests) testAPIModeInvalidRouteInRequest(t *testing.T) {
 
 func (s *APIModeServiceTests) testAPIModeInvalidRouteInRequestInMultipleSchemas(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	p, err := json.Marshal(map[string]any{
 		"firstname": "test",
@@ -2807,7 +2810,7 @@ func (s *APIModeServiceTests) testAPIModeInvalidRouteInRequestInMultipleSchemas(
 
 func (s *APIModeServiceTests) testAPIModeAllMethods(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	// check all supported methods: GET POST PUT PATCH DELETE TRACE OPTIONS HEAD
 	for _, m := range []string{"GET", "POST", "PUT", "PATCH", "DELETE", "TRACE", "OPTIONS", "HEAD"} {
@@ -2849,7 +2852,7 @@ func (s *APIModeServiceTests) testAPIModeAllMethods(t *testing.T) {
 
 func (s *APIModeServiceTests) testConflictsInThePath(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	// check all related paths
 	for _, path := range []string{"/path/testValue1", "/path/value1.php"} {
@@ -2891,7 +2894,7 @@ func (s *APIModeServiceTests) testConflictsInThePath(t *testing.T) {
 
 func (s *APIModeServiceTests) testObjectInQuery(t *testing.T) {
 
-	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &cfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	for _, path := range []string{"/query/paramsObject?f.0%5Bf%5D%5B0%5D=test"} {
 
@@ -2941,7 +2944,7 @@ func (s *APIModeServiceTests) testAPIModeMissedMultipleReqParamsLimitedResponse(
 		MaxErrorsInResponse:        1,
 	}
 
-	handler := handlersAPI.Handlers(s.lock, &updatedCfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil)
+	handler := handlersAPI.Handlers(s.lock, &updatedCfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
 
 	p, err := json.Marshal(map[string]any{
 		"firstname": "test",
@@ -3032,3 +3035,147 @@ func (s *APIModeServiceTests) testAPIModeMissedMultipleReqParamsLimitedResponse(
 	t.Logf("Name of the test: %s; status code: %d; response body: %s", t.Name(), reqCtx.Response.StatusCode(), string(reqCtx.Response.Body()))
 
 }
+
+func (s *APIModeServiceTests) testAPIModeRequestResponsePair(t *testing.T) {
+
+	pairCfg := cfg
+	pairCfg.RequestResponseValidationPath = "/apifw/validate"
+
+	handler := handlersAPI.Handlers(s.lock, &pairCfg, s.shutdown, s.logger, metrics.NewPrometheusMetrics(false), s.dbSpec, nil, nil, nil)
+
+	jsonHeaders := map[string][]string{"Content-Type": {"application/json"}}
+
+	tests := []struct {
+		name               string
+		pair               any
+		expectedStatusCode int
+		expectedResult     int
+		expectedErrCodes   []string
+	}{
+		{
+			name: "valid pair",
+			pair: map[string]any{
+				"request":  map[string]any{"method": "POST", "uri": "/test/signup", "headers": jsonHeaders, "body": `{"email":"test@wallarm.com"}`},
+				"response": map[string]any{"status_code": 200, "headers": jsonHeaders, "body": `{"status":"success"}`},
+			},
+			expectedStatusCode: fasthttp.StatusOK,
+			expectedResult:     fasthttp.StatusOK,
+		},
+		{
+			name: "required response body parameter missed",
+			pair: map[string]any{
+				"request":  map[string]any{"method": "POST", "uri": "/test/signup", "headers": jsonHeaders, "body": `{"email":"test@wallarm.com"}`},
+				"response": map[string]any{"status_code": 200, "headers": jsonHeaders, "body": `{"error":"none"}`},
+			},
+			expectedStatusCode: fasthttp.StatusOK,
+			expectedResult:     fasthttp.StatusForbidden,
+			expectedErrCodes:   []string{validator.ErrCodeRequiredResponseBodyParameterMissed},
+		},
+		{
+			name: "response body parameter invalid value",
+			pair: map[string]any{
+				"request":  map[string]any{"method": "POST", "uri": "/test/signup", "headers": jsonHeaders, "body": `{"email":"test@wallarm.com"}`},
+				"response": map[string]any{"status_code": 200, "headers": jsonHeaders, "body": `{"status":10}`},
+			},
+			expectedStatusCode: fasthttp.StatusOK,
+			expectedResult:     fasthttp.StatusForbidden,
+			expectedErrCodes:   []string{validator.ErrCodeRequiredResponseBodyParameterInvalidValue},
+		},
+		{
+			name: "response status code not found",
+			pair: map[string]any{
+				"request":  map[string]any{"method": "POST", "uri": "/test/signup", "headers": jsonHeaders, "body": `{"email":"test@wallarm.com"}`},
+				"response": map[string]any{"status_code": 500},
+			},
+			expectedStatusCode: fasthttp.StatusOK,
+			expectedResult:     fasthttp.StatusForbidden,
+			expectedErrCodes:   []string{validator.ErrCodeResponseStatusCodeNotFound},
+		},
+		{
+			name: "response content type not found",
+			pair: map[string]any{
+				"request":  map[string]any{"method": "POST", "uri": "/test/signup", "headers": jsonHeaders, "body": `{"email":"test@wallarm.com"}`},
+				"response": map[string]any{"status_code": 200, "headers": map[string][]string{"Content-Type": {"text/plain"}}, "body": "success"},
+			},
+			expectedStatusCode: fasthttp.StatusOK,
+			expectedResult:     fasthttp.StatusForbidden,
+			expectedErrCodes:   []string{validator.ErrCodeResponseContentTypeNotFound},
+		},
+		{
+			name: "request and response errors",
+			pair: map[string]any{
+				"request":  map[string]any{"method": "POST", "uri": "/test/signup", "headers": jsonHeaders, "body": `{}`},
+				"response": map[string]any{"status_code": 200, "headers": jsonHeaders, "body": `{"error":"none"}`},
+			},
+			expectedStatusCode: fasthttp.StatusOK,
+			expectedResult:     fasthttp.StatusForbidden,
+			expectedErrCodes:   []string{validator.ErrCodeRequiredBodyParameterMissed, validator.ErrCodeRequiredResponseBodyParameterMissed},
+		},
+		{
+			name: "request validation skipped",
+			pair: map[string]any{
+				"request":          map[string]any{"method": "POST", "uri": "/test/signup", "headers": jsonHeaders, "body": `{}`},
+				"response":         map[string]any{"status_code": 200, "headers": jsonHeaders, "body": `{"status":"success"}`},
+				"validate_request": false,
+			},
+			expectedStatusCode: fasthttp.StatusOK,
+			expectedResult:     fasthttp.StatusOK,
+		},
+		{
+			name:               "invalid pair",
+			pair:               map[string]any{"response": map[string]any{"status_code": 200}},
+			expectedStatusCode: fasthttp.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			body, err := json.Marshal(tt.pair)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var reqCtx fasthttp.RequestCtx
+			reqCtx.Request.SetRequestURI(pairCfg.RequestResponseValidationPath)
+			reqCtx.Request.Header.SetMethod(fasthttp.MethodPost)
+			reqCtx.Request.Header.SetContentType("application/json")
+			reqCtx.Request.Header.Add(web.XWallarmSchemaIDHeader, fmt.Sprintf("%d", SecondSchemaID))
+			reqCtx.Request.SetBody(body)
+
+			handler(&reqCtx)
+
+			if reqCtx.Response.StatusCode() != tt.expectedStatusCode {
+				t.Fatalf("Incorrect response status code. Expected: %d and got %d",
+					tt.expectedStatusCode, reqCtx.Response.StatusCode())
+			}
+
+			if tt.expectedResult == 0 {
+				return
+			}
+
+			apifwResponse := validator.ValidationResponse{}
+			if err := json.Unmarshal(reqCtx.Response.Body(), &apifwResponse); err != nil {
+				t.Fatalf("Error while JSON response parsing: %v", err)
+			}
+
+			if len(apifwResponse.Summary) != 1 || *apifwResponse.Summary[0].StatusCode != tt.expectedResult {
+				t.Errorf("Incorrect validation summary. Expected status %d and got response %s",
+					tt.expectedResult, string(reqCtx.Response.Body()))
+			}
+
+			var errCodes []string
+			for _, e := range apifwResponse.Errors {
+				errCodes = append(errCodes, e.Code)
+			}
+
+			slices.Sort(errCodes)
+			expectedErrCodes := slices.Clone(tt.expectedErrCodes)
+			slices.Sort(expectedErrCodes)
+
+			if !slices.Equal(errCodes, expectedErrCodes) {
+				t.Errorf("Incorrect error codes. Expected: %v and got %v", expectedErrCodes, errCodes)
+			}
+		})
+	}
+}
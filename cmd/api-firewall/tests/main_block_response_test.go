@@ -0,0 +1,398 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	proxyMode "github.com/wallarm/api-firewall/cmd/api-firewall/internal/handlers/proxy"
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/denylist"
+	proxyPool "github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/ratelimit"
+	"github.com/wallarm/api-firewall/internal/platform/storage"
+	apiModeValidator "github.com/wallarm/api-firewall/pkg/APIMode/validator"
+)
+
+const openAPISpecBlockResponseTest = `
+openapi: 3.0.1
+info:
+  title: Service
+  version: 1.0.0
+servers:
+  - url: /
+paths:
+  /items:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: Items
+  /orders:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: Orders
+  /users:
+    get:
+      x-apifw-block-response:
+        statusCode: 422
+        headers:
+          X-Error-Source: operation
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: Users
+  /upload:
+    post:
+      x-apifw-max-request-body-size: 4
+      requestBody:
+        content:
+          text/plain:
+            schema:
+              type: string
+      responses:
+        '200':
+          description: Upload
+`
+
+const blockResponseBodyTemplate = `{"error":{"id":{{json .RequestID}},"status":{{.StatusCode}},"codes":[{{range $i, $v := .Violations}}{{if $i}},{{end}}{{json $v.Code}}{{end}}]}}`
+
+const blockResponseDetailBodyTemplate = `{"error":{"id":{{json .RequestID}},"status":{{.StatusCode}},"detail":{{json .Detail}}}}`
+
+type testBlockResponseBody struct {
+	Error struct {
+		ID     string   `json:"id"`
+		Status int      `json:"status"`
+		Codes  []string `json:"codes"`
+		Detail string   `json:"detail"`
+	} `json:"error"`
+}
+
+func TestBlockResponse(t *testing.T) {
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var lock sync.RWMutex
+
+	serverUrl, err := url.ParseRequestURI("http://127.0.0.1:80")
+	if err != nil {
+		t.Fatalf("parsing API Host URL: %s", err.Error())
+	}
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	logger = logger.Level(zerolog.ErrorLevel)
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(openAPISpecBlockResponseTest))
+	if err != nil {
+		t.Fatalf("loading OpenAPI specification file: %s", err.Error())
+	}
+
+	dbSpec := storage.NewMockDBOpenAPILoader(mockCtrl)
+	dbSpec.EXPECT().Specification(gomock.Any()).Return(swagger).AnyTimes()
+
+	proxy := proxyPool.NewMockPool(mockCtrl)
+
+	cfg := config.ProxyMode{
+		RequestValidation:     "BLOCK",
+		ResponseValidation:    "BLOCK",
+		CustomBlockStatusCode: 403,
+		BlockResponse: config.BlockResponse{
+			ContentType: "application/json",
+			Headers:     map[string]string{"X-Error-Source": "global"},
+			Body:        blockResponseBodyTemplate,
+		},
+		Endpoints: config.EndpointList{
+			{
+				Path:   "/orders",
+				Method: "GET",
+				ValidationMode: config.ValidationMode{
+					RequestValidation:  "BLOCK",
+					ResponseValidation: "BLOCK",
+				},
+				BlockResponse: &config.BlockResponse{
+					StatusCode: 400,
+					Headers:    map[string]string{"X-Error-Source": "endpoint"},
+				},
+			},
+		},
+	}
+
+	handler := proxyMode.Handlers(&lock, &cfg, serverUrl, shutdown, logger, proxy, dbSpec, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		name               string
+		uri                string
+		expectedStatusCode int
+		expectedSource     string
+		expectedErrCode    string
+	}{
+		{
+			name:               "global block response",
+			uri:                "/items",
+			expectedStatusCode: fasthttp.StatusForbidden,
+			expectedSource:     "global",
+			expectedErrCode:    apiModeValidator.ErrCodeRequiredQueryParameterMissed,
+		},
+		{
+			name:               "endpoint block response",
+			uri:                "/orders",
+			expectedStatusCode: fasthttp.StatusBadRequest,
+			expectedSource:     "endpoint",
+			expectedErrCode:    apiModeValidator.ErrCodeRequiredQueryParameterMissed,
+		},
+		{
+			name:               "operation block response",
+			uri:                "/users",
+			expectedStatusCode: fasthttp.StatusUnprocessableEntity,
+			expectedSource:     "operation",
+			expectedErrCode:    apiModeValidator.ErrCodeRequiredQueryParameterMissed,
+		},
+		{
+			name:               "route not found",
+			uri:                "/unknown",
+			expectedStatusCode: fasthttp.StatusForbidden,
+			expectedSource:     "global",
+			expectedErrCode:    apiModeValidator.ErrCodeMethodAndPathNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var reqCtx fasthttp.RequestCtx
+			reqCtx.Request.SetRequestURI(tt.uri)
+			reqCtx.Request.Header.SetMethod(fasthttp.MethodGet)
+
+			handler(&reqCtx)
+
+			if reqCtx.Response.StatusCode() != tt.expectedStatusCode {
+				t.Errorf("Incorrect response status code. Expected: %d and got %d",
+					tt.expectedStatusCode, reqCtx.Response.StatusCode())
+			}
+
+			if source := string(reqCtx.Response.Header.Peek("X-Error-Source")); source != tt.expectedSource {
+				t.Errorf("Incorrect X-Error-Source header. Expected: %s and got %s", tt.expectedSource, source)
+			}
+
+			if ct := string(reqCtx.Response.Header.ContentType()); ct != "application/json" {
+				t.Errorf("Incorrect content type. Expected: application/json and got %s", ct)
+			}
+
+			var body testBlockResponseBody
+			if err := json.Unmarshal(reqCtx.Response.Body(), &body); err != nil {
+				t.Fatalf("Error while JSON response parsing: %v. Body: %s", err, reqCtx.Response.Body())
+			}
+
+			if body.Error.ID == "" {
+				t.Errorf("Request ID not found in the response body: %s", reqCtx.Response.Body())
+			}
+
+			if body.Error.Status != tt.expectedStatusCode {
+				t.Errorf("Incorrect status in the response body. Expected: %d and got %d", tt.expectedStatusCode, body.Error.Status)
+			}
+
+			if len(body.Error.Codes) != 1 || body.Error.Codes[0] != tt.expectedErrCode {
+				t.Errorf("Incorrect error codes. Expected: [%s] and got %v", tt.expectedErrCode, body.Error.Codes)
+			}
+		})
+	}
+}
+
+func TestBlockResponseMiddlewares(t *testing.T) {
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var lock sync.RWMutex
+
+	serverUrl, err := url.ParseRequestURI("http://127.0.0.1:80")
+	if err != nil {
+		t.Fatalf("parsing API Host URL: %s", err.Error())
+	}
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	logger = logger.Level(zerolog.ErrorLevel)
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(openAPISpecBlockResponseTest))
+	if err != nil {
+		t.Fatalf("loading OpenAPI specification file: %s", err.Error())
+	}
+
+	dbSpec := storage.NewMockDBOpenAPILoader(mockCtrl)
+	dbSpec.EXPECT().Specification(gomock.Any()).Return(swagger).AnyTimes()
+
+	proxy := proxyPool.NewMockPool(mockCtrl)
+	client := proxyPool.NewMockHTTPClient(mockCtrl)
+
+	proxy.EXPECT().Get().Return(client, resolvedIP, nil).AnyTimes()
+	proxy.EXPECT().Put(resolvedIP, client).Return(nil).AnyTimes()
+	client.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(req *fasthttp.Request, resp *fasthttp.Response) error {
+		resp.SetStatusCode(fasthttp.StatusOK)
+		return nil
+	}).AnyTimes()
+
+	cfg := config.ProxyMode{
+		RequestValidation:     "BLOCK",
+		ResponseValidation:    "BLOCK",
+		CustomBlockStatusCode: 403,
+		BlockResponse: config.BlockResponse{
+			ContentType: "application/json",
+			Headers:     map[string]string{"X-Error-Source": "global"},
+			Body:        blockResponseDetailBodyTemplate,
+		},
+		Denylist: struct {
+			Tokens config.Token
+		}{Tokens: config.Token{
+			CookieName: testDeniedCookieName,
+			File:       "../../../resources/test/tokens/test.db",
+		}},
+		RateLimit: config.RateLimit{
+			Requests:    1,
+			Period:      time.Minute,
+			Algorithm:   "TOKEN_BUCKET",
+			Key:         "IP",
+			HeaderName:  "X-Forwarded-For",
+			TrustedHops: 1,
+		},
+	}
+
+	deniedTokens, err := denylist.New(&cfg.Denylist, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rateLimiter, err := ratelimit.NewRateLimiter(&cfg.RateLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := proxyMode.Handlers(&lock, &cfg, serverUrl, shutdown, logger, proxy, dbSpec, deniedTokens, nil, nil, rateLimiter, nil)
+
+	tests := []struct {
+		name               string
+		method             string
+		uri                string
+		body               string
+		ip                 string
+		deniedToken        bool
+		expectedStatusCode int
+		expectedDetail     string
+	}{
+		{
+			name:               "denylist",
+			method:             fasthttp.MethodGet,
+			uri:                "/items?limit=1",
+			ip:                 "10.0.0.1",
+			deniedToken:        true,
+			expectedStatusCode: fasthttp.StatusForbidden,
+			expectedDetail:     "access denied",
+		},
+		{
+			name:               "allowed by rate limit",
+			method:             fasthttp.MethodGet,
+			uri:                "/items?limit=1",
+			ip:                 "10.0.0.2",
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "rate limit",
+			method:             fasthttp.MethodGet,
+			uri:                "/items?limit=1",
+			ip:                 "10.0.0.2",
+			expectedStatusCode: fasthttp.StatusTooManyRequests,
+			expectedDetail:     "rate limit exceeded",
+		},
+		{
+			name:               "request body size limit",
+			method:             fasthttp.MethodPost,
+			uri:                "/upload",
+			body:               "large body",
+			ip:                 "10.0.0.3",
+			expectedStatusCode: fasthttp.StatusRequestEntityTooLarge,
+			expectedDetail:     "request body is too large",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var reqCtx fasthttp.RequestCtx
+			reqCtx.Request.SetRequestURI(tt.uri)
+			reqCtx.Request.Header.SetMethod(tt.method)
+			reqCtx.Request.Header.Set("X-Forwarded-For", tt.ip)
+			if tt.body != "" {
+				reqCtx.Request.Header.SetContentType("text/plain")
+				reqCtx.Request.SetBodyString(tt.body)
+			}
+			if tt.deniedToken {
+				reqCtx.Request.Header.SetCookie(testDeniedCookieName, testDeniedToken)
+			}
+
+			handler(&reqCtx)
+
+			if reqCtx.Response.StatusCode() != tt.expectedStatusCode {
+				t.Fatalf("Incorrect response status code. Expected: %d and got %d",
+					tt.expectedStatusCode, reqCtx.Response.StatusCode())
+			}
+
+			if tt.expectedDetail == "" {
+				return
+			}
+
+			if source := string(reqCtx.Response.Header.Peek("X-Error-Source")); source != "global" {
+				t.Errorf("Incorrect X-Error-Source header. Expected: global and got %s", source)
+			}
+
+			var body testBlockResponseBody
+			if err := json.Unmarshal(reqCtx.Response.Body(), &body); err != nil {
+				t.Fatalf("Error while JSON response parsing: %v. Body: %s", err, reqCtx.Response.Body())
+			}
+
+			if body.Error.ID == "" {
+				t.Errorf("Request ID not found in the response body: %s", reqCtx.Response.Body())
+			}
+
+			if body.Error.Status != tt.expectedStatusCode {
+				t.Errorf("Incorrect status in the response body. Expected: %d and got %d", tt.expectedStatusCode, body.Error.Status)
+			}
+
+			if body.Error.Detail != tt.expectedDetail {
+				t.Errorf("Incorrect detail in the response body. Expected: %s and got %s", tt.expectedDetail, body.Error.Detail)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/foxcpp/go-mockdns"
 	"github.com/rs/zerolog"
 
+	proxyMode "github.com/wallarm/api-firewall/cmd/api-firewall/internal/handlers/proxy"
 	"github.com/wallarm/api-firewall/internal/config"
 	"github.com/wallarm/api-firewall/internal/platform/proxy"
 )
@@ -100,3 +101,79 @@ func TestWithoutRCCDNSCacheBasic(t *testing.T) {
 			addr[0].String())
 	}
 }
+
+func TestSpecTargetDNSRefreshWithoutCache(t *testing.T) {
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	logger = logger.Level(zerolog.ErrorLevel)
+
+	var cfg = config.ProxyMode{
+		RequestValidation:  "BLOCK",
+		ResponseValidation: "BLOCK",
+		APISpecs:           "../../../resources/test/specification/openapi_for_tests.json",
+		DNS: config.DNS{
+			Cache:         false,
+			FetchTimeout:  50 * time.Millisecond,
+			LookupTimeout: 400 * time.Millisecond,
+		},
+	}
+	cfg.Server.URL = "http://example.org:8080/"
+	cfg.Server.MaxConnsPerHost = 16
+	cfg.Server.DialTimeout = time.Second
+
+	srv, _ := mockdns.NewServer(map[string]mockdns.Zone{
+		"example.org.": {
+			A: []string{"1.2.3.4"},
+		},
+	}, false)
+	defer srv.Close()
+
+	srvUpdated, _ := mockdns.NewServer(map[string]mockdns.Zone{
+		"example.org.": {
+			A: []string{"5.6.7.8"},
+		},
+	}, false)
+	defer srvUpdated.Close()
+
+	r := &net.Resolver{}
+	srv.PatchNet(r)
+
+	dnsResolver, err := proxy.NewDNSResolver(r, &proxy.DNSCacheOptions{
+		UseCache:      cfg.DNS.Cache,
+		Logger:        logger,
+		FetchTimeout:  cfg.DNS.FetchTimeout,
+		LookupTimeout: cfg.DNS.LookupTimeout,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dnsResolver.Stop()
+
+	target, err := proxyMode.NewSpecTarget("default", "", "", &cfg, dnsResolver, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Pool.Close()
+
+	pool, ok := target.Pool.(*proxy.PoolV2)
+	if !ok {
+		t.Fatalf("unexpected pool type %T", target.Pool)
+	}
+
+	if backends := pool.Stats().Backends; len(backends) != 1 || backends[0] != "1.2.3.4:8080" {
+		t.Fatalf("unexpected initial backends: %v", backends)
+	}
+
+	// the backend host is re-resolved without the DNS cache
+	srvUpdated.PatchNet(r)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if backends := pool.Stats().Backends; len(backends) == 1 && backends[0] == "5.6.7.8:8080" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Errorf("expected the re-resolved backend 5.6.7.8:8080, got %v", pool.Stats().Backends)
+}
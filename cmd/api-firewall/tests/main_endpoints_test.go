@@ -244,7 +244,7 @@ func TestEndpointConfig(t *testing.T) {
 				Endpoints:             tt.endpoints,
 			}
 
-			handler := proxyMode.Handlers(&lock, &cfg, serverUrl, shutdown, logger, proxy, dbSpec, nil, nil, nil)
+			handler := proxyMode.Handlers(&lock, &cfg, serverUrl, shutdown, logger, proxy, dbSpec, nil, nil, nil, nil, nil)
 
 			req := fasthttp.AcquireRequest()
 			req.SetRequestURI(tt.request.URI)
@@ -273,3 +273,220 @@ func TestEndpointConfig(t *testing.T) {
 		})
 	}
 }
+
+const openAPISpecEndpointPatternsTest = `
+openapi: 3.0.1
+info:
+  title: Service
+  version: 1.0.0
+servers:
+  - url: /
+paths:
+  /admin:
+    get:
+      parameters:
+        - $ref: '#/components/parameters/limit'
+      responses:
+        '200':
+          description: Admin
+  /admin/users/{id}:
+    get:
+      parameters:
+        - $ref: '#/components/parameters/limit'
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: User
+  /reports:
+    get:
+      tags: [reports]
+      parameters:
+        - $ref: '#/components/parameters/limit'
+      responses:
+        '200':
+          description: Reports
+  /orders:
+    get:
+      operationId: listOrders
+      parameters:
+        - $ref: '#/components/parameters/limit'
+      responses:
+        '200':
+          description: Orders
+    post:
+      operationId: createOrder
+      parameters:
+        - $ref: '#/components/parameters/limit'
+      responses:
+        '200':
+          description: Order
+  /v2/items:
+    get:
+      parameters:
+        - $ref: '#/components/parameters/limit'
+      responses:
+        '200':
+          description: Items
+  /users:
+    get:
+      parameters:
+        - $ref: '#/components/parameters/limit'
+      responses:
+        '200':
+          description: Users
+  /roles:
+    get:
+      parameters:
+        - $ref: '#/components/parameters/limit'
+      responses:
+        '200':
+          description: Roles
+  /archive/roles:
+    get:
+      parameters:
+        - $ref: '#/components/parameters/limit'
+      responses:
+        '200':
+          description: Archived roles
+components:
+  parameters:
+    limit:
+      name: limit
+      in: query
+      required: true
+      schema:
+        type: integer
+`
+
+func TestEndpointPatterns(t *testing.T) {
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var lock sync.RWMutex
+
+	serverUrl, err := url.ParseRequestURI("http://127.0.0.1:80")
+	if err != nil {
+		t.Fatalf("parsing API Host URL: %s", err.Error())
+	}
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	logger = logger.Level(zerolog.ErrorLevel)
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(openAPISpecEndpointPatternsTest))
+	if err != nil {
+		t.Fatalf("loading OpenAPI specification file: %s", err.Error())
+	}
+
+	dbSpec := storage.NewMockDBOpenAPILoader(mockCtrl)
+	dbSpec.EXPECT().Specification(gomock.Any()).Return(swagger).AnyTimes()
+
+	proxy := proxyPool.NewMockPool(mockCtrl)
+	client := proxyPool.NewMockHTTPClient(mockCtrl)
+
+	proxy.EXPECT().Get().Return(client, resolvedIP, nil).AnyTimes()
+	proxy.EXPECT().Put(resolvedIP, client).Return(nil).AnyTimes()
+	client.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(req *fasthttp.Request, resp *fasthttp.Response) error {
+		resp.SetStatusCode(fasthttp.StatusOK)
+		return nil
+	}).AnyTimes()
+
+	var endpoints config.EndpointList
+	if err := endpoints.Set("/admin/**|LOG_ONLY|LOG_ONLY;GET:/admin/users/{id}|BLOCK|BLOCK;tag=reports|LOG_ONLY|LOG_ONLY;GET:operationId=listOrders|LOG_ONLY|LOG_ONLY;~/V[0-9]{1,2}/ITEMS|DISABLE|DISABLE;~/(roles|groups)|LOG_ONLY|LOG_ONLY"); err != nil {
+		t.Fatalf("parsing endpoints: %s", err.Error())
+	}
+
+	cfg := config.ProxyMode{
+		RequestValidation:     "BLOCK",
+		ResponseValidation:    "BLOCK",
+		CustomBlockStatusCode: 403,
+		Endpoints:             endpoints,
+	}
+
+	handler := proxyMode.Handlers(&lock, &cfg, serverUrl, shutdown, logger, proxy, dbSpec, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		name               string
+		method             string
+		uri                string
+		expectedStatusCode int
+	}{
+		{
+			name:               "glob pattern matches the path without trailing segments",
+			method:             fasthttp.MethodGet,
+			uri:                "/admin",
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "first matched rule is applied",
+			method:             fasthttp.MethodGet,
+			uri:                "/admin/users/1",
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "tag",
+			method:             fasthttp.MethodGet,
+			uri:                "/reports",
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "operation ID",
+			method:             fasthttp.MethodGet,
+			uri:                "/orders",
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "operation ID of another operation",
+			method:             fasthttp.MethodPost,
+			uri:                "/orders",
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+		{
+			name:               "regular expression",
+			method:             fasthttp.MethodGet,
+			uri:                "/v2/items",
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "regular expression with alternation",
+			method:             fasthttp.MethodGet,
+			uri:                "/roles",
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "regular expression matches the whole path",
+			method:             fasthttp.MethodGet,
+			uri:                "/archive/roles",
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+		{
+			name:               "no matched rules",
+			method:             fasthttp.MethodGet,
+			uri:                "/users",
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var reqCtx fasthttp.RequestCtx
+			reqCtx.Request.SetRequestURI(tt.uri)
+			reqCtx.Request.Header.SetMethod(tt.method)
+
+			handler(&reqCtx)
+
+			if reqCtx.Response.StatusCode() != tt.expectedStatusCode {
+				t.Errorf("Incorrect response status code. Expected: %d and got %d",
+					tt.expectedStatusCode, reqCtx.Response.StatusCode())
+			}
+		})
+	}
+}
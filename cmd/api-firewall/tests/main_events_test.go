@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	proxyMode "github.com/wallarm/api-firewall/cmd/api-firewall/internal/handlers/proxy"
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/events"
+	proxyPool "github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/storage"
+	"github.com/wallarm/api-firewall/internal/platform/web"
+	apiModeValidator "github.com/wallarm/api-firewall/pkg/APIMode/validator"
+)
+
+type testEventsSink struct {
+	events []*events.Event
+}
+
+func (s *testEventsSink) Send(event *events.Event) {
+	s.events = append(s.events, event)
+}
+
+func (s *testEventsSink) Close() error {
+	return nil
+}
+
+func TestSecurityEvents(t *testing.T) {
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var lock sync.RWMutex
+
+	serverUrl, err := url.ParseRequestURI("http://127.0.0.1:80")
+	if err != nil {
+		t.Fatalf("parsing API Host URL: %s", err.Error())
+	}
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	logger = logger.Level(zerolog.ErrorLevel)
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(openAPISpecUsersTest))
+	if err != nil {
+		t.Fatalf("loading OpenAPI specification file: %s", err.Error())
+	}
+
+	dbSpec := storage.NewMockDBOpenAPILoader(mockCtrl)
+	dbSpec.EXPECT().Specification(gomock.Any()).Return(swagger).AnyTimes()
+
+	proxy := proxyPool.NewMockPool(mockCtrl)
+	client := proxyPool.NewMockHTTPClient(mockCtrl)
+
+	proxy.EXPECT().Get().Return(client, resolvedIP, nil).AnyTimes()
+	proxy.EXPECT().Put(resolvedIP, client).Return(nil).AnyTimes()
+	client.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(req *fasthttp.Request, resp *fasthttp.Response) error {
+		resp.SetStatusCode(fasthttp.StatusOK)
+		return nil
+	}).AnyTimes()
+
+	tests := []struct {
+		name               string
+		requestValidation  string
+		uri                string
+		expectedStatusCode int
+		expectedType       string
+		expectedAction     string
+		expectedCode       string
+	}{
+		{
+			name:               "Valid request",
+			requestValidation:  "BLOCK",
+			uri:                "/users/10",
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "Blocked request",
+			requestValidation:  "BLOCK",
+			uri:                "/users/abc",
+			expectedStatusCode: fasthttp.StatusForbidden,
+			expectedType:       events.TypeRequestValidation,
+			expectedAction:     events.ActionBlocked,
+			expectedCode:       apiModeValidator.ErrCodeRequiredPathParameterInvalidValue,
+		},
+		{
+			name:               "Logged request",
+			requestValidation:  "LOG_ONLY",
+			uri:                "/users/abc",
+			expectedStatusCode: fasthttp.StatusOK,
+			expectedType:       events.TypeRequestValidation,
+			expectedAction:     events.ActionLogged,
+			expectedCode:       apiModeValidator.ErrCodeRequiredPathParameterInvalidValue,
+		},
+		{
+			name:               "Route not found",
+			requestValidation:  "BLOCK",
+			uri:                "/unknown",
+			expectedStatusCode: fasthttp.StatusForbidden,
+			expectedType:       events.TypeShadowAPI,
+			expectedAction:     events.ActionBlocked,
+			expectedCode:       apiModeValidator.ErrCodeMethodAndPathNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			cfg := config.ProxyMode{
+				RequestValidation:     tt.requestValidation,
+				ResponseValidation:    "DISABLE",
+				CustomBlockStatusCode: 403,
+			}
+
+			sink := &testEventsSink{}
+			handler := events.Handler(sink, web.ProxyMode,
+				proxyMode.Handlers(&lock, &cfg, serverUrl, shutdown, logger, proxy, dbSpec, nil, nil, nil, nil, nil))
+
+			var reqCtx fasthttp.RequestCtx
+			reqCtx.Request.SetRequestURI(tt.uri)
+			reqCtx.Request.Header.SetMethod(fasthttp.MethodGet)
+
+			handler(&reqCtx)
+
+			if reqCtx.Response.StatusCode() != tt.expectedStatusCode {
+				t.Errorf("Incorrect response status code. Expected: %d and got %d",
+					tt.expectedStatusCode, reqCtx.Response.StatusCode())
+			}
+
+			if tt.expectedType == "" {
+				if len(sink.events) != 0 {
+					t.Errorf("expected no security events, got %d", len(sink.events))
+				}
+				return
+			}
+
+			if len(sink.events) != 1 {
+				t.Fatalf("expected 1 security event, got %d", len(sink.events))
+			}
+
+			e := sink.events[0]
+			if e.Type != tt.expectedType || e.Action != tt.expectedAction || e.RequestID == "" ||
+				e.StatusCode != tt.expectedStatusCode || e.Path != tt.uri {
+				t.Errorf("incorrect security event: %+v", e)
+			}
+
+			if len(e.Violations) == 0 || e.Violations[0].Code != tt.expectedCode {
+				t.Errorf("incorrect violations. Expected code %s and got %+v", tt.expectedCode, e.Violations)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package tests
+
+import (
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	proxyMode "github.com/wallarm/api-firewall/cmd/api-firewall/internal/handlers/proxy"
+	"github.com/wallarm/api-firewall/internal/config"
+	proxyPool "github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/storage"
+)
+
+const openAPISpecExtensionsTest = `
+openapi: 3.0.1
+info:
+  title: Service
+  version: 1.0.0
+servers:
+  - url: /
+paths:
+  /items:
+    get:
+      x-apifw-request-validation: LOG_ONLY
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: Items
+  /orders:
+    x-apifw-request-validation: LOG_ONLY
+    get:
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: Orders
+  /users:
+    get:
+      x-apifw-block-status-code: 422
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: Users
+  /search:
+    get:
+      x-apifw-unknown-parameters-detection: true
+      parameters:
+        - name: q
+          in: query
+          schema:
+            type: string
+      responses:
+        '200':
+          description: Search
+  /upload:
+    post:
+      x-apifw-max-request-body-size: 16
+      requestBody:
+        content:
+          text/plain:
+            schema:
+              type: string
+      responses:
+        '200':
+          description: Upload
+  /upload-logged:
+    post:
+      x-apifw-request-validation: LOG_ONLY
+      x-apifw-max-request-body-size: 16
+      requestBody:
+        content:
+          text/plain:
+            schema:
+              type: string
+      responses:
+        '200':
+          description: Upload
+  /upload-disabled:
+    post:
+      x-apifw-request-validation: DISABLE
+      x-apifw-response-validation: DISABLE
+      x-apifw-max-request-body-size: 16
+      requestBody:
+        content:
+          text/plain:
+            schema:
+              type: string
+      responses:
+        '200':
+          description: Upload
+  /mixed:
+    get:
+      x-apifw-request-validation: STRICT
+      x-apifw-block-status-code: 422
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: Mixed
+  /limited:
+    get:
+      x-apifw-rate-limit:
+        requests: 1
+        period: 1m
+      responses:
+        '200':
+          description: Limited
+`
+
+func TestOperationExtensions(t *testing.T) {
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var lock sync.RWMutex
+
+	serverUrl, err := url.ParseRequestURI("http://127.0.0.1:80")
+	if err != nil {
+		t.Fatalf("parsing API Host URL: %s", err.Error())
+	}
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	logger = logger.Level(zerolog.ErrorLevel)
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(openAPISpecExtensionsTest))
+	if err != nil {
+		t.Fatalf("loading OpenAPI specification file: %s", err.Error())
+	}
+
+	dbSpec := storage.NewMockDBOpenAPILoader(mockCtrl)
+	dbSpec.EXPECT().Specification(gomock.Any()).Return(swagger).AnyTimes()
+
+	proxy := proxyPool.NewMockPool(mockCtrl)
+	client := proxyPool.NewMockHTTPClient(mockCtrl)
+
+	proxy.EXPECT().Get().Return(client, resolvedIP, nil).AnyTimes()
+	proxy.EXPECT().Put(resolvedIP, client).Return(nil).AnyTimes()
+	client.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(req *fasthttp.Request, resp *fasthttp.Response) error {
+		resp.SetStatusCode(fasthttp.StatusOK)
+		return nil
+	}).AnyTimes()
+
+	cfg := config.ProxyMode{
+		RequestValidation:     "BLOCK",
+		ResponseValidation:    "BLOCK",
+		CustomBlockStatusCode: 403,
+		Endpoints: config.EndpointList{
+			{
+				Path:   "/orders",
+				Method: "GET",
+				ValidationMode: config.ValidationMode{
+					RequestValidation:  "BLOCK",
+					ResponseValidation: "BLOCK",
+				},
+			},
+		},
+	}
+
+	handler := proxyMode.Handlers(&lock, &cfg, serverUrl, shutdown, logger, proxy, dbSpec, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		name               string
+		method             string
+		uri                string
+		body               string
+		deflate            bool
+		expectedStatusCode int
+	}{
+		{
+			name:               "operation validation mode",
+			method:             fasthttp.MethodGet,
+			uri:                "/items",
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "endpoint validation mode overrides path item extension",
+			method:             fasthttp.MethodGet,
+			uri:                "/orders",
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+		{
+			name:               "operation block status code",
+			method:             fasthttp.MethodGet,
+			uri:                "/users",
+			expectedStatusCode: fasthttp.StatusUnprocessableEntity,
+		},
+		{
+			name:               "valid extension applied with the invalid one",
+			method:             fasthttp.MethodGet,
+			uri:                "/mixed",
+			expectedStatusCode: 422,
+		},
+		{
+			name:               "operation unknown parameters detection",
+			method:             fasthttp.MethodGet,
+			uri:                "/search?q=test&unknown=1",
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+		{
+			name:               "request body size within the operation limit",
+			method:             fasthttp.MethodPost,
+			uri:                "/upload",
+			body:               "small body",
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "request body size exceeds the operation limit",
+			method:             fasthttp.MethodPost,
+			uri:                "/upload",
+			body:               "the body which is too large",
+			expectedStatusCode: fasthttp.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "decompressed request body size exceeds the operation limit",
+			method:             fasthttp.MethodPost,
+			uri:                "/upload",
+			body:               strings.Repeat("a", 64),
+			deflate:            true,
+			expectedStatusCode: fasthttp.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "request body size limit in log only mode",
+			method:             fasthttp.MethodPost,
+			uri:                "/upload-logged",
+			body:               "the body which is too large",
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "request body size limit in disabled mode",
+			method:             fasthttp.MethodPost,
+			uri:                "/upload-disabled",
+			body:               "the body which is too large",
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "operation rate limit first request",
+			method:             fasthttp.MethodGet,
+			uri:                "/limited",
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "operation rate limit exceeded",
+			method:             fasthttp.MethodGet,
+			uri:                "/limited",
+			expectedStatusCode: fasthttp.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var reqCtx fasthttp.RequestCtx
+			reqCtx.Request.SetRequestURI(tt.uri)
+			reqCtx.Request.Header.SetMethod(tt.method)
+			if tt.body != "" {
+				reqCtx.Request.Header.SetContentType("text/plain")
+				reqCtx.Request.SetBodyString(tt.body)
+			}
+			if tt.deflate {
+				reqCtx.Request.Header.SetContentEncoding("deflate")
+				reqCtx.Request.SetBody(fasthttp.AppendDeflateBytesLevel(nil, []byte(tt.body), fasthttp.CompressBestCompression))
+			}
+
+			handler(&reqCtx)
+
+			if reqCtx.Response.StatusCode() != tt.expectedStatusCode {
+				t.Errorf("Incorrect response status code. Expected: %d and got %d",
+					tt.expectedStatusCode, reqCtx.Response.StatusCode())
+			}
+		})
+	}
+}
@@ -180,7 +180,7 @@ func BenchmarkGraphQL(b *testing.B) {
 		},
 	}
 
-	handler := graphqlHandler.Handlers(&cfg, schema, serverURL, shutdown, logger, pool, wsPool, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, serverURL, shutdown, logger, pool, wsPool, nil, nil, nil)
 
 	srv := fasthttp.Server{
 		Handler: handler,
@@ -191,7 +191,7 @@ func (s *ServiceGraphQLTests) testGQLSuccess(t *testing.T) {
 		t.Fatalf("Loading GraphQL Schema error: %v", err)
 	}
 
-	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil, nil)
 
 	// Construct GraphQL request payload
 	query := `
@@ -292,7 +292,7 @@ func (s *ServiceGraphQLTests) testGQLEndpointNotExists(t *testing.T) {
 		t.Fatalf("Loading GraphQL Schema error: %v", err)
 	}
 
-	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil, nil)
 
 	// Construct GraphQL request payload
 	query := `
@@ -382,7 +382,7 @@ func (s *ServiceGraphQLTests) testGQLGETSuccess(t *testing.T) {
 		t.Fatalf("Loading GraphQL Schema error: %v", err)
 	}
 
-	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil, nil)
 
 	// Construct GraphQL request payload
 	query := `
@@ -477,7 +477,7 @@ func (s *ServiceGraphQLTests) testGQLGETMutationFailed(t *testing.T) {
 		t.Fatalf("Loading GraphQL Schema error: %v", err)
 	}
 
-	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil, nil)
 
 	// Construct GraphQL request payload
 	query := `
@@ -565,7 +565,7 @@ func (s *ServiceGraphQLTests) testGQLValidationFailed(t *testing.T) {
 		t.Fatalf("Loading GraphQL Schema error: %v", err)
 	}
 
-	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil, nil)
 
 	// Construct GraphQL request payload
 	query := `
@@ -664,7 +664,7 @@ func (s *ServiceGraphQLTests) testGQLInvalidQuerySyntax(t *testing.T) {
 		t.Fatalf("Loading GraphQL Schema error: %v", err)
 	}
 
-	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil, nil)
 
 	// Construct GraphQL request payload
 	query := `
@@ -764,7 +764,7 @@ func (s *ServiceGraphQLTests) testGQLInvalidMaxComplexity(t *testing.T) {
 		t.Fatalf("Loading GraphQL Schema error: %v", err)
 	}
 
-	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil, nil)
 
 	// Construct GraphQL request payload
 	query := `
@@ -863,7 +863,7 @@ func (s *ServiceGraphQLTests) testGQLInvalidMaxDepth(t *testing.T) {
 		t.Fatalf("Loading GraphQL Schema error: %v", err)
 	}
 
-	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil, nil)
 
 	// Construct GraphQL request payload
 	query := `
@@ -962,7 +962,7 @@ func (s *ServiceGraphQLTests) testGQLInvalidNodeLimit(t *testing.T) {
 		t.Fatalf("Loading GraphQL Schema error: %v", err)
 	}
 
-	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil, nil)
 
 	// Construct GraphQL request payload
 	query := `
@@ -1064,7 +1064,7 @@ func (s *ServiceGraphQLTests) testGQLBatchQueryLimit(t *testing.T) {
 		t.Fatalf("Loading GraphQL Schema error: %v", err)
 	}
 
-	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil, nil)
 
 	// Construct GraphQL request payload
 	bqReq := `[
@@ -1159,7 +1159,7 @@ func (s *ServiceGraphQLTests) testGQLDenylistBlock(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, deniedTokens, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, deniedTokens, nil, nil)
 
 	// Construct GraphQL request payload
 	query := `
@@ -1395,7 +1395,7 @@ func (s *ServiceGraphQLTests) testGQLSubscription(t *testing.T) {
 	serverUrl, err := url.ParseRequestURI(cfg.Server.URL)
 	assert.Nil(t, err)
 
-	handler := graphqlHandler.Handlers(&cfg, schema, serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil, nil)
 
 	// connection to the backend
 	headers := http.Header{}
@@ -1575,7 +1575,7 @@ func (s *ServiceGraphQLTests) testGQLSubscriptionLogOnly(t *testing.T) {
 	serverUrl, err := url.ParseRequestURI(cfg.Server.URL)
 	assert.Nil(t, err)
 
-	handler := graphqlHandler.Handlers(&cfg, schema, serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil, nil)
 
 	// connection to the backend
 	headers := http.Header{}
@@ -1709,7 +1709,7 @@ func (s *ServiceGraphQLTests) testGQLMaxAliasesNum(t *testing.T) {
 		t.Fatalf("Loading GraphQL Schema error: %v", err)
 	}
 
-	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil, nil)
 
 	// Construct GraphQL request payload
 	query := `
@@ -1819,7 +1819,7 @@ func (s *ServiceGraphQLTests) testGQLDuplicateFields(t *testing.T) {
 		t.Fatalf("Loading GraphQL Schema error: %v", err)
 	}
 
-	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil)
+	handler := graphqlHandler.Handlers(&cfg, schema, s.serverUrl, s.shutdown, logger, s.proxy, s.backendWSClient, nil, nil, nil)
 
 	// Construct GraphQL request payload
 	query := `
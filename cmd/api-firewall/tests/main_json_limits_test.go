@@ -0,0 +1,242 @@
+package tests
+
+import (
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	proxyMode "github.com/wallarm/api-firewall/cmd/api-firewall/internal/handlers/proxy"
+	"github.com/wallarm/api-firewall/internal/config"
+	proxyPool "github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/storage"
+)
+
+const openAPISpecJSONLimitsTest = `
+openapi: 3.0.1
+info:
+  title: Service
+  version: 1.0.0
+servers:
+  - url: /
+paths:
+  /items:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+      responses:
+        '200':
+          description: Items
+  /documents:
+    post:
+      x-apifw-json-limits:
+        maxDepth: 5
+        duplicateKeys: LOG_ONLY
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+      responses:
+        '200':
+          description: Documents
+  /logged:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+      responses:
+        '200':
+          description: Logged
+  /disabled:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+      responses:
+        '200':
+          description: Disabled
+`
+
+func TestJSONLimits(t *testing.T) {
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var lock sync.RWMutex
+
+	serverUrl, err := url.ParseRequestURI("http://127.0.0.1:80")
+	if err != nil {
+		t.Fatalf("parsing API Host URL: %s", err.Error())
+	}
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	logger = logger.Level(zerolog.ErrorLevel)
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(openAPISpecJSONLimitsTest))
+	if err != nil {
+		t.Fatalf("loading OpenAPI specification file: %s", err.Error())
+	}
+
+	dbSpec := storage.NewMockDBOpenAPILoader(mockCtrl)
+	dbSpec.EXPECT().Specification(gomock.Any()).Return(swagger).AnyTimes()
+
+	proxy := proxyPool.NewMockPool(mockCtrl)
+	client := proxyPool.NewMockHTTPClient(mockCtrl)
+
+	proxy.EXPECT().Get().Return(client, resolvedIP, nil).AnyTimes()
+	proxy.EXPECT().Put(resolvedIP, client).Return(nil).AnyTimes()
+	client.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(req *fasthttp.Request, resp *fasthttp.Response) error {
+		resp.SetStatusCode(fasthttp.StatusOK)
+		return nil
+	}).AnyTimes()
+
+	cfg := config.ProxyMode{
+		RequestValidation:     "BLOCK",
+		ResponseValidation:    "BLOCK",
+		CustomBlockStatusCode: 403,
+		JSONLimits: config.JSONLimits{
+			MaxDepth:        2,
+			MaxKeys:         3,
+			MaxArrayLength:  3,
+			MaxStringLength: 8,
+			MaxTokens:       16,
+			DuplicateKeys:   "BLOCK",
+		},
+		Endpoints: config.EndpointList{
+			{
+				Path:   "/logged",
+				Method: "POST",
+				ValidationMode: config.ValidationMode{
+					RequestValidation:  "LOG_ONLY",
+					ResponseValidation: "BLOCK",
+				},
+			},
+			{
+				Path:   "/disabled",
+				Method: "POST",
+				ValidationMode: config.ValidationMode{
+					RequestValidation:  "DISABLE",
+					ResponseValidation: "BLOCK",
+				},
+			},
+		},
+	}
+
+	handler := proxyMode.Handlers(&lock, &cfg, serverUrl, shutdown, logger, proxy, dbSpec, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		name               string
+		uri                string
+		body               string
+		expectedStatusCode int
+	}{
+		{
+			name:               "body within the limits",
+			uri:                "/items",
+			body:               `{"a":{"b":1},"c":[1,2,3]}`,
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "depth limit exceeded",
+			uri:                "/items",
+			body:               `{"a":{"b":{"c":1}}}`,
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+		{
+			name:               "keys limit exceeded",
+			uri:                "/items",
+			body:               `{"a":1,"b":2,"c":3,"d":4}`,
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+		{
+			name:               "array length limit exceeded",
+			uri:                "/items",
+			body:               `{"a":[1,2,3,4]}`,
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+		{
+			name:               "string length limit exceeded",
+			uri:                "/items",
+			body:               `{"a":"long string"}`,
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+		{
+			name:               "tokens limit exceeded",
+			uri:                "/items",
+			body:               `{"a":[1,2,3],"b":[1,2,3],"c":[1,2,3]}`,
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+		{
+			name:               "duplicate keys blocked",
+			uri:                "/items",
+			body:               `{"a":1,"a":2}`,
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+		{
+			name:               "body rejected by the scanner",
+			uri:                "/items",
+			body:               "{\"x\":\"a\x01b\",\"y\":[[[[1]]]],\"y\":2}",
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+		{
+			name:               "operation depth limit",
+			uri:                "/documents",
+			body:               `{"a":{"b":{"c":1}}}`,
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "operation duplicate keys logged",
+			uri:                "/documents",
+			body:               `{"a":1,"a":2}`,
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "limit exceeded in log only mode",
+			uri:                "/logged",
+			body:               `{"a":{"b":{"c":1}},"a":2}`,
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "limit exceeded in disabled mode",
+			uri:                "/disabled",
+			body:               `{"a":{"b":{"c":1}},"a":2}`,
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var reqCtx fasthttp.RequestCtx
+			reqCtx.Request.SetRequestURI(tt.uri)
+			reqCtx.Request.Header.SetMethod(fasthttp.MethodPost)
+			reqCtx.Request.Header.SetContentType("application/json")
+			reqCtx.Request.SetBodyString(tt.body)
+
+			handler(&reqCtx)
+
+			if reqCtx.Response.StatusCode() != tt.expectedStatusCode {
+				t.Errorf("Incorrect response status code. Expected: %d and got %d",
+					tt.expectedStatusCode, reqCtx.Response.StatusCode())
+			}
+		})
+	}
+}
@@ -139,7 +139,7 @@ func TestJSONBasic(t *testing.T) {
 
 func (s *ServiceTests) testBasicObjJSONFieldValidation(t *testing.T) {
 
-	handler := proxyHandler.Handlers(s.lock, &apifwCfg, s.serverUrl, s.shutdown, s.logger, s.proxy, s.dbSpec, nil, nil, nil)
+	handler := proxyHandler.Handlers(s.lock, &apifwCfg, s.serverUrl, s.shutdown, s.logger, s.proxy, s.dbSpec, nil, nil, nil, nil, nil)
 
 	// basic object check
 	p, err := json.Marshal(map[string]any{
@@ -186,7 +186,7 @@ func (s *ServiceTests) testBasicObjJSONFieldValidation(t *testing.T) {
 
 func (s *ServiceTests) testBasicArrJSONFieldValidation(t *testing.T) {
 
-	handler := proxyHandler.Handlers(s.lock, &apifwCfg, s.serverUrl, s.shutdown, s.logger, s.proxy, s.dbSpec, nil, nil, nil)
+	handler := proxyHandler.Handlers(s.lock, &apifwCfg, s.serverUrl, s.shutdown, s.logger, s.proxy, s.dbSpec, nil, nil, nil, nil, nil)
 
 	p, err := json.Marshal([]map[string]any{{
 		"valueNum":           10.1,
@@ -234,7 +234,7 @@ func (s *ServiceTests) testBasicArrJSONFieldValidation(t *testing.T) {
 
 func (s *ServiceTests) testNegativeJSONFieldValidation(t *testing.T) {
 
-	handler := proxyHandler.Handlers(s.lock, &apifwCfg, s.serverUrl, s.shutdown, s.logger, s.proxy, s.dbSpec, nil, nil, nil)
+	handler := proxyHandler.Handlers(s.lock, &apifwCfg, s.serverUrl, s.shutdown, s.logger, s.proxy, s.dbSpec, nil, nil, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test")
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	proxyMode "github.com/wallarm/api-firewall/cmd/api-firewall/internal/handlers/proxy"
+	"github.com/wallarm/api-firewall/internal/config"
+	proxyPool "github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/storage"
+)
+
+const openAPISpecJWKSTest = `
+openapi: 3.0.1
+info:
+  title: Service
+  version: 1.0.0
+servers:
+  - url: /
+paths:
+  /users:
+    get:
+      security:
+        - oauth:
+          - read
+      responses:
+        '200':
+          description: Users
+components:
+  securitySchemes:
+    oauth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://idp.example.com/token
+          scopes:
+            read: read users
+`
+
+func TestJWKSKeptOnReload(t *testing.T) {
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var lock sync.RWMutex
+
+	serverUrl, err := url.ParseRequestURI("http://127.0.0.1:80")
+	if err != nil {
+		t.Fatalf("parsing API Host URL: %s", err.Error())
+	}
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	logger = logger.Level(zerolog.ErrorLevel)
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(openAPISpecJWKSTest))
+	if err != nil {
+		t.Fatalf("loading OpenAPI specification file: %s", err.Error())
+	}
+
+	dbSpec := storage.NewMockDBOpenAPILoader(mockCtrl)
+	dbSpec.EXPECT().Specification(gomock.Any()).Return(swagger).AnyTimes()
+
+	proxy := proxyPool.NewMockPool(mockCtrl)
+	client := proxyPool.NewMockHTTPClient(mockCtrl)
+
+	proxy.EXPECT().Get().Return(client, resolvedIP, nil).AnyTimes()
+	proxy.EXPECT().Put(resolvedIP, client).Return(nil).AnyTimes()
+	client.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(req *fasthttp.Request, resp *fasthttp.Response) error {
+		resp.SetStatusCode(fasthttp.StatusOK)
+		return nil
+	}).AnyTimes()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jwksRequests atomic.Int32
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jwksRequests.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer jwksServer.Close()
+
+	var cfg config.ProxyMode
+	cfg.RequestValidation = "BLOCK"
+	cfg.ResponseValidation = "BLOCK"
+	cfg.CustomBlockStatusCode = 403
+	cfg.Server.Oauth = config.Oauth{
+		ValidationType: "JWT",
+		JWT: config.JWT{
+			SignatureAlgorithm: "RS256",
+			ScopeClaims:        []string{"scope"},
+			JWKS:               config.JWKS{Source: jwksServer.URL, RefreshInterval: time.Hour, MinRefetchInterval: time.Minute, Timeout: time.Second},
+		},
+	}
+
+	oauthValidators, err := proxyMode.NewOAuthValidators(&cfg.Server.Oauth, logger)
+	if err != nil {
+		t.Fatalf("creating OAuth validators: %s", err.Error())
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"scope": "read",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "key1"
+
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the keys are fetched once by the handlers of all reloads
+	for i := 0; i < 3; i++ {
+		handler := proxyMode.Handlers(&lock, &cfg, serverUrl, shutdown, logger, proxy, dbSpec, nil, nil, nil, nil, oauthValidators)
+
+		var reqCtx fasthttp.RequestCtx
+		reqCtx.Request.SetRequestURI("/users")
+		reqCtx.Request.Header.SetMethod(fasthttp.MethodGet)
+		reqCtx.Request.Header.Set("Authorization", "Bearer "+tokenString)
+
+		handler(&reqCtx)
+
+		if reqCtx.Response.StatusCode() != fasthttp.StatusOK {
+			t.Errorf("Incorrect response status code. Expected: %d and got %d",
+				fasthttp.StatusOK, reqCtx.Response.StatusCode())
+		}
+	}
+
+	if jwksRequests.Load() != 1 {
+		t.Errorf("expected 1 JWKS fetch, got %d", jwksRequests.Load())
+	}
+}
@@ -209,7 +209,7 @@ func (s *ModSecIntegrationTests) basicMaliciousRequestBlockMode(t *testing.T) {
 		},
 	}
 
-	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf)
+	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/get/test")
@@ -291,7 +291,7 @@ func (s *ModSecIntegrationTests) basicMaliciousRequestLogOnlyMode(t *testing.T)
 		},
 	}
 
-	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf)
+	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/get/test")
@@ -391,7 +391,7 @@ func (s *ModSecIntegrationTests) basicMaliciousRequestDisableMode(t *testing.T)
 		},
 	}
 
-	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf)
+	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/get/test")
@@ -479,7 +479,7 @@ func (s *ModSecIntegrationTests) basicMaliciousResponseBlockMode(t *testing.T) {
 		},
 	}
 
-	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf)
+	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/get/test")
@@ -569,7 +569,7 @@ func (s *ModSecIntegrationTests) basicMaliciousResponseLogOnlyMode(t *testing.T)
 		},
 	}
 
-	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf)
+	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/get/test")
@@ -673,7 +673,7 @@ func (s *ModSecIntegrationTests) basicMaliciousResponseDisableMode(t *testing.T)
 		},
 	}
 
-	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf)
+	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/get/test")
@@ -765,7 +765,7 @@ func (s *ModSecIntegrationTests) basicMaliciousRequestBody(t *testing.T) {
 		},
 	}
 
-	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf)
+	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/get/test")
@@ -849,7 +849,7 @@ func (s *ModSecIntegrationTests) basicMaliciousRequestHeader(t *testing.T) {
 		},
 	}
 
-	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf)
+	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/get/test")
@@ -946,7 +946,7 @@ func (s *ModSecIntegrationTests) basicMaliciousRequestCookie(t *testing.T) {
 		},
 	}
 
-	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf)
+	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/get/test")
@@ -1043,7 +1043,7 @@ func (s *ModSecIntegrationTests) basicMaliciousRequestPath(t *testing.T) {
 		},
 	}
 
-	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf)
+	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/token/test")
@@ -1139,7 +1139,7 @@ func (s *ModSecIntegrationTests) basicResponseActionRedirect(t *testing.T) {
 		},
 	}
 
-	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf)
+	handler := proxy2.Handlers(s.lock, &cfg, s.serverUrl, s.shutdown, logger, s.proxy, s.dbSpec, nil, nil, waf, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/token/test")
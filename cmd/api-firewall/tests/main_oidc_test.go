@@ -0,0 +1,267 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	proxyMode "github.com/wallarm/api-firewall/cmd/api-firewall/internal/handlers/proxy"
+	"github.com/wallarm/api-firewall/internal/config"
+	proxyPool "github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/storage"
+)
+
+const openAPISpecOIDCTest = `
+openapi: 3.0.1
+info:
+  title: Service
+  version: 1.0.0
+servers:
+  - url: /
+paths:
+  /users:
+    get:
+      security:
+        - usersIdP:
+          - read
+      responses:
+        '200':
+          description: Users
+  /partners:
+    get:
+      security:
+        - partnersIdP: []
+      responses:
+        '200':
+          description: Partners
+components:
+  securitySchemes:
+    usersIdP:
+      type: openIdConnect
+      openIdConnectUrl: https://users.example.com/.well-known/openid-configuration
+    partnersIdP:
+      type: openIdConnect
+      openIdConnectUrl: https://partners.example.com/.well-known/openid-configuration
+`
+
+// newOIDCStandIn starts the local stand-in OpenID Connect provider of the
+// issuer and returns its discovery URL, the token signing function and the
+// number of the discovery document fetches
+func newOIDCStandIn(t *testing.T, issuer string) (string, func(claims jwt.MapClaims) string, *atomic.Int32) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var server *httptest.Server
+	var discovery atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		discovery.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   issuer,
+			"jwks_uri": server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	sign := func(claims jwt.MapClaims) string {
+		if _, ok := claims["iss"]; !ok {
+			claims["iss"] = issuer
+		}
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "key1"
+
+		tokenString, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tokenString
+	}
+
+	return server.URL + "/.well-known/openid-configuration", sign, &discovery
+}
+
+func TestOpenIDConnect(t *testing.T) {
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var lock sync.RWMutex
+
+	serverUrl, err := url.ParseRequestURI("http://127.0.0.1:80")
+	if err != nil {
+		t.Fatalf("parsing API Host URL: %s", err.Error())
+	}
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	logger = logger.Level(zerolog.ErrorLevel)
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(openAPISpecOIDCTest))
+	if err != nil {
+		t.Fatalf("loading OpenAPI specification file: %s", err.Error())
+	}
+
+	dbSpec := storage.NewMockDBOpenAPILoader(mockCtrl)
+	dbSpec.EXPECT().Specification(gomock.Any()).Return(swagger).AnyTimes()
+
+	proxy := proxyPool.NewMockPool(mockCtrl)
+	client := proxyPool.NewMockHTTPClient(mockCtrl)
+
+	proxy.EXPECT().Get().Return(client, resolvedIP, nil).AnyTimes()
+	proxy.EXPECT().Put(resolvedIP, client).Return(nil).AnyTimes()
+	client.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(req *fasthttp.Request, resp *fasthttp.Response) error {
+		resp.SetStatusCode(fasthttp.StatusOK)
+		return nil
+	}).AnyTimes()
+
+	usersURL, signUsers, usersDiscovery := newOIDCStandIn(t, "https://users.example.com")
+	partnersURL, signPartners, _ := newOIDCStandIn(t, "https://partners.example.com")
+
+	var cfg config.ProxyMode
+	cfg.RequestValidation = "BLOCK"
+	cfg.ResponseValidation = "BLOCK"
+	cfg.CustomBlockStatusCode = 403
+	cfg.Server.Oauth = config.Oauth{
+		ValidationType: "JWT",
+		JWT: config.JWT{
+			SignatureAlgorithm: "RS256",
+			JWKS:               config.JWKS{RefreshInterval: time.Hour, MinRefetchInterval: time.Minute, Timeout: time.Second},
+		},
+		OIDC: config.OIDC{
+			RefreshInterval:    time.Hour,
+			MinRefetchInterval: time.Minute,
+			Timeout:            time.Second,
+			Providers: config.OIDCProviderList{
+				{URL: "https://users.example.com/.well-known/openid-configuration", Source: usersURL},
+				{URL: "https://partners.example.com/.well-known/openid-configuration", Source: partnersURL},
+			},
+		},
+	}
+
+	oauthValidators, err := proxyMode.NewOAuthValidators(&cfg.Server.Oauth, logger)
+	if err != nil {
+		t.Fatalf("creating OAuth validators: %s", err.Error())
+	}
+
+	handler := proxyMode.Handlers(&lock, &cfg, serverUrl, shutdown, logger, proxy, dbSpec, nil, nil, nil, nil, oauthValidators)
+
+	tests := []struct {
+		name               string
+		uri                string
+		token              string
+		expectedStatusCode int
+	}{
+		{
+			name:               "valid token",
+			uri:                "/users",
+			token:              signUsers(jwt.MapClaims{"scope": "read"}),
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "missing scope",
+			uri:                "/users",
+			token:              signUsers(jwt.MapClaims{"scope": "write"}),
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+		{
+			name:               "token of the other provider",
+			uri:                "/users",
+			token:              signPartners(jwt.MapClaims{"scope": "read"}),
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+		{
+			name:               "valid token of the second provider",
+			uri:                "/partners",
+			token:              signPartners(jwt.MapClaims{}),
+			expectedStatusCode: fasthttp.StatusOK,
+		},
+		{
+			name:               "other issuer",
+			uri:                "/partners",
+			token:              signPartners(jwt.MapClaims{"iss": "https://users.example.com"}),
+			expectedStatusCode: fasthttp.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var reqCtx fasthttp.RequestCtx
+			reqCtx.Request.SetRequestURI(tt.uri)
+			reqCtx.Request.Header.SetMethod(fasthttp.MethodGet)
+			reqCtx.Request.Header.Set("Authorization", "Bearer "+tt.token)
+
+			handler(&reqCtx)
+
+			if reqCtx.Response.StatusCode() != tt.expectedStatusCode {
+				t.Errorf("Incorrect response status code. Expected: %d and got %d",
+					tt.expectedStatusCode, reqCtx.Response.StatusCode())
+			}
+		})
+	}
+
+	// the provider metadata is kept on the handlers reload
+	handler = proxyMode.Handlers(&lock, &cfg, serverUrl, shutdown, logger, proxy, dbSpec, nil, nil, nil, nil, oauthValidators)
+
+	var reqCtx fasthttp.RequestCtx
+	reqCtx.Request.SetRequestURI("/users")
+	reqCtx.Request.Header.SetMethod(fasthttp.MethodGet)
+	reqCtx.Request.Header.Set("Authorization", "Bearer "+signUsers(jwt.MapClaims{"scope": "read"}))
+
+	handler(&reqCtx)
+
+	if reqCtx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("Incorrect response status code. Expected: %d and got %d",
+			fasthttp.StatusOK, reqCtx.Response.StatusCode())
+	}
+	if usersDiscovery.Load() != 1 {
+		t.Errorf("expected 1 discovery fetch, got %d", usersDiscovery.Load())
+	}
+}
+
+func TestOAuthValidatorsInvalidAlgorithm(t *testing.T) {
+	cfg := config.Oauth{
+		ValidationType: "JWT",
+		JWT:            config.JWT{SignatureAlgorithm: "none"},
+	}
+
+	if _, err := proxyMode.NewOAuthValidators(&cfg, zerolog.Nop()); err == nil {
+		t.Errorf("expected error of the unsigned tokens algorithm")
+	}
+}
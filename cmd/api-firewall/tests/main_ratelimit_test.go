@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	proxyMode "github.com/wallarm/api-firewall/cmd/api-firewall/internal/handlers/proxy"
+	"github.com/wallarm/api-firewall/internal/config"
+	proxyPool "github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/ratelimit"
+	"github.com/wallarm/api-firewall/internal/platform/storage"
+)
+
+func TestRateLimit(t *testing.T) {
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var lock sync.RWMutex
+
+	serverUrl, err := url.ParseRequestURI("http://127.0.0.1:80")
+	if err != nil {
+		t.Fatalf("parsing API Host URL: %s", err.Error())
+	}
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	logger = logger.Level(zerolog.ErrorLevel)
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(openAPISpecUsersTest))
+	if err != nil {
+		t.Fatalf("loading OpenAPI specification file: %s", err.Error())
+	}
+
+	dbSpec := storage.NewMockDBOpenAPILoader(mockCtrl)
+	dbSpec.EXPECT().Specification(gomock.Any()).Return(swagger).AnyTimes()
+
+	proxy := proxyPool.NewMockPool(mockCtrl)
+	client := proxyPool.NewMockHTTPClient(mockCtrl)
+
+	proxy.EXPECT().Get().Return(client, resolvedIP, nil).AnyTimes()
+	proxy.EXPECT().Put(resolvedIP, client).Return(nil).AnyTimes()
+	client.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(req *fasthttp.Request, resp *fasthttp.Response) error {
+		resp.SetStatusCode(fasthttp.StatusOK)
+		return nil
+	}).AnyTimes()
+
+	type request struct {
+		uri                string
+		ip                 string
+		token              string
+		expectedStatusCode int
+	}
+
+	tests := []struct {
+		name      string
+		rateLimit config.RateLimit
+		requests  []request
+	}{
+		{
+			name: "Client IP key",
+			rateLimit: config.RateLimit{
+				Requests:    2,
+				Period:      time.Minute,
+				Algorithm:   "TOKEN_BUCKET",
+				Key:         "IP",
+				HeaderName:  "X-Forwarded-For",
+				TrustedHops: 1,
+			},
+			requests: []request{
+				{uri: "/users/1", ip: "10.0.0.1", expectedStatusCode: 200},
+				{uri: "/users/1", ip: "10.0.0.100, 10.0.0.1", expectedStatusCode: 200},
+				{uri: "/users/1", ip: "10.0.0.101, 10.0.0.1", expectedStatusCode: 429},
+				{uri: "/users/1", ip: "10.0.0.2", expectedStatusCode: 200},
+			},
+		},
+		{
+			name: "Client IP key with trusted hops",
+			rateLimit: config.RateLimit{
+				Requests:    1,
+				Period:      time.Minute,
+				Algorithm:   "TOKEN_BUCKET",
+				Key:         "IP",
+				HeaderName:  "X-Forwarded-For",
+				TrustedHops: 2,
+			},
+			requests: []request{
+				{uri: "/users/1", ip: "10.0.0.1, 192.168.0.1", expectedStatusCode: 200},
+				{uri: "/users/1", ip: "10.0.0.1, 192.168.0.2", expectedStatusCode: 429},
+				{uri: "/users/1", ip: "10.0.0.100, 10.0.0.1, 192.168.0.1", expectedStatusCode: 429},
+				{uri: "/users/1", ip: "10.0.0.2, 192.168.0.1", expectedStatusCode: 200},
+			},
+		},
+		{
+			name: "Token key",
+			rateLimit: config.RateLimit{
+				Requests:         1,
+				Period:           time.Minute,
+				Algorithm:        "SLIDING_WINDOW",
+				Key:              "HEADER",
+				HeaderName:       "Authorization",
+				TrimBearerPrefix: true,
+			},
+			requests: []request{
+				{uri: "/users/1", token: "Bearer token1", expectedStatusCode: 200},
+				{uri: "/users/1", token: "token1", expectedStatusCode: 429},
+				{uri: "/users/1", token: "Bearer token2", expectedStatusCode: 200},
+			},
+		},
+		{
+			name: "Operation key with endpoint limits",
+			rateLimit: config.RateLimit{
+				Requests:  100,
+				Period:    time.Minute,
+				Algorithm: "TOKEN_BUCKET",
+				Key:       "OPERATION",
+				Endpoints: config.RateLimitEndpointList{
+					{Method: "GET", Path: "/users/{id}", Requests: 1, Period: time.Minute},
+				},
+			},
+			requests: []request{
+				{uri: "/users/1", expectedStatusCode: 200},
+				{uri: "/users/2", expectedStatusCode: 429},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			tt.rateLimit.MaxKeys = 100
+
+			cfg := config.ProxyMode{
+				RequestValidation:     "BLOCK",
+				ResponseValidation:    "BLOCK",
+				CustomBlockStatusCode: 403,
+				RateLimit:             tt.rateLimit,
+			}
+
+			rateLimiter, err := ratelimit.NewRateLimiter(&cfg.RateLimit)
+			if err != nil {
+				t.Fatalf("rate limiter init: %s", err.Error())
+			}
+
+			handler := proxyMode.Handlers(&lock, &cfg, serverUrl, shutdown, logger, proxy, dbSpec, nil, nil, nil, rateLimiter, nil)
+
+			for _, r := range tt.requests {
+				var reqCtx fasthttp.RequestCtx
+				reqCtx.Request.SetRequestURI(r.uri)
+				reqCtx.Request.Header.SetMethod(fasthttp.MethodGet)
+				if r.ip != "" {
+					reqCtx.Request.Header.Set("X-Forwarded-For", r.ip)
+				}
+				if r.token != "" {
+					reqCtx.Request.Header.Set("Authorization", r.token)
+				}
+
+				handler(&reqCtx)
+
+				if reqCtx.Response.StatusCode() != r.expectedStatusCode {
+					t.Errorf("Incorrect response status code. Expected: %d and got %d",
+						r.expectedStatusCode, reqCtx.Response.StatusCode())
+				}
+
+				retryAfter := string(reqCtx.Response.Header.Peek(fasthttp.HeaderRetryAfter))
+				if r.expectedStatusCode == 429 && retryAfter == "" {
+					t.Error("Retry-After header is missing in the response")
+				}
+			}
+		})
+	}
+}
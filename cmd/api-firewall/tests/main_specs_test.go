@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	proxyMode "github.com/wallarm/api-firewall/cmd/api-firewall/internal/handlers/proxy"
+	"github.com/wallarm/api-firewall/internal/config"
+	proxyPool "github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/storage"
+)
+
+const openAPISpecBillingTest = `
+openapi: 3.0.1
+info:
+  title: Billing
+  version: 1.0.0
+servers:
+  - url: /
+paths:
+  /invoices:
+    get:
+      responses:
+        '200':
+          description: Invoices.
+          content: {}
+`
+
+const openAPISpecUsersTest = `
+openapi: 3.0.1
+info:
+  title: Users
+  version: 1.0.0
+servers:
+  - url: /
+paths:
+  /users/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: User.
+          content: {}
+`
+
+func TestMultipleSpecifications(t *testing.T) {
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var lock sync.RWMutex
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	logger = logger.Level(zerolog.ErrorLevel)
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	hits := map[string]int{}
+
+	newTarget := func(name, host, pathPrefix, spec string) *proxyMode.SpecTarget {
+		swagger, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+		if err != nil {
+			t.Fatalf("loading OpenAPI specification file: %s", err.Error())
+		}
+
+		dbSpec := storage.NewMockDBOpenAPILoader(mockCtrl)
+		dbSpec.EXPECT().Specification(gomock.Any()).Return(swagger).AnyTimes()
+
+		pool := proxyPool.NewMockPool(mockCtrl)
+		client := proxyPool.NewMockHTTPClient(mockCtrl)
+
+		pool.EXPECT().Get().Return(client, resolvedIP, nil).AnyTimes()
+		pool.EXPECT().Put(resolvedIP, client).Return(nil).AnyTimes()
+		client.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(req *fasthttp.Request, resp *fasthttp.Response) error {
+			hits[name]++
+			resp.SetStatusCode(fasthttp.StatusOK)
+			return nil
+		}).AnyTimes()
+
+		serverURL, err := url.ParseRequestURI("http://127.0.0.1:80")
+		if err != nil {
+			t.Fatalf("parsing API Host URL: %s", err.Error())
+		}
+
+		cfg := &config.ProxyMode{
+			CustomBlockStatusCode: 403,
+			RequestValidation:     "BLOCK",
+			ResponseValidation:    "DISABLE",
+		}
+
+		target := &proxyMode.SpecTarget{
+			Name:       name,
+			Host:       host,
+			PathPrefix: pathPrefix,
+			Cfg:        cfg,
+			ServerURL:  serverURL,
+			Pool:       pool,
+			Storage:    dbSpec,
+		}
+		target.Handler = proxyMode.Handlers(&lock, cfg, serverURL, shutdown, logger, pool, dbSpec, nil, nil, nil, nil, nil)
+
+		return target
+	}
+
+	dispatcher := proxyMode.NewSpecDispatcher(&lock,
+		newTarget("default", "", "", openAPISpecEndpointsTest),
+		[]*proxyMode.SpecTarget{
+			newTarget("billing", "billing.example.com", "", openAPISpecBillingTest),
+			newTarget("users", "", "/users/", openAPISpecUsersTest),
+		},
+	)
+
+	tests := []struct {
+		name               string
+		host               string
+		uri                string
+		expectedStatusCode int
+		expectedSpec       string
+	}{
+		{
+			name:               "Default specification",
+			host:               "api.example.com",
+			uri:                "/api?param=test",
+			expectedStatusCode: 200,
+			expectedSpec:       "default",
+		},
+		{
+			name:               "Route of another specification is not found in the default one",
+			host:               "api.example.com",
+			uri:                "/invoices",
+			expectedStatusCode: 403,
+		},
+		{
+			name:               "Host binding",
+			host:               "billing.example.com",
+			uri:                "/invoices",
+			expectedStatusCode: 200,
+			expectedSpec:       "billing",
+		},
+		{
+			name:               "Host binding with port",
+			host:               "BILLING.example.com:8282",
+			uri:                "/invoices",
+			expectedStatusCode: 200,
+			expectedSpec:       "billing",
+		},
+		{
+			name:               "Host binding with the route of the default specification",
+			host:               "billing.example.com",
+			uri:                "/api?param=test",
+			expectedStatusCode: 403,
+		},
+		{
+			name:               "Path prefix binding",
+			host:               "api.example.com",
+			uri:                "/users/10",
+			expectedStatusCode: 200,
+			expectedSpec:       "users",
+		},
+		{
+			name:               "Path prefix binding with invalid parameter",
+			host:               "api.example.com",
+			uri:                "/users/abc",
+			expectedStatusCode: 403,
+		},
+		{
+			name:               "Path prefix is not matched by the partial segment",
+			host:               "api.example.com",
+			uri:                "/usersabc",
+			expectedStatusCode: 403,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			clear(hits)
+
+			var reqCtx fasthttp.RequestCtx
+			reqCtx.Request.SetRequestURI(tt.uri)
+			reqCtx.Request.Header.SetMethod(fasthttp.MethodGet)
+			reqCtx.Request.Header.SetHost(tt.host)
+
+			dispatcher.Handler(&reqCtx)
+
+			if reqCtx.Response.StatusCode() != tt.expectedStatusCode {
+				t.Errorf("Incorrect response status code. Expected: %d and got %d",
+					tt.expectedStatusCode, reqCtx.Response.StatusCode())
+			}
+
+			if tt.expectedSpec != "" && (hits[tt.expectedSpec] != 1 || len(hits) != 1) {
+				t.Errorf("Request should be proxied to the %s backend only. Got: %v", tt.expectedSpec, hits)
+			}
+
+			if tt.expectedSpec == "" && len(hits) != 0 {
+				t.Errorf("Request should not be proxied. Got: %v", hits)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	proxyMode "github.com/wallarm/api-firewall/cmd/api-firewall/internal/handlers/proxy"
+	"github.com/wallarm/api-firewall/internal/config"
+	proxyPool "github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/storage"
+	"github.com/wallarm/api-firewall/internal/platform/tracing"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testTraceParent = "00-" + testTraceID + "-00f067aa0ba902b7-01"
+)
+
+func TestTracing(t *testing.T) {
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var lock sync.RWMutex
+
+	serverUrl, err := url.ParseRequestURI("http://127.0.0.1:80")
+	if err != nil {
+		t.Fatalf("parsing API Host URL: %s", err.Error())
+	}
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	logger = logger.Level(zerolog.ErrorLevel)
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	tracesFile := filepath.Join(t.TempDir(), "traces.json")
+
+	shutdownTracing, err := tracing.Init(&config.Tracing{
+		Enabled:     true,
+		Exporter:    tracing.ExporterFile,
+		FilePath:    tracesFile,
+		ServiceName: "api-firewall-test",
+		SampleRatio: 1,
+	}, logger)
+	if err != nil {
+		t.Fatalf("tracing init: %s", err.Error())
+	}
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(openAPISpecUsersTest))
+	if err != nil {
+		t.Fatalf("loading OpenAPI specification file: %s", err.Error())
+	}
+
+	dbSpec := storage.NewMockDBOpenAPILoader(mockCtrl)
+	dbSpec.EXPECT().Specification(gomock.Any()).Return(swagger).AnyTimes()
+
+	proxy := proxyPool.NewMockPool(mockCtrl)
+	client := proxyPool.NewMockHTTPClient(mockCtrl)
+
+	var backendTraceParent string
+
+	proxy.EXPECT().Get().Return(client, resolvedIP, nil).AnyTimes()
+	proxy.EXPECT().Put(resolvedIP, client).Return(nil).AnyTimes()
+	client.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(req *fasthttp.Request, resp *fasthttp.Response) error {
+		backendTraceParent = string(req.Header.Peek("traceparent"))
+		resp.SetStatusCode(fasthttp.StatusOK)
+		return nil
+	}).AnyTimes()
+
+	cfg := config.ProxyMode{
+		RequestValidation:     "BLOCK",
+		ResponseValidation:    "BLOCK",
+		CustomBlockStatusCode: 403,
+	}
+
+	handler := proxyMode.Handlers(&lock, &cfg, serverUrl, shutdown, logger, proxy, dbSpec, nil, nil, nil, nil, nil)
+
+	var reqCtx fasthttp.RequestCtx
+	reqCtx.Request.SetRequestURI("/users/10")
+	reqCtx.Request.Header.SetMethod(fasthttp.MethodGet)
+	reqCtx.Request.Header.Set("traceparent", testTraceParent)
+
+	handler(&reqCtx)
+
+	if reqCtx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("Incorrect response status code. Expected: %d and got %d",
+			fasthttp.StatusOK, reqCtx.Response.StatusCode())
+	}
+
+	// the trace context is propagated to the backend with the proxy span as the parent
+	if !strings.HasPrefix(backendTraceParent, "00-"+testTraceID+"-") || backendTraceParent == testTraceParent {
+		t.Errorf("Incorrect traceparent header sent to the backend: %q", backendTraceParent)
+	}
+
+	if err := shutdownTracing(context.Background()); err != nil {
+		t.Fatalf("tracing shutdown: %s", err.Error())
+	}
+
+	f, err := os.Open(tracesFile)
+	if err != nil {
+		t.Fatalf("opening traces file: %s", err.Error())
+	}
+	defer f.Close()
+
+	type span struct {
+		Name        string
+		SpanContext struct {
+			TraceID string
+			SpanID  string
+		}
+		Parent struct {
+			SpanID string
+		}
+	}
+
+	spans := map[string]span{}
+
+	decoder := json.NewDecoder(f)
+	for {
+		var s span
+		if err := decoder.Decode(&s); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			t.Fatalf("decoding traces file: %s", err.Error())
+		}
+
+		if s.SpanContext.TraceID != testTraceID {
+			t.Errorf("span %s has incorrect trace ID %s", s.Name, s.SpanContext.TraceID)
+		}
+		spans[s.Name] = s
+	}
+
+	for _, name := range []string{"GET /users/{id}", "mid.Logger", "mid.Denylist", "mid.WAFModSecurity", "validator.ValidateRequest", "validator.ValidateResponse", "proxy.Perform"} {
+		if _, ok := spans[name]; !ok {
+			t.Errorf("span %s not found in the exported spans", name)
+		}
+	}
+
+	if root, ok := spans["GET /users/{id}"]; ok && root.Parent.SpanID != "00f067aa0ba902b7" {
+		t.Errorf("the server span should be the child of the incoming span. Got parent %s", root.Parent.SpanID)
+	}
+
+	if perform, ok := spans["proxy.Perform"]; ok && !strings.Contains(backendTraceParent, perform.SpanContext.SpanID) {
+		t.Errorf("the backend request should be the child of the proxy span %s. Got %s", perform.SpanContext.SpanID, backendTraceParent)
+	}
+}
@@ -0,0 +1,241 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	proxyMode "github.com/wallarm/api-firewall/cmd/api-firewall/internal/handlers/proxy"
+	"github.com/wallarm/api-firewall/internal/config"
+	proxyPool "github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/storage"
+	apiModeValidator "github.com/wallarm/api-firewall/pkg/APIMode/validator"
+)
+
+const openAPISpecValidationErrorsTest = `
+openapi: 3.0.1
+info:
+  title: Service
+  version: 1.0.0
+servers:
+  - url: /
+paths:
+  /items:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+        - name: X-Token
+          in: header
+          required: true
+          schema:
+            type: string
+      responses:
+        '200':
+          description: Items
+          content:
+            application/json:
+              schema:
+                type: object
+                required:
+                  - items
+                properties:
+                  items:
+                    type: array
+                    items:
+                      type: string
+`
+
+type testValidationErrorsBody struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Errors []struct {
+		Code   string   `json:"code"`
+		Fields []string `json:"related_fields"`
+	} `json:"errors"`
+}
+
+func TestValidationErrorsResponse(t *testing.T) {
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var lock sync.RWMutex
+
+	serverUrl, err := url.ParseRequestURI("http://127.0.0.1:80")
+	if err != nil {
+		t.Fatalf("parsing API Host URL: %s", err.Error())
+	}
+
+	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	logger = logger.Level(zerolog.ErrorLevel)
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	swagger, err := openapi3.NewLoader().LoadFromData([]byte(openAPISpecValidationErrorsTest))
+	if err != nil {
+		t.Fatalf("loading OpenAPI specification file: %s", err.Error())
+	}
+
+	dbSpec := storage.NewMockDBOpenAPILoader(mockCtrl)
+	dbSpec.EXPECT().Specification(gomock.Any()).Return(swagger).AnyTimes()
+
+	proxy := proxyPool.NewMockPool(mockCtrl)
+	client := proxyPool.NewMockHTTPClient(mockCtrl)
+
+	proxy.EXPECT().Get().Return(client, resolvedIP, nil).AnyTimes()
+	proxy.EXPECT().Put(resolvedIP, client).Return(nil).AnyTimes()
+	client.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(req *fasthttp.Request, resp *fasthttp.Response) error {
+		resp.SetStatusCode(fasthttp.StatusOK)
+		resp.Header.SetContentType("application/json")
+		resp.SetBodyString(`{"total": 0}`)
+		return nil
+	}).AnyTimes()
+
+	tests := []struct {
+		name                string
+		collectAll          bool
+		format              string
+		uri                 string
+		headers             map[string]string
+		expectedContentType string
+		expectedErrCodes    []string
+	}{
+		{
+			name:                "first error in JSON",
+			format:              "JSON",
+			uri:                 "/items",
+			expectedContentType: "application/json",
+			expectedErrCodes:    []string{apiModeValidator.ErrCodeRequiredQueryParameterMissed},
+		},
+		{
+			name:                "all errors in JSON",
+			collectAll:          true,
+			format:              "JSON",
+			uri:                 "/items",
+			expectedContentType: "application/json",
+			expectedErrCodes:    []string{apiModeValidator.ErrCodeRequiredHeaderMissed, apiModeValidator.ErrCodeRequiredQueryParameterMissed},
+		},
+		{
+			name:                "all errors in problem JSON",
+			collectAll:          true,
+			format:              "PROBLEM_JSON",
+			uri:                 "/items?limit=test",
+			expectedContentType: "application/problem+json",
+			expectedErrCodes:    []string{apiModeValidator.ErrCodeRequiredHeaderMissed, apiModeValidator.ErrCodeRequiredQueryParameterInvalidValue},
+		},
+		{
+			name:                "request and unknown parameters errors",
+			collectAll:          true,
+			format:              "JSON",
+			uri:                 "/items?offset=10",
+			headers:             map[string]string{"X-Token": "token"},
+			expectedContentType: "application/json",
+			expectedErrCodes:    []string{apiModeValidator.ErrCodeRequiredQueryParameterMissed, apiModeValidator.ErrCodeUnknownParameterFound},
+		},
+		{
+			name:             "empty body",
+			collectAll:       true,
+			format:           "NONE",
+			uri:              "/items",
+			expectedErrCodes: nil,
+		},
+		{
+			name:                "response errors",
+			collectAll:          true,
+			format:              "JSON",
+			uri:                 "/items?limit=10",
+			headers:             map[string]string{"X-Token": "token"},
+			expectedContentType: "application/json",
+			expectedErrCodes:    []string{apiModeValidator.ErrCodeRequiredResponseBodyParameterMissed},
+		},
+		{
+			name:                "route not found",
+			format:              "PROBLEM_JSON",
+			uri:                 "/unknown",
+			expectedContentType: "application/problem+json",
+			expectedErrCodes:    []string{apiModeValidator.ErrCodeMethodAndPathNotFound},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			cfg := config.ProxyMode{
+				RequestValidation:     "BLOCK",
+				ResponseValidation:    "BLOCK",
+				CustomBlockStatusCode: 403,
+				ShadowAPI: config.ShadowAPI{
+					UnknownParametersDetection: true,
+				},
+				ValidationErrors: config.ValidationErrors{
+					CollectAll: tt.collectAll,
+					Format:     tt.format,
+				},
+			}
+
+			handler := proxyMode.Handlers(&lock, &cfg, serverUrl, shutdown, logger, proxy, dbSpec, nil, nil, nil, nil, nil)
+
+			var reqCtx fasthttp.RequestCtx
+			reqCtx.Request.SetRequestURI(tt.uri)
+			reqCtx.Request.Header.SetMethod(fasthttp.MethodGet)
+			for k, v := range tt.headers {
+				reqCtx.Request.Header.Set(k, v)
+			}
+
+			handler(&reqCtx)
+
+			if reqCtx.Response.StatusCode() != fasthttp.StatusForbidden {
+				t.Errorf("Incorrect response status code. Expected: %d and got %d",
+					fasthttp.StatusForbidden, reqCtx.Response.StatusCode())
+			}
+
+			if tt.expectedErrCodes == nil {
+				if len(reqCtx.Response.Body()) != 0 {
+					t.Errorf("Expected empty response body. Got %s", reqCtx.Response.Body())
+				}
+				return
+			}
+
+			if ct := string(reqCtx.Response.Header.ContentType()); ct != tt.expectedContentType {
+				t.Errorf("Incorrect content type. Expected: %s and got %s", tt.expectedContentType, ct)
+			}
+
+			var body testValidationErrorsBody
+			if err := json.Unmarshal(reqCtx.Response.Body(), &body); err != nil {
+				t.Fatalf("Error while JSON response parsing: %v", err)
+			}
+
+			if tt.format == "PROBLEM_JSON" {
+				if body.Type != "about:blank" || body.Status != fasthttp.StatusForbidden || body.Title != "Forbidden" {
+					t.Errorf("Incorrect problem details: %s", reqCtx.Response.Body())
+				}
+			}
+
+			var errCodes []string
+			for _, e := range body.Errors {
+				errCodes = append(errCodes, e.Code)
+			}
+			slices.Sort(errCodes)
+
+			if !slices.Equal(errCodes, tt.expectedErrCodes) {
+				t.Errorf("Incorrect error codes. Expected: %v and got %v", tt.expectedErrCodes, errCodes)
+			}
+		})
+	}
+}
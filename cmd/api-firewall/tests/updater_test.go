@@ -51,7 +51,7 @@ func TestUpdaterBasic(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 
 	// invalid route in the old spec
 	req := fasthttp.AcquireRequest()
@@ -126,7 +126,7 @@ func TestUpdaterBasic(t *testing.T) {
 	// start updater
 	updSpecErrors := make(chan error, 1)
 	health := handlersAPI.Health{}
-	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgUpdater, &api, shutdown, &health, nil, nil)
+	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgUpdater, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfgUpdater.SpecificationUpdatePeriod.Seconds())
 		updSpecErrors <- updater.Start()
@@ -227,7 +227,7 @@ func TestUpdaterFromEmptyDB(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 	health := handlersAPI.Health{}
 
 	// invalid route in the old spec
@@ -267,7 +267,7 @@ func TestUpdaterFromEmptyDB(t *testing.T) {
 
 	// start updater
 	updSpecErrors := make(chan error, 1)
-	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgUpdater, &api, shutdown, &health, nil, nil)
+	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgUpdater, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfgUpdater.SpecificationUpdatePeriod.Seconds())
 		updSpecErrors <- updater.Start()
@@ -368,7 +368,7 @@ func TestUpdaterToEmptyDB(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 	health := handlersAPI.Health{}
 
 	// invalid route in the old spec
@@ -451,7 +451,7 @@ func TestUpdaterToEmptyDB(t *testing.T) {
 
 	// start updater
 	updSpecErrors := make(chan error, 1)
-	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgUpdaterEmpty, &api, shutdown, &health, nil, nil)
+	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgUpdaterEmpty, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfgUpdater.SpecificationUpdatePeriod.Seconds())
 		updSpecErrors <- updater.Start()
@@ -517,7 +517,7 @@ func TestUpdaterInvalidDBSchema(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/new")
@@ -561,7 +561,7 @@ func TestUpdaterInvalidDBFile(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/test/new")
@@ -605,7 +605,7 @@ func TestUpdaterToInvalidDB(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 	health := handlersAPI.Health{}
 
 	// invalid route in the old spec
@@ -688,7 +688,7 @@ func TestUpdaterToInvalidDB(t *testing.T) {
 
 	// start updater
 	updSpecErrors := make(chan error, 1)
-	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgUpdaterEmpty, &api, shutdown, &health, nil, nil)
+	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgUpdaterEmpty, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfgUpdater.SpecificationUpdatePeriod.Seconds())
 		updSpecErrors <- updater.Start()
@@ -754,7 +754,7 @@ func TestUpdaterFromInvalidDB(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 	health := handlersAPI.Health{}
 
 	// invalid route in the old spec
@@ -784,7 +784,7 @@ func TestUpdaterFromInvalidDB(t *testing.T) {
 
 	// start updater
 	updSpecErrors := make(chan error, 1)
-	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgUpdater, &api, shutdown, &health, nil, nil)
+	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgUpdater, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfgUpdater.SpecificationUpdatePeriod.Seconds())
 		updSpecErrors <- updater.Start()
@@ -885,7 +885,7 @@ func TestUpdaterToNotExistDB(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 	health := handlersAPI.Health{}
 
 	// invalid route in the old spec
@@ -968,7 +968,7 @@ func TestUpdaterToNotExistDB(t *testing.T) {
 
 	// start updater
 	updSpecErrors := make(chan error, 1)
-	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgUpdaterEmpty, &api, shutdown, &health, nil, nil)
+	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgUpdaterEmpty, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfgUpdater.SpecificationUpdatePeriod.Seconds())
 		updSpecErrors <- updater.Start()
@@ -1034,7 +1034,7 @@ func TestUpdaterFromNotExistDB(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfgUpdater, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 	health := handlersAPI.Health{}
 
 	// invalid route in the old spec
@@ -1064,7 +1064,7 @@ func TestUpdaterFromNotExistDB(t *testing.T) {
 
 	// start updater
 	updSpecErrors := make(chan error, 1)
-	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgUpdater, &api, shutdown, &health, nil, nil)
+	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgUpdater, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfgUpdater.SpecificationUpdatePeriod.Seconds())
 		updSpecErrors <- updater.Start()
@@ -215,7 +215,7 @@ func TestLoadBasicV2(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 
 	// invalid route in the old spec
 	req := fasthttp.AcquireRequest()
@@ -318,7 +318,7 @@ func TestUpdaterBasicV2(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 	health := handlersAPI.Health{}
 
 	// invalid route in the old spec
@@ -393,7 +393,7 @@ func TestUpdaterBasicV2(t *testing.T) {
 
 	// start updater
 	updSpecErrors := make(chan error, 1)
-	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgV2, &api, shutdown, &health, nil, nil)
+	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgV2, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfg.SpecificationUpdatePeriod.Seconds())
 		updSpecErrors <- updater.Start()
@@ -448,7 +448,7 @@ func TestUpdaterBasicV2(t *testing.T) {
 
 	// start updater second time.
 	updNewSpecErrors := make(chan error, 1)
-	updater = handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgV2, &api, shutdown, &health, nil, nil)
+	updater = handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgV2, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfg.SpecificationUpdatePeriod.Seconds())
 		updNewSpecErrors <- updater.Start()
@@ -514,7 +514,7 @@ func TestUpdaterFromEmptyDBV2(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 	health := handlersAPI.Health{}
 
 	// invalid route in the old spec
@@ -554,7 +554,7 @@ func TestUpdaterFromEmptyDBV2(t *testing.T) {
 
 	// start updater
 	updSpecErrors := make(chan error, 1)
-	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgV2, &api, shutdown, &health, nil, nil)
+	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgV2, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfg.SpecificationUpdatePeriod.Seconds())
 		updSpecErrors <- updater.Start()
@@ -655,7 +655,7 @@ func TestUpdaterToEmptyDBV2(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 	health := handlersAPI.Health{}
 
 	// invalid route in the old spec
@@ -738,7 +738,7 @@ func TestUpdaterToEmptyDBV2(t *testing.T) {
 
 	// start updater
 	updSpecErrors := make(chan error, 1)
-	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgV2Empty, &api, shutdown, &health, nil, nil)
+	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgV2Empty, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfg.SpecificationUpdatePeriod.Seconds())
 		updSpecErrors <- updater.Start()
@@ -804,7 +804,7 @@ func TestUpdaterInvalidDBSchemaV2(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 
 	req := fasthttp.AcquireRequest()
 	req.SetRequestURI("/")
@@ -848,7 +848,7 @@ func TestUpdaterToInvalidDBV2(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 	health := handlersAPI.Health{}
 
 	// invalid route in the old spec
@@ -931,7 +931,7 @@ func TestUpdaterToInvalidDBV2(t *testing.T) {
 
 	// start updater
 	updSpecErrors := make(chan error, 1)
-	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgV2Invalid, &api, shutdown, &health, nil, nil)
+	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgV2Invalid, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfg.SpecificationUpdatePeriod.Seconds())
 		updSpecErrors <- updater.Start()
@@ -997,7 +997,7 @@ func TestUpdaterFromInvalidDBV2(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 	health := handlersAPI.Health{}
 
 	// invalid route in the old spec
@@ -1027,7 +1027,7 @@ func TestUpdaterFromInvalidDBV2(t *testing.T) {
 
 	// start updater
 	updSpecErrors := make(chan error, 1)
-	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgV2, &api, shutdown, &health, nil, nil)
+	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgV2, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfg.SpecificationUpdatePeriod.Seconds())
 		updSpecErrors <- updater.Start()
@@ -1128,7 +1128,7 @@ func TestUpdaterFromV1DBToV2(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 	health := handlersAPI.Health{}
 
 	// invalid route in the old spec
@@ -1217,7 +1217,7 @@ func TestUpdaterFromV1DBToV2(t *testing.T) {
 
 	// start updater
 	updSpecErrors := make(chan error, 1)
-	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgV2, &api, shutdown, &health, nil, nil)
+	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfgV2, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfg.SpecificationUpdatePeriod.Seconds())
 		updSpecErrors <- updater.Start()
@@ -1318,7 +1318,7 @@ func TestUpdaterFromV2DBToV1(t *testing.T) {
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	api := fasthttp.Server{}
-	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil)
+	api.Handler = handlersAPI.Handlers(&lock, &cfg, shutdown, logger, metrics.NewPrometheusMetrics(false), specStorage, nil, nil, nil)
 	health := handlersAPI.Health{}
 
 	// invalid route in the old spec
@@ -1358,7 +1358,7 @@ func TestUpdaterFromV2DBToV1(t *testing.T) {
 
 	// start updater
 	updSpecErrors := make(chan error, 1)
-	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfg, &api, shutdown, &health, nil, nil)
+	updater := handlersAPI.NewHandlerUpdater(&lock, logger, metrics.NewPrometheusMetrics(false), specStorage, &cfg, &api, shutdown, &health, nil, nil, nil)
 	go func() {
 		t.Logf("starting specification regular update process every %.0f seconds", cfg.SpecificationUpdatePeriod.Seconds())
 		updSpecErrors <- updater.Start()
@@ -0,0 +1,7 @@
+package config
+
+type Admin struct {
+	Enabled bool   `conf:"default:false"`
+	Host    string `conf:"default:127.0.0.1:9668"`
+	Token   string `conf:"noprint"`
+}
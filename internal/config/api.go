@@ -6,9 +6,14 @@ type APIMode struct {
 	APIFWInit
 	APIFWServer
 	ModSecurity
-	Metrics Metrics
-	AllowIP AllowIP
-	TLS     TLS
+	Metrics   Metrics
+	AllowIP   AllowIP
+	RateLimit RateLimit
+	Tracing   Tracing
+	TLS       TLS
+
+	StringFormats StringFormats
+	JSONLimits    JSONLimits
 
 	SpecificationUpdatePeriod time.Duration `conf:"default:1m,env:API_MODE_SPECIFICATION_UPDATE_PERIOD"`
 	PathToSpecDB              string        `conf:"env:API_MODE_DEBUG_PATH_DB"`
@@ -18,4 +23,6 @@ type APIMode struct {
 	PassOptionsRequests        bool `conf:"default:false,env:PASS_OPTIONS"`
 
 	MaxErrorsInResponse int `conf:"default:0,env:API_MODE_MAX_ERRORS_IN_RESPONSE"`
+
+	RequestResponseValidationPath string `conf:"env:API_MODE_REQUEST_RESPONSE_VALIDATION_PATH"`
 }
@@ -2,26 +2,61 @@ package config
 
 import "time"
 
+// JWT configures the validation of the JWT access tokens. The iss claim should
+// be one of the Issuers and the aud claim should contain one of the Audiences
+// if they are set. The Leeway is the allowed clock skew of the exp, nbf and
+// iat claims. The scopes are read from the ScopeClaims which are the names or
+// the dot-separated paths of the nested claims
 type JWT struct {
-	SignatureAlgorithm string `conf:"default:RS256"`
-	PubCertFile        string `conf:""`
-	SecretKey          string `conf:""`
+	SignatureAlgorithm string        `conf:"default:RS256"`
+	PubCertFile        string        `conf:""`
+	SecretKey          string        `conf:""`
+	Issuers            []string      `conf:""`
+	Audiences          []string      `conf:""`
+	Leeway             time.Duration `conf:"default:0s" validate:"gte=0"`
+	RequiredClaims     ClaimRuleList `conf:""`
+	ScopeClaims        []string      `conf:"default:scope"`
+	JWKS               JWKS
 }
 
+// JWKS configures the source of the JSON Web Key Set which is the local file
+// or the URL. The keys are refreshed after the RefreshInterval and refetched
+// if the token key ID is unknown not more often than the MinRefetchInterval
+type JWKS struct {
+	Source             string        `conf:""`
+	RefreshInterval    time.Duration `conf:"default:1h" validate:"gt=0"`
+	MinRefetchInterval time.Duration `conf:"default:30s" validate:"gte=0"`
+	Timeout            time.Duration `conf:"default:5s" validate:"gt=0"`
+}
+
+// Introspection configures the validation of the access tokens by the token
+// introspection endpoint by RFC 7662. The requests to the endpoint are
+// authenticated by the ClientAuthMethod which is bearer, client_secret_basic or
+// client_secret_post. The active tokens are cached for the RefreshInterval but
+// not longer than the token expiry and the inactive tokens are cached for the
+// InactiveRefreshInterval. The aud of the token should contain one of the
+// Audiences and the client_id should be one of the ClientIDs if they are set
 type Introspection struct {
-	ClientAuthBearerToken string        `conf:""`
-	Endpoint              string        `conf:""`
-	EndpointParams        string        `conf:""`
-	TokenParamName        string        `conf:""`
-	ContentType           string        `conf:""`
-	EndpointMethod        string        `conf:"default:GET"`
-	RefreshInterval       time.Duration `conf:"default:10m"`
+	ClientAuthMethod        string        `conf:"default:bearer"`
+	ClientAuthBearerToken   string        `conf:""`
+	ClientAuthID            string        `conf:""`
+	ClientAuthSecret        string        `conf:""`
+	Endpoint                string        `conf:""`
+	EndpointParams          string        `conf:""`
+	TokenParamName          string        `conf:""`
+	ContentType             string        `conf:""`
+	EndpointMethod          string        `conf:"default:GET"`
+	RefreshInterval         time.Duration `conf:"default:10m"`
+	InactiveRefreshInterval time.Duration `conf:"default:10s" validate:"gte=0"`
+	Audiences               []string      `conf:""`
+	ClientIDs               []string      `conf:""`
 }
 
 type Oauth struct {
 	ValidationType string `conf:"default:JWT"`
 	JWT            JWT
 	Introspection  Introspection
+	OIDC           OIDC
 }
 
 type ProtectedAPI struct {
@@ -38,6 +73,10 @@ type ProtectedAPI struct {
 	MaxResponseBodySize  int           `conf:"default:0"`
 	DeleteAcceptEncoding bool          `conf:"default:false"`
 	HealthCheckInterval  time.Duration `conf:"default:30s"`
+	HealthCheck          HealthCheck
+	OutlierDetection     OutlierDetection
+	CircuitBreaker       CircuitBreaker
+	Retry                Retry
 	MaxIdleConnDuration  time.Duration `conf:"default:10s"`
 }
 
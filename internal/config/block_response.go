@@ -0,0 +1,46 @@
+package config
+
+import "maps"
+
+// BlockResponse configures the response to the blocked request. The Body is
+// the Go template executed with the request ID and the violations
+type BlockResponse struct {
+	StatusCode  int               `conf:"" validate:"omitempty,HttpStatusCodes"`
+	ContentType string            `conf:""`
+	Headers     map[string]string `conf:""`
+	Body        string            `conf:""`
+}
+
+// IsEmpty method checks if the block response is not configured
+func (b *BlockResponse) IsEmpty() bool {
+	return b == nil || (b.StatusCode == 0 && b.ContentType == "" && len(b.Headers) == 0 && b.Body == "")
+}
+
+// Merge method returns the copy of the block response with the parameters
+// overridden by the non-empty parameters of the passed block response
+func (b BlockResponse) Merge(override *BlockResponse) BlockResponse {
+	if override.IsEmpty() {
+		return b
+	}
+
+	result := b
+	if override.StatusCode != 0 {
+		result.StatusCode = override.StatusCode
+	}
+
+	if override.ContentType != "" {
+		result.ContentType = override.ContentType
+	}
+
+	if override.Body != "" {
+		result.Body = override.Body
+	}
+
+	if len(override.Headers) > 0 {
+		result.Headers = make(map[string]string, len(b.Headers)+len(override.Headers))
+		maps.Copy(result.Headers, b.Headers)
+		maps.Copy(result.Headers, override.Headers)
+	}
+
+	return result
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ClaimRule requires the claim of the token. The Claim is the name of the
+// claim or the dot-separated path of the nested claim. The claim should be
+// equal to the Value or contain it if the claim is an array. Only the presence
+// of the claim is checked if the Value is empty
+type ClaimRule struct {
+	Claim string
+	Value string
+}
+
+type ClaimRuleList []ClaimRule
+
+// Set method parses list of the required claims string to the list of
+// ClaimRule objects. The entries are separated by semicolon and have the
+// CLAIM or CLAIM=VALUE format
+func (c *ClaimRuleList) Set(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	items := strings.Split(value, ";")
+	for _, item := range items {
+		claim, claimValue, _ := strings.Cut(item, "=")
+
+		rule := ClaimRule{
+			Claim: strings.TrimSpace(claim),
+			Value: strings.TrimSpace(claimValue),
+		}
+
+		if rule.Claim == "" {
+			return fmt.Errorf("invalid required claim, expected CLAIM or CLAIM=VALUE")
+		}
+
+		*c = append(*c, rule)
+	}
+
+	return nil
+}
+
+// String method returns a string representation of the ClaimRule objects list
+func (c ClaimRuleList) String() string {
+	var entries []string
+	for _, r := range c {
+		if r.Value == "" {
+			entries = append(entries, r.Claim)
+			continue
+		}
+		entries = append(entries, fmt.Sprintf("%s=%s", r.Claim, r.Value))
+	}
+	return strings.Join(entries, ";")
+}
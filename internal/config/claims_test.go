@@ -0,0 +1,33 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClaimRuleListSet(t *testing.T) {
+	var rules ClaimRuleList
+	if err := rules.Set("sub; tenant = acme;realm_access.roles=api"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := ClaimRuleList{
+		{Claim: "sub"},
+		{Claim: "tenant", Value: "acme"},
+		{Claim: "realm_access.roles", Value: "api"},
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("expected %+v, got %+v", expected, rules)
+	}
+
+	if s := rules.String(); s != "sub;tenant=acme;realm_access.roles=api" {
+		t.Errorf("unexpected string representation: %s", s)
+	}
+
+	for _, input := range []string{"=acme", "sub;", " "} {
+		var invalid ClaimRuleList
+		if err := invalid.Set(input); err == nil {
+			t.Errorf("expected error for input '%s', got nil", input)
+		}
+	}
+}
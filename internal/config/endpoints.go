@@ -1,16 +1,32 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
 
+// Endpoint selects the routes of the specification with the custom settings.
+// The Path is the templated path of the route, the glob pattern with the * and
+// ** wildcards or the regular expression prefixed by ~. The routes could also
+// be selected by the Tag or the OperationID of the operation. All set
+// selectors should match the route
 type Endpoint struct {
 	ValidationMode `mapstructure:",squash"`
-	Path           string `conf:"required" validate:"url"`
-	Method         string `conf:"" validate:"required"`
+	Path           string `conf:""`
+	Method         string `conf:""`
+	Tag            string `conf:""`
+	OperationID    string `conf:""`
+	BlockResponse  *BlockResponse
 }
 
+// selectors of the endpoint in the environment variable
+const (
+	endpointTagSelector         = "tag="
+	endpointOperationIDSelector = "operationId="
+	endpointRegexPrefix         = "~"
+)
+
 type ValidationMode struct {
 	RequestValidation  string `conf:"required" validate:"required,oneof=DISABLE BLOCK LOG_ONLY"`
 	ResponseValidation string `conf:"required" validate:"required,oneof=DISABLE BLOCK LOG_ONLY"`
@@ -18,41 +34,58 @@ type ValidationMode struct {
 
 type EndpointList []Endpoint
 
-// Set method parses list of Endpoints string to the list of Endpoint objects
+// errInvalidEndpointFormat is returned if the endpoint could not be parsed
+var errInvalidEndpointFormat = errors.New("invalid endpoint format, expected [METHOD:]PATH|REQ|RESP")
+
+// Set method parses list of Endpoints string to the list of Endpoint objects.
+// The endpoints are separated by ";". The "," separator is also accepted
+// right after the validation modes, so the regular expressions of the paths
+// could contain both "," and "|"
 func (e *EndpointList) Set(value string) error {
 	if value == "" {
 		return nil
 	}
 
-	items := strings.Split(value, ",")
-	for _, item := range items {
-		parts := strings.Split(item, "|")
-		if len(parts) != 3 {
-			return fmt.Errorf("invalid endpoint format, expected [METHOD:]PATH|REQ|RESP")
+	for _, item := range splitEndpoints(value) {
+		selector, requestValidation, responseValidation, ok := cutValidationModes(item)
+		if !ok {
+			return errInvalidEndpointFormat
 		}
 
 		method := ""
-		path := ""
-
-		if strings.Contains(parts[0], ":") {
-			split := strings.SplitN(parts[0], ":", 2)
-			method = split[0]
-			path = split[1]
-		} else {
-			path = parts[0]
+		selector = strings.TrimSpace(selector)
+
+		// the method is separated only if the selector starts with it. The
+		// empty method is written by String for the endpoints of all methods
+		if before, after, found := strings.Cut(selector, ":"); found && (before == "" || isMethod(before)) {
+			method = before
+			selector = strings.TrimSpace(after)
 		}
 
 		endpoint := Endpoint{
 			Method: method,
-			Path:   strings.TrimSpace(path),
 			ValidationMode: ValidationMode{
-				RequestValidation:  strings.TrimSpace(parts[1]),
-				ResponseValidation: strings.TrimSpace(parts[2]),
+				RequestValidation:  requestValidation,
+				ResponseValidation: responseValidation,
 			},
 		}
 
-		if endpoint.Path == "" || endpoint.RequestValidation == "" || endpoint.ResponseValidation == "" {
-			return fmt.Errorf("invalid endpoint format, expected [METHOD:]PATH|REQ|RESP")
+		switch {
+		case strings.HasPrefix(selector, endpointTagSelector):
+			endpoint.Tag = strings.TrimSpace(strings.TrimPrefix(selector, endpointTagSelector))
+		case strings.HasPrefix(selector, endpointOperationIDSelector):
+			endpoint.OperationID = strings.TrimSpace(strings.TrimPrefix(selector, endpointOperationIDSelector))
+		default:
+			endpoint.Path = selector
+		}
+
+		// only the regular expression could contain "|"
+		if strings.Contains(endpoint.Path, "|") && !strings.HasPrefix(endpoint.Path, endpointRegexPrefix) {
+			return errInvalidEndpointFormat
+		}
+
+		if endpoint.Selector() == "" || !isValidationMode(endpoint.RequestValidation) || !isValidationMode(endpoint.ResponseValidation) {
+			return errInvalidEndpointFormat
 		}
 
 		*e = append(*e, endpoint)
@@ -65,8 +98,90 @@ func (e *EndpointList) Set(value string) error {
 func (e EndpointList) String() string {
 	var entries []string
 	for _, ep := range e {
-		entry := fmt.Sprintf("%s:%s|%s|%s", ep.Method, ep.Path, ep.RequestValidation, ep.ResponseValidation)
+		entry := fmt.Sprintf("%s:%s|%s|%s", ep.Method, ep.Selector(), ep.RequestValidation, ep.ResponseValidation)
 		entries = append(entries, entry)
 	}
 	return strings.Join(entries, ",")
 }
+
+// Selector method returns the string representation of the endpoint selectors
+func (e *Endpoint) Selector() string {
+	var selectors []string
+
+	if e.Path != "" {
+		selectors = append(selectors, e.Path)
+	}
+	if e.Tag != "" {
+		selectors = append(selectors, endpointTagSelector+e.Tag)
+	}
+	if e.OperationID != "" {
+		selectors = append(selectors, endpointOperationIDSelector+e.OperationID)
+	}
+
+	return strings.Join(selectors, "&")
+}
+
+// isMethod checks if the value could be the HTTP method
+func isMethod(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for _, c := range value {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// splitEndpoints splits the list of the endpoints by ";" and by "," which
+// follows the validation modes
+func splitEndpoints(value string) []string {
+	var items []string
+
+	for _, item := range strings.Split(value, ";") {
+		start := 0
+		for i := 0; i < len(item); i++ {
+			if item[i] != ',' {
+				continue
+			}
+
+			if _, req, resp, ok := cutValidationModes(item[start:i]); ok && isValidationMode(req) && isValidationMode(resp) {
+				items = append(items, item[start:i])
+				start = i + 1
+			}
+		}
+
+		items = append(items, item[start:])
+	}
+
+	return items
+}
+
+// cutValidationModes splits the endpoint by the last two "|" separators to
+// the selector and the validation modes
+func cutValidationModes(item string) (selector, requestValidation, responseValidation string, ok bool) {
+	last := strings.LastIndex(item, "|")
+	if last < 0 {
+		return "", "", "", false
+	}
+
+	prev := strings.LastIndex(item[:last], "|")
+	if prev < 0 {
+		return "", "", "", false
+	}
+
+	return item[:prev], strings.TrimSpace(item[prev+1 : last]), strings.TrimSpace(item[last+1:]), true
+}
+
+// isValidationMode checks if the value is the supported validation mode
+func isValidationMode(value string) bool {
+	switch value {
+	case "DISABLE", "BLOCK", "LOG_ONLY":
+		return true
+	}
+
+	return false
+}
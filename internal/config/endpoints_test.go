@@ -1,6 +1,9 @@
 package config
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestEndpointListSet_ValidInputs(t *testing.T) {
 	tests := []struct {
@@ -58,6 +61,108 @@ func TestEndpointListSet_ValidInputs(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "Glob pattern",
+			input: "GET:/admin/**|LOG_ONLY|LOG_ONLY",
+			expected: EndpointList{
+				{
+					Method: "GET",
+					Path:   "/admin/**",
+					ValidationMode: ValidationMode{
+						RequestValidation:  "LOG_ONLY",
+						ResponseValidation: "LOG_ONLY",
+					},
+				},
+			},
+		},
+		{
+			name:  "Regular expression with colon",
+			input: "~^/v[0-9]+/items:batch$|BLOCK|DISABLE",
+			expected: EndpointList{
+				{
+					Path: "~^/v[0-9]+/items:batch$",
+					ValidationMode: ValidationMode{
+						RequestValidation:  "BLOCK",
+						ResponseValidation: "DISABLE",
+					},
+				},
+			},
+		},
+		{
+			name:  "Regular expression with alternation",
+			input: "~^/admin/(users|roles)$|LOG_ONLY|LOG_ONLY",
+			expected: EndpointList{
+				{
+					Path: "~^/admin/(users|roles)$",
+					ValidationMode: ValidationMode{
+						RequestValidation:  "LOG_ONLY",
+						ResponseValidation: "LOG_ONLY",
+					},
+				},
+			},
+		},
+		{
+			name:  "Regular expression with repetition",
+			input: "~^/v[0-9]{1,2}/admin$|LOG_ONLY|LOG_ONLY",
+			expected: EndpointList{
+				{
+					Path: "~^/v[0-9]{1,2}/admin$",
+					ValidationMode: ValidationMode{
+						RequestValidation:  "LOG_ONLY",
+						ResponseValidation: "LOG_ONLY",
+					},
+				},
+			},
+		},
+		{
+			name:  "Semicolon and comma separators",
+			input: "GET:~^/(a|b){1,2}$|BLOCK|DISABLE;/list|LOG_ONLY|DISABLE,/items|DISABLE|BLOCK",
+			expected: EndpointList{
+				{
+					Method: "GET",
+					Path:   "~^/(a|b){1,2}$",
+					ValidationMode: ValidationMode{
+						RequestValidation:  "BLOCK",
+						ResponseValidation: "DISABLE",
+					},
+				},
+				{
+					Path: "/list",
+					ValidationMode: ValidationMode{
+						RequestValidation:  "LOG_ONLY",
+						ResponseValidation: "DISABLE",
+					},
+				},
+				{
+					Path: "/items",
+					ValidationMode: ValidationMode{
+						RequestValidation:  "DISABLE",
+						ResponseValidation: "BLOCK",
+					},
+				},
+			},
+		},
+		{
+			name:  "Tag and operation ID",
+			input: "tag=admin|LOG_ONLY|BLOCK,POST:operationId=createUser|DISABLE|DISABLE",
+			expected: EndpointList{
+				{
+					Tag: "admin",
+					ValidationMode: ValidationMode{
+						RequestValidation:  "LOG_ONLY",
+						ResponseValidation: "BLOCK",
+					},
+				},
+				{
+					Method:      "POST",
+					OperationID: "createUser",
+					ValidationMode: ValidationMode{
+						RequestValidation:  "DISABLE",
+						ResponseValidation: "DISABLE",
+					},
+				},
+			},
+		},
 		{
 			name:     "Empty input string",
 			input:    "",
@@ -111,6 +216,18 @@ func TestEndpointListSet_InvalidInputs(t *testing.T) {
 			name:  "Empty required segment #3",
 			input: "/api||BLOCK",
 		},
+		{
+			name:  "Empty tag",
+			input: "tag=|BLOCK|BLOCK",
+		},
+		{
+			name:  "Unknown validation mode",
+			input: "/api|BLOCK|BLOCKED",
+		},
+		{
+			name:  "Separator in the path",
+			input: "/api|v2|BLOCK|BLOCK",
+		},
 		{
 			name:  "Just delimiter",
 			input: "|||",
@@ -148,7 +265,15 @@ func TestEndpointListString(t *testing.T) {
 		},
 	}
 
-	expected := "POST:/submit|BLOCK|DISABLE,GET:/fetch|LOG_ONLY|BLOCK"
+	epList = append(epList, Endpoint{
+		Tag: "admin",
+		ValidationMode: ValidationMode{
+			RequestValidation:  "LOG_ONLY",
+			ResponseValidation: "LOG_ONLY",
+		},
+	})
+
+	expected := "POST:/submit|BLOCK|DISABLE,GET:/fetch|LOG_ONLY|BLOCK,:tag=admin|LOG_ONLY|LOG_ONLY"
 	result := epList.String()
 
 	// Strings are expected to be exactly equal
@@ -156,3 +281,19 @@ func TestEndpointListString(t *testing.T) {
 		t.Errorf("expected string: %s, got: %s", expected, result)
 	}
 }
+
+func TestEndpointListString_RoundTrip(t *testing.T) {
+	var epList EndpointList
+	if err := epList.Set("GET:~/(users|roles)/[0-9]{1,3}|BLOCK|LOG_ONLY;POST:~/items/(a|b)+|LOG_ONLY|BLOCK;/orders/*|DISABLE|BLOCK;tag=admin|LOG_ONLY|LOG_ONLY"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var parsed EndpointList
+	if err := parsed.Set(epList.String()); err != nil {
+		t.Fatalf("unexpected error while parsing %q: %v", epList.String(), err)
+	}
+
+	if !reflect.DeepEqual(epList, parsed) {
+		t.Errorf("expected endpoints: %+v, got: %+v", epList, parsed)
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import "time"
+
+type Events struct {
+	File    EventsFile
+	Syslog  EventsSyslog
+	Webhook EventsWebhook
+}
+
+type EventsFile struct {
+	Path       string `conf:""`
+	MaxSize    int64  `conf:"default:104857600" validate:"gte=0"`
+	MaxBackups int    `conf:"default:5" validate:"gte=0"`
+	QueueSize  int    `conf:"default:10000" validate:"gt=0"`
+}
+
+type EventsSyslog struct {
+	Address   string `conf:""`
+	Network   string `conf:"default:udp" validate:"oneof=udp tcp"`
+	AppName   string `conf:"default:api-firewall"`
+	Facility  int    `conf:"default:13" validate:"gte=0,lte=23"`
+	QueueSize int    `conf:"default:10000" validate:"gt=0"`
+}
+
+type EventsWebhook struct {
+	URL           string        `conf:""`
+	Authorization string        `conf:""`
+	BatchSize     int           `conf:"default:100" validate:"gt=0"`
+	FlushInterval time.Duration `conf:"default:1s" validate:"gt=0"`
+	Timeout       time.Duration `conf:"default:5s"`
+	MaxRetries    int           `conf:"default:3" validate:"gte=0"`
+	RetryBackoff  time.Duration `conf:"default:500ms"`
+	QueueSize     int           `conf:"default:10000" validate:"gt=0"`
+}
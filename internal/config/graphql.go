@@ -3,11 +3,15 @@ package config
 type GraphQLMode struct {
 	APIFWInit
 	APIFWServer
-	Graphql  GraphQL
-	TLS      TLS
-	Server   ProtectedAPI
-	Denylist Denylist
-	AllowIP  AllowIP
+	Graphql   GraphQL
+	TLS       TLS
+	Server    ProtectedAPI
+	Denylist  Denylist
+	AllowIP   AllowIP
+	RateLimit RateLimit
+	Metrics   Metrics
+	Tracing   Tracing
+	Events    Events
 }
 
 type GraphQL struct {
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type HealthCheck struct {
+	Path               string        `conf:""`
+	Method             string        `conf:"default:GET"`
+	ExpectedStatus     string        `conf:"default:200-399"`
+	ExpectedBody       string        `conf:""`
+	Timeout            time.Duration `conf:"default:1s"`
+	HealthyThreshold   int           `conf:"default:1" validate:"gte=0"`
+	UnhealthyThreshold int           `conf:"default:1" validate:"gte=0"`
+}
+
+// StatusRange method parses the expected status of the health check response.
+// Supported formats are single status code (200) and the range of codes (200-399)
+func (h *HealthCheck) StatusRange() (int, int, error) {
+	value := strings.TrimSpace(h.ExpectedStatus)
+	if value == "" {
+		return 200, 399, nil
+	}
+
+	minStr, maxStr, isRange := strings.Cut(value, "-")
+	if !isRange {
+		maxStr = minStr
+	}
+
+	minStatus, err := strconv.Atoi(strings.TrimSpace(minStr))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid health check expected status %q: %w", h.ExpectedStatus, err)
+	}
+
+	maxStatus, err := strconv.Atoi(strings.TrimSpace(maxStr))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid health check expected status %q: %w", h.ExpectedStatus, err)
+	}
+
+	if minStatus < 100 || maxStatus > 599 || minStatus > maxStatus {
+		return 0, 0, fmt.Errorf("invalid health check expected status %q: expected range within 100-599", h.ExpectedStatus)
+	}
+
+	return minStatus, maxStatus, nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import "testing"
+
+func TestHealthCheckStatusRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		min, max int
+		wantErr  bool
+	}{
+		{input: "", min: 200, max: 399},
+		{input: "200-399", min: 200, max: 399},
+		{input: "204", min: 204, max: 204},
+		{input: " 200 - 299 ", min: 200, max: 299},
+		{input: "299-200", wantErr: true},
+		{input: "200-600", wantErr: true},
+		{input: "abc", wantErr: true},
+		{input: "200-", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			hc := HealthCheck{ExpectedStatus: tt.input}
+			minStatus, maxStatus, err := hc.StatusRange()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error for input '%s', got nil", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if minStatus != tt.min || maxStatus != tt.max {
+				t.Errorf("expected range %d-%d, got %d-%d", tt.min, tt.max, minStatus, maxStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package config
+
+// JSONLimits configures the structural limits of the JSON request bodies
+// which are checked before the body is parsed. The zero limit is not checked.
+// The DuplicateKeys mode defines the action on the duplicate object keys
+type JSONLimits struct {
+	MaxDepth        int    `conf:"default:0" validate:"gte=0"`
+	MaxKeys         int    `conf:"default:0" validate:"gte=0"`
+	MaxArrayLength  int    `conf:"default:0" validate:"gte=0"`
+	MaxStringLength int    `conf:"default:0" validate:"gte=0"`
+	MaxTokens       int    `conf:"default:0" validate:"gte=0"`
+	DuplicateKeys   string `conf:"default:DISABLE" validate:"oneof=DISABLE BLOCK LOG_ONLY"`
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OIDC configures the OpenID Connect providers of the openIdConnect security
+// schemes. The discovery document of the openIdConnectUrl is refreshed after
+// the RefreshInterval and the failed fetch is retried not more often than the
+// MinRefetchInterval. The Providers replace the discovery documents of the
+// openIdConnectUrls by the local files or other URLs, e.g. the stand-in
+// providers of the test environments
+type OIDC struct {
+	RefreshInterval    time.Duration    `conf:"default:1h" validate:"gt=0"`
+	MinRefetchInterval time.Duration    `conf:"default:30s" validate:"gte=0"`
+	Timeout            time.Duration    `conf:"default:5s" validate:"gt=0"`
+	Providers          OIDCProviderList `conf:""`
+}
+
+// OIDCProvider replaces the discovery document of the openIdConnectUrl by the
+// Source which is the local file or the URL
+type OIDCProvider struct {
+	URL    string
+	Source string
+}
+
+type OIDCProviderList []OIDCProvider
+
+// Set method parses list of the providers string to the list of OIDCProvider
+// objects. The entries are separated by commas and have the URL|SOURCE format
+func (o *OIDCProviderList) Set(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	items := strings.Split(value, ",")
+	for _, item := range items {
+		url, source, found := strings.Cut(item, "|")
+
+		provider := OIDCProvider{
+			URL:    strings.TrimSpace(url),
+			Source: strings.TrimSpace(source),
+		}
+
+		if !found || provider.URL == "" || provider.Source == "" {
+			return fmt.Errorf("invalid OpenID Connect provider format, expected URL|SOURCE")
+		}
+
+		*o = append(*o, provider)
+	}
+
+	return nil
+}
+
+// String method returns a string representation of the OIDCProvider objects list
+func (o OIDCProviderList) String() string {
+	var entries []string
+	for _, p := range o {
+		entries = append(entries, fmt.Sprintf("%s|%s", p.URL, p.Source))
+	}
+	return strings.Join(entries, ",")
+}
+
+// Source method returns the source of the discovery document of the
+// openIdConnectUrl
+func (o OIDCProviderList) Source(url string) string {
+	for _, p := range o {
+		if p.URL == url {
+			return p.Source
+		}
+	}
+	return url
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOIDCProviderListSet(t *testing.T) {
+	var providers OIDCProviderList
+	if err := providers.Set("https://idp.example.com/.well-known/openid-configuration|http://localhost:8081/discovery, https://other.example.com/.well-known/openid-configuration | /etc/apifw/discovery.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := OIDCProviderList{
+		{URL: "https://idp.example.com/.well-known/openid-configuration", Source: "http://localhost:8081/discovery"},
+		{URL: "https://other.example.com/.well-known/openid-configuration", Source: "/etc/apifw/discovery.json"},
+	}
+	if !reflect.DeepEqual(providers, expected) {
+		t.Errorf("expected %+v, got %+v", expected, providers)
+	}
+
+	if s := providers.String(); s != "https://idp.example.com/.well-known/openid-configuration|http://localhost:8081/discovery,https://other.example.com/.well-known/openid-configuration|/etc/apifw/discovery.json" {
+		t.Errorf("unexpected string representation: %s", s)
+	}
+
+	if source := providers.Source("https://other.example.com/.well-known/openid-configuration"); source != "/etc/apifw/discovery.json" {
+		t.Errorf("unexpected source %s", source)
+	}
+	if source := providers.Source("https://unknown.example.com"); source != "https://unknown.example.com" {
+		t.Errorf("unexpected source %s", source)
+	}
+
+	for _, input := range []string{"https://idp.example.com", "|/discovery.json", "https://idp.example.com|"} {
+		var invalid OIDCProviderList
+		if err := invalid.Set(input); err == nil {
+			t.Errorf("expected error for input '%s', got nil", input)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package config
+
+import "time"
+
+type OutlierDetection struct {
+	ConsecutiveErrors int           `conf:"default:0" validate:"gte=0"`
+	BaseEjectionTime  time.Duration `conf:"default:30s"`
+	MaxEjectionTime   time.Duration `conf:"default:5m"`
+}
+
+type CircuitBreaker struct {
+	FailureThreshold    int           `conf:"default:0" validate:"gte=0"`
+	OpenTimeout         time.Duration `conf:"default:30s"`
+	HalfOpenMaxRequests int           `conf:"default:1" validate:"gte=0"`
+}
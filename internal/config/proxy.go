@@ -11,8 +11,19 @@ type ProxyMode struct {
 	Denylist  Denylist
 	Server    Backend `mapstructure:"Backend"`
 	AllowIP   AllowIP
+	RateLimit RateLimit
+	Metrics   Metrics
+	Tracing   Tracing
+	Events    Events
+	Admin     Admin
 	DNS       DNS
 	Endpoints EndpointList
+	Specs     APISpecList
+
+	ValidationErrors ValidationErrors
+	BlockResponse    BlockResponse
+	StringFormats    StringFormats
+	JSONLimits       JSONLimits
 
 	RequestValidation         string       `conf:"required" validate:"required,oneof=DISABLE BLOCK LOG_ONLY"`
 	ResponseValidation        string       `conf:"required" validate:"required,oneof=DISABLE BLOCK LOG_ONLY"`
@@ -20,6 +31,7 @@ type ProxyMode struct {
 	AddValidationStatusHeader bool         `conf:"default:false"`
 	APISpecs                  string       `conf:"required,env:API_SPECS" validate:"required"`
 	APISpecsCustomHeader      CustomHeader `conf:"env:API_SPECS_CUSTOM_HEADER"`
+	APISpecsRefs              SpecRefs     `conf:""`
 	PassOptionsRequests       bool         `conf:"default:false,env:PASS_OPTIONS"`
 
 	SpecificationUpdatePeriod time.Duration `conf:"default:0"`
@@ -30,6 +42,15 @@ type CustomHeader struct {
 	Value string
 }
 
+// SpecRefs configures the external references of the specification. The
+// referenced files are allowed under the BaseDir only which is the directory
+// of the specification file by default. The referenced URLs are allowed from
+// the specification URL host and the AllowedHosts only
+type SpecRefs struct {
+	BaseDir      string   `conf:""`
+	AllowedHosts []string `conf:""`
+}
+
 type ShadowAPI struct {
 	ExcludeList                []int `conf:"default:404,env:SHADOW_API_EXCLUDE_LIST" validate:"HttpStatusCodes"`
 	UnknownParametersDetection bool  `conf:"default:true,env:SHADOW_API_UNKNOWN_PARAMETERS_DETECTION"`
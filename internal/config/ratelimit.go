@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RateLimit struct {
+	Requests         int           `conf:"default:0" validate:"gte=0"`
+	Period           time.Duration `conf:"default:1s"`
+	Burst            int           `conf:"default:0" validate:"gte=0"`
+	Algorithm        string        `conf:"default:TOKEN_BUCKET" validate:"oneof=TOKEN_BUCKET SLIDING_WINDOW"`
+	Key              string        `conf:"default:IP" validate:"oneof=IP HEADER COOKIE OPERATION"`
+	HeaderName       string        `conf:""`
+	TrustedHops      int           `conf:"default:1" validate:"gte=0"`
+	CookieName       string        `conf:""`
+	TrimBearerPrefix bool          `conf:"default:true"`
+	MaxKeys          int64         `conf:"default:100000" validate:"gt=0"`
+	Endpoints        RateLimitEndpointList
+}
+
+type RateLimitEndpoint struct {
+	Path     string        `conf:"required" validate:"url"`
+	Method   string        `conf:""`
+	Requests int           `conf:"" validate:"gte=0"`
+	Period   time.Duration `conf:""`
+	Burst    int           `conf:"" validate:"gte=0"`
+}
+
+type RateLimitEndpointList []RateLimitEndpoint
+
+// Set method parses list of the rate limits string to the list of RateLimitEndpoint objects
+func (e *RateLimitEndpointList) Set(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	items := strings.Split(value, ",")
+	for _, item := range items {
+		parts := strings.Split(item, "|")
+		if len(parts) != 3 && len(parts) != 4 {
+			return fmt.Errorf("invalid rate limit format, expected [METHOD:]PATH|REQUESTS|PERIOD[|BURST]")
+		}
+
+		endpoint := RateLimitEndpoint{}
+
+		if method, path, found := strings.Cut(parts[0], ":"); found {
+			endpoint.Method = strings.TrimSpace(method)
+			endpoint.Path = strings.TrimSpace(path)
+		} else {
+			endpoint.Path = strings.TrimSpace(parts[0])
+		}
+
+		if endpoint.Path == "" {
+			return fmt.Errorf("invalid rate limit format, expected [METHOD:]PATH|REQUESTS|PERIOD[|BURST]")
+		}
+
+		requests, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || requests < 0 {
+			return fmt.Errorf("invalid rate limit requests number %q", parts[1])
+		}
+		endpoint.Requests = requests
+
+		period, err := time.ParseDuration(strings.TrimSpace(parts[2]))
+		if err != nil || period <= 0 {
+			return fmt.Errorf("invalid rate limit period %q", parts[2])
+		}
+		endpoint.Period = period
+
+		if len(parts) == 4 {
+			burst, err := strconv.Atoi(strings.TrimSpace(parts[3]))
+			if err != nil || burst < 0 {
+				return fmt.Errorf("invalid rate limit burst %q", parts[3])
+			}
+			endpoint.Burst = burst
+		}
+
+		*e = append(*e, endpoint)
+	}
+
+	return nil
+}
+
+// String method returns a string representation of the RateLimitEndpoint objects list
+func (e RateLimitEndpointList) String() string {
+	var entries []string
+	for _, ep := range e {
+		entry := fmt.Sprintf("%s:%s|%d|%s|%d", ep.Method, ep.Path, ep.Requests, ep.Period, ep.Burst)
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRateLimitEndpointListSet_ValidInputs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected RateLimitEndpointList
+	}{
+		{
+			name:  "With method",
+			input: "POST:/login|5|1m",
+			expected: RateLimitEndpointList{
+				{Method: "POST", Path: "/login", Requests: 5, Period: time.Minute},
+			},
+		},
+		{
+			name:  "Without method and with burst",
+			input: "/search|100|1s|200",
+			expected: RateLimitEndpointList{
+				{Path: "/search", Requests: 100, Period: time.Second, Burst: 200},
+			},
+		},
+		{
+			name:  "Multiple entries and disabled limit",
+			input: "POST:/login|5|1m,GET:/health|0|1s",
+			expected: RateLimitEndpointList{
+				{Method: "POST", Path: "/login", Requests: 5, Period: time.Minute},
+				{Method: "GET", Path: "/health", Requests: 0, Period: time.Second},
+			},
+		},
+		{
+			name:     "Empty input",
+			input:    "",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var list RateLimitEndpointList
+			if err := list.Set(tt.input); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(list, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, list)
+			}
+		})
+	}
+}
+
+func TestRateLimitEndpointListSet_InvalidInputs(t *testing.T) {
+	inputs := []string{
+		"/login|5",
+		"/login|5|1m|2|3",
+		"|5|1m",
+		"/login|abc|1m",
+		"/login|-1|1m",
+		"/login|5|abc",
+		"/login|5|0s",
+		"/login|5|1m|abc",
+	}
+
+	for _, input := range inputs {
+		var list RateLimitEndpointList
+		if err := list.Set(input); err == nil {
+			t.Errorf("expected error for input %q", input)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+type Retry struct {
+	MaxRetries           int           `conf:"default:0" validate:"gte=0"`
+	PerTryTimeout        time.Duration `conf:"default:0s"`
+	BackoffBase          time.Duration `conf:"default:25ms"`
+	BackoffMax           time.Duration `conf:"default:250ms"`
+	BudgetPercent        int           `conf:"default:20" validate:"gte=0,lte=100"`
+	BudgetMinConcurrency int           `conf:"default:3" validate:"gte=0"`
+}
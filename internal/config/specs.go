@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// APISpec defines an additional OpenAPI specification which is selected by the
+// request Host header and/or path prefix and proxied to its own backend
+type APISpec struct {
+	Name                      string
+	Host                      string
+	PathPrefix                string
+	APISpecs                  string `validate:"required"`
+	APISpecsCustomHeader      CustomHeader
+	APISpecsRefs              SpecRefs
+	URL                       string `validate:"required,url"`
+	RequestHostHeader         string
+	RequestValidation         string `validate:"omitempty,oneof=DISABLE BLOCK LOG_ONLY"`
+	ResponseValidation        string `validate:"omitempty,oneof=DISABLE BLOCK LOG_ONLY"`
+	SpecificationUpdatePeriod time.Duration
+}
+
+type APISpecList []APISpec
+
+// Set method parses list of the specifications string to the list of APISpec objects
+func (s *APISpecList) Set(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	items := strings.Split(value, ",")
+	for _, item := range items {
+		parts := strings.Split(item, "|")
+		if len(parts) != 5 && len(parts) != 7 {
+			return fmt.Errorf("invalid spec format, expected NAME|HOST|PATH_PREFIX|API_SPECS|URL[|REQ|RESP]")
+		}
+
+		spec := APISpec{
+			Name:       strings.TrimSpace(parts[0]),
+			Host:       strings.TrimSpace(parts[1]),
+			PathPrefix: strings.TrimSpace(parts[2]),
+			APISpecs:   strings.TrimSpace(parts[3]),
+			URL:        strings.TrimSpace(parts[4]),
+		}
+
+		if len(parts) == 7 {
+			spec.RequestValidation = strings.TrimSpace(parts[5])
+			spec.ResponseValidation = strings.TrimSpace(parts[6])
+		}
+
+		if spec.APISpecs == "" || spec.URL == "" || (spec.Host == "" && spec.PathPrefix == "") {
+			return fmt.Errorf("invalid spec format, expected NAME|HOST|PATH_PREFIX|API_SPECS|URL[|REQ|RESP] with HOST or PATH_PREFIX set")
+		}
+
+		*s = append(*s, spec)
+	}
+
+	return nil
+}
+
+// String method returns a string representation of the APISpec objects list
+func (s APISpecList) String() string {
+	var entries []string
+	for _, spec := range s {
+		entry := fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s", spec.Name, spec.Host, spec.PathPrefix, spec.APISpecs, spec.URL, spec.RequestValidation, spec.ResponseValidation)
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, ",")
+}
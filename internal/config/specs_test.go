@@ -0,0 +1,133 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAPISpecListSet_ValidInputs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected APISpecList
+	}{
+		{
+			name:  "Host binding",
+			input: "billing|billing.example.com||/opt/billing.yaml|http://billing:8080",
+			expected: APISpecList{
+				{
+					Name:     "billing",
+					Host:     "billing.example.com",
+					APISpecs: "/opt/billing.yaml",
+					URL:      "http://billing:8080",
+				},
+			},
+		},
+		{
+			name:  "Path prefix binding with validation modes",
+			input: "users||/users|/opt/users.yaml|http://users:8080|LOG_ONLY|DISABLE",
+			expected: APISpecList{
+				{
+					Name:               "users",
+					PathPrefix:         "/users",
+					APISpecs:           "/opt/users.yaml",
+					URL:                "http://users:8080",
+					RequestValidation:  "LOG_ONLY",
+					ResponseValidation: "DISABLE",
+				},
+			},
+		},
+		{
+			name:  "Multiple entries",
+			input: "a|a.example.com|/v1|a.yaml|http://a:80,b|b.example.com||b.yaml|https://b:443",
+			expected: APISpecList{
+				{
+					Name:       "a",
+					Host:       "a.example.com",
+					PathPrefix: "/v1",
+					APISpecs:   "a.yaml",
+					URL:        "http://a:80",
+				},
+				{
+					Name:     "b",
+					Host:     "b.example.com",
+					APISpecs: "b.yaml",
+					URL:      "https://b:443",
+				},
+			},
+		},
+		{
+			name:     "Empty input string",
+			input:    "",
+			expected: APISpecList{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var specs APISpecList
+			if err := specs.Set(tt.input); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(specs) != len(tt.expected) {
+				t.Fatalf("expected %d specs, got %d", len(tt.expected), len(specs))
+			}
+			for i := range tt.expected {
+				if !reflect.DeepEqual(specs[i], tt.expected[i]) {
+					t.Errorf("expected spec[%d] = %+v, got %+v", i, tt.expected[i], specs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAPISpecListSet_InvalidInputs(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "Missing backend URL",
+			input: "a|a.example.com||a.yaml",
+		},
+		{
+			name:  "Missing host and path prefix",
+			input: "a|||a.yaml|http://a:80",
+		},
+		{
+			name:  "Missing specification",
+			input: "a|a.example.com|||http://a:80",
+		},
+		{
+			name:  "Only request validation mode",
+			input: "a|a.example.com||a.yaml|http://a:80|BLOCK",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var specs APISpecList
+			if err := specs.Set(tt.input); err == nil {
+				t.Errorf("expected error for input '%s', got nil", tt.input)
+			}
+		})
+	}
+}
+
+func TestAPISpecListString(t *testing.T) {
+	specs := APISpecList{
+		{
+			Name:               "a",
+			Host:               "a.example.com",
+			APISpecs:           "a.yaml",
+			URL:                "http://a:80",
+			RequestValidation:  "BLOCK",
+			ResponseValidation: "LOG_ONLY",
+		},
+	}
+
+	expected := "a|a.example.com||a.yaml|http://a:80|BLOCK|LOG_ONLY"
+	if result := specs.String(); result != expected {
+		t.Errorf("expected string: %s, got: %s", expected, result)
+	}
+}
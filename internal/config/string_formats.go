@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StringFormats configures the validation of the string formats. The
+// standard formats and the Custom formats are validated if the Validation is
+// enabled
+type StringFormats struct {
+	Validation bool             `conf:"default:false"`
+	Custom     StringFormatList `conf:""`
+}
+
+// StringFormat defines the custom string format by the regular expression
+// Pattern or by the name of the built-in Validator
+type StringFormat struct {
+	Name      string
+	Pattern   string
+	Validator string
+}
+
+// stringFormatPatternPrefix is the prefix of the custom format definition
+// which is the regular expression
+const stringFormatPatternPrefix = "~"
+
+type StringFormatList []StringFormat
+
+// Set method parses list of the custom formats string to the list of
+// StringFormat objects. The entries are separated by semicolon because the
+// regular expressions could contain commas
+func (s *StringFormatList) Set(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	items := strings.Split(value, ";")
+	for _, item := range items {
+		name, definition, found := strings.Cut(item, "=")
+		if !found {
+			return fmt.Errorf("invalid string format, expected NAME=VALIDATOR or NAME=~REGEX")
+		}
+
+		format := StringFormat{Name: strings.TrimSpace(name)}
+
+		definition = strings.TrimSpace(definition)
+		if pattern, ok := strings.CutPrefix(definition, stringFormatPatternPrefix); ok {
+			format.Pattern = pattern
+		} else {
+			format.Validator = definition
+		}
+
+		if format.Name == "" || (format.Pattern == "" && format.Validator == "") {
+			return fmt.Errorf("invalid string format, expected NAME=VALIDATOR or NAME=~REGEX")
+		}
+
+		*s = append(*s, format)
+	}
+
+	return nil
+}
+
+// String method returns a string representation of the StringFormat objects list
+func (s StringFormatList) String() string {
+	var entries []string
+	for _, f := range s {
+		definition := f.Validator
+		if f.Pattern != "" {
+			definition = stringFormatPatternPrefix + f.Pattern
+		}
+		entries = append(entries, fmt.Sprintf("%s=%s", f.Name, definition))
+	}
+	return strings.Join(entries, ";")
+}
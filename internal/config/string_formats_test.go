@@ -0,0 +1,33 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStringFormatListSet(t *testing.T) {
+	var formats StringFormatList
+	if err := formats.Set("iban=iban; order-id=~^ORD-[0-9]{2,8}$;ratio=~^[0-9]+=[0-9]+$"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := StringFormatList{
+		{Name: "iban", Validator: "iban"},
+		{Name: "order-id", Pattern: "^ORD-[0-9]{2,8}$"},
+		{Name: "ratio", Pattern: "^[0-9]+=[0-9]+$"},
+	}
+	if !reflect.DeepEqual(formats, expected) {
+		t.Errorf("expected %+v, got %+v", expected, formats)
+	}
+
+	if s := formats.String(); s != "iban=iban;order-id=~^ORD-[0-9]{2,8}$;ratio=~^[0-9]+=[0-9]+$" {
+		t.Errorf("unexpected string representation: %s", s)
+	}
+
+	for _, input := range []string{"iban", "=iban", "iban=", "iban=~"} {
+		var invalid StringFormatList
+		if err := invalid.Set(input); err == nil {
+			t.Errorf("expected error for input '%s', got nil", input)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package config
+
+type Tracing struct {
+	Enabled     bool    `conf:"default:false"`
+	Exporter    string  `conf:"default:OTLP" validate:"oneof=OTLP FILE"`
+	Endpoint    string  `conf:"default:localhost:4318"`
+	Insecure    bool    `conf:"default:false"`
+	FilePath    string  `conf:"default:traces.json"`
+	ServiceName string  `conf:"default:api-firewall"`
+	SampleRatio float64 `conf:"default:1" validate:"gte=0,lte=1"`
+}
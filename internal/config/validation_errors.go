@@ -0,0 +1,8 @@
+package config
+
+// ValidationErrors configures the validation errors reporting in the responses
+// to the blocked requests
+type ValidationErrors struct {
+	CollectAll bool   `conf:"default:false"`
+	Format     string `conf:"default:NONE" validate:"oneof=NONE JSON PROBLEM_JSON"`
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/wallarm/api-firewall/internal/config"
 	"github.com/wallarm/api-firewall/internal/platform/allowiplist"
+	"github.com/wallarm/api-firewall/internal/platform/events"
 	"github.com/wallarm/api-firewall/internal/platform/router"
 	"github.com/wallarm/api-firewall/internal/platform/web"
 )
@@ -21,6 +22,10 @@ type IPAllowListOptions struct {
 	CustomBlockStatusCode int
 	AllowedIPs            *allowiplist.AllowedIPsType
 	Logger                zerolog.Logger
+
+	// BlockResponse is sent to the blocked requests. The custom block status
+	// code and the empty body are sent if the block response is not set
+	BlockResponse *web.BlockResponse
 }
 
 var errAccessDeniedIP = errors.New("access denied to this IP")
@@ -70,6 +75,9 @@ func IPAllowlist(options *IPAllowListOptions) web.Middleware {
 						Str("source_ip_address", ipToCheck).
 						Msg("allow IP: could not parse source IP address")
 
+					ctx.SetUserValue(web.RequestBlockReason, web.BlockReasonAllowlist)
+					events.Record(ctx, events.TypeAllowlist, events.ActionBlocked, events.Violation{Code: events.CodeIPNotAllowed, Fields: []string{ipToCheck}})
+
 					switch options.Mode {
 					case web.APIMode:
 						ctx.SetUserValue(web.GlobalResponseStatusCodeKey, options.CustomBlockStatusCode)
@@ -78,7 +86,7 @@ func IPAllowlist(options *IPAllowListOptions) web.Middleware {
 						ctx.Response.SetStatusCode(options.CustomBlockStatusCode)
 						return web.RespondGraphQLErrors(&ctx.Response, errAccessDeniedIP)
 					}
-					return web.RespondError(ctx, options.CustomBlockStatusCode, "")
+					return web.RespondBlocked(ctx, options.BlockResponse, options.CustomBlockStatusCode, errAccessDeniedIP.Error())
 				}
 
 				if _, found := options.AllowedIPs.Cache.Get(ip.String()); !found {
@@ -89,6 +97,9 @@ func IPAllowlist(options *IPAllowListOptions) web.Middleware {
 						Str("source_ip_address", ipToCheck).
 						Msg("allow IP: requests from the source IP address are not allowed")
 
+					ctx.SetUserValue(web.RequestBlockReason, web.BlockReasonAllowlist)
+					events.Record(ctx, events.TypeAllowlist, events.ActionBlocked, events.Violation{Code: events.CodeIPNotAllowed, Fields: []string{ipToCheck}})
+
 					switch options.Mode {
 					case web.APIMode:
 						ctx.SetUserValue(web.GlobalResponseStatusCodeKey, options.CustomBlockStatusCode)
@@ -98,7 +109,7 @@ func IPAllowlist(options *IPAllowListOptions) web.Middleware {
 						return web.RespondGraphQLErrors(&ctx.Response, errAccessDeniedIP)
 					}
 
-					return web.RespondError(ctx, options.CustomBlockStatusCode, "")
+					return web.RespondBlocked(ctx, options.BlockResponse, options.CustomBlockStatusCode, errAccessDeniedIP.Error())
 				}
 
 			}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/wallarm/api-firewall/internal/config"
 	"github.com/wallarm/api-firewall/internal/platform/denylist"
+	"github.com/wallarm/api-firewall/internal/platform/events"
 	"github.com/wallarm/api-firewall/internal/platform/router"
 	"github.com/wallarm/api-firewall/internal/platform/web"
 )
@@ -19,6 +20,10 @@ type DenylistOptions struct {
 	CustomBlockStatusCode int
 	DeniedTokens          *denylist.DeniedTokens
 	Logger                zerolog.Logger
+
+	// BlockResponse is sent to the blocked requests. The custom block status
+	// code and the empty body are sent if the block response is not set
+	BlockResponse *web.BlockResponse
 }
 
 var errAccessDenied = errors.New("access denied")
@@ -45,11 +50,14 @@ func Denylist(options *DenylistOptions) web.Middleware {
 							Str("token", token).
 							Msg("The request with the API token has been blocked")
 
+						ctx.SetUserValue(web.RequestBlockReason, web.BlockReasonDenylist)
+						events.Record(ctx, events.TypeDenylist, events.ActionBlocked, events.Violation{Code: events.CodeDenylistedToken})
+
 						if strings.EqualFold(options.Mode, web.GraphQLMode) {
 							ctx.Response.SetStatusCode(options.CustomBlockStatusCode)
 							return web.RespondGraphQLErrors(&ctx.Response, errAccessDenied)
 						}
-						return web.RespondError(ctx, options.CustomBlockStatusCode, "")
+						return web.RespondBlocked(ctx, options.BlockResponse, options.CustomBlockStatusCode, errAccessDenied.Error())
 					}
 				}
 				if options.Config.Tokens.HeaderName != "" {
@@ -67,11 +75,14 @@ func Denylist(options *DenylistOptions) web.Middleware {
 							Str("token", token).
 							Msg("The request with the API token has been blocked")
 
+						ctx.SetUserValue(web.RequestBlockReason, web.BlockReasonDenylist)
+						events.Record(ctx, events.TypeDenylist, events.ActionBlocked, events.Violation{Code: events.CodeDenylistedToken})
+
 						if strings.EqualFold(options.Mode, web.GraphQLMode) {
 							ctx.Response.SetStatusCode(options.CustomBlockStatusCode)
 							return web.RespondGraphQLErrors(&ctx.Response, errAccessDenied)
 						}
-						return web.RespondError(ctx, options.CustomBlockStatusCode, "")
+						return web.RespondBlocked(ctx, options.BlockResponse, options.CustomBlockStatusCode, errAccessDenied.Error())
 					}
 				}
 			}
@@ -16,6 +16,7 @@ import (
 	utils "github.com/savsgio/gotils/strconv"
 
 	"github.com/valyala/fasthttp"
+	"github.com/wallarm/api-firewall/internal/platform/events"
 	"github.com/wallarm/api-firewall/internal/platform/router"
 	"github.com/wallarm/api-firewall/internal/platform/web"
 	"github.com/wallarm/api-firewall/pkg/APIMode/validator"
@@ -28,6 +29,11 @@ type ModSecurityOptions struct {
 	ResponseValidation    string
 	CustomBlockStatusCode int
 	Logger                zerolog.Logger
+
+	// BlockResponse is sent to the blocked requests and responses. The custom
+	// block status code and the empty body are sent if the block response is
+	// not set
+	BlockResponse *web.BlockResponse
 }
 
 var ErrModSecMaliciousRequest = errors.New("malicious request")
@@ -167,6 +173,8 @@ func WAFModSecurity(options *ModSecurityOptions) web.Middleware {
 					}
 				} else if it != nil {
 
+					recordModSecurityEvent(ctx, it, options.Mode == web.APIMode || strings.EqualFold(options.RequestValidation, web.ValidationBlock))
+
 					if options.Mode == web.APIMode {
 						if err := respondAPIModeErrors(ctx, ErrModSecMaliciousRequest.Error(), fmt.Sprintf("ModSecurity rules: request blocked due to rule %d", it.RuleID)); err != nil {
 							options.Logger.Error().
@@ -181,7 +189,7 @@ func WAFModSecurity(options *ModSecurityOptions) web.Middleware {
 					}
 
 					if strings.EqualFold(options.RequestValidation, web.ValidationBlock) {
-						return performResponseAction(ctx, it, options.CustomBlockStatusCode)
+						return performResponseAction(ctx, it, options)
 					}
 				}
 			}
@@ -203,8 +211,10 @@ func WAFModSecurity(options *ModSecurityOptions) web.Middleware {
 				})
 
 				if it := tx.ProcessResponseHeaders(ctx.Response.StatusCode(), utils.B2S(ctx.Request.Header.Protocol())); it != nil {
+					recordModSecurityEvent(ctx, it, strings.EqualFold(options.ResponseValidation, web.ValidationBlock))
+
 					if strings.EqualFold(options.ResponseValidation, web.ValidationBlock) {
-						return performResponseAction(ctx, it, options.CustomBlockStatusCode)
+						return performResponseAction(ctx, it, options)
 					}
 				}
 
@@ -220,8 +230,10 @@ func WAFModSecurity(options *ModSecurityOptions) web.Middleware {
 						}
 
 						if it != nil {
+							recordModSecurityEvent(ctx, it, strings.EqualFold(options.ResponseValidation, web.ValidationBlock))
+
 							if strings.EqualFold(options.ResponseValidation, web.ValidationBlock) {
-								return performResponseAction(ctx, it, options.CustomBlockStatusCode)
+								return performResponseAction(ctx, it, options)
 							}
 						}
 					}
@@ -234,8 +246,10 @@ func WAFModSecurity(options *ModSecurityOptions) web.Middleware {
 						}
 					} else if it != nil {
 
+						recordModSecurityEvent(ctx, it, strings.EqualFold(options.ResponseValidation, web.ValidationBlock))
+
 						if strings.EqualFold(options.ResponseValidation, web.ValidationBlock) {
-							return performResponseAction(ctx, it, options.CustomBlockStatusCode)
+							return performResponseAction(ctx, it, options)
 						}
 					}
 				}
@@ -251,20 +265,38 @@ func WAFModSecurity(options *ModSecurityOptions) web.Middleware {
 	return m
 }
 
+// recordModSecurityEvent records the security event of the ModSecurity rule
+// which interrupted the transaction
+func recordModSecurityEvent(ctx *fasthttp.RequestCtx, it *types.Interruption, blocked bool) {
+	events.Record(ctx, events.TypeModSecurity, events.Action(blocked), events.Violation{
+		Code:    events.CodeModSecurityRule,
+		Message: fmt.Sprintf("ModSecurity rule %d matched", it.RuleID),
+	})
+}
+
 // obtainStatusCodeFromInterruptionOrDefault returns the desired status code derived from the interruption
 // on a "deny" action or a default value.
-func performResponseAction(ctx *fasthttp.RequestCtx, it *types.Interruption, blockStatusCode int) error {
+func performResponseAction(ctx *fasthttp.RequestCtx, it *types.Interruption, options *ModSecurityOptions) error {
 
 	switch it.Action {
 	case "deny", "drop":
-		statusCode := it.Status
-		if statusCode == 0 {
-			statusCode = blockStatusCode
+		ctx.SetUserValue(web.RequestBlockReason, web.BlockReasonModSecurity)
+
+		// the status code of the rule overrides the status code of the block response
+		statusCode := options.CustomBlockStatusCode
+		blockResponse := options.BlockResponse
+		if it.Status != 0 {
+			statusCode = it.Status
+			blockResponse = blockResponse.WithStatusCode(it.Status)
 		}
 
-		ctx.Response.Header.SetContentLength(0)
-		return web.RespondError(ctx, statusCode, "")
+		if blockResponse == nil {
+			ctx.Response.Header.SetContentLength(0)
+		}
+		return web.RespondBlocked(ctx, blockResponse, statusCode, fmt.Sprintf("ModSecurity rules: blocked due to rule %d", it.RuleID))
 	case "redirect":
+		ctx.SetUserValue(web.RequestBlockReason, web.BlockReasonModSecurity)
+
 		ctx.Response.Header.SetContentLength(0)
 		ctx.Redirect(it.Data, it.Status)
 		return nil
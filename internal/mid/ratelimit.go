@@ -0,0 +1,184 @@
+package mid
+
+import (
+	"errors"
+	"math"
+	"net"
+	strconv2 "strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/savsgio/gotils/strconv"
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/events"
+	"github.com/wallarm/api-firewall/internal/platform/ratelimit"
+	"github.com/wallarm/api-firewall/internal/platform/router"
+	"github.com/wallarm/api-firewall/internal/platform/web"
+)
+
+const (
+	rateLimitKeyIP        = "ip"
+	rateLimitKeyHeader    = "header"
+	rateLimitKeyCookie    = "cookie"
+	rateLimitKeyOperation = "operation"
+
+	rateLimitChecked = "__wallarm_apifw_rate_limit_checked"
+)
+
+type RateLimitOptions struct {
+	Mode        string
+	Config      *config.RateLimit
+	RateLimiter *ratelimit.RateLimiter
+	Logger      zerolog.Logger
+
+	// Operations holds the limiters of the operations with the limits set in
+	// the OpenAPI specification by the operation key
+	Operations map[string]ratelimit.Limiter
+
+	// BlockResponse is sent to the blocked requests. The 429 status code and
+	// the empty body are sent if the block response is not set
+	BlockResponse *web.BlockResponse
+}
+
+var errRateLimitExceeded = errors.New("rate limit exceeded")
+
+// RateLimit rejects requests which exceed the configured rate limits
+func RateLimit(options *RateLimitOptions) web.Middleware {
+
+	// This is the actual middleware function to be executed.
+	m := func(before router.Handler) router.Handler {
+
+		// Create the handler that will be attached in the middleware chain.
+		h := func(ctx *fasthttp.RequestCtx) error {
+
+			// the request is checked once (API mode handles it for each schema ID)
+			if options.RateLimiter == nil || ctx.UserValue(rateLimitChecked) != nil {
+				return before(ctx)
+			}
+			ctx.SetUserValue(rateLimitChecked, true)
+
+			method := strconv.B2S(ctx.Method())
+
+			var routePattern string
+			if rctx, ok := ctx.UserValue(router.RouteCtxKey).(*router.Context); ok {
+				routePattern = rctx.RoutePattern()
+			}
+
+			limiter := options.RateLimiter.FindOperation(method, routePattern, options.Operations)
+			if limiter == nil {
+				return before(ctx)
+			}
+
+			key := rateLimitKey(ctx, options.Config, options.Mode, method, routePattern)
+
+			allowed, retryAfter := limiter.Allow(key)
+			if allowed {
+				return before(ctx)
+			}
+
+			retryAfterSec := strconv2.Itoa(max(int(math.Ceil(retryAfter.Seconds())), 1))
+
+			options.Logger.Info().
+				Interface("request_id", ctx.UserValue(web.RequestID)).
+				Bytes("host", ctx.Request.Header.Host()).
+				Bytes("path", ctx.Path()).
+				Bytes("method", ctx.Request.Header.Method()).
+				Str("client_address", ctx.RemoteAddr().String()).
+				Str("rate_limit_key", strings.ToLower(options.Config.Key)).
+				Str("retry_after", retryAfterSec).
+				Msg("The request has been blocked by the rate limit")
+
+			ctx.SetUserValue(web.RequestBlockReason, web.BlockReasonRateLimit)
+			events.Record(ctx, events.TypeRateLimit, events.ActionBlocked, events.Violation{Code: events.CodeRateLimitExceeded})
+
+			switch options.Mode {
+			case web.APIMode:
+				ctx.SetUserValue(web.GlobalResponseStatusCodeKey, fasthttp.StatusTooManyRequests)
+				ctx.SetUserValue(web.GlobalResponseRetryAfterKey, retryAfterSec)
+				return nil
+			case web.GraphQLMode:
+				ctx.Response.SetStatusCode(fasthttp.StatusTooManyRequests)
+				ctx.Response.Header.Set(fasthttp.HeaderRetryAfter, retryAfterSec)
+				return web.RespondGraphQLErrors(&ctx.Response, errRateLimitExceeded)
+			}
+
+			if err := web.RespondBlocked(ctx, options.BlockResponse, fasthttp.StatusTooManyRequests, errRateLimitExceeded.Error()); err != nil {
+				return err
+			}
+			ctx.Response.Header.Set(fasthttp.HeaderRetryAfter, retryAfterSec)
+
+			return nil
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// rateLimitKey returns the key the requests are limited by. The client IP is
+// used if the token is not found in the request
+func rateLimitKey(ctx *fasthttp.RequestCtx, cfg *config.RateLimit, mode, method, routePattern string) string {
+
+	switch strings.ToLower(cfg.Key) {
+	case rateLimitKeyOperation:
+		key := method + " " + routePattern
+		// the same path could be defined in the different schemas in API mode
+		if schemaID, ok := ctx.UserValue(web.RequestSchemaID).(string); ok {
+			key = schemaID + ":" + key
+		}
+		return key
+	case rateLimitKeyHeader:
+		token := strconv.B2S(ctx.Request.Header.Peek(cfg.HeaderName))
+		if cfg.TrimBearerPrefix {
+			token = strings.TrimPrefix(token, "Bearer ")
+		}
+		if token != "" {
+			return "token:" + token
+		}
+	case rateLimitKeyCookie:
+		if token := strconv.B2S(ctx.Request.Header.Cookie(cfg.CookieName)); token != "" {
+			return "token:" + token
+		}
+	case rateLimitKeyIP:
+		if cfg.HeaderName != "" {
+			// the connection IP is added to the X-Forwarded-For header by the
+			// proxy middleware in all modes except API mode
+			entries := strings.Split(strconv.B2S(ctx.Request.Header.Peek(cfg.HeaderName)), ",")
+			if mode == web.APIMode || !strings.EqualFold(cfg.HeaderName, fasthttp.HeaderXForwardedFor) {
+				entries = append(entries, ctx.RemoteIP().String())
+			}
+
+			if ip := trustedClientIP(entries, cfg.TrustedHops); ip != "" {
+				return "ip:" + ip
+			}
+		}
+	}
+
+	if addr, ok := ctx.RemoteAddr().(*net.TCPAddr); ok {
+		return "ip:" + addr.IP.String()
+	}
+
+	return "ip:" + ctx.RemoteAddr().String()
+}
+
+// trustedClientIP returns the client IP of the list of the addresses ending
+// with the connection IP. The entries are added by the proxies, so the left
+// ones could be set by the client. The entry which is the number of the
+// trusted hops to the left of the connection IP is used. Empty string is
+// returned if the entry is not found or is not a valid IP
+func trustedClientIP(entries []string, trustedHops int) string {
+	idx := len(entries) - 1 - trustedHops
+	if trustedHops <= 0 || idx < 0 {
+		return ""
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(entries[idx]))
+	if ip == nil {
+		return ""
+	}
+
+	return ip.String()
+}
@@ -1,12 +1,14 @@
 package mid
 
 import (
+	"fmt"
 	"slices"
 
 	"github.com/rs/zerolog"
 	"github.com/valyala/fasthttp"
 
 	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/events"
 	"github.com/wallarm/api-firewall/internal/platform/router"
 	"github.com/wallarm/api-firewall/internal/platform/web"
 )
@@ -61,6 +63,11 @@ func ShadowAPIMonitor(logger zerolog.Logger, cfg *config.ShadowAPI) web.Middlewa
 					Str("client_address", ctx.RemoteAddr().String()).
 					Str("violation", "shadow_api").
 					Msg("Shadow API detected: response status code not found in the OpenAPI specification")
+
+				events.Record(ctx, events.TypeShadowAPI, events.ActionLogged, events.Violation{
+					Code:    events.CodeUnknownResponseStatus,
+					Message: fmt.Sprintf("response status code %d not found in the OpenAPI specification", statusCode),
+				})
 			}
 
 			// Return the error, so it can be handled further up the chain.
@@ -0,0 +1,201 @@
+package events
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/router"
+	"github.com/wallarm/api-firewall/internal/platform/web"
+)
+
+// Types of the security events
+const (
+	TypeRequestValidation  = web.BlockReasonRequestValidation
+	TypeResponseValidation = web.BlockReasonResponseValidation
+	TypeShadowAPI          = "shadow_api"
+	TypeDenylist           = web.BlockReasonDenylist
+	TypeAllowlist          = web.BlockReasonAllowlist
+	TypeRateLimit          = web.BlockReasonRateLimit
+	TypeModSecurity        = web.BlockReasonModSecurity
+)
+
+// Actions applied to the requests which triggered the security events
+const (
+	ActionBlocked = "blocked"
+	ActionLogged  = "logged"
+)
+
+// Codes of the violations which are not related to the OpenAPI validation. The
+// OpenAPI validation violations use the API mode error codes
+const (
+	CodeDenylistedToken       = "denylisted_token"
+	CodeIPNotAllowed          = "ip_not_allowed"
+	CodeRateLimitExceeded     = "rate_limit_exceeded"
+	CodeModSecurityRule       = "modsecurity_rule_matched"
+	CodeUnknownResponseStatus = "unknown_response_status"
+	CodeGraphQLInvalidRequest = "graphql_invalid_request"
+	CodeRequestBodyTooLarge   = "request_body_too_large"
+)
+
+// Violation is the violation of the security policy found in the request or response
+type Violation struct {
+	Code    string   `json:"code"`
+	Message string   `json:"message,omitempty"`
+	Fields  []string `json:"fields,omitempty"`
+}
+
+// Event is the security event. The JSON representation of the event is stable
+// and could be consumed by the SIEM systems
+type Event struct {
+	Timestamp  time.Time   `json:"timestamp"`
+	Type       string      `json:"event_type"`
+	Action     string      `json:"action"`
+	RequestID  string      `json:"request_id"`
+	Mode       string      `json:"mode"`
+	Spec       string      `json:"spec,omitempty"`
+	Operation  string      `json:"operation,omitempty"`
+	Method     string      `json:"method"`
+	Host       string      `json:"host"`
+	Path       string      `json:"path"`
+	ClientIP   string      `json:"client_ip"`
+	StatusCode int         `json:"status_code"`
+	Violations []Violation `json:"violations"`
+}
+
+// Sink receives the security events. The implementations should not block the
+// request processing
+type Sink interface {
+	Send(event *Event)
+	Close() error
+}
+
+// New creates the sink which sends the security events to all configured
+// outputs. Nil is returned if no output is configured
+func New(cfg *config.Events, logger zerolog.Logger) (Sink, error) {
+	var sinks multiSink
+
+	if cfg.File.Path != "" {
+		s, err := NewFileSink(&cfg.File, logger)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+
+	if cfg.Syslog.Address != "" {
+		sinks = append(sinks, NewSyslogSink(&cfg.Syslog, logger))
+	}
+
+	if cfg.Webhook.URL != "" {
+		sinks = append(sinks, NewWebhookSink(&cfg.Webhook, logger))
+	}
+
+	switch len(sinks) {
+	case 0:
+		return nil, nil
+	case 1:
+		return sinks[0], nil
+	}
+
+	return sinks, nil
+}
+
+// multiSink sends the events to all the sinks
+type multiSink []Sink
+
+func (m multiSink) Send(event *Event) {
+	for _, s := range m {
+		s.Send(event)
+	}
+}
+
+func (m multiSink) Close() error {
+	var firstErr error
+	for _, s := range m {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// contextKey is the key of the request context value which holds the security
+// events found during the request processing
+type contextKey struct{}
+
+type eventList struct {
+	events []*Event
+}
+
+// Record adds the security event to the request. The events are sent to the
+// sink by the Handler after the request is processed. Nothing is done if the
+// request is not processed by the Handler
+func Record(ctx *fasthttp.RequestCtx, eventType, action string, violations ...Violation) {
+	list, ok := ctx.UserValue(contextKey{}).(*eventList)
+	if !ok {
+		return
+	}
+
+	list.events = append(list.events, &Event{
+		Timestamp:  time.Now().UTC(),
+		Type:       eventType,
+		Action:     action,
+		Violations: violations,
+	})
+}
+
+// Action returns the action applied to the request by the validation mode
+func Action(blocked bool) string {
+	if blocked {
+		return ActionBlocked
+	}
+	return ActionLogged
+}
+
+// Handler collects the security events recorded during the request processing
+// and sends them to the sink with the request details. The next handler is
+// returned if the sink is nil
+func Handler(sink Sink, mode string, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	if sink == nil {
+		return next
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		list := &eventList{}
+		ctx.SetUserValue(contextKey{}, list)
+
+		next(ctx)
+
+		if len(list.events) == 0 {
+			return
+		}
+
+		requestID, _ := ctx.UserValue(web.RequestID).(string)
+		spec, _ := ctx.UserValue(web.RequestSpecName).(string)
+
+		var operation string
+		if rctx, ok := ctx.UserValue(router.RouteCtxKey).(*router.Context); ok && rctx.RoutePattern() != "" {
+			operation = string(ctx.Method()) + " " + rctx.RoutePattern()
+		}
+
+		for _, e := range list.events {
+			e.RequestID = requestID
+			e.Mode = mode
+			e.Spec = spec
+			e.Operation = operation
+			e.Method = string(ctx.Method())
+			e.Host = string(ctx.Host())
+			e.Path = string(ctx.Path())
+			e.ClientIP = ctx.RemoteIP().String()
+			e.StatusCode = ctx.Response.StatusCode()
+			if e.Violations == nil {
+				e.Violations = []Violation{}
+			}
+
+			sink.Send(e)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/router"
+	"github.com/wallarm/api-firewall/internal/platform/web"
+)
+
+type testSink struct {
+	mu     sync.Mutex
+	events []*Event
+}
+
+func (s *testSink) Send(event *Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func (s *testSink) Close() error {
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+
+	sink := &testSink{}
+
+	handler := Handler(sink, web.ProxyMode, func(ctx *fasthttp.RequestCtx) {
+		ctx.SetUserValue(web.RequestID, "c7a8b7d2")
+		ctx.SetUserValue(web.RequestSpecName, "users")
+
+		rctx := router.NewRouteContext()
+		rctx.RoutePatterns = []string{"/users/{id}"}
+		ctx.SetUserValue(router.RouteCtxKey, rctx)
+
+		Record(ctx, TypeRequestValidation, ActionBlocked, Violation{Code: "required_path_parameter_invalid_value", Fields: []string{"id"}})
+		ctx.SetStatusCode(fasthttp.StatusForbidden)
+	})
+
+	var reqCtx fasthttp.RequestCtx
+	reqCtx.Request.Header.SetMethod(fasthttp.MethodGet)
+	reqCtx.Request.SetRequestURI("http://api.example.com/users/abc")
+
+	handler(&reqCtx)
+
+	if len(sink.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(sink.events))
+	}
+
+	e := sink.events[0]
+	if e.Type != TypeRequestValidation || e.Action != ActionBlocked || e.RequestID != "c7a8b7d2" ||
+		e.Mode != web.ProxyMode || e.Spec != "users" || e.Operation != "GET /users/{id}" ||
+		e.Path != "/users/abc" || e.Host != "api.example.com" || e.StatusCode != fasthttp.StatusForbidden {
+		t.Errorf("incorrect event: %+v", e)
+	}
+
+	if len(e.Violations) != 1 || e.Violations[0].Code != "required_path_parameter_invalid_value" {
+		t.Errorf("incorrect violations: %+v", e.Violations)
+	}
+
+	// events are not recorded without the handler
+	var plainCtx fasthttp.RequestCtx
+	Record(&plainCtx, TypeDenylist, ActionBlocked)
+	if plainCtx.UserValue(contextKey{}) != nil {
+		t.Errorf("the event should not be recorded")
+	}
+
+	// no events
+	sink.events = nil
+	Handler(sink, web.ProxyMode, func(ctx *fasthttp.RequestCtx) {})(&reqCtx)
+	if len(sink.events) != 0 {
+		t.Errorf("expected no events, got %d", len(sink.events))
+	}
+}
+
+func TestFileSinkRotation(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "events.json")
+
+	event := &Event{Type: TypeDenylist, Action: ActionBlocked, Violations: []Violation{{Code: CodeDenylistedToken}}}
+	line, _ := json.Marshal(event)
+	lineSize := int64(len(line) + 1)
+
+	sink, err := NewFileSink(&config.EventsFile{Path: path, MaxSize: lineSize * 2, MaxBackups: 2, QueueSize: 10}, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 7 {
+		sink.Send(event)
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 7 events: 2 in each backup file, 1 in the current file and the oldest 2 are removed
+	for name, expected := range map[string]int{path: 1, path + ".1": 2, path + ".2": 2} {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("opening %s: %v", name, err)
+		}
+
+		lines := 0
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var e Event
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				t.Errorf("%s: decoding event: %v", name, err)
+			}
+			lines++
+		}
+		f.Close()
+
+		if lines != expected {
+			t.Errorf("%s: expected %d events, got %d", name, expected, lines)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("the number of backups should be limited")
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink := NewSyslogSink(&config.EventsSyslog{
+		Address:   conn.LocalAddr().String(),
+		Network:   "udp",
+		AppName:   "api-firewall",
+		Facility:  13,
+		QueueSize: 10,
+	}, zerolog.Nop())
+
+	timestamp := time.Date(2024, 5, 1, 10, 20, 30, 123456000, time.UTC)
+	sink.Send(&Event{Timestamp: timestamp, Type: TypeRateLimit, Action: ActionBlocked, RequestID: "c7a8b7d2"})
+
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("reading syslog message: %v", err)
+	}
+
+	msg := string(buf[:n])
+
+	// facility 13 (log audit) and severity 4 (warning) of the blocked request
+	expectedPrefix := "<108>1 2024-05-01T10:20:30.123456Z "
+	if !strings.HasPrefix(msg, expectedPrefix) {
+		t.Errorf("incorrect syslog message header: %q", msg)
+	}
+
+	fields := strings.SplitN(msg, " ", 8)
+	if len(fields) != 8 || fields[3] != "api-firewall" || fields[5] != TypeRateLimit || fields[6] != "-" {
+		t.Fatalf("incorrect syslog message: %q", msg)
+	}
+
+	var e Event
+	if err := json.Unmarshal([]byte(fields[7]), &e); err != nil || e.RequestID != "c7a8b7d2" {
+		t.Errorf("incorrect syslog message body: %q", fields[7])
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+
+	var (
+		mu       sync.Mutex
+		attempts int
+		batches  [][]Event
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		attempts++
+
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		// the first batch is accepted after the retry
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+
+		var batch []Event
+		if err := json.Unmarshal(body, &batch); err != nil {
+			t.Errorf("decoding batch: %v", err)
+		}
+		batches = append(batches, batch)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(&config.EventsWebhook{
+		URL:           server.URL,
+		Authorization: "Bearer secret",
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		Timeout:       5 * time.Second,
+		MaxRetries:    1,
+		RetryBackoff:  time.Millisecond,
+		QueueSize:     10,
+	}, zerolog.Nop())
+
+	for _, id := range []string{"1", "2", "3"} {
+		sink.Send(&Event{Type: TypeDenylist, Action: ActionBlocked, RequestID: id})
+	}
+
+	// the last incomplete batch is sent on close
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if attempts != 3 {
+		t.Errorf("expected 3 webhook requests, got %d", attempts)
+	}
+
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("incorrect batches: %+v", batches)
+	}
+
+	if batches[0][0].RequestID != "1" || batches[0][1].RequestID != "2" || batches[1][0].RequestID != "3" {
+		t.Errorf("incorrect events order: %+v", batches)
+	}
+}
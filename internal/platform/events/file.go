@@ -0,0 +1,171 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+// fileSink writes the events to the file as JSON lines. The file is rotated
+// when its size exceeds the limit
+type fileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	logger     zerolog.Logger
+
+	queue chan *Event
+	done  chan struct{}
+	stop  chan struct{}
+}
+
+var _ Sink = (*fileSink)(nil)
+
+// NewFileSink opens the events file for appending
+func NewFileSink(cfg *config.EventsFile, logger zerolog.Logger) (Sink, error) {
+	s := &fileSink{
+		path:       cfg.Path,
+		maxSize:    cfg.MaxSize,
+		maxBackups: cfg.MaxBackups,
+		logger:     logger,
+		queue:      make(chan *Event, cfg.QueueSize),
+		done:       make(chan struct{}),
+		stop:       make(chan struct{}),
+	}
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	go s.run()
+
+	return s, nil
+}
+
+func (s *fileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "events file open")
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "events file stat")
+	}
+
+	s.file = f
+	s.size = info.Size()
+
+	return nil
+}
+
+// rotate renames the current file to path.1 shifting the older backups and
+// removing the oldest one
+func (s *fileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	s.file = nil
+
+	if s.maxBackups == 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return s.open()
+	}
+
+	for i := s.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(s.backupName(i), s.backupName(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if err := os.Rename(s.path, s.backupName(1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return s.open()
+}
+
+func (s *fileSink) backupName(i int) string {
+	return fmt.Sprintf("%s.%d", s.path, i)
+}
+
+func (s *fileSink) write(event *Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("security events: event encoding error")
+		return
+	}
+	data = append(data, '\n')
+
+	if s.file == nil {
+		return
+	}
+
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(data)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			s.logger.Error().Err(err).Msg("security events: file rotation error")
+			if s.file == nil {
+				return
+			}
+		}
+	}
+
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("security events: file write error")
+	}
+}
+
+func (s *fileSink) run() {
+	defer close(s.done)
+
+	for {
+		select {
+		case e := <-s.queue:
+			s.write(e)
+		case <-s.stop:
+			for {
+				select {
+				case e := <-s.queue:
+					s.write(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *fileSink) Send(event *Event) {
+	select {
+	case <-s.stop:
+	case s.queue <- event:
+	default:
+		s.logger.Warn().Msg("security events: file queue is full, the event has been dropped")
+	}
+}
+
+func (s *fileSink) Close() error {
+	close(s.stop)
+	<-s.done
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+
+	return err
+}
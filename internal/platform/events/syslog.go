@@ -0,0 +1,180 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+const (
+	syslogSeverityWarning = 4
+	syslogSeverityNotice  = 5
+
+	syslogDialTimeout = 5 * time.Second
+
+	// RFC 5424 allows up to 6 digits of the fractional seconds
+	syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// syslogSink sends the events to the syslog server in the RFC 5424 format. The
+// messages are sent over TCP using the octet counting framing (RFC 6587)
+type syslogSink struct {
+	network  string
+	address  string
+	appName  string
+	facility int
+	hostname string
+	conn     net.Conn
+	logger   zerolog.Logger
+
+	queue chan *Event
+	done  chan struct{}
+	stop  chan struct{}
+}
+
+var _ Sink = (*syslogSink)(nil)
+
+// NewSyslogSink creates the syslog sink. The connection is established on the
+// first event and re-established after the write errors
+func NewSyslogSink(cfg *config.EventsSyslog, logger zerolog.Logger) Sink {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	s := &syslogSink{
+		network:  strings.ToLower(cfg.Network),
+		address:  cfg.Address,
+		appName:  cfg.AppName,
+		facility: cfg.Facility,
+		hostname: hostname,
+		logger:   logger,
+		queue:    make(chan *Event, cfg.QueueSize),
+		done:     make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s
+}
+
+// format returns the RFC 5424 message with the JSON encoded event
+func (s *syslogSink) format(event *Event) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	severity := syslogSeverityNotice
+	if event.Action == ActionBlocked {
+		severity = syslogSeverityWarning
+	}
+
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		s.facility*8+severity,
+		event.Timestamp.UTC().Format(syslogTimeFormat),
+		s.hostname,
+		nilValue(s.appName),
+		os.Getpid(),
+		nilValue(event.Type),
+		data,
+	)
+
+	if s.network == "tcp" {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	return []byte(msg), nil
+}
+
+// nilValue returns the NILVALUE of the RFC 5424 header field if the value is empty
+func nilValue(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
+
+func (s *syslogSink) write(msg []byte) error {
+	var err error
+
+	// reconnect once if the connection is broken
+	for range 2 {
+		if s.conn == nil {
+			s.conn, err = net.DialTimeout(s.network, s.address, syslogDialTimeout)
+			if err != nil {
+				s.conn = nil
+				return err
+			}
+		}
+
+		if _, err = s.conn.Write(msg); err == nil {
+			return nil
+		}
+
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	return err
+}
+
+func (s *syslogSink) send(event *Event) {
+	msg, err := s.format(event)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("security events: event encoding error")
+		return
+	}
+
+	if err := s.write(msg); err != nil {
+		s.logger.Error().Err(err).Str("address", s.address).Msg("security events: syslog write error")
+	}
+}
+
+func (s *syslogSink) run() {
+	defer close(s.done)
+
+	for {
+		select {
+		case e := <-s.queue:
+			s.send(e)
+		case <-s.stop:
+			for {
+				select {
+				case e := <-s.queue:
+					s.send(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *syslogSink) Send(event *Event) {
+	select {
+	case <-s.stop:
+	case s.queue <- event:
+	default:
+		s.logger.Warn().Msg("security events: syslog queue is full, the event has been dropped")
+	}
+}
+
+func (s *syslogSink) Close() error {
+	close(s.stop)
+	<-s.done
+
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+// webhookSink sends the batches of the events to the HTTP endpoint as JSON
+// arrays. The failed batches are retried with the exponential backoff
+type webhookSink struct {
+	cfg    *config.EventsWebhook
+	client *fasthttp.Client
+	logger zerolog.Logger
+
+	queue chan *Event
+	done  chan struct{}
+	stop  chan struct{}
+}
+
+var _ Sink = (*webhookSink)(nil)
+
+// NewWebhookSink creates the webhook sink
+func NewWebhookSink(cfg *config.EventsWebhook, logger zerolog.Logger) Sink {
+	s := &webhookSink{
+		cfg:    cfg,
+		client: &fasthttp.Client{NoDefaultUserAgentHeader: true},
+		logger: logger,
+		queue:  make(chan *Event, cfg.QueueSize),
+		done:   make(chan struct{}),
+		stop:   make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s
+}
+
+var errWebhookStatus = errors.New("unexpected webhook response status code")
+
+// post sends the batch to the webhook. The returned bool is true if the
+// request could be retried
+func (s *webhookSink) post(body []byte) (bool, error) {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(s.cfg.URL)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.SetContentType("application/json")
+	if s.cfg.Authorization != "" {
+		req.Header.Set(fasthttp.HeaderAuthorization, s.cfg.Authorization)
+	}
+	req.SetBody(body)
+
+	if err := s.client.DoTimeout(req, resp, s.cfg.Timeout); err != nil {
+		return true, err
+	}
+
+	statusCode := resp.StatusCode()
+	if statusCode >= 200 && statusCode < 300 {
+		return false, nil
+	}
+
+	retry := statusCode >= 500 || statusCode == fasthttp.StatusTooManyRequests
+	return retry, errors.Wrapf(errWebhookStatus, "status code %d", statusCode)
+}
+
+func (s *webhookSink) flush(batch []*Event) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("security events: events encoding error")
+		return
+	}
+
+	backoff := s.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(body)
+		if err == nil {
+			return
+		}
+
+		if !retry || attempt >= s.cfg.MaxRetries {
+			s.logger.Error().
+				Err(err).
+				Int("events", len(batch)).
+				Int("attempts", attempt+1).
+				Msg("security events: webhook error, the events have been dropped")
+			return
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (s *webhookSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Event, 0, s.cfg.BatchSize)
+
+	add := func(e *Event) {
+		batch = append(batch, e)
+		if len(batch) >= s.cfg.BatchSize {
+			s.flush(batch)
+			batch = make([]*Event, 0, s.cfg.BatchSize)
+		}
+	}
+
+	for {
+		select {
+		case e := <-s.queue:
+			add(e)
+		case <-ticker.C:
+			if len(batch) > 0 {
+				s.flush(batch)
+				batch = make([]*Event, 0, s.cfg.BatchSize)
+			}
+		case <-s.stop:
+			for {
+				select {
+				case e := <-s.queue:
+					add(e)
+				default:
+					if len(batch) > 0 {
+						s.flush(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *webhookSink) Send(event *Event) {
+	select {
+	case <-s.stop:
+	case s.queue <- event:
+	default:
+		s.logger.Warn().Msg("security events: webhook queue is full, the event has been dropped")
+	}
+}
+
+func (s *webhookSink) Close() error {
+	close(s.stop)
+	<-s.done
+	return nil
+}
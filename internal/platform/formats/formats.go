@@ -0,0 +1,274 @@
+package formats
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+// Validator validates the string value of the format
+type Validator func(value string) error
+
+var (
+	uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	e164Regexp = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+	ibanRegexp = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	byteRegexp = regexp.MustCompile(openapi3.FormatOfStringByte)
+)
+
+// Builtin holds the built-in validators by name. The custom formats could use
+// them by the validator name
+var Builtin = map[string]Validator{
+	"email":     validateEmail,
+	"uuid":      validateUUID,
+	"ipv4":      validateIPv4,
+	"ipv6":      validateIPv6,
+	"hostname":  validateHostname,
+	"date":      validateDate,
+	"date-time": validateDateTime,
+	"uri":       validateURI,
+	"byte":      validateByte,
+	"iban":      validateIBAN,
+	"e164":      validateE164,
+}
+
+// standard formats of the OpenAPI and JSON schema specifications which are
+// validated if the string formats validation is enabled
+var standard = []string{"email", "uuid", "ipv4", "ipv6", "hostname", "date", "date-time", "uri", "byte"}
+
+var (
+	lock sync.RWMutex
+	// active holds the validators of the enabled formats
+	active map[string]Validator
+	// replaced holds the kin-openapi format validators replaced by the active
+	// validators to restore them on the next configuration
+	replaced map[string]openapi3.StringFormatValidator
+)
+
+// Configure enables the validation of the standard and custom string formats
+// if it is enabled in the configuration. The validators are registered in
+// the kin-openapi formats registry which is used by the OpenAPI 3.0 schemas.
+// The previously configured validators are removed
+func Configure(cfg *config.StringFormats) error {
+	validators := make(map[string]Validator)
+
+	if cfg.Validation {
+		for _, name := range standard {
+			validators[name] = Builtin[name]
+		}
+
+		for _, format := range cfg.Custom {
+			validator, err := newValidator(&format)
+			if err != nil {
+				return fmt.Errorf("string format %s: %w", format.Name, err)
+			}
+			validators[format.Name] = validator
+		}
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// restore the validators of the kin-openapi registry
+	for name := range active {
+		if validator, ok := replaced[name]; ok {
+			openapi3.DefineStringFormatValidator(name, validator)
+			continue
+		}
+		delete(openapi3.SchemaStringFormats, name)
+	}
+
+	replaced = make(map[string]openapi3.StringFormatValidator)
+	for name, validator := range validators {
+		if existing, ok := openapi3.SchemaStringFormats[name]; ok {
+			replaced[name] = existing
+		}
+		openapi3.DefineStringFormatValidator(name, openapi3.NewCallbackValidator(validator))
+	}
+
+	active = validators
+
+	return nil
+}
+
+// JSONSchemaFormats returns the validators of the enabled formats to be
+// asserted by the JSON schema compiler of the OpenAPI 3.1 schemas. Nil is
+// returned if the string formats validation is disabled
+func JSONSchemaFormats() map[string]func(any) bool {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	if len(active) == 0 {
+		return nil
+	}
+
+	result := make(map[string]func(any) bool, len(active))
+	for name, validator := range active {
+		result[name] = func(v any) bool {
+			s, ok := v.(string)
+			if !ok {
+				// the format applies to the strings only
+				return true
+			}
+			return validator(s) == nil
+		}
+	}
+
+	return result
+}
+
+// newValidator returns the validator of the custom format
+func newValidator(format *config.StringFormat) (Validator, error) {
+	if format.Pattern != "" {
+		re, err := regexp.Compile(format.Pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(value string) error {
+			if !re.MatchString(value) {
+				return fmt.Errorf("string doesn't match pattern %q", re.String())
+			}
+			return nil
+		}, nil
+	}
+
+	validator, ok := Builtin[strings.ToLower(format.Validator)]
+	if !ok {
+		return nil, fmt.Errorf("unknown validator %q", format.Validator)
+	}
+
+	return validator, nil
+}
+
+func validateEmail(value string) error {
+	addr, err := mail.ParseAddress(value)
+	if err != nil {
+		return errors.New("not an email address")
+	}
+
+	// the display name and angle brackets are not allowed
+	if addr.Address != value {
+		return errors.New("not an email address")
+	}
+
+	return nil
+}
+
+func validateUUID(value string) error {
+	if !uuidRegexp.MatchString(value) {
+		return errors.New("not a UUID")
+	}
+	return nil
+}
+
+func validateIPv4(value string) error {
+	addr, err := netip.ParseAddr(value)
+	if err != nil || !addr.Is4() {
+		return errors.New("not an IPv4 address")
+	}
+	return nil
+}
+
+func validateIPv6(value string) error {
+	addr, err := netip.ParseAddr(value)
+	if err != nil || !addr.Is6() {
+		return errors.New("not an IPv6 address")
+	}
+	return nil
+}
+
+// validateHostname validates the hostname by RFC 1123
+func validateHostname(value string) error {
+	hostname := strings.TrimSuffix(value, ".")
+	if hostname == "" || len(hostname) > 253 {
+		return errors.New("not a hostname")
+	}
+
+	for _, label := range strings.Split(hostname, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return errors.New("not a hostname")
+		}
+
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' {
+				return errors.New("not a hostname")
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateDate(value string) error {
+	if _, err := time.Parse(time.DateOnly, value); err != nil {
+		return errors.New("not a RFC 3339 date")
+	}
+	return nil
+}
+
+func validateDateTime(value string) error {
+	if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+		return errors.New("not a RFC 3339 date-time")
+	}
+	return nil
+}
+
+func validateURI(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || !u.IsAbs() {
+		return errors.New("not an absolute URI")
+	}
+	return nil
+}
+
+func validateByte(value string) error {
+	if !byteRegexp.MatchString(value) {
+		return errors.New("not a base64 encoded string")
+	}
+	return nil
+}
+
+func validateE164(value string) error {
+	if !e164Regexp.MatchString(value) {
+		return errors.New("not an E.164 phone number")
+	}
+	return nil
+}
+
+// validateIBAN validates the format and the check digits of the IBAN
+func validateIBAN(value string) error {
+	iban := strings.ReplaceAll(value, " ", "")
+	if !ibanRegexp.MatchString(iban) {
+		return errors.New("not an IBAN")
+	}
+
+	// the first four characters are moved to the end and the letters are
+	// replaced by the numbers to calculate the remainder
+	var digits strings.Builder
+	for _, c := range iban[4:] + iban[:4] {
+		if c >= 'A' && c <= 'Z' {
+			digits.WriteString(fmt.Sprint(c - 'A' + 10))
+			continue
+		}
+		digits.WriteRune(c)
+	}
+
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok || new(big.Int).Mod(n, big.NewInt(97)).Int64() != 1 {
+		return errors.New("invalid IBAN check digits")
+	}
+
+	return nil
+}
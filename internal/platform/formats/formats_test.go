@@ -0,0 +1,103 @@
+package formats
+
+import (
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+func TestBuiltin(t *testing.T) {
+	tests := []struct {
+		validator string
+		valid     []string
+		invalid   []string
+	}{
+		{"email", []string{"user@example.com"}, []string{"user", "User <user@example.com>"}},
+		{"uuid", []string{"123e4567-e89b-12d3-a456-426614174000"}, []string{"123e4567-e89b-12d3-a456"}},
+		{"ipv4", []string{"192.168.0.1"}, []string{"::1", "256.0.0.1"}},
+		{"ipv6", []string{"::1", "2001:db8::1"}, []string{"192.168.0.1"}},
+		{"hostname", []string{"example.com", "localhost", "a-b.example.com."}, []string{"-example.com", "exa_mple.com", ""}},
+		{"date", []string{"2024-02-29"}, []string{"2023-02-29", "2024-2-1"}},
+		{"date-time", []string{"2024-02-29T10:00:00Z", "2024-02-29T10:00:00.123+02:00"}, []string{"2024-02-29 10:00:00"}},
+		{"uri", []string{"https://example.com/path"}, []string{"/path", "example.com"}},
+		{"iban", []string{"DE89370400440532013000", "GB82 WEST 1234 5698 7654 32"}, []string{"DE89370400440532013001", "DE89"}},
+		{"e164", []string{"+14155552671"}, []string{"14155552671", "+0123"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.validator, func(t *testing.T) {
+			for _, v := range tt.valid {
+				if err := Builtin[tt.validator](v); err != nil {
+					t.Errorf("expected valid value %q, got %v", v, err)
+				}
+			}
+			for _, v := range tt.invalid {
+				if err := Builtin[tt.validator](v); err == nil {
+					t.Errorf("expected invalid value %q", v)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	defer Configure(&config.StringFormats{})
+
+	schema := func(format string) *openapi3.Schema {
+		return openapi3.NewStringSchema().WithFormat(format)
+	}
+
+	if err := schema("email").VisitJSON("user"); err != nil {
+		t.Fatalf("expected no format validation by default, got %v", err)
+	}
+
+	var custom config.StringFormatList
+	if err := custom.Set("iban=iban;order-id=~^ORD-[0-9]{2,8}$;date=e164"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := Configure(&config.StringFormats{Validation: true, Custom: custom}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := schema("email").VisitJSON("user"); err == nil {
+		t.Error("expected email format validation error")
+	}
+	if err := schema("order-id").VisitJSON("ORD-1"); err == nil {
+		t.Error("expected custom regular expression format validation error")
+	}
+	if err := schema("order-id").VisitJSON("ORD-12345"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := schema("iban").VisitJSON("DE89370400440532013001"); err == nil {
+		t.Error("expected custom built-in format validation error")
+	}
+	if err := schema("date").VisitJSON("+14155552671"); err != nil {
+		t.Errorf("expected standard format to be overridden by the custom format, got %v", err)
+	}
+
+	if f := JSONSchemaFormats(); f == nil || f["order-id"]("ORD-1") || !f["order-id"](1) {
+		t.Error("expected JSON schema formats of the enabled formats")
+	}
+
+	// the kin-openapi formats are restored
+	if err := Configure(&config.StringFormats{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := schema("email").VisitJSON("user"); err != nil {
+		t.Errorf("expected no email format validation, got %v", err)
+	}
+	if err := schema("date").VisitJSON("+14155552671"); err == nil {
+		t.Error("expected default date format validation error")
+	}
+	if JSONSchemaFormats() != nil {
+		t.Error("expected no JSON schema formats")
+	}
+
+	if err := Configure(&config.StringFormats{Validation: true, Custom: config.StringFormatList{{Name: "phone", Validator: "unknown"}}}); err == nil {
+		t.Error("expected unknown validator error")
+	}
+}
@@ -0,0 +1,247 @@
+package jsonlimits
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/pkg/APIMode/validator"
+)
+
+// Names of the structural limits of the JSON document
+const (
+	LimitDepth        = "depth"
+	LimitKeys         = "keys"
+	LimitArrayLength  = "array length"
+	LimitStringLength = "string length"
+	LimitTokens       = "tokens"
+)
+
+// duplicate keys detection modes
+const (
+	modeDisable = "DISABLE"
+	modeBlock   = "BLOCK"
+)
+
+// LimitError is returned if the JSON document exceeds the structural limit.
+// The Path is the JSON pointer of the value which exceeds the limit
+type LimitError struct {
+	Limit string
+	Max   int
+	Path  string
+}
+
+func (e *LimitError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("JSON %s limit of %d exceeded", e.Limit, e.Max)
+	}
+	return fmt.Sprintf("JSON %s limit of %d exceeded at %s", e.Limit, e.Max, e.Path)
+}
+
+// SyntaxError is returned if the JSON document could not be scanned. The
+// document is rejected because the scanner and the body parser could accept
+// different documents and the limits could be bypassed by the document which
+// is accepted by the parser only
+type SyntaxError struct {
+	Offset int64
+	Err    error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("JSON syntax error at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// Enabled checks if any structural limit or the duplicate keys detection is
+// configured
+func Enabled(limits *config.JSONLimits) bool {
+	return limits.MaxDepth > 0 || limits.MaxKeys > 0 || limits.MaxArrayLength > 0 ||
+		limits.MaxStringLength > 0 || limits.MaxTokens > 0 ||
+		(limits.DuplicateKeys != "" && !strings.EqualFold(limits.DuplicateKeys, modeDisable))
+}
+
+// IsJSON checks if the content type is the JSON media type
+func IsJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// frame is the object or array which is being scanned
+type frame struct {
+	object    bool
+	expectKey bool
+	// count is the number of the object keys or array elements
+	count int
+	// key is the current key of the object
+	key  string
+	keys map[string]struct{}
+}
+
+// Check scans the JSON document token by token and stops at the first
+// exceeded limit which is returned as the LimitError. The JSON pointers of the
+// duplicate object keys are returned if the duplicate keys detection is
+// enabled. The scan stops at the first duplicate key in the BLOCK mode. The
+// document which could not be scanned is rejected with the SyntaxError
+func Check(data []byte, limits *config.JSONLimits) ([]string, error) {
+	var duplicates []string
+	var stack []frame
+	var tokens int
+
+	detectDuplicates := limits.DuplicateKeys != "" && !strings.EqualFold(limits.DuplicateKeys, modeDisable)
+	blockDuplicates := strings.EqualFold(limits.DuplicateKeys, modeBlock)
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	for {
+		token, err := dec.Token()
+		if err == io.EOF && len(stack) == 0 {
+			return duplicates, nil
+		}
+		if err != nil {
+			return duplicates, &SyntaxError{Offset: dec.InputOffset(), Err: err}
+		}
+
+		tokens++
+		if limits.MaxTokens > 0 && tokens > limits.MaxTokens {
+			return duplicates, &LimitError{Limit: LimitTokens, Max: limits.MaxTokens, Path: pointer(stack)}
+		}
+
+		// the end of the object or array
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 {
+				stack[len(stack)-1].expectKey = stack[len(stack)-1].object
+			}
+			continue
+		}
+
+		if len(stack) > 0 {
+			parent := &stack[len(stack)-1]
+
+			// the object key
+			if parent.expectKey {
+				key, _ := token.(string)
+				parent.expectKey = false
+				parent.count++
+				parent.key = key
+
+				if limits.MaxKeys > 0 && parent.count > limits.MaxKeys {
+					return duplicates, &LimitError{Limit: LimitKeys, Max: limits.MaxKeys, Path: pointer(stack)}
+				}
+				if limits.MaxStringLength > 0 && len(key) > limits.MaxStringLength {
+					return duplicates, &LimitError{Limit: LimitStringLength, Max: limits.MaxStringLength, Path: pointer(stack)}
+				}
+
+				if detectDuplicates {
+					if _, ok := parent.keys[key]; ok {
+						duplicates = append(duplicates, pointer(stack))
+						if blockDuplicates {
+							return duplicates, nil
+						}
+					}
+					parent.keys[key] = struct{}{}
+				}
+				continue
+			}
+
+			// the array element
+			if !parent.object {
+				parent.count++
+				if limits.MaxArrayLength > 0 && parent.count > limits.MaxArrayLength {
+					return duplicates, &LimitError{Limit: LimitArrayLength, Max: limits.MaxArrayLength, Path: pointer(stack)}
+				}
+			}
+		}
+
+		switch v := token.(type) {
+		case json.Delim:
+			if limits.MaxDepth > 0 && len(stack) >= limits.MaxDepth {
+				return duplicates, &LimitError{Limit: LimitDepth, Max: limits.MaxDepth, Path: pointer(stack)}
+			}
+
+			f := frame{object: v == '{', expectKey: v == '{'}
+			if f.object && detectDuplicates {
+				f.keys = make(map[string]struct{})
+			}
+			stack = append(stack, f)
+			continue
+		case string:
+			if limits.MaxStringLength > 0 && len(v) > limits.MaxStringLength {
+				return duplicates, &LimitError{Limit: LimitStringLength, Max: limits.MaxStringLength, Path: pointer(stack)}
+			}
+		}
+
+		// the scalar value of the object key has been scanned
+		if len(stack) > 0 {
+			stack[len(stack)-1].expectKey = stack[len(stack)-1].object
+		}
+	}
+}
+
+// pointerEscaper escapes the JSON pointer reference tokens by RFC 6901
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// pointer returns the JSON pointer of the current value
+func pointer(stack []frame) string {
+	var b strings.Builder
+	for _, f := range stack {
+		b.WriteByte('/')
+		if f.object {
+			b.WriteString(pointerEscaper.Replace(f.key))
+			continue
+		}
+		b.WriteString(strconv.Itoa(f.count - 1))
+	}
+
+	return b.String()
+}
+
+// ValidationErrors returns the validation errors with the API mode error codes
+// of the exceeded limit or the syntax error and the duplicate keys
+func ValidationErrors(limitErr error, duplicates []string) []*validator.ValidationError {
+	var result []*validator.ValidationError
+
+	var le *LimitError
+	if errors.As(limitErr, &le) {
+		ve := &validator.ValidationError{
+			Message: le.Error(),
+			Code:    validator.ErrCodeRequiredBodyLimitExceeded,
+		}
+		if le.Path != "" {
+			ve.Fields = []string{le.Path}
+		}
+		result = append(result, ve)
+	}
+
+	var se *SyntaxError
+	if errors.As(limitErr, &se) {
+		result = append(result, &validator.ValidationError{
+			Message: se.Error(),
+			Code:    validator.ErrCodeRequiredBodyParseError,
+		})
+	}
+
+	if len(duplicates) > 0 {
+		result = append(result, &validator.ValidationError{
+			Message: "duplicate keys found in the JSON object",
+			Code:    validator.ErrCodeRequiredBodyDuplicateKey,
+			Fields:  duplicates,
+		})
+	}
+
+	return result
+}
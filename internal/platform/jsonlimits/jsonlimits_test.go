@@ -0,0 +1,120 @@
+package jsonlimits
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/pkg/APIMode/validator"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		limits config.JSONLimits
+		limit  string
+		path   string
+	}{
+		{"within limits", `{"a":[1,2],"b":{"c":"abc"}}`, config.JSONLimits{MaxDepth: 2, MaxKeys: 2, MaxArrayLength: 2, MaxStringLength: 3, MaxTokens: 13}, "", ""},
+		{"depth", `{"a":{"b":[1]}}`, config.JSONLimits{MaxDepth: 2}, LimitDepth, "/a/b"},
+		{"depth of scalar document", `"value"`, config.JSONLimits{MaxDepth: 1}, "", ""},
+		{"keys", `{"a":1,"b":{"c":1,"d":2,"e":3}}`, config.JSONLimits{MaxKeys: 2}, LimitKeys, "/b/e"},
+		{"array length", `{"a":[[1,2],[1,2,3]]}`, config.JSONLimits{MaxArrayLength: 2}, LimitArrayLength, "/a/1/2"},
+		{"string value length", `{"a":"abcd"}`, config.JSONLimits{MaxStringLength: 3}, LimitStringLength, "/a"},
+		{"string key length", `{"a/b~":1}`, config.JSONLimits{MaxStringLength: 3}, LimitStringLength, "/a~1b~0"},
+		{"escaped string length", `["\u0061b"]`, config.JSONLimits{MaxStringLength: 2}, "", ""},
+		{"tokens", `[1,2,3]`, config.JSONLimits{MaxTokens: 4}, LimitTokens, "/2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Check([]byte(tt.body), &tt.limits)
+			if tt.limit == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("expected the limit error, got %v", err)
+			}
+			if limitErr.Limit != tt.limit || limitErr.Path != tt.path {
+				t.Errorf("expected the %s limit at %q, got the %s limit at %q", tt.limit, tt.path, limitErr.Limit, limitErr.Path)
+			}
+		})
+	}
+}
+
+func TestCheckSyntaxError(t *testing.T) {
+	for name, body := range map[string]string{
+		"truncated document": `{"a":`,
+		"trailing data":      `{"a":1} x`,
+		// the control character in the string is accepted by fastjson only
+		"control character": "{\"x\":\"a\x01b\",\"y\":[[[[[[[[[[1]]]]]]]]]],\"y\":2}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			duplicates, err := Check([]byte(body), &config.JSONLimits{MaxDepth: 3, DuplicateKeys: "BLOCK"})
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("expected the syntax error, got %v", err)
+			}
+			if len(duplicates) != 0 {
+				t.Errorf("unexpected duplicate keys %v", duplicates)
+			}
+
+			validationErrs := ValidationErrors(err, duplicates)
+			if len(validationErrs) != 1 || validationErrs[0].Code != validator.ErrCodeRequiredBodyParseError {
+				t.Errorf("unexpected validation errors %+v", validationErrs)
+			}
+		})
+	}
+
+	// the empty body is validated by the parser
+	if _, err := Check(nil, &config.JSONLimits{MaxDepth: 1}); err != nil {
+		t.Errorf("unexpected error of the empty body: %v", err)
+	}
+}
+
+func TestCheckDuplicateKeys(t *testing.T) {
+	body := []byte(`{"a":1,"b":{"c":1,"c":2},"a":[{"d":1,"d":2}]}`)
+
+	duplicates, err := Check(body, &config.JSONLimits{DuplicateKeys: "DISABLE"})
+	if err != nil || duplicates != nil {
+		t.Fatalf("expected no duplicate keys, got %v, %v", duplicates, err)
+	}
+
+	duplicates, err = Check(body, &config.JSONLimits{DuplicateKeys: "LOG_ONLY"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"/b/c", "/a", "/a/0/d"}; !reflect.DeepEqual(duplicates, expected) {
+		t.Errorf("expected duplicate keys %v, got %v", expected, duplicates)
+	}
+
+	duplicates, err = Check(body, &config.JSONLimits{DuplicateKeys: "BLOCK"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"/b/c"}; !reflect.DeepEqual(duplicates, expected) {
+		t.Errorf("expected duplicate keys %v, got %v", expected, duplicates)
+	}
+}
+
+func TestIsJSON(t *testing.T) {
+	for contentType, expected := range map[string]bool{
+		"application/json":                true,
+		"application/json; charset=utf-8": true,
+		"application/problem+json":        true,
+		"text/plain":                      false,
+		"":                                false,
+	} {
+		if IsJSON(contentType) != expected {
+			t.Errorf("content type %q: expected %t", contentType, expected)
+		}
+	}
+}
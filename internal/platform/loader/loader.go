@@ -2,19 +2,79 @@ package loader
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/oasdiff/yaml"
 )
 
 var (
 	ErrOASValidation = errors.New("OpenAPI specification validation error")
 	ErrOASParsing    = errors.New("OpenAPI specification parsing error")
+	ErrOASConversion = errors.New("Swagger 2.0 specification conversion error")
 )
 
+// specVersion is used to detect the version of the specification
+type specVersion struct {
+	Swagger any `json:"swagger"`
+	OpenAPI any `json:"openapi"`
+}
+
+// isSwagger2 checks if the specification is the Swagger 2.0 document. The
+// unquoted YAML version is parsed as the number
+func isSwagger2(schema []byte) bool {
+	var version specVersion
+	if err := yaml.Unmarshal(schema, &version); err != nil {
+		return false
+	}
+
+	switch v := version.Swagger.(type) {
+	case string:
+		return v == "2.0"
+	case float64:
+		return v == 2
+	}
+
+	return false
+}
+
+// convertSwagger2 converts the Swagger 2.0 specification to the OpenAPI 3.0
+// specification. The loader resolves the references of the converted
+// specification
+func convertSwagger2(schema []byte, loader *openapi3.Loader, location *url.URL) (*openapi3.T, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(schema, &raw); err != nil {
+		return nil, err
+	}
+
+	// the unquoted version is not accepted by the Swagger 2.0 parser
+	raw["swagger"] = "2.0"
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc2 openapi2.T
+	if err := json.Unmarshal(data, &doc2); err != nil {
+		return nil, err
+	}
+
+	return openapi2conv.ToV3WithLoader(&doc2, loader, location)
+}
+
 func validateOAS(spec *openapi3.T) error {
 
+	if strings.HasPrefix(spec.OpenAPI, "3.1") {
+		return validateOAS31(spec)
+	}
+
 	if err := spec.Validate(
 		context.Background(),
 		openapi3.DisableExamplesValidation(),
@@ -28,18 +88,95 @@ func validateOAS(spec *openapi3.T) error {
 	return nil
 }
 
+// RefReader reads the content of the external reference of the specification
+type RefReader func(location *url.URL) ([]byte, error)
+
 func ParseOAS(schema []byte, SchemaVersion string, schemaID int) (*openapi3.T, error) {
+	return parseOAS(schema, nil, nil, SchemaVersion, schemaID)
+}
+
+// ParseOASWithRefs parses the specification located by the passed location.
+// The external references are resolved relative to the location and read by
+// the passed reader
+func ParseOASWithRefs(schema []byte, location *url.URL, readRef RefReader, SchemaVersion string, schemaID int) (*openapi3.T, error) {
+	return parseOAS(schema, location, readRef, SchemaVersion, schemaID)
+}
 
-	// parse specification
+// newOASLoader returns the OpenAPI loader. The external references are allowed
+// if the reader is passed
+func newOASLoader(readRef RefReader, normalize bool) *openapi3.Loader {
 	loader := openapi3.NewLoader()
-	parsedSpec, err := loader.LoadFromData(schema)
-	if err != nil {
-		return nil, fmt.Errorf("%w: schema version '%s', schema ID %d: %w", ErrOASParsing, SchemaVersion, schemaID, err)
+	if readRef == nil {
+		return loader
+	}
+
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = func(_ *openapi3.Loader, location *url.URL) ([]byte, error) {
+		data, err := readRef(location)
+		if err != nil {
+			return nil, err
+		}
+
+		if normalize {
+			return normalizeOpenAPI31(data)
+		}
+
+		return data, nil
+	}
+
+	return loader
+}
+
+// loadOAS loads the specification from the data. The specification location is
+// used to resolve the external references
+func loadOAS(loader *openapi3.Loader, data []byte, location *url.URL) (*openapi3.T, error) {
+	if location == nil {
+		return loader.LoadFromData(data)
+	}
+
+	return loader.LoadFromDataWithPath(data, location)
+}
+
+func parseOAS(schema []byte, location *url.URL, readRef RefReader, SchemaVersion string, schemaID int) (*openapi3.T, error) {
+
+	var parsedSpec *openapi3.T
+	var err error
+
+	if isSwagger2(schema) {
+		// convert Swagger 2.0 specification to OpenAPI 3.0
+		parsedSpec, err = convertSwagger2(schema, newOASLoader(readRef, false), location)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w: schema version '%s', schema ID %d: %w", ErrOASParsing, ErrOASConversion, SchemaVersion, schemaID, err)
+		}
+	} else if isOpenAPI31(schema) {
+		// parse OpenAPI 3.1 specification changed to be accepted by the
+		// OpenAPI 3.0 parser. The original schemas are used to validate data
+		normalizedSchema, err := normalizeOpenAPI31(schema)
+		if err != nil {
+			return nil, fmt.Errorf("%w: schema version '%s', schema ID %d: %w", ErrOASParsing, SchemaVersion, schemaID, err)
+		}
+
+		parsedSpec, err = loadOAS(newOASLoader(readRef, true), normalizedSchema, location)
+		if err != nil {
+			return nil, fmt.Errorf("%w: schema version '%s', schema ID %d: %w", ErrOASParsing, SchemaVersion, schemaID, err)
+		}
+	} else {
+		// parse specification
+		parsedSpec, err = loadOAS(newOASLoader(readRef, false), schema, location)
+		if err != nil {
+			return nil, fmt.Errorf("%w: schema version '%s', schema ID %d: %w", ErrOASParsing, SchemaVersion, schemaID, err)
+		}
 	}
 
 	if err := validateOAS(parsedSpec); err != nil {
 		return nil, fmt.Errorf("%w: schema version '%s', schema ID %d: %w: ", ErrOASValidation, SchemaVersion, schemaID, err)
 	}
 
+	if strings.HasPrefix(parsedSpec.OpenAPI, "3.1") {
+		if err := compileJSONSchemas(parsedSpec, schema, location, readRef); err != nil {
+			return nil, fmt.Errorf("%w: schema version '%s', schema ID %d: %w", ErrOASParsing, SchemaVersion, schemaID, err)
+		}
+	}
+
 	return parsedSpec, nil
 }
@@ -0,0 +1,504 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/oasdiff/yaml"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/wallarm/api-firewall/internal/platform/formats"
+)
+
+// jsonSchemaExtension is the schema extension which holds the JSON Schema
+// 2020-12 compiled from the OpenAPI 3.1 specification
+const jsonSchemaExtension = "x-apifw-json-schema"
+
+// specLocation is the location of the OpenAPI 3.1 specification used to
+// resolve the local references of the JSON schemas
+const specLocation = "apifw:///openapi.json"
+
+// openAPI31Keywords are the JSON Schema 2020-12 and OpenAPI 3.1 keywords which
+// are not supported by the OpenAPI 3.0 parser
+var openAPI31Keywords = []string{
+	"$schema", "$id", "$anchor", "$dynamicAnchor", "$dynamicRef", "$defs", "$comment", "$vocabulary",
+	"const", "examples", "prefixItems", "contains", "minContains", "maxContains",
+	"unevaluatedItems", "unevaluatedProperties", "patternProperties", "propertyNames",
+	"dependentRequired", "dependentSchemas", "if", "then", "else",
+	"contentEncoding", "contentMediaType", "contentSchema",
+	"webhooks", "jsonSchemaDialect", "pathItems", "summary", "identifier",
+}
+
+// JSONSchema is the JSON Schema 2020-12 compiled from the schema of the
+// OpenAPI 3.1 specification
+type JSONSchema struct {
+	*jsonschema.Schema
+
+	// subschemas of all compiled schemas of the specification by location
+	subschemas map[string]*jsonschema.Schema
+}
+
+// Subschema returns the compiled subschema by the absolute location
+func (s *JSONSchema) Subschema(location string) *jsonschema.Schema {
+	return s.subschemas[location]
+}
+
+// GetJSONSchema returns the JSON Schema 2020-12 of the schema. Nil is returned
+// if the schema is not loaded from the OpenAPI 3.1 specification
+func GetJSONSchema(schema *openapi3.Schema) *JSONSchema {
+	if schema == nil {
+		return nil
+	}
+
+	jsonSchema, _ := schema.Extensions[jsonSchemaExtension].(*JSONSchema)
+	return jsonSchema
+}
+
+// isOpenAPI31 checks if the specification is the OpenAPI 3.1 document
+func isOpenAPI31(schema []byte) bool {
+	var version specVersion
+	if err := yaml.Unmarshal(schema, &version); err != nil {
+		return false
+	}
+
+	v, ok := version.OpenAPI.(string)
+	return ok && strings.HasPrefix(v, "3.1")
+}
+
+// normalizeOpenAPI31 returns the OpenAPI 3.1 specification with the keywords
+// changed to be accepted by the OpenAPI 3.0 parser. The changed specification
+// is used to route and decode the requests only
+func normalizeOpenAPI31(schema []byte) ([]byte, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(schema, &raw); err != nil {
+		return nil, err
+	}
+
+	normalizeSchemaKeywords(raw)
+
+	return json.Marshal(raw)
+}
+
+// normalizeSchemaKeywords replaces the numeric exclusiveMinimum and
+// exclusiveMaximum keywords by the minimum and maximum keywords, the null
+// type by the nullable keyword and sets the missing items of the arrays. The
+// example, examples, const and enum values are skipped as they are the
+// instances, not the schemas. The names of the properties are not the keywords
+// so only the property schemas are normalized
+func normalizeSchemaKeywords(value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		switch t := v["type"].(type) {
+		case string:
+			if t == "null" {
+				delete(v, "type")
+				v["nullable"] = true
+			}
+		case []any:
+			types := make([]any, 0, len(t))
+			for _, item := range t {
+				if item == "null" {
+					v["nullable"] = true
+					continue
+				}
+				types = append(types, item)
+			}
+			v["type"] = types
+		}
+		if _, ok := v["items"]; !ok && hasType(v["type"], "array") {
+			// items are optional in JSON Schema 2020-12
+			v["items"] = map[string]any{}
+		}
+		for keyword, limit := range map[string]string{"exclusiveMinimum": "minimum", "exclusiveMaximum": "maximum"} {
+			if n, ok := v[keyword].(float64); ok {
+				v[limit] = n
+				v[keyword] = true
+			}
+		}
+		for keyword, item := range v {
+			switch keyword {
+			case "example", "examples", "const", "enum":
+				continue
+			case "properties", "patternProperties", "$defs":
+				if schemas, ok := item.(map[string]any); ok {
+					for _, schema := range schemas {
+						normalizeSchemaKeywords(schema)
+					}
+					continue
+				}
+			}
+			normalizeSchemaKeywords(item)
+		}
+	case []any:
+		for _, item := range v {
+			normalizeSchemaKeywords(item)
+		}
+	}
+}
+
+// hasType checks if the type keyword value contains the type
+func hasType(value any, name string) bool {
+	switch t := value.(type) {
+	case string:
+		return t == name
+	case []any:
+		for _, item := range t {
+			if item == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// validateOAS31 validates the OpenAPI 3.1 specification by the OpenAPI 3.0
+// rules allowing the JSON Schema 2020-12 keywords
+func validateOAS31(spec *openapi3.T) error {
+
+	if spec.Paths == nil {
+		// paths are optional in OpenAPI 3.1
+		spec.Paths = openapi3.NewPaths()
+	}
+
+	if err := spec.Validate(
+		context.Background(),
+		openapi3.DisableExamplesValidation(),
+		openapi3.DisableSchemaFormatValidation(),
+		openapi3.DisableSchemaDefaultsValidation(),
+		openapi3.DisableSchemaPatternValidation(),
+		openapi3.AllowExtraSiblingFields(openAPI31Keywords...),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// jsonSchemaCompiler compiles the schemas of the operations of the OpenAPI
+// 3.1 specification by their locations in the specification
+type jsonSchemaCompiler struct {
+	compiler   *jsonschema.Compiler
+	subschemas map[string]*jsonschema.Schema
+	compiled   []*jsonschema.Schema
+}
+
+// compileJSONSchemas compiles the JSON Schemas 2020-12 of the parameters,
+// request bodies, responses and headers of the specification operations. The
+// external references are read by the reader relative to the location
+func compileJSONSchemas(spec *openapi3.T, schema []byte, location *url.URL, readRef RefReader) error {
+
+	data, err := yaml.YAMLToJSON(schema)
+	if err != nil {
+		return err
+	}
+
+	specURL, err := url.Parse(specLocation)
+	if err != nil {
+		return err
+	}
+
+	if location != nil {
+		specURL = copyURL(location)
+		specURL.Fragment = ""
+		if specURL.Scheme == "" {
+			// the JSON schema compiler accepts the absolute URLs only
+			specURL.Scheme = "file"
+		}
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+
+	// the formats are annotations in JSON schema 2020-12 and asserted only if
+	// the string formats validation is enabled
+	if assertFormats := formats.JSONSchemaFormats(); assertFormats != nil {
+		compiler.AssertFormat = true
+		compiler.Formats = assertFormats
+	}
+	compiler.LoadURL = func(ref string) (io.ReadCloser, error) {
+		if readRef == nil {
+			return nil, fmt.Errorf("external reference %s is not allowed", ref)
+		}
+
+		refURL, err := url.Parse(ref)
+		if err != nil {
+			return nil, err
+		}
+		if refURL.Scheme == "file" {
+			refURL.Scheme = ""
+		}
+
+		content, err := readRef(refURL)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err = yaml.YAMLToJSON(content)
+		if err != nil {
+			return nil, err
+		}
+
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+
+	if err := compiler.AddResource(specURL.String(), bytes.NewReader(data)); err != nil {
+		return err
+	}
+
+	c := jsonSchemaCompiler{
+		compiler:   compiler,
+		subschemas: make(map[string]*jsonschema.Schema),
+	}
+
+	for path, pathItem := range spec.Paths.Map() {
+		pathLocation := refOrLocation(pathItem.Ref, specURL.String()+"#/paths/"+escapePointer(path))
+
+		if err := c.compileParameters(pathItem.Parameters, pathLocation+"/parameters"); err != nil {
+			return err
+		}
+
+		for method, operation := range pathItem.Operations() {
+			operationLocation := pathLocation + "/" + strings.ToLower(method)
+
+			if err := c.compileParameters(operation.Parameters, operationLocation+"/parameters"); err != nil {
+				return err
+			}
+
+			if operation.RequestBody != nil && operation.RequestBody.Value != nil {
+				location := refOrLocation(operation.RequestBody.Ref, operationLocation+"/requestBody")
+				if err := c.compileContent(operation.RequestBody.Value.Content, location+"/content"); err != nil {
+					return err
+				}
+			}
+
+			if operation.Responses == nil {
+				continue
+			}
+
+			for status, response := range operation.Responses.Map() {
+				if response.Value == nil {
+					continue
+				}
+
+				location := refOrLocation(response.Ref, operationLocation+"/responses/"+escapePointer(status))
+				if err := c.compileContent(response.Value.Content, location+"/content"); err != nil {
+					return err
+				}
+
+				for name, header := range response.Value.Headers {
+					if header.Value == nil {
+						continue
+					}
+
+					headerLocation := refOrLocation(header.Ref, location+"/headers/"+escapePointer(name))
+					if err := c.compileSchema(header.Value.Schema, headerLocation+"/schema"); err != nil {
+						return err
+					}
+					if err := c.compileContent(header.Value.Content, headerLocation+"/content"); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	// index all subschemas to find the schemas of the validation errors
+	for _, s := range c.compiled {
+		indexSubschemas(s, c.subschemas)
+	}
+
+	return nil
+}
+
+func (c *jsonSchemaCompiler) compileParameters(parameters openapi3.Parameters, location string) error {
+	for i, parameter := range parameters {
+		if parameter.Value == nil {
+			continue
+		}
+
+		parameterLocation := refOrLocation(parameter.Ref, fmt.Sprintf("%s/%d", location, i))
+		if err := c.compileSchema(parameter.Value.Schema, parameterLocation+"/schema"); err != nil {
+			return err
+		}
+		if err := c.compileContent(parameter.Value.Content, parameterLocation+"/content"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *jsonSchemaCompiler) compileContent(content openapi3.Content, location string) error {
+	for mediaType, media := range content {
+		if media == nil {
+			continue
+		}
+
+		if err := c.compileSchema(media.Schema, location+"/"+escapePointer(mediaType)+"/schema"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *jsonSchemaCompiler) compileSchema(schema *openapi3.SchemaRef, location string) error {
+	if schema == nil || schema.Value == nil || GetJSONSchema(schema.Value) != nil {
+		return nil
+	}
+
+	// the schema references are resolved by the compiler
+	compiled, err := c.compiler.Compile(location)
+	if err != nil {
+		return fmt.Errorf("JSON schema %s: %w", location, err)
+	}
+
+	if schema.Value.Extensions == nil {
+		schema.Value.Extensions = make(map[string]any)
+	}
+
+	schema.Value.Extensions[jsonSchemaExtension] = &JSONSchema{
+		Schema:     compiled,
+		subschemas: c.subschemas,
+	}
+	c.compiled = append(c.compiled, compiled)
+
+	return nil
+}
+
+// copyURL returns the copy of the URL
+func copyURL(u *url.URL) *url.URL {
+	c := *u
+	return &c
+}
+
+// indexSubschemas adds the schema and all its subschemas to the index
+func indexSubschemas(s *jsonschema.Schema, index map[string]*jsonschema.Schema) {
+	if s == nil {
+		return
+	}
+
+	if _, ok := index[s.Location]; ok {
+		return
+	}
+	index[s.Location] = s
+
+	for _, sub := range []*jsonschema.Schema{s.Ref, s.RecursiveRef, s.DynamicRef, s.Not, s.If, s.Then, s.Else,
+		s.PropertyNames, s.UnevaluatedProperties, s.Items2020, s.Contains, s.UnevaluatedItems, s.ContentSchema} {
+		indexSubschemas(sub, index)
+	}
+
+	for _, list := range [][]*jsonschema.Schema{s.AllOf, s.AnyOf, s.OneOf, s.PrefixItems} {
+		for _, sub := range list {
+			indexSubschemas(sub, index)
+		}
+	}
+
+	for _, sub := range s.Properties {
+		indexSubschemas(sub, index)
+	}
+
+	for _, sub := range s.PatternProperties {
+		indexSubschemas(sub, index)
+	}
+
+	for _, sub := range s.DependentSchemas {
+		indexSubschemas(sub, index)
+	}
+
+	for _, item := range []any{s.AdditionalProperties, s.AdditionalItems, s.Items} {
+		switch sub := item.(type) {
+		case *jsonschema.Schema:
+			indexSubschemas(sub, index)
+		case []*jsonschema.Schema:
+			for _, itemSchema := range sub {
+				indexSubschemas(itemSchema, index)
+			}
+		}
+	}
+}
+
+// refOrLocation returns the reference resolved relative to the document of the
+// location if the reference is set. Otherwise, the location is returned
+func refOrLocation(ref, location string) string {
+	if ref == "" {
+		return location
+	}
+
+	document, _, _ := strings.Cut(location, "#")
+	refDocument, fragment, _ := strings.Cut(ref, "#")
+
+	if refDocument != "" {
+		documentURL, err := url.Parse(document)
+		if err != nil {
+			return ref
+		}
+
+		refURL, err := url.Parse(refDocument)
+		if err != nil {
+			return ref
+		}
+
+		document = documentURL.ResolveReference(refURL).String()
+	}
+
+	return document + "#" + fragment
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// escapePointer escapes the JSON pointer token
+func escapePointer(token string) string {
+	return pointerEscaper.Replace(token)
+}
+
+// PropertyNames returns the names of the properties defined by the schema and
+// its subschemas and the patterns of the pattern properties
+func (s *JSONSchema) PropertyNames() ([]string, []*regexp.Regexp) {
+	var names []string
+	var patterns []*regexp.Regexp
+
+	visited := make(map[*jsonschema.Schema]struct{})
+
+	var visit func(schema *jsonschema.Schema)
+	visit = func(schema *jsonschema.Schema) {
+		if schema == nil {
+			return
+		}
+		if _, ok := visited[schema]; ok {
+			return
+		}
+		visited[schema] = struct{}{}
+
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		for pattern := range schema.PatternProperties {
+			patterns = append(patterns, pattern)
+		}
+
+		for _, sub := range []*jsonschema.Schema{schema.Ref, schema.DynamicRef, schema.If, schema.Then, schema.Else} {
+			visit(sub)
+		}
+		for _, list := range [][]*jsonschema.Schema{schema.AllOf, schema.AnyOf, schema.OneOf} {
+			for _, sub := range list {
+				visit(sub)
+			}
+		}
+		for _, sub := range schema.DependentSchemas {
+			visit(sub)
+		}
+	}
+
+	visit(s.Schema)
+
+	return names, patterns
+}
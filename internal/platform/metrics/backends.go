@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"net"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/wallarm/api-firewall/internal/platform/proxy"
+)
+
+var (
+	backendHealthyDesc = prometheus.NewDesc(
+		"wallarm_apifw_backend_healthy",
+		"Health state of the backend: 1 if the backend is healthy and 0 otherwise",
+		[]string{"spec", "host", "backend"}, nil,
+	)
+
+	backendEjectedDesc = prometheus.NewDesc(
+		"wallarm_apifw_backend_ejected",
+		"Outlier detection state of the backend: 1 if the backend is ejected and 0 otherwise",
+		[]string{"spec", "host", "backend"}, nil,
+	)
+
+	backendsHealthyCountDesc = prometheus.NewDesc(
+		"wallarm_apifw_backends_healthy_count",
+		"Number of the healthy backends of the host",
+		[]string{"spec", "host"}, nil,
+	)
+
+	backendsCircuitOpenDesc = prometheus.NewDesc(
+		"wallarm_apifw_backends_circuit_open",
+		"Circuit breaker state of the host: 1 if the circuit is open and 0 otherwise",
+		[]string{"spec", "host"}, nil,
+	)
+)
+
+// BackendPool is implemented by the backend connections pools which provide
+// the health state of the backends
+type BackendPool interface {
+	Stats() proxy.PoolV2Stats
+}
+
+type backendPool struct {
+	spec string
+	pool BackendPool
+}
+
+// AddBackendPool adds the backend connections pool of the specification to the
+// exported metrics. It should be called before the metrics service is started
+func (p *PrometheusMetrics) AddBackendPool(spec string, pool BackendPool) {
+	if !p.enabled {
+		return
+	}
+
+	p.backendPools = append(p.backendPools, backendPool{spec: spec, pool: pool})
+}
+
+// backendCollector exports the state of the backends collected from the pools
+// on each scrape
+type backendCollector struct {
+	pools []backendPool
+}
+
+var _ prometheus.Collector = (*backendCollector)(nil)
+
+func (c *backendCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- backendHealthyDesc
+	ch <- backendEjectedDesc
+	ch <- backendsHealthyCountDesc
+	ch <- backendsCircuitOpenDesc
+}
+
+func (c *backendCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, bp := range c.pools {
+		stats := bp.pool.Stats()
+		if stats.IsClosed {
+			continue
+		}
+
+		host := net.JoinHostPort(stats.Host, stats.Port)
+
+		for _, backend := range stats.BackendsStatus {
+			ch <- prometheus.MustNewConstMetric(backendHealthyDesc, prometheus.GaugeValue, boolToFloat(backend.Healthy), bp.spec, host, backend.Address)
+			ch <- prometheus.MustNewConstMetric(backendEjectedDesc, prometheus.GaugeValue, boolToFloat(backend.Ejected), bp.spec, host, backend.Address)
+		}
+
+		ch <- prometheus.MustNewConstMetric(backendsHealthyCountDesc, prometheus.GaugeValue, float64(stats.HealthyCount), bp.spec, host)
+		ch <- prometheus.MustNewConstMetric(backendsCircuitOpenDesc, prometheus.GaugeValue, boolToFloat(stats.CircuitOpen), bp.spec, host)
+	}
+}
+
+func boolToFloat(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
@@ -2,10 +2,13 @@ package metrics
 
 import (
 	"time"
+
+	"github.com/valyala/fasthttp"
 )
 
 type Metrics interface {
 	IncErrorTypeCounter(err string, schemaID int)
 	IncHTTPRequestStat(start time.Time, schemaID int, statusCode int)
 	IncHTTPRequestTotalCountOnly(schemaID int, statusCode int)
+	IncRequestStat(ctx *fasthttp.RequestCtx, mode string, start time.Time)
 }
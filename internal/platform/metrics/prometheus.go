@@ -14,6 +14,8 @@ import (
 	"github.com/valyala/fasthttp/fasthttpadaptor"
 
 	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/router"
+	"github.com/wallarm/api-firewall/internal/platform/web"
 )
 
 const logMetricsPrefix = "Prometheus metrics"
@@ -53,8 +55,58 @@ var (
 		},
 		[]string{"schema_id"},
 	)
+
+	// Counter: Total number of requests processed in the PROXY and GraphQL modes
+	ProxyRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallarm_apifw_proxy_requests_total",
+			Help: "Total number of HTTP requests processed in the PROXY and GraphQL modes",
+		},
+		[]string{"mode", "spec", "operation", "outcome", "status_code"},
+	)
+
+	// Histogram: Duration of requests processed in the PROXY and GraphQL modes
+	ProxyRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "wallarm_apifw_proxy_request_duration_seconds",
+			Help:    "Duration of HTTP requests processed in the PROXY and GraphQL modes in seconds",
+			Buckets: []float64{.001, .005, .025, .05, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"mode", "spec", "operation", "outcome"},
+	)
+
+	// Counter: Request and response validation failures
+	ValidationFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallarm_apifw_validation_failures_total",
+			Help: "Total number of request and response validation failures",
+		},
+		[]string{"mode", "spec", "operation", "type", "action"},
+	)
+
+	// Counter: Blocked requests by reason
+	BlockedRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallarm_apifw_blocked_requests_total",
+			Help: "Total number of blocked requests by reason",
+		},
+		[]string{"mode", "spec", "reason"},
+	)
 )
 
+// Request outcomes
+const (
+	OutcomePassed        = "passed"
+	OutcomeBlocked       = "blocked"
+	OutcomeInvalid       = "invalid"
+	OutcomeRouteNotFound = "route_not_found"
+	OutcomeProxyFailed   = "proxy_failed"
+)
+
+// unknownOperation is the operation label of the requests which do not match
+// any route of the specification
+const unknownOperation = "none"
+
 type Options struct {
 	EndpointName string
 	Host         string
@@ -63,10 +115,11 @@ type Options struct {
 }
 
 type PrometheusMetrics struct {
-	logger      *zerolog.Logger
-	serviceOpts *Options
-	enabled     bool
-	registry    *prometheus.Registry
+	logger       *zerolog.Logger
+	serviceOpts  *Options
+	enabled      bool
+	registry     *prometheus.Registry
+	backendPools []backendPool
 }
 
 var _ Metrics = (*PrometheusMetrics)(nil)
@@ -120,7 +173,12 @@ func (p *PrometheusMetrics) StartService(logger *zerolog.Logger, options *Option
 func (p *PrometheusMetrics) initializeMetrics() error {
 	if p.registry == nil {
 		p.registry = prometheus.NewRegistry()
-		p.registry.MustRegister(TotalErrors, ErrorTypeCounter, HttpRequestsTotal, HttpRequestDuration)
+		p.registry.MustRegister(TotalErrors, ErrorTypeCounter, HttpRequestsTotal, HttpRequestDuration,
+			ProxyRequestsTotal, ProxyRequestDuration, ValidationFailuresTotal, BlockedRequestsTotal)
+
+		if len(p.backendPools) > 0 {
+			p.registry.MustRegister(&backendCollector{pools: p.backendPools})
+		}
 
 		return nil
 	}
@@ -153,3 +211,85 @@ func (p *PrometheusMetrics) IncHTTPRequestTotalCountOnly(schemaID int, statusCod
 
 	HttpRequestsTotal.WithLabelValues(strconv2.Itoa(schemaID), strconv2.Itoa(statusCode)).Inc()
 }
+
+// RequestHandler returns the handler which records the statistics of the
+// requests processed by the next handler
+func (p *PrometheusMetrics) RequestHandler(mode string, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	if !p.enabled {
+		return next
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		start := time.Now()
+
+		next(ctx)
+
+		p.IncRequestStat(ctx, mode, start)
+	}
+}
+
+// IncRequestStat records the statistics of the processed request. The request
+// outcome, validation failures and block reason are taken from the request
+// context values set by the handlers
+func (p *PrometheusMetrics) IncRequestStat(ctx *fasthttp.RequestCtx, mode string, start time.Time) {
+	if !p.enabled {
+		return
+	}
+
+	spec, _ := ctx.UserValue(web.RequestSpecName).(string)
+
+	operation := unknownOperation
+	if rctx, ok := ctx.UserValue(router.RouteCtxKey).(*router.Context); ok && rctx.RoutePattern() != "" {
+		operation = string(ctx.Method()) + " " + rctx.RoutePattern()
+	}
+
+	requestBlocked, _ := ctx.UserValue(web.RequestBlocked).(bool)
+	responseBlocked, _ := ctx.UserValue(web.ResponseBlocked).(bool)
+	requestInvalid, _ := ctx.UserValue(web.RequestValidationFailed).(bool)
+	responseInvalid, _ := ctx.UserValue(web.ResponseValidationFailed).(bool)
+	noRoute, _ := ctx.UserValue(web.RequestProxyNoRoute).(bool)
+	proxyFailed, _ := ctx.UserValue(web.RequestProxyFailed).(bool)
+
+	reason, _ := ctx.UserValue(web.RequestBlockReason).(string)
+	switch {
+	case reason != "":
+	case requestBlocked:
+		reason = web.BlockReasonRequestValidation
+	case responseBlocked:
+		reason = web.BlockReasonResponseValidation
+	}
+
+	if requestInvalid {
+		ValidationFailuresTotal.WithLabelValues(mode, spec, operation, "request", validationAction(requestBlocked)).Inc()
+	}
+
+	if responseInvalid {
+		ValidationFailuresTotal.WithLabelValues(mode, spec, operation, "response", validationAction(responseBlocked)).Inc()
+	}
+
+	var outcome string
+	switch {
+	case reason != "":
+		outcome = OutcomeBlocked
+		BlockedRequestsTotal.WithLabelValues(mode, spec, reason).Inc()
+	case noRoute:
+		outcome = OutcomeRouteNotFound
+	case proxyFailed:
+		outcome = OutcomeProxyFailed
+	case requestInvalid || responseInvalid:
+		outcome = OutcomeInvalid
+	default:
+		outcome = OutcomePassed
+	}
+
+	ProxyRequestDuration.WithLabelValues(mode, spec, operation, outcome).Observe(time.Since(start).Seconds())
+	ProxyRequestsTotal.WithLabelValues(mode, spec, operation, outcome, strconv2.Itoa(ctx.Response.StatusCode())).Inc()
+}
+
+// validationAction returns the label of the action applied to the invalid request or response
+func validationAction(blocked bool) string {
+	if blocked {
+		return "blocked"
+	}
+	return "logged"
+}
@@ -0,0 +1,247 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/platform/proxy"
+	"github.com/wallarm/api-firewall/internal/platform/router"
+	"github.com/wallarm/api-firewall/internal/platform/web"
+)
+
+type testBackendPool struct {
+	stats proxy.PoolV2Stats
+}
+
+func (p *testBackendPool) Stats() proxy.PoolV2Stats {
+	return p.stats
+}
+
+// gatherValue returns the value of the counter or gauge with the passed labels
+func gatherValue(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) float64 {
+	t.Helper()
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gathering metrics: %v", err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+	metricsLoop:
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if v, ok := labels[l.GetName()]; ok && v != l.GetValue() {
+					continue metricsLoop
+				}
+			}
+
+			switch {
+			case m.GetCounter() != nil:
+				return m.GetCounter().GetValue()
+			case m.GetGauge() != nil:
+				return m.GetGauge().GetValue()
+			case m.GetHistogram() != nil:
+				return float64(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+
+	return 0
+}
+
+func TestPrometheusMetrics_RequestHandler(t *testing.T) {
+
+	p := NewPrometheusMetrics(true)
+	if err := p.initializeMetrics(); err != nil {
+		t.Fatal(err)
+	}
+
+	const mode = "test_request_handler"
+
+	tests := []struct {
+		name            string
+		routePattern    string
+		userValues      map[string]any
+		statusCode      int
+		expectedOutcome string
+		expectedReason  string
+	}{
+		{
+			name:            "passed",
+			routePattern:    "/users/{id}",
+			statusCode:      fasthttp.StatusOK,
+			expectedOutcome: OutcomePassed,
+		},
+		{
+			name:            "request blocked by validation",
+			routePattern:    "/users/{id}",
+			userValues:      map[string]any{web.RequestBlocked: true, web.RequestValidationFailed: true},
+			statusCode:      fasthttp.StatusForbidden,
+			expectedOutcome: OutcomeBlocked,
+			expectedReason:  web.BlockReasonRequestValidation,
+		},
+		{
+			name:            "request blocked by denylist",
+			routePattern:    "/users/{id}",
+			userValues:      map[string]any{web.RequestBlockReason: web.BlockReasonDenylist},
+			statusCode:      fasthttp.StatusForbidden,
+			expectedOutcome: OutcomeBlocked,
+			expectedReason:  web.BlockReasonDenylist,
+		},
+		{
+			name:            "validation failure is logged",
+			routePattern:    "/users/{id}",
+			userValues:      map[string]any{web.ResponseValidationFailed: true},
+			statusCode:      fasthttp.StatusOK,
+			expectedOutcome: OutcomeInvalid,
+		},
+		{
+			name:            "route not found",
+			userValues:      map[string]any{web.RequestProxyNoRoute: true},
+			statusCode:      fasthttp.StatusForbidden,
+			expectedOutcome: OutcomeRouteNotFound,
+		},
+		{
+			name:            "proxy failed",
+			routePattern:    "/users/{id}",
+			userValues:      map[string]any{web.RequestProxyFailed: true},
+			statusCode:      fasthttp.StatusBadGateway,
+			expectedOutcome: OutcomeProxyFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			spec := strings.ReplaceAll(tt.name, " ", "_")
+
+			handler := p.RequestHandler(mode, func(ctx *fasthttp.RequestCtx) {
+				ctx.SetUserValue(web.RequestSpecName, spec)
+
+				if tt.routePattern != "" {
+					rctx := router.NewRouteContext()
+					rctx.RoutePatterns = []string{tt.routePattern}
+					ctx.SetUserValue(router.RouteCtxKey, rctx)
+				}
+
+				for k, v := range tt.userValues {
+					ctx.SetUserValue(k, v)
+				}
+
+				ctx.SetStatusCode(tt.statusCode)
+			})
+
+			var reqCtx fasthttp.RequestCtx
+			reqCtx.Request.Header.SetMethod(fasthttp.MethodGet)
+			reqCtx.Request.SetRequestURI("/users/1")
+
+			handler(&reqCtx)
+
+			operation := unknownOperation
+			if tt.routePattern != "" {
+				operation = fasthttp.MethodGet + " " + tt.routePattern
+			}
+
+			labels := map[string]string{"mode": mode, "spec": spec, "operation": operation, "outcome": tt.expectedOutcome}
+
+			if v := gatherValue(t, p.registry, "wallarm_apifw_proxy_requests_total", labels); v != 1 {
+				t.Errorf("expected 1 request with the %s outcome, got %v", tt.expectedOutcome, v)
+			}
+
+			if v := gatherValue(t, p.registry, "wallarm_apifw_proxy_request_duration_seconds", labels); v != 1 {
+				t.Errorf("expected 1 request duration observation, got %v", v)
+			}
+
+			if tt.expectedReason != "" {
+				if v := gatherValue(t, p.registry, "wallarm_apifw_blocked_requests_total", map[string]string{"mode": mode, "spec": spec, "reason": tt.expectedReason}); v != 1 {
+					t.Errorf("expected 1 request blocked by %s, got %v", tt.expectedReason, v)
+				}
+			}
+
+			if tt.expectedOutcome == OutcomeInvalid {
+				if v := gatherValue(t, p.registry, "wallarm_apifw_validation_failures_total", map[string]string{"mode": mode, "spec": spec, "type": "response", "action": "logged"}); v != 1 {
+					t.Errorf("expected 1 logged response validation failure, got %v", v)
+				}
+			}
+		})
+	}
+}
+
+func TestPrometheusMetrics_Disabled(t *testing.T) {
+
+	p := NewPrometheusMetrics(false)
+
+	called := false
+	next := func(ctx *fasthttp.RequestCtx) {
+		called = true
+	}
+
+	var reqCtx fasthttp.RequestCtx
+	p.RequestHandler(web.ProxyMode, next)(&reqCtx)
+
+	if !called {
+		t.Errorf("the next handler should be called")
+	}
+
+	p.AddBackendPool("default", &testBackendPool{})
+	if len(p.backendPools) != 0 {
+		t.Errorf("backend pools should not be added if the metrics are disabled")
+	}
+}
+
+func TestPrometheusMetrics_Backends(t *testing.T) {
+
+	p := NewPrometheusMetrics(true)
+
+	pool := &testBackendPool{
+		stats: proxy.PoolV2Stats{
+			Host: "backend",
+			Port: "80",
+			BackendsStatus: []proxy.BackendStatus{
+				{Address: "10.0.0.5:80", Healthy: true},
+				{Address: "10.0.0.6:80", Healthy: false, Ejected: true},
+			},
+			HealthyCount: 1,
+			CircuitOpen:  true,
+		},
+	}
+
+	p.AddBackendPool("users", pool)
+
+	if err := p.initializeMetrics(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		expected float64
+	}{
+		{"wallarm_apifw_backend_healthy", map[string]string{"spec": "users", "host": "backend:80", "backend": "10.0.0.5:80"}, 1},
+		{"wallarm_apifw_backend_healthy", map[string]string{"spec": "users", "host": "backend:80", "backend": "10.0.0.6:80"}, 0},
+		{"wallarm_apifw_backend_ejected", map[string]string{"spec": "users", "host": "backend:80", "backend": "10.0.0.6:80"}, 1},
+		{"wallarm_apifw_backends_healthy_count", map[string]string{"spec": "users", "host": "backend:80"}, 1},
+		{"wallarm_apifw_backends_circuit_open", map[string]string{"spec": "users", "host": "backend:80"}, 1},
+	}
+
+	for _, tt := range tests {
+		if v := gatherValue(t, p.registry, tt.name, tt.labels); v != tt.expected {
+			t.Errorf("%s%v: expected %v, got %v", tt.name, tt.labels, tt.expected, v)
+		}
+	}
+
+	// the state is collected on each scrape
+	pool.stats.BackendsStatus[1].Healthy = true
+	pool.stats.HealthyCount = 2
+
+	if v := gatherValue(t, p.registry, "wallarm_apifw_backends_healthy_count", map[string]string{"spec": "users"}); v != 2 {
+		t.Errorf("expected 2 healthy backends, got %v", v)
+	}
+}
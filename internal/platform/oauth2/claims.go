@@ -0,0 +1,105 @@
+package oauth2
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+// defaultScopeClaim is the claim with the space-separated scopes by RFC 8693
+const defaultScopeClaim = "scope"
+
+// claimValue returns the value of the claim by the name or by the
+// dot-separated path of the nested claim. The claim with the dots in the name
+// is found before the nested claims
+func claimValue(claims map[string]any, path string) (any, bool) {
+	if value, ok := claims[path]; ok {
+		return value, true
+	}
+
+	name, rest, found := strings.Cut(path, ".")
+	if !found {
+		return nil, false
+	}
+
+	nested, ok := claims[name].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+
+	return claimValue(nested, rest)
+}
+
+// claimStrings returns the string values of the claim. The string claim is
+// split by spaces if the split is set and the array claim returns its string
+// elements
+func claimStrings(value any, split bool) []string {
+	switch v := value.(type) {
+	case string:
+		if split {
+			return strings.Fields(v)
+		}
+		return []string{v}
+	case []any:
+		var result []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+
+	return nil
+}
+
+// validateClaims method checks the issuer, the audience and the required
+// claims of the token
+func (j *JWT) validateClaims(claims jwt.MapClaims) error {
+	if issuers := j.Cfg.JWT.Issuers; len(issuers) > 0 {
+		issuer, _ := claims.GetIssuer()
+		if !slices.Contains(issuers, issuer) {
+			return fmt.Errorf("token issuer %q is not accepted", issuer)
+		}
+	}
+
+	if audiences := j.Cfg.JWT.Audiences; len(audiences) > 0 {
+		audience, _ := claims.GetAudience()
+		if !slices.ContainsFunc(audience, func(aud string) bool { return slices.Contains(audiences, aud) }) {
+			return errors.New("token audience is not accepted")
+		}
+	}
+
+	for _, rule := range j.Cfg.JWT.RequiredClaims {
+		value, found := claimValue(claims, rule.Claim)
+		if !found || value == nil {
+			return fmt.Errorf("token doesn't contain the required claim %s", rule.Claim)
+		}
+
+		if rule.Value != "" && !slices.Contains(claimStrings(value, false), rule.Value) {
+			return fmt.Errorf("token claim %s doesn't match the required value", rule.Claim)
+		}
+	}
+
+	return nil
+}
+
+// scopes method returns the scopes of the token from all scope claims
+func (j *JWT) scopes(claims jwt.MapClaims) []string {
+	scopeClaims := j.Cfg.JWT.ScopeClaims
+	if len(scopeClaims) == 0 {
+		scopeClaims = []string{defaultScopeClaim}
+	}
+
+	var result []string
+	for _, name := range scopeClaims {
+		if value, found := claimValue(claims, strings.TrimSpace(name)); found {
+			result = append(result, claimStrings(value, true)...)
+		}
+	}
+
+	return result
+}
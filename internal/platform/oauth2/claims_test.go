@@ -0,0 +1,114 @@
+package oauth2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+func TestJWTClaimsPolicy(t *testing.T) {
+	secret := []byte("secret")
+
+	cfg := config.Oauth{
+		ValidationType: "JWT",
+		JWT: config.JWT{
+			SignatureAlgorithm: "HS256",
+			Issuers:            []string{"https://idp.example.com"},
+			Audiences:          []string{"api", "api-admin"},
+			Leeway:             time.Minute,
+			RequiredClaims:     config.ClaimRuleList{{Claim: "sub"}, {Claim: "tenant.id", Value: "acme"}},
+			ScopeClaims:        []string{"scope", "scp", "realm_access.roles"},
+		},
+	}
+
+	validator := &JWT{
+		Cfg:        &cfg,
+		Logger:     zerolog.Nop(),
+		Algorithms: []string{"HS256"},
+		SecretKey:  secret,
+	}
+
+	now := time.Now()
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":          "https://idp.example.com",
+			"aud":          []string{"other", "api"},
+			"sub":          "user",
+			"exp":          now.Add(time.Hour).Unix(),
+			"tenant":       map[string]any{"id": "acme"},
+			"scope":        "read",
+			"scp":          []string{"write"},
+			"realm_access": map[string]any{"roles": []string{"admin"}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		update func(claims jwt.MapClaims)
+		scopes []string
+		valid  bool
+	}{
+		{"valid", func(jwt.MapClaims) {}, []string{"read", "write", "admin"}, true},
+		{"string audience", func(c jwt.MapClaims) { c["aud"] = "api-admin" }, nil, true},
+		{"expired within leeway", func(c jwt.MapClaims) { c["exp"] = now.Add(-30 * time.Second).Unix() }, nil, true},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = now.Add(-2 * time.Minute).Unix() }, nil, false},
+		{"not before", func(c jwt.MapClaims) { c["nbf"] = now.Add(2 * time.Minute).Unix() }, nil, false},
+		{"issued in the future", func(c jwt.MapClaims) { c["iat"] = now.Add(2 * time.Minute).Unix() }, nil, false},
+		{"issued within leeway", func(c jwt.MapClaims) { c["iat"] = now.Add(30 * time.Second).Unix() }, nil, true},
+		{"other issuer", func(c jwt.MapClaims) { c["iss"] = "https://other.example.com" }, nil, false},
+		{"missing issuer", func(c jwt.MapClaims) { delete(c, "iss") }, nil, false},
+		{"other audience", func(c jwt.MapClaims) { c["aud"] = "other" }, nil, false},
+		{"missing required claim", func(c jwt.MapClaims) { delete(c, "sub") }, nil, false},
+		{"required claim value", func(c jwt.MapClaims) { c["tenant"] = map[string]any{"id": "other"} }, nil, false},
+		{"array required claim value", func(c jwt.MapClaims) { c["tenant"] = map[string]any{"id": []string{"other", "acme"}} }, nil, true},
+		{"missing scope", func(jwt.MapClaims) {}, []string{"delete"}, false},
+		{"missing nested scope", func(c jwt.MapClaims) { delete(c, "realm_access") }, []string{"admin"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.update(claims)
+
+			tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = validator.Validate(context.Background(), "Bearer "+tokenString, tt.scopes)
+			if tt.valid && err != nil {
+				t.Errorf("expected valid token, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("expected invalid token")
+			}
+		})
+	}
+}
+
+func TestClaimValue(t *testing.T) {
+	claims := map[string]any{
+		"realm_access":     map[string]any{"roles": []any{"admin", 1}},
+		"https://ns/roles": "user",
+	}
+
+	value, found := claimValue(claims, "realm_access.roles")
+	if !found {
+		t.Fatal("expected nested claim")
+	}
+	if roles := claimStrings(value, true); len(roles) != 1 || roles[0] != "admin" {
+		t.Errorf("unexpected roles %v", roles)
+	}
+
+	if _, found := claimValue(claims, "https://ns/roles"); !found {
+		t.Errorf("expected claim with dots in the name")
+	}
+	if _, found := claimValue(claims, "realm_access.groups"); found {
+		t.Errorf("unexpected claim")
+	}
+}
@@ -2,10 +2,13 @@ package oauth2
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/karlseguin/ccache/v2"
 	"github.com/pkg/errors"
@@ -14,19 +17,48 @@ import (
 	"github.com/wallarm/api-firewall/internal/config"
 )
 
+// Client authentication methods of the requests to the introspection endpoint
+const (
+	ClientAuthBearer      = "bearer"
+	ClientAuthSecretBasic = "client_secret_basic"
+	ClientAuthSecretPost  = "client_secret_post"
+)
+
 type Introspection struct {
 	Cfg    *config.Oauth
 	Logger zerolog.Logger
 	Cache  *ccache.Cache
 }
 
-func (i *Introspection) Validate(ctx context.Context, tokenWithBearer string, scopes []string) error {
-
-	// openapi doesn't contain scopes in endpoint configuration
-	if len(scopes) == 0 {
+// ValidateClientAuth checks the client authentication settings of the
+// introspection endpoint. The client credentials are sent in the request body
+// by the client_secret_post method which requires the POST requests
+func ValidateClientAuth(cfg *config.Introspection) error {
+	switch strings.ToLower(cfg.ClientAuthMethod) {
+	case "", ClientAuthBearer:
 		return nil
+	case ClientAuthSecretBasic:
+	case ClientAuthSecretPost:
+		if !strings.EqualFold(cfg.EndpointMethod, fasthttp.MethodPost) {
+			return fmt.Errorf("client authentication method %s requires the POST introspection requests", ClientAuthSecretPost)
+		}
+	default:
+		return fmt.Errorf("unsupported client authentication method %q", cfg.ClientAuthMethod)
+	}
+
+	if cfg.ClientAuthID == "" {
+		return fmt.Errorf("client ID is required by the client authentication method %s", cfg.ClientAuthMethod)
+	}
+
+	if cfg.ClientAuthSecret == "" {
+		return fmt.Errorf("client secret is required by the client authentication method %s", cfg.ClientAuthMethod)
 	}
 
+	return nil
+}
+
+func (i *Introspection) Validate(ctx context.Context, tokenWithBearer string, scopes []string) error {
+
 	tokenString := strings.TrimPrefix(tokenWithBearer, "Bearer ")
 
 	if tokenString == "" {
@@ -34,28 +66,49 @@ func (i *Introspection) Validate(ctx context.Context, tokenWithBearer string, sc
 	}
 
 	var meta map[string]any
-	var err error
 
 	metaCached := i.Cache.Get(tokenString)
-	switch metaCached {
-	case nil:
+	switch {
+	case metaCached == nil || metaCached.Expired():
+		var err error
 		meta, err = i.getTokenMetaInfo(tokenString)
 		if err != nil {
 			return err
 		}
+
+		// the inactive and invalid tokens are cached briefly to protect the
+		// introspection endpoint
+		ttl := i.Cfg.Introspection.InactiveRefreshInterval
+		if i.validateMeta(meta) == nil {
+			ttl = i.Cfg.Introspection.RefreshInterval
+			if exp, ok := numericDate(meta, "exp"); ok {
+				ttl = min(ttl, time.Until(exp))
+			}
+		}
+
+		if ttl > 0 {
+			i.Cache.Set(tokenString, meta, ttl)
+		}
 	default:
 		meta = metaCached.Value().(map[string]any)
 	}
 
+	if err := i.validateMeta(meta); err != nil {
+		return err
+	}
+
+	// openapi doesn't contain scopes in endpoint configuration
+	if len(scopes) == 0 {
+		return nil
+	}
+
 	scopeString, ok := meta["scope"].(string)
-	if !ok && len(scopes) > 0 {
+	if !ok {
 		return errors.New("scope field not found in OAuth provider response")
 	}
 
 	scopesInToken := strings.Split(scopeString, " ")
 
-	i.Cache.Set(tokenString, meta, i.Cfg.Introspection.RefreshInterval)
-
 	for _, scope := range scopes {
 		scopeFound := false
 		for _, scopeInToken := range scopesInToken {
@@ -72,35 +125,97 @@ func (i *Introspection) Validate(ctx context.Context, tokenWithBearer string, sc
 	return nil
 }
 
+// validateMeta method checks the state, the validity period, the audience and
+// the client of the token in the introspection response
+func (i *Introspection) validateMeta(meta map[string]any) error {
+	if active, _ := meta["active"].(bool); !active {
+		return errors.New("oauth token is not active")
+	}
+
+	now := time.Now()
+
+	if exp, ok := numericDate(meta, "exp"); ok && !now.Before(exp) {
+		return errors.New("oauth token is expired")
+	}
+
+	if nbf, ok := numericDate(meta, "nbf"); ok && now.Before(nbf) {
+		return errors.New("oauth token is not valid yet")
+	}
+
+	if audiences := i.Cfg.Introspection.Audiences; len(audiences) > 0 {
+		audience := claimStrings(meta["aud"], false)
+		if !slices.ContainsFunc(audience, func(aud string) bool { return slices.Contains(audiences, aud) }) {
+			return errors.New("oauth token audience is not accepted")
+		}
+	}
+
+	if clientIDs := i.Cfg.Introspection.ClientIDs; len(clientIDs) > 0 {
+		clientID, _ := meta["client_id"].(string)
+		if !slices.Contains(clientIDs, clientID) {
+			return fmt.Errorf("oauth token client %q is not accepted", clientID)
+		}
+	}
+
+	return nil
+}
+
+// numericDate returns the time of the NumericDate field of the introspection
+// response
+func numericDate(meta map[string]any, name string) (time.Time, bool) {
+	value, ok := meta[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, int64(value*float64(time.Second))), true
+}
+
 func (i *Introspection) getTokenMetaInfo(token string) (map[string]any, error) {
 
 	req := fasthttp.AcquireRequest()
+	res := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(res)
+
 	req.Header.SetMethod(i.Cfg.Introspection.EndpointMethod)
 
 	parsedEndpointURL, err := url.Parse(i.Cfg.Introspection.Endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse introspection endpoint url: %v", err)
 	}
+
+	params := url.Values{}
+	if i.Cfg.Introspection.TokenParamName != "" {
+		params.Set(i.Cfg.Introspection.TokenParamName, token)
+	}
+
+	authMethod := strings.ToLower(i.Cfg.Introspection.ClientAuthMethod)
+
 	switch strings.ToLower(i.Cfg.Introspection.EndpointMethod) {
 	case "post":
-		if i.Cfg.Introspection.TokenParamName != "" {
-			req.SetBodyString(fmt.Sprintf("%s=%s", i.Cfg.Introspection.TokenParamName, token))
-			if i.Cfg.Introspection.EndpointParams != "" {
-				req.AppendBodyString(fmt.Sprintf("&%s", i.Cfg.Introspection.EndpointParams))
-			}
-		} else {
-			if i.Cfg.Introspection.EndpointParams != "" {
-				req.SetBodyString(i.Cfg.Introspection.EndpointParams)
+		if authMethod == ClientAuthSecretPost {
+			params.Set("client_id", i.Cfg.Introspection.ClientAuthID)
+			params.Set("client_secret", i.Cfg.Introspection.ClientAuthSecret)
+		}
+
+		body := params.Encode()
+		if i.Cfg.Introspection.EndpointParams != "" {
+			if body != "" {
+				body += "&"
 			}
+			body += i.Cfg.Introspection.EndpointParams
 		}
+		req.SetBodyString(body)
 	case "get":
 		if i.Cfg.Introspection.EndpointParams != "" {
 			parsedEndpointURL.RawQuery = i.Cfg.Introspection.EndpointParams
 		}
 
-		if i.Cfg.Introspection.TokenParamName != "" {
+		if len(params) > 0 {
 			reqQuery := parsedEndpointURL.Query()
-			reqQuery.Add(i.Cfg.Introspection.TokenParamName, token)
+			for name, values := range params {
+				reqQuery[name] = append(reqQuery[name], values...)
+			}
 			parsedEndpointURL.RawQuery = reqQuery.Encode()
 		}
 
@@ -109,31 +224,44 @@ func (i *Introspection) getTokenMetaInfo(token string) (map[string]any, error) {
 	t := parsedEndpointURL.String()
 	req.SetRequestURI(t)
 
-	if i.Cfg.Introspection.ClientAuthBearerToken == "" {
-		req.Header.Set("Authorization", "Bearer "+token)
-	} else {
-		req.Header.Set("Authorization", "Bearer "+i.Cfg.Introspection.ClientAuthBearerToken)
+	switch authMethod {
+	case ClientAuthSecretBasic:
+		// the client credentials are form-urlencoded by RFC 6749 section 2.3.1
+		credentials := url.QueryEscape(i.Cfg.Introspection.ClientAuthID) + ":" + url.QueryEscape(i.Cfg.Introspection.ClientAuthSecret)
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	case ClientAuthSecretPost:
+	default:
+		if i.Cfg.Introspection.ClientAuthBearerToken == "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+i.Cfg.Introspection.ClientAuthBearerToken)
+		}
 	}
 
-	// use default Content-Type in case of it's not set in configuration
-	if i.Cfg.Introspection.ContentType != "" {
+	// the parameters of the POST requests are form-encoded by RFC 7662 if
+	// Content-Type is not set in configuration
+	switch {
+	case i.Cfg.Introspection.ContentType != "":
 		req.Header.SetContentType(i.Cfg.Introspection.ContentType)
+	case strings.EqualFold(i.Cfg.Introspection.EndpointMethod, fasthttp.MethodPost):
+		req.Header.SetContentType("application/x-www-form-urlencoded")
 	}
+	req.Header.Set(fasthttp.HeaderAccept, "application/json")
 
-	res := fasthttp.AcquireResponse()
 	if err := fasthttp.Do(req, res); err != nil {
 		return nil, fmt.Errorf("failed to send introspection request: %v", err)
 	}
-	fasthttp.ReleaseRequest(req)
 
 	body := res.Body()
 
+	if res.StatusCode() != fasthttp.StatusOK {
+		return nil, fmt.Errorf("introspection endpoint response status code %d", res.StatusCode())
+	}
+
 	var tokenStatus map[string]any
 	if err := json.Unmarshal(body, &tokenStatus); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal extension properties: %v (%s)", err, body)
 	}
 
-	fasthttp.ReleaseResponse(res)
-
 	return tokenStatus, nil
 }
@@ -0,0 +1,275 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/karlseguin/ccache/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+// introspectionEndpoint is the test introspection endpoint which returns the
+// responses of the tokens
+type introspectionEndpoint struct {
+	server   *httptest.Server
+	requests atomic.Int32
+
+	lock      sync.Mutex
+	responses map[string]map[string]any
+	request   *http.Request
+	form      map[string]string
+}
+
+func newIntrospectionEndpoint(t *testing.T) *introspectionEndpoint {
+	t.Helper()
+
+	e := &introspectionEndpoint{responses: make(map[string]map[string]any)}
+	e.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.requests.Add(1)
+
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		e.lock.Lock()
+		defer e.lock.Unlock()
+
+		e.request = r
+		e.form = map[string]string{}
+		for name := range r.PostForm {
+			e.form[name] = r.PostForm.Get(name)
+		}
+
+		response, ok := e.responses[r.Form.Get("token")]
+		if !ok {
+			response = map[string]any{"active": false}
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(e.server.Close)
+
+	return e
+}
+
+func (e *introspectionEndpoint) set(token string, response map[string]any) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.responses[token] = response
+}
+
+func newIntrospection(endpoint *introspectionEndpoint, update func(cfg *config.Introspection)) *Introspection {
+	cfg := config.Oauth{
+		ValidationType: "INTROSPECTION",
+		Introspection: config.Introspection{
+			Endpoint:                endpoint.server.URL,
+			EndpointMethod:          "POST",
+			TokenParamName:          "token",
+			RefreshInterval:         time.Hour,
+			InactiveRefreshInterval: time.Hour,
+		},
+	}
+	if update != nil {
+		update(&cfg.Introspection)
+	}
+
+	return &Introspection{
+		Cfg:    &cfg,
+		Logger: zerolog.Nop(),
+		Cache:  ccache.New(ccache.Configure()),
+	}
+}
+
+func TestIntrospectionClaims(t *testing.T) {
+	endpoint := newIntrospectionEndpoint(t)
+
+	validResponse := func() map[string]any {
+		return map[string]any{
+			"active":    true,
+			"scope":     "read write",
+			"client_id": "client1",
+			"aud":       []string{"other", "api"},
+			"exp":       time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	introspection := newIntrospection(endpoint, func(cfg *config.Introspection) {
+		cfg.Audiences = []string{"api"}
+		cfg.ClientIDs = []string{"client1", "client2"}
+	})
+
+	tests := []struct {
+		name   string
+		update func(response map[string]any)
+		scopes []string
+		valid  bool
+	}{
+		{"valid", func(map[string]any) {}, []string{"read"}, true},
+		{"valid without scopes", func(map[string]any) {}, nil, true},
+		{"inactive", func(r map[string]any) { r["active"] = false }, nil, false},
+		{"active is missing", func(r map[string]any) { delete(r, "active") }, nil, false},
+		{"expired", func(r map[string]any) { r["exp"] = time.Now().Add(-time.Minute).Unix() }, nil, false},
+		{"not valid yet", func(r map[string]any) { r["nbf"] = time.Now().Add(time.Minute).Unix() }, nil, false},
+		{"string audience", func(r map[string]any) { r["aud"] = "api" }, nil, true},
+		{"other audience", func(r map[string]any) { r["aud"] = "other" }, nil, false},
+		{"other client", func(r map[string]any) { r["client_id"] = "client3" }, nil, false},
+		{"missing scope", func(map[string]any) {}, []string{"delete"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := validResponse()
+			tt.update(response)
+			endpoint.set(tt.name, response)
+
+			err := introspection.Validate(context.Background(), "Bearer "+tt.name, tt.scopes)
+			if tt.valid && err != nil {
+				t.Errorf("expected valid token, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("expected invalid token")
+			}
+		})
+	}
+}
+
+func TestIntrospectionCache(t *testing.T) {
+	endpoint := newIntrospectionEndpoint(t)
+
+	introspection := newIntrospection(endpoint, func(cfg *config.Introspection) {
+		cfg.InactiveRefreshInterval = 100 * time.Millisecond
+	})
+	ctx := context.Background()
+
+	// the inactive token is cached for the inactive refresh interval
+	for range 3 {
+		if err := introspection.Validate(ctx, "Bearer token1", nil); err == nil {
+			t.Fatal("expected inactive token")
+		}
+	}
+	if endpoint.requests.Load() != 1 {
+		t.Errorf("expected 1 introspection request, got %d", endpoint.requests.Load())
+	}
+
+	exp := time.Now().Add(2 * time.Second).Unix()
+	endpoint.set("token1", map[string]any{"active": true, "exp": exp})
+	time.Sleep(200 * time.Millisecond)
+
+	if err := introspection.Validate(ctx, "Bearer token1", nil); err != nil {
+		t.Fatalf("expected active token, got %v", err)
+	}
+	if err := introspection.Validate(ctx, "Bearer token1", nil); err != nil {
+		t.Fatalf("expected cached active token, got %v", err)
+	}
+	if endpoint.requests.Load() != 2 {
+		t.Errorf("expected 2 introspection requests, got %d", endpoint.requests.Load())
+	}
+
+	// the active token is cached until the expiry
+	time.Sleep(time.Until(time.Unix(exp, 0)))
+
+	if err := introspection.Validate(ctx, "Bearer token1", nil); err == nil {
+		t.Errorf("expected expired token")
+	}
+	if endpoint.requests.Load() != 3 {
+		t.Errorf("expected 3 introspection requests, got %d", endpoint.requests.Load())
+	}
+}
+
+func TestIntrospectionClientAuth(t *testing.T) {
+	endpoint := newIntrospectionEndpoint(t)
+	endpoint.set("token1", map[string]any{"active": true})
+
+	tests := []struct {
+		name     string
+		update   func(cfg *config.Introspection)
+		check    func(r *http.Request, form map[string]string) bool
+		expected string
+	}{
+		{
+			name:   "bearer",
+			update: func(cfg *config.Introspection) { cfg.ClientAuthBearerToken = "bearer-token" },
+			check: func(r *http.Request, form map[string]string) bool {
+				return r.Header.Get("Authorization") == "Bearer bearer-token"
+			},
+		},
+		{
+			name: "client_secret_basic",
+			update: func(cfg *config.Introspection) {
+				cfg.ClientAuthMethod = "client_secret_basic"
+				cfg.ClientAuthID = "client:1"
+				cfg.ClientAuthSecret = "secret 1"
+			},
+			check: func(r *http.Request, form map[string]string) bool {
+				id, secret, ok := r.BasicAuth()
+				return ok && id == "client%3A1" && secret == "secret+1" && form["client_secret"] == ""
+			},
+		},
+		{
+			name: "client_secret_post",
+			update: func(cfg *config.Introspection) {
+				cfg.ClientAuthMethod = "CLIENT_SECRET_POST"
+				cfg.ClientAuthID = "client1"
+				cfg.ClientAuthSecret = "secret1"
+				cfg.EndpointParams = "token_type_hint=access_token"
+			},
+			check: func(r *http.Request, form map[string]string) bool {
+				return r.Header.Get("Authorization") == "" &&
+					r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" &&
+					form["client_id"] == "client1" && form["client_secret"] == "secret1" &&
+					form["token"] == "token1" && form["token_type_hint"] == "access_token"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			introspection := newIntrospection(endpoint, tt.update)
+
+			if err := introspection.Validate(context.Background(), "Bearer token1", nil); err != nil {
+				t.Fatalf("expected valid token, got %v", err)
+			}
+
+			endpoint.lock.Lock()
+			defer endpoint.lock.Unlock()
+
+			if !tt.check(endpoint.request, endpoint.form) {
+				t.Errorf("unexpected introspection request: %v %v", endpoint.request.Header, endpoint.form)
+			}
+		})
+	}
+}
+
+func TestValidateClientAuth(t *testing.T) {
+	for _, cfg := range []config.Introspection{
+		{},
+		{ClientAuthMethod: "bearer"},
+		{ClientAuthMethod: "client_secret_basic", ClientAuthID: "client1", ClientAuthSecret: "secret1", EndpointMethod: "GET"},
+		{ClientAuthMethod: "client_secret_post", ClientAuthID: "client1", ClientAuthSecret: "secret1", EndpointMethod: "POST"},
+	} {
+		if err := ValidateClientAuth(&cfg); err != nil {
+			t.Errorf("unexpected error of %+v: %v", cfg, err)
+		}
+	}
+
+	for _, cfg := range []config.Introspection{
+		{ClientAuthMethod: "private_key_jwt"},
+		{ClientAuthMethod: "client_secret_basic"},
+		{ClientAuthMethod: "client_secret_post", ClientAuthID: "client1", ClientAuthSecret: "secret1", EndpointMethod: "GET"},
+		{ClientAuthMethod: "client_secret_basic", ClientAuthID: "client1"},
+		{ClientAuthMethod: "client_secret_post", ClientAuthID: "client1", EndpointMethod: "POST"},
+	} {
+		if err := ValidateClientAuth(&cfg); err == nil {
+			t.Errorf("expected error of %+v", cfg)
+		}
+	}
+}
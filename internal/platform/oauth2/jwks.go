@@ -0,0 +1,319 @@
+package oauth2
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+var ErrKeyNotFound = errors.New("key not found in JWKS")
+
+// jwk is the JSON Web Key by RFC 7517
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// RSA public key
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC and OKP public keys
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwksKey is the public key of the key set with the algorithm of the JWK
+type jwksKey struct {
+	key any
+	alg string
+}
+
+// jwks is the JSON Web Key Set by RFC 7517
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// KeySet holds the public keys of the JWKS source which is the local file or
+// the URL. The keys are fetched on the first use and refreshed after the
+// refresh interval. The unknown key ID causes the refetch of the keys which
+// is done not more often than the min refetch interval
+type KeySet struct {
+	cfg    *config.JWKS
+	logger zerolog.Logger
+	client *fasthttp.Client
+
+	lock      sync.RWMutex
+	keys      map[string]jwksKey
+	fetchedAt time.Time
+
+	// fetchLock serializes the fetches of the keys
+	fetchLock sync.Mutex
+}
+
+// NewKeySet creates the key set of the JWKS source. The keys are not fetched
+// until they are used
+func NewKeySet(cfg *config.JWKS, logger zerolog.Logger) *KeySet {
+	return &KeySet{
+		cfg:    cfg,
+		logger: logger,
+		client: &fasthttp.Client{NoDefaultUserAgentHeader: true},
+	}
+}
+
+// Key returns the public key by the key ID to verify the signature of the
+// algorithm. The only key of the set is returned if the key ID is empty. The
+// key is not returned if the JWK algorithm differs from the algorithm
+func (k *KeySet) Key(kid, alg string) (any, error) {
+	key, err := k.key(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.alg != "" && key.alg != alg {
+		return nil, fmt.Errorf("key %q is not used for %s algorithm", kid, alg)
+	}
+
+	return key.key, nil
+}
+
+func (k *KeySet) key(kid string) (jwksKey, error) {
+	k.lock.RLock()
+	key, found := k.find(kid)
+	stale := k.fetchedAt.IsZero() || time.Since(k.fetchedAt) >= k.cfg.RefreshInterval
+	fetchedAt := k.fetchedAt
+	k.lock.RUnlock()
+
+	if found && !stale {
+		return key, nil
+	}
+
+	// the unknown key is refetched not more often than the min refetch interval
+	if !stale && time.Since(fetchedAt) < k.cfg.MinRefetchInterval {
+		return jwksKey{}, errors.Wrapf(ErrKeyNotFound, "kid %q", kid)
+	}
+
+	if err := k.refresh(fetchedAt); err != nil {
+		k.logger.Error().Err(err).Str("source", k.cfg.Source).Msg("OAuth2: JWKS fetching error")
+
+		// the cached keys are used until the source is available
+		if found {
+			return key, nil
+		}
+		return jwksKey{}, err
+	}
+
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+
+	if key, found = k.find(kid); !found {
+		return jwksKey{}, errors.Wrapf(ErrKeyNotFound, "kid %q", kid)
+	}
+
+	return key, nil
+}
+
+// find method returns the key by the key ID. The lock should be held by the caller
+func (k *KeySet) find(kid string) (jwksKey, bool) {
+	if kid == "" {
+		if len(k.keys) != 1 {
+			return jwksKey{}, false
+		}
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// refresh method fetches the keys if they have not been fetched by another
+// request after the fetchedAt time
+func (k *KeySet) refresh(fetchedAt time.Time) error {
+	k.fetchLock.Lock()
+	defer k.fetchLock.Unlock()
+
+	k.lock.RLock()
+	refreshed := k.fetchedAt.After(fetchedAt)
+	k.lock.RUnlock()
+
+	if refreshed {
+		return nil
+	}
+
+	keys, err := k.fetch()
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	// the failed fetch is not retried until the min refetch interval is passed
+	k.fetchedAt = time.Now()
+	if err != nil {
+		return err
+	}
+
+	k.keys = keys
+
+	k.logger.Debug().Str("source", k.cfg.Source).Int("keys", len(keys)).Msg("OAuth2: JWKS successfully loaded")
+
+	return nil
+}
+
+// fetch method reads the JWKS source and parses the signature keys
+func (k *KeySet) fetch() (map[string]jwksKey, error) {
+	data, err := readSource(k.client, k.cfg.Source, k.cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseJWKS(data)
+}
+
+// readSource reads the local file or the http(s) URL
+func readSource(client *fasthttp.Client, source string, timeout time.Duration) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(source)
+	req.Header.SetMethod(fasthttp.MethodGet)
+	req.Header.Set(fasthttp.HeaderAccept, "application/json")
+
+	if err := client.DoTimeout(req, resp, timeout); err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, fmt.Errorf("response status code %d of %s", resp.StatusCode(), source)
+	}
+
+	return append([]byte(nil), resp.Body()...), nil
+}
+
+// parseJWKS parses the JWKS document. The keys which are not used for the
+// signatures and the keys of the unsupported types are skipped
+func parseJWKS(data []byte) (map[string]jwksKey, error) {
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("JWKS parsing error: %w", err)
+	}
+
+	keys := make(map[string]jwksKey, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		publicKey, err := key.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %q: %w", key.Kid, err)
+		}
+		if publicKey == nil {
+			continue
+		}
+
+		keys[key.Kid] = jwksKey{key: publicKey, alg: key.Alg}
+	}
+
+	return keys, nil
+}
+
+// publicKey method returns the public key of the JWK. Nil is returned if the
+// key type is not supported
+func (j *jwk) publicKey() (any, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		var ecdhCurve ecdh.Curve
+		switch j.Crv {
+		case "P-256":
+			curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, nil
+		}
+
+		x, errX := base64.RawURLEncoding.DecodeString(j.X)
+		y, errY := base64.RawURLEncoding.DecodeString(j.Y)
+		size := (curve.Params().BitSize + 7) / 8
+		if errX != nil || errY != nil || len(x) != size || len(y) != size {
+			return nil, errors.New("invalid coordinates")
+		}
+
+		// the point should be on the curve
+		if _, err := ecdhCurve.NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, fmt.Errorf("invalid point: %w", err)
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, nil
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid public key")
+		}
+
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, nil
+}
+
+// decodeBigInt decodes the base64url-encoded big-endian integer
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("empty value")
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
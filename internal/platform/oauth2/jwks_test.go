@@ -0,0 +1,172 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+func rsaJWK(t *testing.T, kid string, key *rsa.PrivateKey) map[string]string {
+	t.Helper()
+
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func signRS256(t *testing.T, kid string, key *rsa.PrivateKey) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"scope": "read write",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tokenString
+}
+
+func TestJWTKeySetRotation(t *testing.T) {
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lock sync.Mutex
+	var fetches atomic.Int32
+	keys := []map[string]string{rsaJWK(t, "key1", key1)}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	}))
+	defer server.Close()
+
+	cfg := config.Oauth{
+		ValidationType: "JWT",
+		JWT: config.JWT{
+			SignatureAlgorithm: "RS256",
+			JWKS: config.JWKS{
+				Source:             server.URL,
+				RefreshInterval:    time.Hour,
+				MinRefetchInterval: 0,
+				Timeout:            time.Second,
+			},
+		},
+	}
+
+	validator := &JWT{
+		Cfg:        &cfg,
+		Logger:     zerolog.Nop(),
+		Algorithms: []string{"RS256"},
+		KeySet:     NewKeySet(&cfg.JWT.JWKS, zerolog.Nop()),
+	}
+
+	if err := validator.Validate(context.Background(), "Bearer "+signRS256(t, "key1", key1), []string{"read"}); err != nil {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+
+	// the cached keys are used
+	if err := validator.Validate(context.Background(), "Bearer "+signRS256(t, "", key1), []string{"read"}); err != nil {
+		t.Fatalf("expected valid token without kid, got %v", err)
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("expected 1 JWKS fetch, got %d", fetches.Load())
+	}
+
+	// the keys are rotated and the unknown kid causes the refetch
+	lock.Lock()
+	keys = []map[string]string{rsaJWK(t, "key1", key1), rsaJWK(t, "key2", key2)}
+	lock.Unlock()
+
+	if err := validator.Validate(context.Background(), "Bearer "+signRS256(t, "key2", key2), []string{"read"}); err != nil {
+		t.Fatalf("expected valid token signed by the rotated key, got %v", err)
+	}
+	if fetches.Load() != 2 {
+		t.Errorf("expected 2 JWKS fetches, got %d", fetches.Load())
+	}
+
+	// the token signed by the other key
+	if err := validator.Validate(context.Background(), "Bearer "+signRS256(t, "key1", key2), []string{"read"}); err == nil {
+		t.Errorf("expected invalid token signature")
+	}
+
+	// the kid is ambiguous if the set contains several keys
+	if err := validator.Validate(context.Background(), "Bearer "+signRS256(t, "", key1), []string{"read"}); err == nil {
+		t.Errorf("expected error of the token without kid")
+	}
+}
+
+func TestKeySetMinRefetchInterval(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(map[string]any{"keys": []map[string]string{rsaJWK(t, "key1", key)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keySet := NewKeySet(&config.JWKS{
+		Source:             path,
+		RefreshInterval:    time.Hour,
+		MinRefetchInterval: time.Hour,
+	}, zerolog.Nop())
+
+	if _, err := keySet.Key("key1", "RS256"); err != nil {
+		t.Fatalf("expected key, got %v", err)
+	}
+
+	// the source is not reread until the min refetch interval is passed
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := keySet.Key("unknown", "RS256"); err == nil {
+		t.Errorf("expected error of the unknown key")
+	}
+	if _, err := keySet.Key("key1", "RS256"); err != nil {
+		t.Errorf("expected cached key, got %v", err)
+	}
+}
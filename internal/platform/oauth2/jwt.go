@@ -2,8 +2,14 @@ package oauth2
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -13,52 +19,111 @@ import (
 	"github.com/wallarm/api-firewall/internal/config"
 )
 
+// algorithms is the list of the supported JWT signature algorithms
+var algorithms = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"HS256", "HS384", "HS512",
+	"EdDSA",
+}
+
 type JWT struct {
-	Cfg       *config.Oauth
-	Logger    zerolog.Logger
-	PubKey    *rsa.PublicKey
+	Cfg    *config.Oauth
+	Logger zerolog.Logger
+	// Algorithms is the list of the accepted signature algorithms
+	Algorithms []string
+	// PubKey is the RSA, ECDSA or Ed25519 public key
+	PubKey    crypto.PublicKey
 	SecretKey []byte
+	// KeySet is used instead of the PubKey if the JWKS source is configured
+	KeySet *KeySet
 }
 
-func (j *JWT) Validate(ctx context.Context, tokenWithBearer string, scopes []string) error {
+// ParseAlgorithms parses the list of the accepted signature algorithms
+// separated by commas. The unsigned tokens could not be accepted
+func ParseAlgorithms(value string) ([]string, error) {
+	var result []string
 
-	tokenString := strings.TrimPrefix(tokenWithBearer, "Bearer ")
+	for _, alg := range strings.Split(value, ",") {
+		alg = strings.TrimSpace(alg)
+		if alg == "" {
+			continue
+		}
+
+		if strings.EqualFold(alg, jwt.SigningMethodNone.Alg()) {
+			return nil, errors.New("unsigned tokens (alg none) could not be accepted")
+		}
 
-	type MyCustomClaims struct {
-		Scope string `json:"scope"`
-		jwt.RegisteredClaims
+		i := slices.IndexFunc(algorithms, func(a string) bool { return strings.EqualFold(a, alg) })
+		if i < 0 {
+			return nil, fmt.Errorf("unsupported signature algorithm %q", alg)
+		}
+
+		result = append(result, algorithms[i])
 	}
 
-	token, err := jwt.ParseWithClaims(tokenString, &MyCustomClaims{}, func(token *jwt.Token) (any, error) {
+	if len(result) == 0 {
+		return nil, errors.New("signature algorithm is not set")
+	}
 
-		switch j.Cfg.JWT.SignatureAlgorithm {
-		case "RS256", "RS384", "RS512":
-			if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-				return nil, errors.New("unknown signing method")
-			}
-			return j.PubKey, nil
-		case "HS256", "HS384", "HS512":
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unknown signing method")
-			}
-			return j.SecretKey, nil
-		}
+	return result, nil
+}
+
+// ParsePublicKeyFromPEM parses the PEM encoded PKIX or PKCS1 public key or the
+// public key of the certificate
+func ParsePublicKeyFromPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("PEM encoded key not found")
+	}
+
+	if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+		return cert.PublicKey, nil
+	}
+
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, errors.New("unsupported public key")
+}
+
+func (j *JWT) Validate(ctx context.Context, tokenWithBearer string, scopes []string) error {
 
-		return nil, errors.New("unknown signing method")
-	})
+	tokenString := strings.TrimPrefix(tokenWithBearer, "Bearer ")
+
+	if len(j.Algorithms) == 0 {
+		return errors.New("oauth2 token invalid: no signature algorithm is accepted")
+	}
+
+	// the exp and nbf claims are validated by the parser and the iat claim
+	// should not be in the future
+	token, err := jwt.ParseWithClaims(tokenString, jwt.MapClaims{}, j.keyFunc,
+		jwt.WithValidMethods(j.Algorithms),
+		jwt.WithLeeway(j.Cfg.JWT.Leeway),
+		jwt.WithIssuedAt(),
+	)
 
 	if err != nil {
 		return fmt.Errorf("oauth2 token invalid: %s", err)
 	}
 
-	claims, ok := token.Claims.(*MyCustomClaims)
-	if ok && token.Valid {
-		j.Logger.Debug().Msgf("%v %v", claims.Scope, claims.RegisteredClaims.ExpiresAt)
-	} else {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
 		return errors.New("oauth2 token invalid")
 	}
 
-	scopesInToken := strings.Split(strings.ToLower(claims.Scope), " ")
+	if err := j.validateClaims(claims); err != nil {
+		return fmt.Errorf("oauth2 token invalid: %s", err)
+	}
+
+	scopesInToken := j.scopes(claims)
+	j.Logger.Debug().Strs("scopes", scopesInToken).Msg("OAuth2: JWT claims")
 
 	for _, scope := range scopes {
 		scopeFound := false
@@ -75,3 +140,57 @@ func (j *JWT) Validate(ctx context.Context, tokenWithBearer string, scopes []str
 
 	return nil
 }
+
+// keyFunc method returns the key to verify the token signature. The secret key
+// is used by the HMAC algorithms only and the type of the public key should
+// match the algorithm to prevent the algorithm confusion
+func (j *JWT) keyFunc(token *jwt.Token) (any, error) {
+	alg := token.Method.Alg()
+	if !slices.Contains(j.Algorithms, alg) {
+		return nil, fmt.Errorf("signing method %s is not accepted", alg)
+	}
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(j.SecretKey) == 0 {
+			return nil, errors.New("secret key is not configured")
+		}
+		return j.SecretKey, nil
+	}
+
+	key := j.PubKey
+	if j.KeySet != nil {
+		kid, _ := token.Header["kid"].(string)
+
+		var err error
+		if key, err = j.KeySet.Key(kid, alg); err != nil {
+			return nil, err
+		}
+	}
+
+	if key == nil {
+		return nil, errors.New("public key is not configured")
+	}
+
+	if !keyMatchesMethod(key, token.Method) {
+		return nil, fmt.Errorf("%T key could not be used by signing method %s", key, alg)
+	}
+
+	return key, nil
+}
+
+// keyMatchesMethod checks if the public key could be used by the signing method
+func keyMatchesMethod(key crypto.PublicKey, method jwt.SigningMethod) bool {
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok := key.(*rsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodECDSA:
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		return ok && ecKey.Curve.Params().BitSize == m.CurveBits
+	case *jwt.SigningMethodEd25519:
+		_, ok := key.(ed25519.PublicKey)
+		return ok
+	}
+
+	return false
+}
@@ -0,0 +1,188 @@
+package oauth2
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key any) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, jwt.MapClaims{
+		"scope": "read",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tokenString
+}
+
+func TestParseAlgorithms(t *testing.T) {
+	algs, err := ParseAlgorithms("RS256, es256,EdDSA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(algs) != 3 || algs[0] != "RS256" || algs[1] != "ES256" || algs[2] != "EdDSA" {
+		t.Errorf("unexpected algorithms %v", algs)
+	}
+
+	for _, value := range []string{"", "none", "RS256,none", "ES256K"} {
+		if _, err := ParseAlgorithms(value); err == nil {
+			t.Errorf("expected error of the algorithms %q", value)
+		}
+	}
+}
+
+func TestJWTAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaPEM, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaPEM})
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"scope": "read"}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		algorithms []string
+		pubKey     crypto.PublicKey
+		secretKey  []byte
+		token      string
+		valid      bool
+	}{
+		{"RS256", []string{"RS256"}, &rsaKey.PublicKey, nil, signToken(t, jwt.SigningMethodRS256, "", rsaKey), true},
+		{"PS256", []string{"PS256"}, &rsaKey.PublicKey, nil, signToken(t, jwt.SigningMethodPS256, "", rsaKey), true},
+		{"ES256", []string{"ES256"}, &ecKey.PublicKey, nil, signToken(t, jwt.SigningMethodES256, "", ecKey), true},
+		{"ES384", []string{"ES256", "ES384"}, &ecKey384.PublicKey, nil, signToken(t, jwt.SigningMethodES384, "", ecKey384), true},
+		{"EdDSA", []string{"EdDSA"}, edPub, nil, signToken(t, jwt.SigningMethodEdDSA, "", edKey), true},
+		{"HS256", []string{"HS256"}, nil, []byte("secret"), signToken(t, jwt.SigningMethodHS256, "", []byte("secret")), true},
+		{"algorithm not accepted", []string{"RS256"}, &rsaKey.PublicKey, nil, signToken(t, jwt.SigningMethodPS256, "", rsaKey), false},
+		{"unsigned token", []string{"RS256", "HS256"}, &rsaKey.PublicKey, []byte("secret"), unsigned, false},
+		{"HMAC signed by public key", []string{"RS256", "HS256"}, &rsaKey.PublicKey, nil, signToken(t, jwt.SigningMethodHS256, "", rsaPEM), false},
+		{"ECDSA key curve mismatch", []string{"ES256", "ES384"}, &ecKey.PublicKey, nil, signToken(t, jwt.SigningMethodES384, "", ecKey384), false},
+		{"key type mismatch", []string{"ES256", "RS256"}, &rsaKey.PublicKey, nil, signToken(t, jwt.SigningMethodES256, "", ecKey), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &JWT{
+				Cfg:        &config.Oauth{},
+				Logger:     zerolog.Nop(),
+				Algorithms: tt.algorithms,
+				PubKey:     tt.pubKey,
+				SecretKey:  tt.secretKey,
+			}
+
+			err := validator.Validate(context.Background(), "Bearer "+tt.token, []string{"read"})
+			if tt.valid && err != nil {
+				t.Errorf("expected valid token, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("expected invalid token")
+			}
+		})
+	}
+}
+
+func TestJWTKeySetAlgorithms(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecPub, err := ecKey.PublicKey.ECDH()
+	if err != nil {
+		t.Fatal(err)
+	}
+	point := ecPub.Bytes()
+
+	keys := []map[string]string{
+		{
+			"kty": "EC", "kid": "ec", "alg": "ES256", "crv": "P-256",
+			"x": base64.RawURLEncoding.EncodeToString(point[1:33]),
+			"y": base64.RawURLEncoding.EncodeToString(point[33:]),
+		},
+		{
+			"kty": "OKP", "kid": "ed", "crv": "Ed25519",
+			"x": base64.RawURLEncoding.EncodeToString(edPub),
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	}))
+	defer server.Close()
+
+	jwks := config.JWKS{Source: server.URL, RefreshInterval: time.Hour, MinRefetchInterval: time.Hour, Timeout: time.Second}
+	validator := &JWT{
+		Cfg:        &config.Oauth{},
+		Logger:     zerolog.Nop(),
+		Algorithms: []string{"ES256", "ES384", "EdDSA"},
+		KeySet:     NewKeySet(&jwks, zerolog.Nop()),
+	}
+
+	if err := validator.Validate(context.Background(), "Bearer "+signToken(t, jwt.SigningMethodES256, "ec", ecKey), nil); err != nil {
+		t.Errorf("expected valid ES256 token, got %v", err)
+	}
+	if err := validator.Validate(context.Background(), "Bearer "+signToken(t, jwt.SigningMethodEdDSA, "ed", edKey), nil); err != nil {
+		t.Errorf("expected valid EdDSA token, got %v", err)
+	}
+
+	// the algorithm of the JWK differs from the token algorithm
+	ecKey384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validator.Validate(context.Background(), "Bearer "+signToken(t, jwt.SigningMethodES384, "ec", ecKey384), nil); err == nil {
+		t.Errorf("expected invalid token with the algorithm of the other key")
+	}
+}
@@ -0,0 +1,238 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+// discoveryPath is the path of the discovery document relative to the issuer
+const discoveryPath = "/.well-known/openid-configuration"
+
+// discovery is the OpenID Connect provider metadata
+type discovery struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// Providers holds the OpenID Connect providers of the openIdConnect security
+// schemes by the openIdConnectUrl. The providers are created on the first use
+type Providers struct {
+	cfg        *config.Oauth
+	logger     zerolog.Logger
+	algorithms []string
+
+	lock      sync.Mutex
+	providers map[string]*Provider
+}
+
+// NewProviders creates the OpenID Connect providers registry. The tokens are
+// validated by the JWT settings and the accepted algorithms
+func NewProviders(cfg *config.Oauth, algorithms []string, logger zerolog.Logger) *Providers {
+	return &Providers{
+		cfg:        cfg,
+		logger:     logger,
+		algorithms: algorithms,
+		providers:  make(map[string]*Provider),
+	}
+}
+
+// Provider returns the provider of the openIdConnectUrl
+func (p *Providers) Provider(url string) *Provider {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	provider, ok := p.providers[url]
+	if !ok {
+		provider = &Provider{
+			url:        url,
+			source:     p.cfg.OIDC.Providers.Source(url),
+			cfg:        p.cfg,
+			logger:     p.logger,
+			algorithms: p.algorithms,
+			client:     &fasthttp.Client{NoDefaultUserAgentHeader: true},
+		}
+		p.providers[url] = provider
+	}
+
+	return provider
+}
+
+// Provider validates the JWT access tokens by the OpenID Connect provider.
+// The discovery document is fetched on the first use and refreshed after the
+// refresh interval. The token should be issued by the provider issuer and
+// signed by the key of the provider JWKS
+type Provider struct {
+	url        string
+	source     string
+	cfg        *config.Oauth
+	logger     zerolog.Logger
+	algorithms []string
+	client     *fasthttp.Client
+
+	lock      sync.RWMutex
+	validator *JWT
+	fetchedAt time.Time
+
+	// fetchLock serializes the fetches of the discovery document
+	fetchLock sync.Mutex
+}
+
+func (p *Provider) Validate(ctx context.Context, tokenWithBearer string, scopes []string) error {
+	validator, err := p.jwtValidator()
+	if err != nil {
+		return err
+	}
+
+	return validator.Validate(ctx, tokenWithBearer, scopes)
+}
+
+// jwtValidator method returns the validator of the current provider metadata
+func (p *Provider) jwtValidator() (*JWT, error) {
+	p.lock.RLock()
+	validator := p.validator
+	fetchedAt := p.fetchedAt
+	p.lock.RUnlock()
+
+	if validator != nil && time.Since(fetchedAt) < p.cfg.OIDC.RefreshInterval {
+		return validator, nil
+	}
+
+	// the failed fetch is retried not more often than the min refetch interval
+	if validator == nil && !fetchedAt.IsZero() && time.Since(fetchedAt) < p.cfg.OIDC.MinRefetchInterval {
+		return nil, fmt.Errorf("OpenID Connect provider %s is not available", p.url)
+	}
+
+	if err := p.refresh(fetchedAt); err != nil {
+		p.logger.Error().Err(err).Str("url", p.url).Str("source", p.source).Msg("OAuth2: OpenID Connect discovery error")
+
+		// the cached metadata is used until the provider is available
+		if validator != nil {
+			return validator, nil
+		}
+		return nil, fmt.Errorf("OpenID Connect provider %s is not available", p.url)
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.validator, nil
+}
+
+// refresh method fetches the discovery document if it has not been fetched by
+// another request after the fetchedAt time. The JWKS keys are kept if the
+// issuer and the JWKS URI are not changed
+func (p *Provider) refresh(fetchedAt time.Time) error {
+	p.fetchLock.Lock()
+	defer p.fetchLock.Unlock()
+
+	p.lock.RLock()
+	refreshed := p.fetchedAt.After(fetchedAt)
+	validator := p.validator
+	p.lock.RUnlock()
+
+	if refreshed {
+		if validator == nil {
+			return errors.New("discovery document is not available")
+		}
+		return nil
+	}
+
+	metadata, err := p.fetch()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.fetchedAt = time.Now()
+	if err != nil {
+		return err
+	}
+
+	if validator != nil && validator.Cfg.JWT.Issuers[0] == metadata.Issuer && validator.Cfg.JWT.JWKS.Source == metadata.JWKSURI {
+		return nil
+	}
+
+	// the token should be issued by the provider and signed by its keys
+	cfg := *p.cfg
+	cfg.JWT.Issuers = []string{metadata.Issuer}
+	cfg.JWT.JWKS.Source = metadata.JWKSURI
+
+	p.validator = &JWT{
+		Cfg:        &cfg,
+		Logger:     p.logger,
+		Algorithms: p.algorithms,
+		KeySet:     NewKeySet(&cfg.JWT.JWKS, p.logger),
+	}
+
+	p.logger.Debug().Str("url", p.url).Str("issuer", metadata.Issuer).Str("jwks_uri", metadata.JWKSURI).Msg("OAuth2: OpenID Connect discovery document successfully loaded")
+
+	return nil
+}
+
+// fetch method reads and parses the discovery document
+func (p *Provider) fetch() (*discovery, error) {
+	data, err := readSource(p.client, p.source, p.cfg.OIDC.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata discovery
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("discovery document parsing error: %w", err)
+	}
+
+	if metadata.Issuer == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("discovery document should contain the issuer and jwks_uri")
+	}
+
+	if err := p.validateIssuer(metadata.Issuer); err != nil {
+		return nil, err
+	}
+
+	// the keys are not read from the local files by the provider metadata
+	jwksURI, err := url.Parse(metadata.JWKSURI)
+	if err != nil || (jwksURI.Scheme != "https" && jwksURI.Scheme != "http") || jwksURI.Host == "" {
+		return nil, fmt.Errorf("discovery document jwks_uri %q should be the HTTP(S) URL", metadata.JWKSURI)
+	}
+
+	return &metadata, nil
+}
+
+// validateIssuer method checks that the issuer of the discovery document is
+// the issuer of the openIdConnectUrl. The discovery document of the issuer is
+// located at the well-known path of the issuer URL. Otherwise, the issuer
+// should have the same origin as the openIdConnectUrl
+func (p *Provider) validateIssuer(issuer string) error {
+	if expected, ok := strings.CutSuffix(p.url, discoveryPath); ok {
+		if strings.TrimSuffix(issuer, "/") != strings.TrimSuffix(expected, "/") {
+			return fmt.Errorf("discovery document issuer %q does not match the issuer %q of %s", issuer, expected, p.url)
+		}
+		return nil
+	}
+
+	issuerURL, err := url.Parse(issuer)
+	if err != nil {
+		return fmt.Errorf("discovery document issuer %q parsing error: %w", issuer, err)
+	}
+
+	providerURL, err := url.Parse(p.url)
+	if err != nil {
+		return fmt.Errorf("openIdConnectUrl %q parsing error: %w", p.url, err)
+	}
+
+	if !strings.EqualFold(issuerURL.Scheme, providerURL.Scheme) || !strings.EqualFold(issuerURL.Host, providerURL.Host) {
+		return fmt.Errorf("discovery document issuer %q does not match the origin of %s", issuer, p.url)
+	}
+
+	return nil
+}
@@ -0,0 +1,278 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+// oidcProvider is the local stand-in OpenID Connect provider
+type oidcProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	lock      sync.Mutex
+	issuer    string
+	jwksURI   string
+	discovery atomic.Int32
+	jwks      atomic.Int32
+}
+
+func newOIDCProvider(t *testing.T) *oidcProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &oidcProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		p.discovery.Add(1)
+
+		p.lock.Lock()
+		defer p.lock.Unlock()
+
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   p.issuer,
+			"jwks_uri": p.jwksURI,
+		})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		p.jwks.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{rsaJWK(t, "key1", key)}})
+	})
+
+	p.server = httptest.NewServer(mux)
+	p.issuer = p.server.URL
+	p.jwksURI = p.server.URL + "/jwks"
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+func (p *oidcProvider) token(t *testing.T, issuer string) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   issuer,
+		"scope": "read",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "key1"
+
+	tokenString, err := token.SignedString(p.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return "Bearer " + tokenString
+}
+
+func TestOIDCProviders(t *testing.T) {
+	provider1 := newOIDCProvider(t)
+	provider2 := newOIDCProvider(t)
+
+	// the second provider is replaced by the stand-in provider
+	specURL := "https://idp.example.com/.well-known/openid-configuration"
+	provider2.issuer = "https://idp.example.com"
+
+	cfg := config.Oauth{
+		JWT: config.JWT{
+			JWKS: config.JWKS{RefreshInterval: time.Hour, Timeout: time.Second},
+		},
+		OIDC: config.OIDC{
+			RefreshInterval:    time.Hour,
+			MinRefetchInterval: time.Hour,
+			Timeout:            time.Second,
+			Providers:          config.OIDCProviderList{{URL: specURL, Source: provider2.server.URL + "/.well-known/openid-configuration"}},
+		},
+	}
+
+	providers := NewProviders(&cfg, []string{"RS256"}, zerolog.Nop())
+	ctx := context.Background()
+
+	oidc1 := providers.Provider(provider1.server.URL + "/.well-known/openid-configuration")
+	oidc2 := providers.Provider(specURL)
+
+	if providers.Provider(specURL) != oidc2 {
+		t.Errorf("expected the same provider of the URL")
+	}
+
+	if err := oidc1.Validate(ctx, provider1.token(t, provider1.issuer), []string{"read"}); err != nil {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+	if err := oidc2.Validate(ctx, provider2.token(t, provider2.issuer), []string{"read"}); err != nil {
+		t.Fatalf("expected valid token of the stand-in provider, got %v", err)
+	}
+
+	// the token of the other provider
+	if err := oidc1.Validate(ctx, provider2.token(t, provider1.issuer), nil); err == nil {
+		t.Errorf("expected invalid signature of the token")
+	}
+	if err := oidc1.Validate(ctx, provider1.token(t, provider2.issuer), nil); err == nil {
+		t.Errorf("expected invalid issuer of the token")
+	}
+	if err := oidc1.Validate(ctx, provider1.token(t, provider1.issuer), []string{"write"}); err == nil {
+		t.Errorf("expected missing scope")
+	}
+
+	if provider1.discovery.Load() != 1 || provider2.discovery.Load() != 1 {
+		t.Errorf("expected 1 discovery fetch of each provider, got %d and %d", provider1.discovery.Load(), provider2.discovery.Load())
+	}
+}
+
+func TestOIDCProviderRefresh(t *testing.T) {
+	provider := newOIDCProvider(t)
+
+	cfg := config.Oauth{
+		JWT: config.JWT{
+			JWKS: config.JWKS{RefreshInterval: time.Hour, Timeout: time.Second},
+		},
+		OIDC: config.OIDC{
+			RefreshInterval: 50 * time.Millisecond,
+			Timeout:         time.Second,
+		},
+	}
+
+	oidc := NewProviders(&cfg, []string{"RS256"}, zerolog.Nop()).Provider(provider.server.URL + "/.well-known/openid-configuration")
+	ctx := context.Background()
+
+	if err := oidc.Validate(ctx, provider.token(t, provider.issuer), nil); err != nil {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+
+	// the JWKS of the refreshed metadata is used
+	provider.lock.Lock()
+	provider.jwksURI = provider.server.URL + "/rotated/jwks"
+	provider.lock.Unlock()
+
+	time.Sleep(100 * time.Millisecond)
+
+	jwks := provider.jwks.Load()
+	if err := oidc.Validate(ctx, provider.token(t, provider.issuer), nil); err != nil {
+		t.Fatalf("expected valid token by the new JWKS, got %v", err)
+	}
+	if provider.discovery.Load() != 2 {
+		t.Errorf("expected 2 discovery fetches, got %d", provider.discovery.Load())
+	}
+	if provider.jwks.Load() != jwks+1 {
+		t.Errorf("expected the new JWKS to be fetched")
+	}
+
+	// the cached metadata is used if the provider is not available
+	provider.server.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	if err := oidc.Validate(ctx, provider.token(t, provider.issuer), nil); err != nil {
+		t.Errorf("expected valid token by the cached metadata, got %v", err)
+	}
+}
+
+func TestOIDCProviderInvalidMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		issuer  string
+		jwksURI string
+	}{
+		{
+			name:    "local JWKS file",
+			jwksURI: "{file}",
+		},
+		{
+			name:    "JWKS file URL",
+			jwksURI: "file://{file}",
+		},
+		{
+			name:   "issuer of the other provider",
+			issuer: "https://other.example.com",
+		},
+		{
+			name:   "issuer of the other origin",
+			url:    "https://idp.example.com/discovery",
+			issuer: "https://other.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newOIDCProvider(t)
+			if tt.issuer != "" {
+				provider.issuer = tt.issuer
+			}
+			if tt.jwksURI != "" {
+				// the local file contains the valid keys of the provider
+				file := filepath.Join(t.TempDir(), "jwks.json")
+				data, err := json.Marshal(map[string]any{"keys": []map[string]string{rsaJWK(t, "key1", provider.key)}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file, data, 0600); err != nil {
+					t.Fatal(err)
+				}
+				provider.jwksURI = strings.ReplaceAll(tt.jwksURI, "{file}", file)
+			}
+
+			specURL := provider.server.URL + "/.well-known/openid-configuration"
+			if tt.url != "" {
+				specURL = tt.url
+			}
+
+			cfg := config.Oauth{
+				JWT: config.JWT{
+					JWKS: config.JWKS{RefreshInterval: time.Hour, Timeout: time.Second},
+				},
+				OIDC: config.OIDC{
+					RefreshInterval:    time.Hour,
+					MinRefetchInterval: time.Hour,
+					Timeout:            time.Second,
+					Providers:          config.OIDCProviderList{{URL: specURL, Source: provider.server.URL + "/.well-known/openid-configuration"}},
+				},
+			}
+
+			oidc := NewProviders(&cfg, []string{"RS256"}, zerolog.Nop()).Provider(specURL)
+
+			if err := oidc.Validate(context.Background(), provider.token(t, provider.issuer), nil); err == nil {
+				t.Errorf("expected error of the invalid discovery document")
+			}
+			if provider.jwks.Load() != 0 {
+				t.Errorf("expected the JWKS not to be fetched")
+			}
+		})
+	}
+}
+
+func TestOIDCProviderUnavailable(t *testing.T) {
+	cfg := config.Oauth{
+		OIDC: config.OIDC{
+			RefreshInterval:    time.Hour,
+			MinRefetchInterval: time.Hour,
+			Timeout:            time.Second,
+			Providers:          config.OIDCProviderList{{URL: "https://idp.example.com", Source: "/nonexistent/discovery.json"}},
+		},
+	}
+
+	oidc := NewProviders(&cfg, []string{"RS256"}, zerolog.Nop()).Provider("https://idp.example.com")
+
+	if err := oidc.Validate(context.Background(), "Bearer token", nil); err == nil {
+		t.Errorf("expected error of the unavailable provider")
+	}
+}
@@ -0,0 +1,176 @@
+package proxy
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// ErrCircuitOpen is returned when the request is rejected by the circuit breaker
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+const (
+	circuitClosed int32 = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreakerOptions configures the circuit breaker. The circuit is opened
+// after FailureThreshold consecutive failed requests and all requests are
+// rejected during OpenTimeout. Then up to HalfOpenMaxRequests trial requests
+// are passed to the backend: the circuit is closed if all of them succeed and
+// opened again on the first failure. FailureThreshold = 0 disables the breaker
+type CircuitBreakerOptions struct {
+	FailureThreshold    int
+	OpenTimeout         time.Duration
+	HalfOpenMaxRequests int
+}
+
+// CircuitBreaker tracks the results of the requests sent to the backend and
+// rejects new requests when the backend is failing
+type CircuitBreaker struct {
+	opts   CircuitBreakerOptions
+	logger zerolog.Logger
+
+	// state and failures are accessed without lock in the closed state (hot path)
+	state    atomic.Int32
+	failures atomic.Int32
+
+	mu                sync.Mutex
+	openedAt          time.Time
+	halfOpenInFlight  int
+	halfOpenSuccesses int
+}
+
+// NewCircuitBreaker creates a new circuit breaker. Nil is returned if the
+// failure threshold is not configured
+func NewCircuitBreaker(opts *CircuitBreakerOptions, logger zerolog.Logger) *CircuitBreaker {
+	if opts == nil || opts.FailureThreshold <= 0 {
+		return nil
+	}
+
+	cb := &CircuitBreaker{
+		opts:   *opts,
+		logger: logger,
+	}
+
+	if cb.opts.OpenTimeout <= 0 {
+		cb.opts.OpenTimeout = 30 * time.Second
+	}
+
+	if cb.opts.HalfOpenMaxRequests <= 0 {
+		cb.opts.HalfOpenMaxRequests = 1
+	}
+
+	return cb
+}
+
+// Allow checks if the request could be sent to the backend
+func (cb *CircuitBreaker) Allow() bool {
+	if cb.state.Load() == circuitClosed {
+		return true
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state.Load() {
+	case circuitClosed:
+		return true
+	case circuitOpen:
+		if time.Since(cb.openedAt) < cb.opts.OpenTimeout {
+			return false
+		}
+
+		cb.state.Store(circuitHalfOpen)
+		cb.halfOpenInFlight = 0
+		cb.halfOpenSuccesses = 0
+
+		cb.logger.Info().Msg("Circuit breaker is half-open")
+	}
+
+	// half-open state: pass limited number of trial requests
+	if cb.halfOpenInFlight+cb.halfOpenSuccesses >= cb.opts.HalfOpenMaxRequests {
+		return false
+	}
+
+	cb.halfOpenInFlight++
+	return true
+}
+
+// Done reports the result of the request allowed by the circuit breaker
+func (cb *CircuitBreaker) Done(failed bool) {
+	if cb.state.Load() == circuitClosed {
+		if !failed {
+			if cb.failures.Load() != 0 {
+				cb.failures.Store(0)
+			}
+			return
+		}
+
+		if cb.failures.Add(1) < int32(cb.opts.FailureThreshold) {
+			return
+		}
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state.Load() {
+	case circuitClosed:
+		if failed && cb.failures.Load() >= int32(cb.opts.FailureThreshold) {
+			cb.open()
+		}
+	case circuitHalfOpen:
+		if cb.halfOpenInFlight > 0 {
+			cb.halfOpenInFlight--
+		}
+
+		if failed {
+			cb.open()
+			return
+		}
+
+		cb.halfOpenSuccesses++
+		if cb.halfOpenSuccesses >= cb.opts.HalfOpenMaxRequests {
+			cb.state.Store(circuitClosed)
+			cb.failures.Store(0)
+
+			cb.logger.Info().Msg("Circuit breaker is closed")
+		}
+	}
+}
+
+// Release releases the request allowed by the circuit breaker without counting
+// its result. It is used if the request has not reached the backend
+func (cb *CircuitBreaker) Release() {
+	if cb.state.Load() == circuitClosed {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state.Load() == circuitHalfOpen && cb.halfOpenInFlight > 0 {
+		cb.halfOpenInFlight--
+	}
+}
+
+// IsOpen returns true if the circuit breaker rejects requests
+func (cb *CircuitBreaker) IsOpen() bool {
+	return cb.state.Load() != circuitClosed
+}
+
+// open switches the circuit breaker to the open state. The lock should be held
+func (cb *CircuitBreaker) open() {
+	cb.state.Store(circuitOpen)
+	cb.openedAt = time.Now()
+	cb.failures.Store(0)
+
+	cb.logger.Warn().
+		Dur("open_timeout", cb.opts.OpenTimeout).
+		Msg("Circuit breaker is open")
+}
@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	if cb := NewCircuitBreaker(&CircuitBreakerOptions{}, zerolog.Nop()); cb != nil {
+		t.Error("expected nil circuit breaker when the failure threshold is not set")
+	}
+	if cb := NewCircuitBreaker(nil, zerolog.Nop()); cb != nil {
+		t.Error("expected nil circuit breaker for nil options")
+	}
+}
+
+func TestCircuitBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	cb := NewCircuitBreaker(&CircuitBreakerOptions{
+		FailureThreshold: 3,
+		OpenTimeout:      time.Hour,
+	}, zerolog.Nop())
+
+	cb.Done(true)
+	cb.Done(true)
+
+	// success resets the counter
+	cb.Done(false)
+	cb.Done(true)
+	cb.Done(true)
+	if cb.IsOpen() || !cb.Allow() {
+		t.Fatal("expected circuit breaker to be closed")
+	}
+
+	cb.Done(true)
+	if !cb.IsOpen() {
+		t.Fatal("expected circuit breaker to be open")
+	}
+	if cb.Allow() {
+		t.Error("expected request to be rejected by the open circuit breaker")
+	}
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	cb := NewCircuitBreaker(&CircuitBreakerOptions{
+		FailureThreshold:    1,
+		OpenTimeout:         50 * time.Millisecond,
+		HalfOpenMaxRequests: 2,
+	}, zerolog.Nop())
+
+	cb.Done(true)
+	if cb.Allow() {
+		t.Fatal("expected request to be rejected by the open circuit breaker")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	// only the configured number of trial requests is allowed
+	if !cb.Allow() || !cb.Allow() {
+		t.Fatal("expected trial requests to be allowed by the half-open circuit breaker")
+	}
+	if cb.Allow() {
+		t.Fatal("expected request over the trial limit to be rejected")
+	}
+
+	cb.Done(false)
+	if !cb.IsOpen() {
+		t.Fatal("expected circuit breaker to stay half-open until all trial requests succeed")
+	}
+
+	cb.Done(false)
+	if cb.IsOpen() || !cb.Allow() {
+		t.Fatal("expected circuit breaker to be closed after successful trial requests")
+	}
+}
+
+func TestCircuitBreaker_HalfOpenFailure(t *testing.T) {
+	cb := NewCircuitBreaker(&CircuitBreakerOptions{
+		FailureThreshold: 1,
+		OpenTimeout:      50 * time.Millisecond,
+	}, zerolog.Nop())
+
+	cb.Done(true)
+	time.Sleep(60 * time.Millisecond)
+
+	if !cb.Allow() {
+		t.Fatal("expected trial request to be allowed")
+	}
+
+	cb.Done(true)
+	if cb.Allow() {
+		t.Error("expected circuit breaker to be open again after the failed trial request")
+	}
+}
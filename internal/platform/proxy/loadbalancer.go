@@ -1,27 +1,123 @@
 package proxy
 
 import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
 	"net"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
 )
 
 // LoadBalancer provides round-robin load balancing across multiple backends
 // with health checking support. It is lock-free for the hot path (Next).
+// The list of backends could be replaced at runtime (SetBackends).
 type LoadBalancer struct {
-	backends []string
-	healthy  []atomic.Bool
-	current  atomic.Uint64
+	set     atomic.Pointer[backendSet]
+	current atomic.Uint64
+	updMu   sync.Mutex
 
 	healthCheckInterval time.Duration
 	healthCheckTimeout  time.Duration
+	httpCheck           *HTTPHealthCheck
+	httpCheckClient     *fasthttp.Client
+	healthyThreshold    int
+	unhealthyThreshold  int
+	outlier             OutlierDetectionOptions
 	stopCh              chan struct{}
 	stopped             atomic.Bool
 	wg                  sync.WaitGroup
 	logger              zerolog.Logger
+
+	// results of the health checks by backend
+	stateMu sync.RWMutex
+	states  map[string]*probeState
+}
+
+// probeState keeps the results of the consecutive health checks of the backend
+type probeState struct {
+	successes int
+	failures  int
+	lastErr   error
+}
+
+// HTTPHealthCheck configures active HTTP health checks of the backends.
+// The backend is healthy if the response status is within StatusMin-StatusMax
+// and the response body contains Body (if set)
+type HTTPHealthCheck struct {
+	Scheme    string
+	Path      string
+	Method    string
+	Host      string
+	StatusMin int
+	StatusMax int
+	Body      string
+	TLSConfig *tls.Config
+}
+
+// BackendStatus contains the health state of the backend
+type BackendStatus struct {
+	Address   string `json:"address"`
+	Healthy   bool   `json:"healthy"`
+	Ejected   bool   `json:"ejected,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// OutlierDetectionOptions configures the passive outlier detection. The backend
+// is ejected from the load balancing after ConsecutiveErrors consecutive failed
+// requests. The ejection time is doubled with each next ejection of the backend
+// up to MaxEjectionTime. ConsecutiveErrors = 0 disables the outlier detection
+type OutlierDetectionOptions struct {
+	ConsecutiveErrors int
+	BaseEjectionTime  time.Duration
+	MaxEjectionTime   time.Duration
+}
+
+// backendSet is an immutable list of backends with their health state
+type backendSet struct {
+	backends []string
+	index    map[string]int
+	healthy  []atomic.Bool
+
+	// outlier detection state
+	failures     []atomic.Int32
+	ejections    []atomic.Int32
+	ejectedUntil []atomic.Int64
+}
+
+// newBackendSet creates the set of backends marked as healthy
+func newBackendSet(backends []string) *backendSet {
+	bs := &backendSet{
+		backends:     backends,
+		index:        make(map[string]int, len(backends)),
+		healthy:      make([]atomic.Bool, len(backends)),
+		failures:     make([]atomic.Int32, len(backends)),
+		ejections:    make([]atomic.Int32, len(backends)),
+		ejectedUntil: make([]atomic.Int64, len(backends)),
+	}
+
+	for i := range bs.healthy {
+		bs.healthy[i].Store(true)
+		bs.index[backends[i]] = i
+	}
+
+	return bs
+}
+
+// available checks if the backend is healthy and not ejected
+func (bs *backendSet) available(idx int) bool {
+	if !bs.healthy[idx].Load() {
+		return false
+	}
+
+	until := bs.ejectedUntil[idx].Load()
+	return until == 0 || time.Now().UnixNano() >= until
 }
 
 // LoadBalancerOptions configures the load balancer
@@ -29,7 +125,19 @@ type LoadBalancerOptions struct {
 	Backends            []string
 	HealthCheckInterval time.Duration
 	HealthCheckTimeout  time.Duration
-	Logger              zerolog.Logger
+
+	// HTTP health check - if nil, backends are checked by the TCP connection
+	HTTPHealthCheck *HTTPHealthCheck
+
+	// Number of consecutive successful (failed) checks to mark the backend
+	// as healthy (unhealthy). The default value is 1
+	HealthyThreshold   int
+	UnhealthyThreshold int
+
+	// Passive outlier detection
+	OutlierDetection OutlierDetectionOptions
+
+	Logger zerolog.Logger
 }
 
 // NewLoadBalancer creates a new load balancer with the given backends
@@ -38,22 +146,63 @@ func NewLoadBalancer(opts *LoadBalancerOptions) *LoadBalancer {
 		opts.HealthCheckTimeout = 1 * time.Second
 	}
 
+	if opts.HealthyThreshold <= 0 {
+		opts.HealthyThreshold = 1
+	}
+
+	if opts.UnhealthyThreshold <= 0 {
+		opts.UnhealthyThreshold = 1
+	}
+
 	lb := &LoadBalancer{
-		backends:            opts.Backends,
-		healthy:             make([]atomic.Bool, len(opts.Backends)),
 		stopCh:              make(chan struct{}),
 		healthCheckInterval: opts.HealthCheckInterval,
 		healthCheckTimeout:  opts.HealthCheckTimeout,
+		healthyThreshold:    opts.HealthyThreshold,
+		unhealthyThreshold:  opts.UnhealthyThreshold,
+		outlier:             opts.OutlierDetection,
 		logger:              opts.Logger,
+		states:              make(map[string]*probeState),
 	}
 
-	// Mark all backends as healthy initially
-	for i := range lb.healthy {
-		lb.healthy[i].Store(true)
+	if lb.outlier.BaseEjectionTime <= 0 {
+		lb.outlier.BaseEjectionTime = 30 * time.Second
+	}
+
+	if lb.outlier.MaxEjectionTime < lb.outlier.BaseEjectionTime {
+		lb.outlier.MaxEjectionTime = lb.outlier.BaseEjectionTime
+	}
+
+	if opts.HTTPHealthCheck != nil {
+		check := *opts.HTTPHealthCheck
+		if check.Scheme == "" {
+			check.Scheme = "http"
+		}
+		if check.Method == "" {
+			check.Method = fasthttp.MethodGet
+		}
+		if !strings.HasPrefix(check.Path, "/") {
+			check.Path = "/" + check.Path
+		}
+		if check.StatusMin == 0 && check.StatusMax == 0 {
+			check.StatusMin, check.StatusMax = fasthttp.StatusOK, 399
+		}
+
+		lb.httpCheck = &check
+		lb.httpCheckClient = &fasthttp.Client{
+			NoDefaultUserAgentHeader:      true,
+			DisableHeaderNamesNormalizing: true,
+			TLSConfig:                     check.TLSConfig,
+			ReadTimeout:                   opts.HealthCheckTimeout,
+			WriteTimeout:                  opts.HealthCheckTimeout,
+		}
 	}
 
+	// Mark all backends as healthy initially
+	lb.set.Store(newBackendSet(opts.Backends))
+
 	// Start health check goroutine if interval is configured
-	if opts.HealthCheckInterval > 0 && len(opts.Backends) > 0 {
+	if opts.HealthCheckInterval > 0 {
 		lb.wg.Add(1)
 		go lb.runHealthChecks()
 	}
@@ -61,37 +210,58 @@ func NewLoadBalancer(opts *LoadBalancerOptions) *LoadBalancer {
 	return lb
 }
 
-// Next returns the next healthy backend using round-robin selection.
+// Next returns the next healthy and not ejected backend using round-robin selection.
 // This method is lock-free and safe for concurrent use.
 func (lb *LoadBalancer) Next() string {
-	n := len(lb.backends)
+	set := lb.set.Load()
+
+	n := len(set.backends)
 	if n == 0 {
 		return ""
 	}
 
 	// Fast path: single backend
 	if n == 1 {
-		return lb.backends[0]
+		return set.backends[0]
 	}
 
 	// Round-robin with health awareness
 	start := lb.current.Add(1)
 	for i := 0; i < n; i++ {
 		idx := (int(start) + i) % n
-		if lb.healthy[idx].Load() {
-			return lb.backends[idx]
+		if set.available(idx) {
+			return set.backends[idx]
 		}
 	}
 
-	// Fallback: return any backend if all appear unhealthy
+	// Fallback: return any backend if all appear unhealthy or ejected
 	// This prevents total failure when health checks are failing
-	return lb.backends[int(start)%n]
+	return set.backends[int(start)%n]
+}
+
+// NextExcluding returns the next available backend which is not in the
+// excluded list. Empty string is returned if there is no such backend
+func (lb *LoadBalancer) NextExcluding(exclude []string) string {
+	set := lb.set.Load()
+
+	n := len(set.backends)
+	start := lb.current.Add(1)
+	for i := 0; i < n; i++ {
+		idx := (int(start) + i) % n
+		if set.available(idx) && !slices.Contains(exclude, set.backends[idx]) {
+			return set.backends[idx]
+		}
+	}
+
+	return ""
 }
 
 func (lb *LoadBalancer) GetHealthyCount() int {
+	set := lb.set.Load()
+
 	count := 0
-	for i := range lb.healthy {
-		if lb.healthy[i].Load() {
+	for i := range set.healthy {
+		if set.healthy[i].Load() {
 			count++
 		}
 	}
@@ -99,20 +269,165 @@ func (lb *LoadBalancer) GetHealthyCount() int {
 }
 
 func (lb *LoadBalancer) GetBackends() []string {
-	return append([]string(nil), lb.backends...)
+	return append([]string(nil), lb.set.Load().backends...)
+}
+
+// Has checks if the backend is in the list of the load balancer
+func (lb *LoadBalancer) Has(backend string) bool {
+	_, ok := lb.set.Load().index[backend]
+	return ok
 }
 
 func (lb *LoadBalancer) IsHealthy(idx int) bool {
-	if idx < 0 || idx >= len(lb.healthy) {
+	set := lb.set.Load()
+
+	if idx < 0 || idx >= len(set.healthy) {
 		return false
 	}
-	return lb.healthy[idx].Load()
+	return set.healthy[idx].Load()
+}
+
+// GetBackendsStatus returns the health state of all backends
+func (lb *LoadBalancer) GetBackendsStatus() []BackendStatus {
+	set := lb.set.Load()
+
+	lb.stateMu.RLock()
+	defer lb.stateMu.RUnlock()
+
+	statuses := make([]BackendStatus, 0, len(set.backends))
+	for i, backend := range set.backends {
+		status := BackendStatus{
+			Address: backend,
+			Healthy: set.healthy[i].Load(),
+			Ejected: !set.available(i) && set.healthy[i].Load(),
+		}
+		if st, ok := lb.states[backend]; ok && st.lastErr != nil {
+			status.LastError = st.lastErr.Error()
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses
 }
 
 func (lb *LoadBalancer) SetHealthy(idx int, healthy bool) {
-	if idx >= 0 && idx < len(lb.healthy) {
-		lb.healthy[idx].Store(healthy)
+	set := lb.set.Load()
+
+	if idx >= 0 && idx < len(set.healthy) {
+		set.healthy[idx].Store(healthy)
+	}
+}
+
+// SetBackends replaces the list of backends. The health state of the backends
+// which are present in both lists is kept, new backends are marked as healthy.
+// Connections that are already established are not affected.
+func (lb *LoadBalancer) SetBackends(backends []string) (added []string, removed []string) {
+	lb.updMu.Lock()
+	defer lb.updMu.Unlock()
+
+	old := lb.set.Load()
+
+	oldIdx := make(map[string]int, len(old.backends))
+	for i, b := range old.backends {
+		oldIdx[b] = i
+	}
+
+	newSet := newBackendSet(append([]string(nil), backends...))
+	present := make(map[string]struct{}, len(backends))
+	for i, b := range newSet.backends {
+		present[b] = struct{}{}
+		if j, ok := oldIdx[b]; ok {
+			newSet.healthy[i].Store(old.healthy[j].Load())
+			newSet.failures[i].Store(old.failures[j].Load())
+			newSet.ejections[i].Store(old.ejections[j].Load())
+			newSet.ejectedUntil[i].Store(old.ejectedUntil[j].Load())
+			continue
+		}
+		added = append(added, b)
+	}
+
+	for _, b := range old.backends {
+		if _, ok := present[b]; !ok {
+			removed = append(removed, b)
+		}
+	}
+
+	// nothing changed: keep the current list (and its order)
+	if len(added) == 0 && len(removed) == 0 && len(old.backends) == len(newSet.backends) {
+		return nil, nil
+	}
+
+	lb.set.Store(newSet)
+
+	return added, removed
+}
+
+// ReportSuccess resets the consecutive errors counter of the backend
+func (lb *LoadBalancer) ReportSuccess(backend string) {
+	if lb.outlier.ConsecutiveErrors <= 0 {
+		return
+	}
+
+	set := lb.set.Load()
+	idx, ok := set.index[backend]
+	if !ok {
+		return
 	}
+
+	set.failures[idx].Store(0)
+
+	// the backend works after the ejection: reset the ejection time backoff
+	if until := set.ejectedUntil[idx].Load(); until != 0 && time.Now().UnixNano() >= until {
+		set.ejectedUntil[idx].Store(0)
+		set.ejections[idx].Store(0)
+	}
+}
+
+// ReportFailure increments the consecutive errors counter of the backend and
+// ejects the backend if the counter reaches the configured threshold.
+// Returns true if the backend has been ejected
+func (lb *LoadBalancer) ReportFailure(backend string) bool {
+	if lb.outlier.ConsecutiveErrors <= 0 {
+		return false
+	}
+
+	set := lb.set.Load()
+	idx, ok := set.index[backend]
+	if !ok {
+		return false
+	}
+
+	if set.failures[idx].Add(1) < int32(lb.outlier.ConsecutiveErrors) {
+		return false
+	}
+
+	// the backend is already ejected by the concurrent request
+	now := time.Now()
+	until := set.ejectedUntil[idx].Load()
+	if until != 0 && now.UnixNano() < until {
+		return false
+	}
+
+	ejections := set.ejections[idx].Add(1)
+	ejectionTime := lb.outlier.BaseEjectionTime
+	for i := int32(1); i < ejections && ejectionTime < lb.outlier.MaxEjectionTime; i++ {
+		ejectionTime *= 2
+	}
+	if ejectionTime > lb.outlier.MaxEjectionTime {
+		ejectionTime = lb.outlier.MaxEjectionTime
+	}
+
+	if !set.ejectedUntil[idx].CompareAndSwap(until, now.Add(ejectionTime).UnixNano()) {
+		return false
+	}
+	set.failures[idx].Store(0)
+
+	lb.logger.Warn().
+		Str("backend", backend).
+		Dur("ejection_time", ejectionTime).
+		Msg("Backend ejected by the outlier detection")
+
+	return true
 }
 
 // runHealthChecks periodically checks all backends
@@ -132,11 +447,32 @@ func (lb *LoadBalancer) runHealthChecks() {
 	}
 }
 
-// checkAll probes all backends and updates their health status
+// checkAll probes all backends and updates their health status. The results
+// are stored to the current backends set, since the set could be replaced by
+// SetBackends during the probes
 func (lb *LoadBalancer) checkAll() {
-	for i, backend := range lb.backends {
-		healthy := lb.probe(backend)
-		wasHealthy := lb.healthy[i].Swap(healthy)
+	set := lb.set.Load()
+
+	errs := make([]error, len(set.backends))
+	for i, backend := range set.backends {
+		errs[i] = lb.probe(backend)
+	}
+
+	lb.updMu.Lock()
+	defer lb.updMu.Unlock()
+
+	current := lb.set.Load()
+
+	for i, backend := range set.backends {
+		// the backend has been removed during the probes
+		idx, ok := current.index[backend]
+		if !ok {
+			continue
+		}
+
+		err := errs[i]
+		healthy := lb.updateState(backend, err, current.healthy[idx].Load())
+		wasHealthy := current.healthy[idx].Swap(healthy)
 
 		// Log health status changes
 		if healthy != wasHealthy {
@@ -146,21 +482,97 @@ func (lb *LoadBalancer) checkAll() {
 					Msg("Backend became healthy")
 			} else {
 				lb.logger.Warn().
+					Err(err).
 					Str("backend", backend).
 					Msg("Backend became unhealthy")
 			}
 		}
 	}
+
+	// Forget the results of the removed backends
+	lb.stateMu.Lock()
+	for backend := range lb.states {
+		if _, ok := current.index[backend]; !ok {
+			delete(lb.states, backend)
+		}
+	}
+	lb.stateMu.Unlock()
+}
+
+// updateState saves the probe result and returns the new health status of the backend
+func (lb *LoadBalancer) updateState(backend string, err error, healthy bool) bool {
+	lb.stateMu.Lock()
+	defer lb.stateMu.Unlock()
+
+	st, ok := lb.states[backend]
+	if !ok {
+		st = &probeState{}
+		lb.states[backend] = st
+	}
+
+	st.lastErr = err
+	if err != nil {
+		st.failures++
+		st.successes = 0
+	} else {
+		st.successes++
+		st.failures = 0
+	}
+
+	switch {
+	case healthy && st.failures >= lb.unhealthyThreshold:
+		return false
+	case !healthy && st.successes >= lb.healthyThreshold:
+		return true
+	}
+
+	return healthy
 }
 
 // probe checks if a backend is reachable
-func (lb *LoadBalancer) probe(backend string) bool {
+func (lb *LoadBalancer) probe(backend string) error {
+	if lb.httpCheck != nil {
+		return lb.probeHTTP(backend)
+	}
+
 	conn, err := net.DialTimeout("tcp", backend, lb.healthCheckTimeout)
 	if err != nil {
-		return false
+		return err
 	}
 	conn.Close()
-	return true
+	return nil
+}
+
+// probeHTTP sends the health check request to the backend and checks the response
+func (lb *LoadBalancer) probeHTTP(backend string) error {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(lb.httpCheck.Scheme + "://" + backend + lb.httpCheck.Path)
+	req.Header.SetMethod(lb.httpCheck.Method)
+	req.SetConnectionClose()
+
+	if lb.httpCheck.Host != "" {
+		req.UseHostHeader = true
+		req.Header.SetHost(lb.httpCheck.Host)
+	}
+
+	if err := lb.httpCheckClient.DoTimeout(req, resp, lb.healthCheckTimeout); err != nil {
+		return err
+	}
+
+	if resp.StatusCode() < lb.httpCheck.StatusMin || resp.StatusCode() > lb.httpCheck.StatusMax {
+		return fmt.Errorf("unexpected response status code %d", resp.StatusCode())
+	}
+
+	if lb.httpCheck.Body != "" && !bytes.Contains(resp.Body(), []byte(lb.httpCheck.Body)) {
+		return fmt.Errorf("response body does not contain %q", lb.httpCheck.Body)
+	}
+
+	return nil
 }
 
 func (lb *LoadBalancer) Stop() {
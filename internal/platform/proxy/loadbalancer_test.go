@@ -2,6 +2,8 @@ package proxy
 
 import (
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -213,6 +215,280 @@ func TestLoadBalancer_HealthCheck_Integration(t *testing.T) {
 	}
 }
 
+func TestLoadBalancer_SetBackends(t *testing.T) {
+	lb := NewLoadBalancer(&LoadBalancerOptions{
+		Backends: []string{"127.0.0.1:8080", "127.0.0.1:8081"},
+		Logger:   zerolog.Nop(),
+	})
+	defer lb.Stop()
+
+	lb.SetHealthy(1, false)
+
+	added, removed := lb.SetBackends([]string{"127.0.0.1:8081", "127.0.0.1:8082"})
+	if len(added) != 1 || added[0] != "127.0.0.1:8082" {
+		t.Errorf("expected 127.0.0.1:8082 to be added, got %v", added)
+	}
+	if len(removed) != 1 || removed[0] != "127.0.0.1:8080" {
+		t.Errorf("expected 127.0.0.1:8080 to be removed, got %v", removed)
+	}
+
+	// health state of the kept backend is preserved
+	if lb.IsHealthy(0) {
+		t.Error("expected 127.0.0.1:8081 to stay unhealthy")
+	}
+	if !lb.IsHealthy(1) {
+		t.Error("expected new backend to be healthy")
+	}
+
+	for i := 0; i < 10; i++ {
+		if backend := lb.Next(); backend != "127.0.0.1:8082" {
+			t.Fatalf("expected only healthy backend to be selected, got %s", backend)
+		}
+	}
+
+	// the same set in another order does not change the list
+	added, removed = lb.SetBackends([]string{"127.0.0.1:8082", "127.0.0.1:8081"})
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("expected no changes, got added %v, removed %v", added, removed)
+	}
+	if backends := lb.GetBackends(); backends[0] != "127.0.0.1:8081" {
+		t.Errorf("expected backends order to be kept, got %v", backends)
+	}
+}
+
+func TestLoadBalancer_HTTPHealthCheck(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" || r.Method != http.MethodHead && r.Method != http.MethodGet || r.Host != "backend.test" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(int(status.Load()))
+		w.Write([]byte("status: up"))
+	}))
+	defer server.Close()
+
+	lb := NewLoadBalancer(&LoadBalancerOptions{
+		Backends:           []string{server.Listener.Addr().String()},
+		HealthCheckTimeout: 500 * time.Millisecond,
+		HTTPHealthCheck: &HTTPHealthCheck{
+			Path:      "health",
+			Host:      "backend.test",
+			StatusMin: 200,
+			StatusMax: 299,
+			Body:      "up",
+		},
+		HealthyThreshold:   2,
+		UnhealthyThreshold: 2,
+		Logger:             zerolog.Nop(),
+	})
+	defer lb.Stop()
+
+	lb.checkAll()
+	if !lb.IsHealthy(0) {
+		t.Fatal("expected backend to be healthy")
+	}
+
+	// one failed check is below the unhealthy threshold
+	status.Store(http.StatusServiceUnavailable)
+	lb.checkAll()
+	if !lb.IsHealthy(0) {
+		t.Fatal("expected backend to stay healthy after one failed check")
+	}
+
+	lb.checkAll()
+	if lb.IsHealthy(0) {
+		t.Fatal("expected backend to be unhealthy after two failed checks")
+	}
+
+	statuses := lb.GetBackendsStatus()
+	if len(statuses) != 1 || statuses[0].Healthy || statuses[0].LastError == "" {
+		t.Errorf("unexpected backends status: %+v", statuses)
+	}
+
+	// one successful check is below the healthy threshold
+	status.Store(http.StatusOK)
+	lb.checkAll()
+	if lb.IsHealthy(0) {
+		t.Fatal("expected backend to stay unhealthy after one successful check")
+	}
+
+	lb.checkAll()
+	if !lb.IsHealthy(0) {
+		t.Fatal("expected backend to be healthy after two successful checks")
+	}
+
+	statuses = lb.GetBackendsStatus()
+	if len(statuses) != 1 || !statuses[0].Healthy || statuses[0].LastError != "" {
+		t.Errorf("unexpected backends status: %+v", statuses)
+	}
+}
+
+func TestLoadBalancer_HealthCheck_SetBackendsDuringCheck(t *testing.T) {
+	var lb *LoadBalancer
+	var swapped atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the backends list is replaced by the DNS refresh during the check
+		if swapped.CompareAndSwap(false, true) {
+			lb.SetBackends([]string{"127.0.0.1:1", r.Host})
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	backend := server.Listener.Addr().String()
+
+	lb = NewLoadBalancer(&LoadBalancerOptions{
+		Backends:           []string{backend},
+		HealthCheckTimeout: 500 * time.Millisecond,
+		HTTPHealthCheck: &HTTPHealthCheck{
+			Path:      "/health",
+			StatusMin: 200,
+			StatusMax: 299,
+		},
+		HealthyThreshold:   1,
+		UnhealthyThreshold: 1,
+		Logger:             zerolog.Nop(),
+	})
+	defer lb.Stop()
+
+	lb.checkAll()
+
+	if !swapped.Load() {
+		t.Fatal("expected the backends to be replaced during the check")
+	}
+
+	// the result is stored to the current backends list
+	statuses := lb.GetBackendsStatus()
+	if len(statuses) != 2 || statuses[1].Address != backend || statuses[1].Healthy {
+		t.Errorf("expected the checked backend to be unhealthy, got %+v", statuses)
+	}
+	if !statuses[0].Healthy {
+		t.Errorf("expected the added backend not to be affected by the check, got %+v", statuses)
+	}
+}
+
+func TestLoadBalancer_HTTPHealthCheck_Body(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("status: down"))
+	}))
+	defer server.Close()
+
+	lb := NewLoadBalancer(&LoadBalancerOptions{
+		Backends: []string{server.Listener.Addr().String()},
+		HTTPHealthCheck: &HTTPHealthCheck{
+			Path: "/health",
+			Body: "status: up",
+		},
+		Logger: zerolog.Nop(),
+	})
+	defer lb.Stop()
+
+	lb.checkAll()
+	if lb.IsHealthy(0) {
+		t.Error("expected backend to be unhealthy when the body does not match")
+	}
+}
+
+func TestLoadBalancer_NextExcluding(t *testing.T) {
+	lb := NewLoadBalancer(&LoadBalancerOptions{
+		Backends: []string{"127.0.0.1:8080", "127.0.0.1:8081", "127.0.0.1:8082"},
+		Logger:   zerolog.Nop(),
+	})
+	defer lb.Stop()
+
+	lb.SetHealthy(2, false)
+
+	for i := 0; i < 10; i++ {
+		if backend := lb.NextExcluding([]string{"127.0.0.1:8080"}); backend != "127.0.0.1:8081" {
+			t.Fatalf("expected 127.0.0.1:8081, got %s", backend)
+		}
+	}
+
+	// unhealthy backends are not used for the fallback
+	if backend := lb.NextExcluding([]string{"127.0.0.1:8080", "127.0.0.1:8081"}); backend != "" {
+		t.Errorf("expected no backend, got %s", backend)
+	}
+}
+
+func TestLoadBalancer_OutlierDetection(t *testing.T) {
+	lb := NewLoadBalancer(&LoadBalancerOptions{
+		Backends: []string{"127.0.0.1:8080", "127.0.0.1:8081"},
+		OutlierDetection: OutlierDetectionOptions{
+			ConsecutiveErrors: 2,
+			BaseEjectionTime:  50 * time.Millisecond,
+			MaxEjectionTime:   80 * time.Millisecond,
+		},
+		Logger: zerolog.Nop(),
+	})
+	defer lb.Stop()
+
+	if lb.ReportFailure("127.0.0.1:8080") {
+		t.Fatal("expected backend not to be ejected after one error")
+	}
+
+	// success resets the consecutive errors counter
+	lb.ReportSuccess("127.0.0.1:8080")
+	if lb.ReportFailure("127.0.0.1:8080") {
+		t.Fatal("expected backend not to be ejected after the counter reset")
+	}
+
+	if !lb.ReportFailure("127.0.0.1:8080") {
+		t.Fatal("expected backend to be ejected after two consecutive errors")
+	}
+
+	for i := 0; i < 10; i++ {
+		if backend := lb.Next(); backend != "127.0.0.1:8081" {
+			t.Fatalf("expected ejected backend to be skipped, got %s", backend)
+		}
+	}
+
+	statuses := lb.GetBackendsStatus()
+	if !statuses[0].Ejected || !statuses[0].Healthy || statuses[1].Ejected {
+		t.Errorf("unexpected backends status: %+v", statuses)
+	}
+
+	// the backend returns to the load balancing after the ejection time
+	time.Sleep(60 * time.Millisecond)
+
+	counts := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		counts[lb.Next()]++
+	}
+	if counts["127.0.0.1:8080"] == 0 {
+		t.Error("expected backend to be returned after the ejection time")
+	}
+
+	// the second ejection is longer (up to the max ejection time)
+	lb.ReportFailure("127.0.0.1:8080")
+	if !lb.ReportFailure("127.0.0.1:8080") {
+		t.Fatal("expected backend to be ejected again")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if backend := lb.Next(); backend != "127.0.0.1:8081" {
+		t.Errorf("expected backend to be still ejected, got %s", backend)
+	}
+}
+
+func TestLoadBalancer_OutlierDetection_Disabled(t *testing.T) {
+	lb := NewLoadBalancer(&LoadBalancerOptions{
+		Backends: []string{"127.0.0.1:8080", "127.0.0.1:8081"},
+		Logger:   zerolog.Nop(),
+	})
+	defer lb.Stop()
+
+	for i := 0; i < 100; i++ {
+		if lb.ReportFailure("127.0.0.1:8080") {
+			t.Fatal("expected backend not to be ejected when outlier detection is disabled")
+		}
+	}
+}
+
 func BenchmarkLoadBalancer_Next(b *testing.B) {
 	backends := []string{
 		"127.0.0.1:8080",
@@ -1,10 +1,12 @@
 package proxy
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -30,30 +32,61 @@ type Pool interface {
 	Close()
 }
 
+// RequestTracker is implemented by the pools which track the results of the
+// proxied requests to eject failing backends and to break the circuit
+type RequestTracker interface {
+	Allow() bool
+	Done(backend string, failed bool)
+	Release()
+}
+
+// BackendSelector is implemented by the pools which select the backend of the
+// request explicitly. The selected backend is returned even if the request
+// fails before the response is read, e.g. on the dial error or timeout, so the
+// result of the request could be attributed to the backend
+type BackendSelector interface {
+	DoNext(req *fasthttp.Request, resp *fasthttp.Response, timeout time.Duration) (string, error)
+}
+
 // PoolV2 is a lock-free connection pool that leverages
-// fasthttp.Client's internal connection pooling.
+// fasthttp.HostClient's internal connection pooling.
 //
 // Key design:
 // - Lock-free operation using atomic values
-// - Single fasthttp.Client with proper MaxConnsPerHost
+// - fasthttp.HostClient per backend with MaxConnsPerHost connections
 // - Zero allocations per request in Get/Put
 // - Built-in load balancing with health checks
 type PoolV2 struct {
-	client    *fasthttp.Client
+	opts      PoolV2Options
 	lb        *LoadBalancer
+	breaker   *CircuitBreaker
+	retry     *RetryPolicy
 	tlsConfig *tls.Config
 	closed    atomic.Bool
 	logger    zerolog.Logger
 
+	// DNS re-resolving of the backend host
+	resolver         DNSCache
+	dnsLookupTimeout time.Duration
+	stopCh           chan struct{}
+	wg               sync.WaitGroup
+
+	// Clients of the backends. The clients are stored for the backends of
+	// the load balancer only and are guarded by clientsMu on updates
+	backendClients sync.Map
+	clientsMu      sync.Mutex
+
 	// Cached for metrics/debugging
-	host    string
-	port    string
+	host     string
+	port     string
 	hostPort string
 }
 
 // PoolV2Options configures the PoolV2 connection pool
 type PoolV2Options struct {
-	// Connection settings
+	// Connection settings. MaxConnsPerHost limits the connections to each
+	// backend, so the host resolved to N addresses gets up to N times more
+	// connections
 	MaxConnsPerHost     int
 	MaxIdleConnDuration time.Duration
 	ReadTimeout         time.Duration
@@ -73,6 +106,27 @@ type PoolV2Options struct {
 	Backends            []string
 	HealthCheckInterval time.Duration
 
+	// Active HTTP health checks - if HTTPHealthCheck is nil, backends are
+	// checked by the TCP connection. HealthCheckTimeout defaults to DialTimeout
+	HTTPHealthCheck    *HTTPHealthCheck
+	HealthCheckTimeout time.Duration
+	HealthyThreshold   int
+	UnhealthyThreshold int
+
+	// Passive outlier detection and circuit breaking
+	OutlierDetection OutlierDetectionOptions
+	CircuitBreaker   *CircuitBreakerOptions
+
+	// Retries of the failed requests - each retry is sent to another backend
+	Retry *RetryOptions
+
+	// DNS resolving - if DNSResolver is nil, the system resolver is used.
+	// If DNSRefreshInterval > 0, the host is re-resolved periodically and
+	// the load balancer backends are updated without dropping connections
+	DNSResolver        DNSCache
+	DNSRefreshInterval time.Duration
+	DNSLookupTimeout   time.Duration
+
 	// Logging
 	Logger zerolog.Logger
 }
@@ -98,72 +152,70 @@ func NewPoolV2(hostAddr string, opts *PoolV2Options) (Pool, error) {
 	// Determine backends for load balancer
 	backends := opts.Backends
 	if len(backends) == 0 {
-		// Single backend mode - resolve initial IP
-		ips, err := net.LookupIP(host)
+		// Single backend mode - resolve initial IPs
+		backends, err = resolveBackends(opts.DNSResolver, host, port, opts.DNSLookupTimeout)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve host: %w", err)
 		}
 
-		// Collect all resolved IPs as backends
-		for _, ip := range ips {
-			if ipv4 := ip.To4(); ipv4 != nil {
-				backends = append(backends, net.JoinHostPort(ipv4.String(), port))
-			}
-		}
-
-		// Fallback to IPv6 if no IPv4
-		if len(backends) == 0 {
-			for _, ip := range ips {
-				if ipv6 := ip.To16(); ipv6 != nil {
-					backends = append(backends, net.JoinHostPort(ipv6.String(), port))
-				}
-			}
-		}
-
 		// Last resort: use original host
 		if len(backends) == 0 {
 			backends = []string{hostAddr}
 		}
 	}
 
+	healthCheckTimeout := opts.HealthCheckTimeout
+	if healthCheckTimeout == 0 {
+		healthCheckTimeout = opts.DialTimeout
+	}
+
+	// HTTP health check requests are sent with the backend host name
+	var httpCheck *HTTPHealthCheck
+	if opts.HTTPHealthCheck != nil {
+		check := *opts.HTTPHealthCheck
+		if check.Host == "" {
+			check.Host = host
+		}
+		if check.TLSConfig == nil {
+			check.TLSConfig = tlsConfig.Clone()
+			if check.TLSConfig.ServerName == "" && net.ParseIP(host) == nil {
+				check.TLSConfig.ServerName = host
+			}
+		}
+		httpCheck = &check
+	}
+
 	// Create load balancer
 	lb := NewLoadBalancer(&LoadBalancerOptions{
 		Backends:            backends,
 		HealthCheckInterval: opts.HealthCheckInterval,
-		HealthCheckTimeout:  opts.DialTimeout,
+		HealthCheckTimeout:  healthCheckTimeout,
+		HTTPHealthCheck:     httpCheck,
+		HealthyThreshold:    opts.HealthyThreshold,
+		UnhealthyThreshold:  opts.UnhealthyThreshold,
+		OutlierDetection:    opts.OutlierDetection,
 		Logger:              opts.Logger,
 	})
 
 	p := &PoolV2{
-		lb:        lb,
-		tlsConfig: tlsConfig,
-		logger:    opts.Logger,
-		host:      host,
-		port:      port,
-		hostPort:  net.JoinHostPort(host, port),
+		opts:             *opts,
+		lb:               lb,
+		breaker:          NewCircuitBreaker(opts.CircuitBreaker, opts.Logger.With().Str("host", host).Logger()),
+		retry:            NewRetryPolicy(opts.Retry),
+		tlsConfig:        tlsConfig,
+		logger:           opts.Logger,
+		host:             host,
+		port:             port,
+		hostPort:         net.JoinHostPort(host, port),
+		resolver:         opts.DNSResolver,
+		dnsLookupTimeout: opts.DNSLookupTimeout,
+		stopCh:           make(chan struct{}),
 	}
 
-	// Create single fasthttp.Client that manages its own connection pool
-	p.client = &fasthttp.Client{
-		NoDefaultUserAgentHeader:      true,
-		DisableHeaderNamesNormalizing: true,
-		DisablePathNormalizing:        true,
-		MaxConnsPerHost:               opts.MaxConnsPerHost,
-		MaxIdleConnDuration:           opts.MaxIdleConnDuration,
-		ReadTimeout:                   opts.ReadTimeout,
-		WriteTimeout:                  opts.WriteTimeout,
-		ReadBufferSize:                opts.ReadBufferSize,
-		WriteBufferSize:               opts.WriteBufferSize,
-		MaxResponseBodySize:           opts.MaxResponseBodySize,
-		TLSConfig:                     tlsConfig,
-		Dial: func(addr string) (net.Conn, error) {
-			// Use load balancer to select backend
-			backend := lb.Next()
-			if backend == "" {
-				return nil, errNoBackends
-			}
-			return fasthttp.DialTimeout(backend, opts.DialTimeout)
-		},
+	// Start DNS refresh goroutine if the backends are resolved from the host name
+	if len(opts.Backends) == 0 && opts.DNSRefreshInterval > 0 && net.ParseIP(host) == nil {
+		p.wg.Add(1)
+		go p.runDNSRefresh(opts.DNSRefreshInterval)
 	}
 
 	p.logger.Info().
@@ -176,19 +228,19 @@ func NewPoolV2(hostAddr string, opts *PoolV2Options) (Pool, error) {
 	return p, nil
 }
 
-// Get returns the shared HTTP client. The actual backend is selected
-// inside the Dial function when the connection is established.
+// Get returns the pool itself as the HTTP client. The backend is selected
+// by the load balancer for each request
 func (p *PoolV2) Get() (HTTPClient, string, error) {
 	if p.closed.Load() {
 		return nil, "", errPoolClosed
 	}
 
-	return p.client, p.hostPort, nil
+	return p, p.hostPort, nil
 }
 
-// Put is a no-op -- fasthttp.Client manages connection lifecycle internally.
+// Put is a no-op -- fasthttp.HostClient manages connection lifecycle internally.
 func (p *PoolV2) Put(ip string, client HTTPClient) error {
-	// No-op: fasthttp.Client handles connection reuse automatically
+	// No-op: fasthttp.HostClient handles connection reuse automatically
 	return nil
 }
 
@@ -198,11 +250,16 @@ func (p *PoolV2) Close() {
 		return
 	}
 
-	// Stop load balancer health checks
+	// Stop DNS refresh and load balancer health checks
+	close(p.stopCh)
+	p.wg.Wait()
 	p.lb.Stop()
 
 	// Close idle connections
-	p.client.CloseIdleConnections()
+	p.backendClients.Range(func(_, client any) bool {
+		client.(*fasthttp.HostClient).CloseIdleConnections()
+		return true
+	})
 
 	p.logger.Info().
 		Str("host", p.host).
@@ -210,22 +267,257 @@ func (p *PoolV2) Close() {
 		Msg("PoolV2 closed")
 }
 
+// Allow checks if the request could be sent to the backend by the circuit
+// breaker. The breaker is shared by all backends of the pool
+func (p *PoolV2) Allow() bool {
+	if p.breaker == nil {
+		return true
+	}
+	return p.breaker.Allow()
+}
+
+// Done reports the result of the request sent to the backend. The result is
+// counted by the breaker of the pool and by the outlier detection of the
+// backend. The backend address is empty if the backend is unknown
+func (p *PoolV2) Done(backend string, failed bool) {
+	if p.breaker != nil {
+		p.breaker.Done(failed)
+	}
+
+	if backend == "" {
+		return
+	}
+
+	if failed {
+		p.lb.ReportFailure(backend)
+		return
+	}
+	p.lb.ReportSuccess(backend)
+}
+
+// Release reports the request which has failed by the local reason, e.g. the
+// connection limit is reached. The result is counted neither as the success
+// nor as the failure of the backend
+func (p *PoolV2) Release() {
+	if p.breaker != nil {
+		p.breaker.Release()
+	}
+}
+
+// RetryPolicy returns the retry policy of the pool. Nil is returned if
+// retries are disabled
+func (p *PoolV2) RetryPolicy() *RetryPolicy {
+	return p.retry
+}
+
+// NextBackend returns the available backend which is not in the excluded list
+func (p *PoolV2) NextBackend(exclude []string) string {
+	return p.lb.NextExcluding(exclude)
+}
+
+// Do sends the request to the backend selected by the load balancer
+func (p *PoolV2) Do(req *fasthttp.Request, resp *fasthttp.Response) error {
+	_, err := p.DoNext(req, resp, 0)
+	return err
+}
+
+// DoNext sends the request to the backend selected by the load balancer and
+// returns the selected backend
+func (p *PoolV2) DoNext(req *fasthttp.Request, resp *fasthttp.Response, timeout time.Duration) (string, error) {
+	backend := p.lb.Next()
+	if backend == "" {
+		return "", errNoBackends
+	}
+
+	return backend, p.DoBackend(req, resp, backend, timeout)
+}
+
+// DoBackend sends the request to the particular backend
+func (p *PoolV2) DoBackend(req *fasthttp.Request, resp *fasthttp.Response, backend string, timeout time.Duration) error {
+	if p.closed.Load() {
+		return errPoolClosed
+	}
+
+	client, stored := p.backendClient(backend, req)
+	if !stored {
+		// the backend has been removed, so the connection is not reused
+		defer client.CloseIdleConnections()
+	}
+
+	if timeout > 0 {
+		return client.DoTimeout(req, resp, timeout)
+	}
+	return client.Do(req, resp)
+}
+
+// backendClient returns the client of the backend. The client is created on
+// the first request to the backend and is stored if the backend is still in
+// the load balancer list. False is returned if the client is not stored
+func (p *PoolV2) backendClient(backend string, req *fasthttp.Request) (*fasthttp.HostClient, bool) {
+	if client, ok := p.backendClients.Load(backend); ok {
+		return client.(*fasthttp.HostClient), true
+	}
+
+	isTLS := string(req.URI().Scheme()) == "https"
+	var tlsConfig *tls.Config
+	if isTLS {
+		// the backend is addressed by IP so the server name is set explicitly
+		tlsConfig = p.tlsConfig.Clone()
+		if tlsConfig.ServerName == "" {
+			if serverName, _, err := net.SplitHostPort(string(req.URI().Host())); err == nil {
+				tlsConfig.ServerName = serverName
+			} else {
+				tlsConfig.ServerName = string(req.URI().Host())
+			}
+		}
+	}
+
+	client := &fasthttp.HostClient{
+		Addr:                          backend,
+		IsTLS:                         isTLS,
+		TLSConfig:                     tlsConfig,
+		NoDefaultUserAgentHeader:      true,
+		DisableHeaderNamesNormalizing: true,
+		DisablePathNormalizing:        true,
+		MaxConns:                      p.opts.MaxConnsPerHost,
+		MaxIdleConnDuration:           p.opts.MaxIdleConnDuration,
+		ReadTimeout:                   p.opts.ReadTimeout,
+		WriteTimeout:                  p.opts.WriteTimeout,
+		ReadBufferSize:                p.opts.ReadBufferSize,
+		WriteBufferSize:               p.opts.WriteBufferSize,
+		MaxResponseBodySize:           p.opts.MaxResponseBodySize,
+		// the failures are reported by the caller as the backend is known
+		Dial: func(addr string) (net.Conn, error) {
+			return fasthttp.DialTimeout(addr, p.opts.DialTimeout)
+		},
+	}
+
+	p.clientsMu.Lock()
+	defer p.clientsMu.Unlock()
+
+	if !p.lb.Has(backend) {
+		return client, false
+	}
+
+	actual, _ := p.backendClients.LoadOrStore(backend, client)
+	return actual.(*fasthttp.HostClient), true
+}
+
+// runDNSRefresh periodically re-resolves the host and updates the backends
+func (p *PoolV2) runDNSRefresh(interval time.Duration) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.refreshBackends()
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+// refreshBackends resolves the host and updates the list of the load balancer
+// backends. The current list is kept if the host could not be resolved
+func (p *PoolV2) refreshBackends() {
+	backends, err := resolveBackends(p.resolver, p.host, p.port, p.dnsLookupTimeout)
+	if err != nil || len(backends) == 0 {
+		p.logger.Warn().
+			Err(err).
+			Str("host", p.host).
+			Msg("PoolV2: failed to re-resolve host, keeping current backends")
+		return
+	}
+
+	// the clients are not stored for the backends being removed
+	p.clientsMu.Lock()
+	added, removed := p.lb.SetBackends(backends)
+
+	// Close idle connections of the removed backends.
+	// Requests in progress keep their connections
+	for _, backend := range removed {
+		if client, ok := p.backendClients.LoadAndDelete(backend); ok {
+			client.(*fasthttp.HostClient).CloseIdleConnections()
+		}
+	}
+	p.clientsMu.Unlock()
+
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+
+	p.logger.Info().
+		Str("host", p.host).
+		Strs("added", added).
+		Strs("removed", removed).
+		Msg("PoolV2: backends updated")
+}
+
+// resolveBackends resolves the host and returns the list of backend addresses.
+// IPv4 addresses have higher priority than IPv6 ones
+func resolveBackends(resolver DNSCache, host, port string, lookupTimeout time.Duration) ([]string, error) {
+	ctx := context.Background()
+	if lookupTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, lookupTimeout)
+		defer cancel()
+	}
+
+	var ips []net.IPAddr
+	var err error
+
+	switch resolver {
+	case nil:
+		ips, err = net.DefaultResolver.LookupIPAddr(ctx, host)
+	default:
+		ips, err = resolver.LookupIPAddr(ctx, host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect all resolved IPs as backends
+	var backends []string
+	for _, ip := range ips {
+		if ipv4 := ip.IP.To4(); ipv4 != nil {
+			backends = append(backends, net.JoinHostPort(ipv4.String(), port))
+		}
+	}
+
+	// Fallback to IPv6 if no IPv4
+	if len(backends) == 0 {
+		for _, ip := range ips {
+			if ipv6 := ip.IP.To16(); ipv6 != nil {
+				backends = append(backends, net.JoinHostPort(ipv6.String(), port))
+			}
+		}
+	}
+
+	return backends, nil
+}
+
 func (p *PoolV2) Stats() PoolV2Stats {
 	return PoolV2Stats{
 		Host:           p.host,
 		Port:           p.port,
 		Backends:       p.lb.GetBackends(),
+		BackendsStatus: p.lb.GetBackendsStatus(),
 		HealthyCount:   p.lb.GetHealthyCount(),
+		CircuitOpen:    p.breaker != nil && p.breaker.IsOpen(),
 		IsClosed:       p.closed.Load(),
 	}
 }
 
 // PoolV2Stats contains pool statistics
 type PoolV2Stats struct {
-	Host         string
-	Port         string
-	Backends     []string
-	HealthyCount int
-	IsClosed     bool
+	Host           string
+	Port           string
+	Backends       []string
+	BackendsStatus []BackendStatus
+	HealthyCount   int
+	CircuitOpen    bool
+	IsClosed       bool
 }
-
@@ -1,6 +1,8 @@
 package proxy
 
 import (
+	"context"
+	"errors"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +13,8 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/platform/web"
 )
 
 func TestPoolV2_NewPoolV2_ValidConfig(t *testing.T) {
@@ -219,6 +223,399 @@ func TestPoolV2_ActualRequest(t *testing.T) {
 	}
 }
 
+func TestPoolV2_CircuitBreaker(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	host := server.Listener.Addr().String()
+
+	pool, err := NewPoolV2(host, &PoolV2Options{
+		MaxConnsPerHost: 100,
+		ReadTimeout:     5 * time.Second,
+		WriteTimeout:    5 * time.Second,
+		DialTimeout:     1 * time.Second,
+		OutlierDetection: OutlierDetectionOptions{
+			ConsecutiveErrors: 2,
+			BaseEjectionTime:  time.Hour,
+		},
+		CircuitBreaker: &CircuitBreakerOptions{
+			FailureThreshold: 3,
+			OpenTimeout:      time.Hour,
+		},
+		Logger: zerolog.Nop(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	perform := func() (int, error) {
+		var reqCtx fasthttp.RequestCtx
+		reqCtx.Request.SetRequestURI("http://" + host + "/test")
+		reqCtx.Request.Header.SetMethod("GET")
+
+		err := Perform(&reqCtx, pool, "")
+		return reqCtx.Response.StatusCode(), err
+	}
+
+	for i := 0; i < 3; i++ {
+		status, err := perform()
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if status != http.StatusInternalServerError {
+			t.Errorf("expected status 500, got %d", status)
+		}
+	}
+
+	stats := pool.(*PoolV2).Stats()
+	if !stats.CircuitOpen {
+		t.Error("expected circuit breaker to be open")
+	}
+	if len(stats.BackendsStatus) != 1 || !stats.BackendsStatus[0].Ejected {
+		t.Errorf("expected backend to be ejected, got %+v", stats.BackendsStatus)
+	}
+
+	status, err := perform()
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected circuit open error, got %v", err)
+	}
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", status)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("expected 3 requests to reach the backend, got %d", hits.Load())
+	}
+}
+
+func TestPoolV2_CircuitBreaker_SharedByBackends(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	healthy := server.Listener.Addr().String()
+	failing := "127.0.0.1:1"
+
+	pool, err := NewPoolV2(healthy, &PoolV2Options{
+		MaxConnsPerHost: 100,
+		ReadTimeout:     5 * time.Second,
+		WriteTimeout:    5 * time.Second,
+		DialTimeout:     1 * time.Second,
+		Backends:        []string{healthy, failing},
+		CircuitBreaker: &CircuitBreakerOptions{
+			FailureThreshold: 2,
+			OpenTimeout:      time.Hour,
+		},
+		Logger: zerolog.Nop(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	// the failures of one backend open the circuit of the whole pool
+	p := pool.(*PoolV2)
+	p.Done(failing, true)
+	p.Done(failing, true)
+
+	if !p.Stats().CircuitOpen {
+		t.Fatal("expected circuit breaker to be open")
+	}
+
+	var reqCtx fasthttp.RequestCtx
+	reqCtx.Request.SetRequestURI("http://" + healthy + "/test")
+	reqCtx.Request.Header.SetMethod("GET")
+
+	if err := Perform(&reqCtx, pool, ""); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected circuit open error for the healthy backend, got %v", err)
+	}
+}
+
+func TestPoolV2_BackendClients_StoredForCurrentBackends(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	removedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer removedServer.Close()
+
+	host := server.Listener.Addr().String()
+	removed := removedServer.Listener.Addr().String()
+
+	pool, err := NewPoolV2(host, &PoolV2Options{
+		MaxConnsPerHost: 100,
+		ReadTimeout:     5 * time.Second,
+		WriteTimeout:    5 * time.Second,
+		DialTimeout:     time.Second,
+		Backends:        []string{host},
+		Logger:          zerolog.Nop(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	p := pool.(*PoolV2)
+
+	for _, backend := range []string{host, removed} {
+		var reqCtx fasthttp.RequestCtx
+		reqCtx.Request.SetRequestURI("http://" + host + "/test")
+		reqCtx.Request.Header.SetMethod("GET")
+
+		if err := p.DoBackend(&reqCtx.Request, &reqCtx.Response, backend, 0); err != nil {
+			t.Fatalf("request to %s failed: %v", backend, err)
+		}
+	}
+
+	if _, ok := p.backendClients.Load(host); !ok {
+		t.Error("expected the client of the current backend to be stored")
+	}
+	if _, ok := p.backendClients.Load(removed); ok {
+		t.Error("expected the client of the removed backend not to be stored")
+	}
+}
+
+func TestPoolV2_CircuitBreaker_IgnoresConnectionLimit(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	host := server.Listener.Addr().String()
+
+	pool, err := NewPoolV2(host, &PoolV2Options{
+		MaxConnsPerHost: 1,
+		ReadTimeout:     5 * time.Second,
+		WriteTimeout:    5 * time.Second,
+		DialTimeout:     time.Second,
+		Backends:        []string{host},
+		OutlierDetection: OutlierDetectionOptions{
+			ConsecutiveErrors: 1,
+			BaseEjectionTime:  time.Hour,
+			MaxEjectionTime:   time.Hour,
+		},
+		CircuitBreaker: &CircuitBreakerOptions{
+			FailureThreshold: 1,
+			OpenTimeout:      time.Hour,
+		},
+		Logger: zerolog.Nop(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	// the only connection is held by the request in progress
+	done := make(chan error, 1)
+	go func() {
+		var reqCtx fasthttp.RequestCtx
+		reqCtx.Request.SetRequestURI("http://" + host + "/test")
+		reqCtx.Request.Header.SetMethod("GET")
+		done <- Perform(&reqCtx, pool, "")
+	}()
+	<-started
+
+	for i := 0; i < 3; i++ {
+		var reqCtx fasthttp.RequestCtx
+		reqCtx.Request.SetRequestURI("http://" + host + "/test")
+		reqCtx.Request.Header.SetMethod("GET")
+
+		if err := Perform(&reqCtx, pool, ""); !errors.Is(err, fasthttp.ErrNoFreeConns) {
+			t.Errorf("expected no free connections error, got %v", err)
+		}
+		if reqCtx.Response.StatusCode() != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", reqCtx.Response.StatusCode())
+		}
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error of the request in progress: %v", err)
+	}
+
+	stats := pool.(*PoolV2).Stats()
+	if stats.CircuitOpen {
+		t.Error("expected circuit breaker to stay closed")
+	}
+	if len(stats.BackendsStatus) != 1 || stats.BackendsStatus[0].Ejected {
+		t.Errorf("expected backend not to be ejected, got %+v", stats.BackendsStatus)
+	}
+}
+
+func TestPoolV2_OutlierDetection_ConnectionErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle func(conn net.Conn)
+	}{
+		{
+			name: "read timeout",
+			handle: func(conn net.Conn) {
+				// the response is not sent before the read timeout
+				time.Sleep(time.Second)
+				conn.Close()
+			},
+		},
+		{
+			name: "connection reset",
+			handle: func(conn net.Conn) {
+				conn.Close()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("failed to listen: %v", err)
+			}
+			defer listener.Close()
+
+			go func() {
+				for {
+					conn, err := listener.Accept()
+					if err != nil {
+						return
+					}
+					go tt.handle(conn)
+				}
+			}()
+
+			host := listener.Addr().String()
+
+			pool, err := NewPoolV2(host, &PoolV2Options{
+				MaxConnsPerHost: 100,
+				ReadTimeout:     100 * time.Millisecond,
+				WriteTimeout:    100 * time.Millisecond,
+				DialTimeout:     time.Second,
+				Backends:        []string{host},
+				OutlierDetection: OutlierDetectionOptions{
+					ConsecutiveErrors: 2,
+					BaseEjectionTime:  time.Hour,
+					MaxEjectionTime:   time.Hour,
+				},
+				Logger: zerolog.Nop(),
+			})
+			if err != nil {
+				t.Fatalf("failed to create pool: %v", err)
+			}
+			defer pool.Close()
+
+			// the failed requests are charged to the selected backend
+			for i := 0; i < 2; i++ {
+				var reqCtx fasthttp.RequestCtx
+				reqCtx.Request.SetRequestURI("http://" + host + "/test")
+				reqCtx.Request.Header.SetMethod("GET")
+
+				if err := Perform(&reqCtx, pool, ""); err == nil {
+					t.Fatal("expected the request error")
+				}
+			}
+
+			stats := pool.(*PoolV2).Stats()
+			if len(stats.BackendsStatus) != 1 || !stats.BackendsStatus[0].Ejected {
+				t.Errorf("expected backend to be ejected, got %+v", stats.BackendsStatus)
+			}
+		})
+	}
+}
+
+func TestPoolV2_Retry(t *testing.T) {
+	var failedHits, okHits atomic.Int32
+
+	failed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failedHits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failed.Close()
+
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		okHits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ok.Close()
+
+	host := failed.Listener.Addr().String()
+
+	pool, err := NewPoolV2(host, &PoolV2Options{
+		MaxConnsPerHost: 100,
+		ReadTimeout:     5 * time.Second,
+		WriteTimeout:    5 * time.Second,
+		DialTimeout:     1 * time.Second,
+		Backends:        []string{failed.Listener.Addr().String(), ok.Listener.Addr().String()},
+		Retry: &RetryOptions{
+			MaxRetries:    2,
+			PerTryTimeout: time.Second,
+			BackoffBase:   time.Millisecond,
+		},
+		Logger: zerolog.Nop(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	perform := func(method string, retrySafe bool) int {
+		var reqCtx fasthttp.RequestCtx
+		reqCtx.Request.SetRequestURI("http://" + host + "/test")
+		reqCtx.Request.Header.SetMethod(method)
+		if retrySafe {
+			reqCtx.SetUserValue(web.RequestRetrySafe, true)
+		}
+
+		if err := Perform(&reqCtx, pool, ""); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return reqCtx.Response.StatusCode()
+	}
+
+	// idempotent requests are retried on another backend
+	for i := 0; i < 10; i++ {
+		failedHits.Store(0)
+		okHits.Store(0)
+
+		if status := perform(fasthttp.MethodGet, false); status != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", status)
+		}
+		if failedHits.Load() > 1 || okHits.Load() != 1 {
+			t.Fatalf("expected each backend to be tried once, got failed %d, ok %d", failedHits.Load(), okHits.Load())
+		}
+	}
+
+	// non-idempotent requests are not retried
+	for i := 0; i < 10; i++ {
+		failedHits.Store(0)
+		okHits.Store(0)
+
+		status := perform(fasthttp.MethodPost, false)
+		if failedHits.Load()+okHits.Load() != 1 {
+			t.Fatalf("expected POST request to be sent once, got %d", failedHits.Load()+okHits.Load())
+		}
+		if failedHits.Load() == 1 && status != http.StatusServiceUnavailable {
+			t.Fatalf("expected status 503, got %d", status)
+		}
+	}
+
+	// retry-safe requests are retried regardless of the method
+	for i := 0; i < 10; i++ {
+		if status := perform(fasthttp.MethodPost, true); status != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", status)
+		}
+	}
+}
+
 func TestPoolV2_Stats(t *testing.T) {
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
@@ -431,6 +828,111 @@ func TestPoolV2_InvalidRootCA(t *testing.T) {
 	}
 }
 
+// staticResolver is a DNSCache returning configured addresses
+type staticResolver struct {
+	mu    sync.Mutex
+	addrs []net.IPAddr
+	err   error
+}
+
+func (r *staticResolver) set(addrs []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.addrs = nil
+	for _, a := range addrs {
+		r.addrs = append(r.addrs, net.IPAddr{IP: net.ParseIP(a)})
+	}
+	r.err = err
+}
+
+func (r *staticResolver) LookupIPAddr(_ context.Context, _ string) ([]net.IPAddr, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]net.IPAddr(nil), r.addrs...), r.err
+}
+
+func (r *staticResolver) Refresh() {}
+
+func (r *staticResolver) Stop() {}
+
+func TestPoolV2_DNSRefresh_UpdatesBackends(t *testing.T) {
+	resolver := &staticResolver{}
+	resolver.set([]string{"127.0.0.1"}, nil)
+
+	pool, err := NewPoolV2("backend.test:8080", &PoolV2Options{
+		MaxConnsPerHost:    100,
+		DialTimeout:        1 * time.Second,
+		DNSResolver:        resolver,
+		DNSRefreshInterval: 20 * time.Millisecond,
+		Logger:             zerolog.Nop(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	poolV2 := pool.(*PoolV2)
+
+	if backends := poolV2.Stats().Backends; len(backends) != 1 || backends[0] != "127.0.0.1:8080" {
+		t.Fatalf("unexpected initial backends: %v", backends)
+	}
+
+	waitBackends := func(expected int) []string {
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if backends := poolV2.Stats().Backends; len(backends) == expected {
+				return backends
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("expected %d backends, got %v", expected, poolV2.Stats().Backends)
+		return nil
+	}
+
+	// new address added
+	resolver.set([]string{"127.0.0.1", "127.0.0.2"}, nil)
+	waitBackends(2)
+
+	// old address removed
+	resolver.set([]string{"127.0.0.2"}, nil)
+	if backends := waitBackends(1); backends[0] != "127.0.0.2:8080" {
+		t.Errorf("expected 127.0.0.2:8080 backend, got %v", backends)
+	}
+
+	// resolving errors keep the current backends
+	resolver.set(nil, errors.New("lookup failed"))
+	time.Sleep(100 * time.Millisecond)
+	if backends := poolV2.Stats().Backends; len(backends) != 1 || backends[0] != "127.0.0.2:8080" {
+		t.Errorf("expected backends to be kept on resolving error, got %v", backends)
+	}
+}
+
+func TestPoolV2_DNSRefresh_DisabledForExplicitBackends(t *testing.T) {
+	resolver := &staticResolver{}
+	resolver.set([]string{"127.0.0.1"}, nil)
+
+	pool, err := NewPoolV2("backend.test:8080", &PoolV2Options{
+		MaxConnsPerHost:    100,
+		DialTimeout:        1 * time.Second,
+		Backends:           []string{"127.0.0.3:8080"},
+		DNSResolver:        resolver,
+		DNSRefreshInterval: 20 * time.Millisecond,
+		Logger:             zerolog.Nop(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	time.Sleep(100 * time.Millisecond)
+
+	if backends := pool.(*PoolV2).Stats().Backends; len(backends) != 1 || backends[0] != "127.0.0.3:8080" {
+		t.Errorf("expected explicit backends to be kept, got %v", backends)
+	}
+}
+
 func BenchmarkPoolV2_Get(b *testing.B) {
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
@@ -485,3 +987,59 @@ func BenchmarkPoolV2_Get_Parallel(b *testing.B) {
 	})
 }
 
+func TestPoolV2_Retry_ExcludesFailedBackend(t *testing.T) {
+	// the backend does not accept connections
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	host := listener.Addr().String()
+	listener.Close()
+
+	pool, err := NewPoolV2(host, &PoolV2Options{
+		MaxConnsPerHost: 100,
+		ReadTimeout:     time.Second,
+		WriteTimeout:    time.Second,
+		DialTimeout:     time.Second,
+		Backends:        []string{host},
+		OutlierDetection: OutlierDetectionOptions{
+			ConsecutiveErrors: 100,
+			BaseEjectionTime:  time.Minute,
+			MaxEjectionTime:   time.Minute,
+		},
+		Retry: &RetryOptions{
+			MaxRetries:           2,
+			BackoffBase:          time.Millisecond,
+			BudgetPercent:        100,
+			BudgetMinConcurrency: 1,
+		},
+		Logger: zerolog.Nop(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	// the backend of the failed request is known without the response
+	var req fasthttp.Request
+	var resp fasthttp.Response
+	req.SetRequestURI("http://" + host + "/test")
+
+	backend, err := pool.(BackendSelector).DoNext(&req, &resp, 0)
+	if err == nil || backend != host {
+		t.Fatalf("expected the error of the backend %s, got %q, %v", host, backend, err)
+	}
+
+	// the request which failed on dial is not retried on the same backend
+	var reqCtx fasthttp.RequestCtx
+	reqCtx.Request.SetRequestURI("http://" + host + "/test")
+	reqCtx.Request.Header.SetMethod(fasthttp.MethodGet)
+
+	if err := Perform(&reqCtx, pool, ""); err == nil {
+		t.Fatal("expected the request error")
+	}
+
+	if failures := pool.(*PoolV2).lb.set.Load().failures[0].Load(); failures != 1 {
+		t.Errorf("expected the backend to be tried once, got %d failures", failures)
+	}
+}
@@ -1,25 +1,129 @@
 package proxy
 
 import (
+	"time"
+
 	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/wallarm/api-firewall/internal/platform/tracing"
 	"github.com/wallarm/api-firewall/internal/platform/web"
 )
 
 // Perform function proxies the request to the backend server
 func Perform(ctx *fasthttp.RequestCtx, proxyPool Pool, customHostHeader string) error {
 
+	span := tracing.Start(ctx, "proxy.Perform")
+
+	// propagate the trace context to the backend
+	tracing.Inject(ctx, &ctx.Request)
+
+	err := perform(ctx, proxyPool, customHostHeader)
+
+	span.SetAttributes(attribute.Int("http.response.status_code", ctx.Response.StatusCode()))
+	if addr := ctx.Response.RemoteAddr(); addr != nil {
+		span.SetAttributes(attribute.String("network.peer.address", addr.String()))
+	}
+	span.End(err)
+
+	return err
+}
+
+func perform(ctx *fasthttp.RequestCtx, proxyPool Pool, customHostHeader string) error {
+
 	client, ip, err := proxyPool.Get()
 	if err != nil {
 		return err
 	}
 	defer proxyPool.Put(ip, client)
 
+	// fast response if the circuit breaker does not allow requests to the backend
+	tracker, isTracked := proxyPool.(RequestTracker)
+	if isTracked && !tracker.Allow() {
+		ctx.SetUserValue(web.RequestProxyFailed, true)
+
+		if err := web.RespondError(ctx, fasthttp.StatusServiceUnavailable, ""); err != nil {
+			return err
+		}
+
+		return ErrCircuitOpen
+	}
+
 	if customHostHeader != "" {
 		ctx.Request.Header.SetHost(customHostHeader)
 		ctx.Request.URI().SetHost(customHostHeader)
 	}
 
-	if err := client.Do(&ctx.Request, &ctx.Response); err != nil {
+	// retries are applied to the idempotent and retry-safe requests only
+	var policy *RetryPolicy
+	retrier, isRetrier := proxyPool.(Retrier)
+	if isRetrier {
+		if policy = retrier.RetryPolicy(); policy != nil {
+			policy.Begin()
+			defer policy.End()
+
+			if !IsRetryable(ctx) {
+				policy = nil
+			}
+		}
+	}
+
+	var perTryTimeout time.Duration
+	if policy != nil {
+		perTryTimeout = policy.PerTryTimeout()
+	}
+
+	// the backend is known on the failed requests if it is selected by the pool
+	var backend string
+	if selector, ok := proxyPool.(BackendSelector); ok {
+		backend, err = selector.DoNext(&ctx.Request, &ctx.Response, perTryTimeout)
+	} else {
+		if c, ok := client.(timeoutClient); ok && perTryTimeout > 0 {
+			err = c.DoTimeout(&ctx.Request, &ctx.Response, perTryTimeout)
+		} else {
+			err = client.Do(&ctx.Request, &ctx.Response)
+		}
+
+		if addr := ctx.Response.RemoteAddr(); addr != nil {
+			backend = addr.String()
+		}
+	}
+
+	// report the result for the outlier detection and circuit breaking
+	if isTracked {
+		reportResult(tracker, backend, err, ctx.Response.StatusCode())
+	}
+
+	// resend the failed request to the backends which have not been tried yet
+	var tried []string
+	for retry := 1; policy != nil && retry <= policy.MaxRetries() && shouldRetry(err, ctx.Response.StatusCode()); retry++ {
+		if backend != "" {
+			tried = append(tried, backend)
+		}
+
+		next := retrier.NextBackend(tried)
+		if next == "" || !policy.AcquireRetry() {
+			break
+		}
+
+		time.Sleep(policy.Backoff(retry))
+
+		if isTracked && !tracker.Allow() {
+			policy.ReleaseRetry()
+			break
+		}
+
+		ctx.Response.Reset()
+		err = retrier.DoBackend(&ctx.Request, &ctx.Response, next, perTryTimeout)
+		policy.ReleaseRetry()
+
+		backend = next
+		if isTracked {
+			reportResult(tracker, backend, err, ctx.Response.StatusCode())
+		}
+	}
+
+	if err != nil {
 		// request proxy has been failed
 		ctx.SetUserValue(web.RequestProxyFailed, true)
 
@@ -44,3 +148,19 @@ func Perform(ctx *fasthttp.RequestCtx, proxyPool Pool, customHostHeader string)
 
 	return nil
 }
+
+// reportResult reports the result of the request to the tracker. The local
+// errors are not counted as the backend failures
+func reportResult(tracker RequestTracker, backend string, err error, statusCode int) {
+	if isLocalError(err) {
+		tracker.Release()
+		return
+	}
+
+	tracker.Done(backend, err != nil || statusCode >= fasthttp.StatusInternalServerError)
+}
+
+// timeoutClient is implemented by the clients which support the request timeout
+type timeoutClient interface {
+	DoTimeout(req *fasthttp.Request, resp *fasthttp.Response, timeout time.Duration) error
+}
@@ -0,0 +1,168 @@
+package proxy
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/platform/web"
+)
+
+// Retrier is implemented by the pools which could resend the failed request
+// to another backend
+type Retrier interface {
+	RetryPolicy() *RetryPolicy
+	NextBackend(exclude []string) string
+	DoBackend(req *fasthttp.Request, resp *fasthttp.Response, backend string, timeout time.Duration) error
+}
+
+// RetryOptions configures the retries of the failed requests. The request is
+// retried up to MaxRetries times with the exponential backoff (BackoffBase,
+// BackoffBase*2, ... up to BackoffMax) and random jitter. The number of the
+// concurrent retries is limited by the retry budget: BudgetPercent of the
+// active requests but not less than BudgetMinConcurrency.
+// MaxRetries = 0 disables retries
+type RetryOptions struct {
+	MaxRetries           int
+	PerTryTimeout        time.Duration
+	BackoffBase          time.Duration
+	BackoffMax           time.Duration
+	BudgetPercent        int
+	BudgetMinConcurrency int
+}
+
+// RetryPolicy decides whether the failed request could be retried
+type RetryPolicy struct {
+	opts RetryOptions
+
+	active  atomic.Int64
+	retries atomic.Int64
+}
+
+// NewRetryPolicy creates a new retry policy. Nil is returned if retries are
+// not configured
+func NewRetryPolicy(opts *RetryOptions) *RetryPolicy {
+	if opts == nil || opts.MaxRetries <= 0 {
+		return nil
+	}
+
+	p := &RetryPolicy{opts: *opts}
+
+	if p.opts.BackoffBase <= 0 {
+		p.opts.BackoffBase = 25 * time.Millisecond
+	}
+
+	if p.opts.BackoffMax < p.opts.BackoffBase {
+		p.opts.BackoffMax = 10 * p.opts.BackoffBase
+	}
+
+	if p.opts.BudgetPercent <= 0 {
+		p.opts.BudgetPercent = 20
+	}
+
+	if p.opts.BudgetMinConcurrency <= 0 {
+		p.opts.BudgetMinConcurrency = 3
+	}
+
+	return p
+}
+
+// MaxRetries returns the max number of retries of one request
+func (p *RetryPolicy) MaxRetries() int {
+	return p.opts.MaxRetries
+}
+
+// PerTryTimeout returns the timeout of each attempt. Zero value means that
+// the client timeouts are used
+func (p *RetryPolicy) PerTryTimeout() time.Duration {
+	return p.opts.PerTryTimeout
+}
+
+// Backoff returns the delay before the retry. The retry number starts from 1
+func (p *RetryPolicy) Backoff(retry int) time.Duration {
+	backoff := p.opts.BackoffBase
+	for i := 1; i < retry && backoff < p.opts.BackoffMax; i++ {
+		backoff *= 2
+	}
+
+	backoff = min(backoff, p.opts.BackoffMax)
+
+	// full jitter
+	return rand.N(backoff) + 1
+}
+
+// Begin registers the active request
+func (p *RetryPolicy) Begin() {
+	p.active.Add(1)
+}
+
+// End unregisters the active request
+func (p *RetryPolicy) End() {
+	p.active.Add(-1)
+}
+
+// AcquireRetry checks the retry budget and registers the retry. ReleaseRetry
+// should be called when the retry is finished
+func (p *RetryPolicy) AcquireRetry() bool {
+	limit := max(p.active.Load()*int64(p.opts.BudgetPercent)/100, int64(p.opts.BudgetMinConcurrency))
+
+	if p.retries.Add(1) > limit {
+		p.retries.Add(-1)
+		return false
+	}
+
+	return true
+}
+
+// ReleaseRetry unregisters the finished retry
+func (p *RetryPolicy) ReleaseRetry() {
+	p.retries.Add(-1)
+}
+
+// IsRetryable checks if the request could be safely sent to the backend
+// again: the request method is idempotent or the operation is marked as
+// retry-safe in the OpenAPI specification
+func IsRetryable(ctx *fasthttp.RequestCtx) bool {
+	if ctx.Request.IsBodyStream() {
+		return false
+	}
+
+	switch string(ctx.Request.Header.Method()) {
+	case fasthttp.MethodGet, fasthttp.MethodHead, fasthttp.MethodOptions,
+		fasthttp.MethodTrace, fasthttp.MethodPut, fasthttp.MethodDelete:
+		return true
+	}
+
+	retrySafe, ok := ctx.UserValue(web.RequestRetrySafe).(bool)
+	return ok && retrySafe
+}
+
+// shouldRetry checks if the result of the request allows to retry it
+func shouldRetry(err error, statusCode int) bool {
+	switch err {
+	case nil:
+		switch statusCode {
+		case fasthttp.StatusBadGateway, fasthttp.StatusServiceUnavailable, fasthttp.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	case fasthttp.ErrBodyTooLarge:
+		// the retry would not help to handle the request
+		return false
+	}
+
+	return !isLocalError(err)
+}
+
+// isLocalError checks if the request has failed by the local reason, e.g. the
+// connection limit of the firewall is reached, and has not reached the backend
+func isLocalError(err error) bool {
+	switch err {
+	case fasthttp.ErrNoFreeConns, errPoolClosed:
+		return true
+	}
+
+	return false
+}
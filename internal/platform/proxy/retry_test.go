@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/platform/web"
+)
+
+func TestRetryPolicy_Disabled(t *testing.T) {
+	if p := NewRetryPolicy(&RetryOptions{}); p != nil {
+		t.Error("expected nil retry policy when max retries is not set")
+	}
+	if p := NewRetryPolicy(nil); p != nil {
+		t.Error("expected nil retry policy for nil options")
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := NewRetryPolicy(&RetryOptions{
+		MaxRetries:  5,
+		BackoffBase: 10 * time.Millisecond,
+		BackoffMax:  40 * time.Millisecond,
+	})
+
+	limits := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond}
+	for i, limit := range limits {
+		for j := 0; j < 100; j++ {
+			if backoff := p.Backoff(i + 1); backoff <= 0 || backoff > limit {
+				t.Fatalf("retry %d: backoff %s is out of range (0, %s]", i+1, backoff, limit)
+			}
+		}
+	}
+}
+
+func TestRetryPolicy_Budget(t *testing.T) {
+	p := NewRetryPolicy(&RetryOptions{
+		MaxRetries:           1,
+		BudgetPercent:        50,
+		BudgetMinConcurrency: 1,
+	})
+
+	// min concurrency is used when there are few active requests
+	p.Begin()
+	if !p.AcquireRetry() {
+		t.Fatal("expected retry to be allowed")
+	}
+	if p.AcquireRetry() {
+		t.Fatal("expected retry to be rejected by the budget")
+	}
+	p.ReleaseRetry()
+
+	// the budget grows with the number of active requests
+	for i := 0; i < 5; i++ {
+		p.Begin()
+	}
+	for i := 0; i < 3; i++ {
+		if !p.AcquireRetry() {
+			t.Fatalf("expected retry %d to be allowed", i+1)
+		}
+	}
+	if p.AcquireRetry() {
+		t.Error("expected retry to be rejected by the budget")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		method    string
+		retrySafe bool
+		expected  bool
+	}{
+		{method: fasthttp.MethodGet, expected: true},
+		{method: fasthttp.MethodHead, expected: true},
+		{method: fasthttp.MethodPut, expected: true},
+		{method: fasthttp.MethodDelete, expected: true},
+		{method: fasthttp.MethodPost, expected: false},
+		{method: fasthttp.MethodPatch, expected: false},
+		{method: fasthttp.MethodPost, retrySafe: true, expected: true},
+	}
+
+	for _, tt := range tests {
+		var reqCtx fasthttp.RequestCtx
+		reqCtx.Request.Header.SetMethod(tt.method)
+		if tt.retrySafe {
+			reqCtx.SetUserValue(web.RequestRetrySafe, true)
+		}
+
+		if got := IsRetryable(&reqCtx); got != tt.expected {
+			t.Errorf("%s (retry-safe %v): expected %v, got %v", tt.method, tt.retrySafe, tt.expected, got)
+		}
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	tests := []struct {
+		err        error
+		statusCode int
+		expected   bool
+	}{
+		{statusCode: fasthttp.StatusOK, expected: false},
+		{statusCode: fasthttp.StatusInternalServerError, expected: false},
+		{statusCode: fasthttp.StatusBadGateway, expected: true},
+		{statusCode: fasthttp.StatusServiceUnavailable, expected: true},
+		{statusCode: fasthttp.StatusGatewayTimeout, expected: true},
+		{err: fasthttp.ErrDialTimeout, expected: true},
+		{err: fasthttp.ErrTimeout, expected: true},
+		{err: errors.New("connection reset"), expected: true},
+		{err: fasthttp.ErrNoFreeConns, expected: false},
+		{err: fasthttp.ErrBodyTooLarge, expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := shouldRetry(tt.err, tt.statusCode); got != tt.expected {
+			t.Errorf("error %v, status %d: expected %v, got %v", tt.err, tt.statusCode, tt.expected, got)
+		}
+	}
+}
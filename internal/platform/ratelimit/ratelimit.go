@@ -0,0 +1,375 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karlseguin/ccache/v2"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+const (
+	TokenBucket   = "TOKEN_BUCKET"
+	SlidingWindow = "SLIDING_WINDOW"
+)
+
+// defaultMaxKeys is the number of the stored keys if it is not configured
+const defaultMaxKeys = 100000
+
+var errInvalidPeriod = errors.New("rate limit period should be greater than 0")
+
+// Limiter limits the number of requests per key
+type Limiter interface {
+	// Allow checks if the request with the key is allowed. The duration after
+	// which the request could be retried is returned if it is not allowed
+	Allow(key string) (bool, time.Duration)
+}
+
+// Options configures the limiter: Requests per Period are allowed for each
+// key. Burst is the capacity of the token bucket (Requests by default).
+// The state of at most MaxKeys keys is stored
+type Options struct {
+	Algorithm string
+	Requests  int
+	Period    time.Duration
+	Burst     int
+	MaxKeys   int64
+}
+
+// New creates a new limiter. Nil is returned if the number of requests is not
+// configured
+func New(opts *Options) (Limiter, error) {
+	if opts.Requests <= 0 {
+		return nil, nil
+	}
+
+	if opts.Period <= 0 {
+		return nil, errInvalidPeriod
+	}
+
+	cache := ccache.New(ccache.Configure().MaxSize(opts.MaxKeys))
+
+	switch strings.ToUpper(opts.Algorithm) {
+	case SlidingWindow:
+		return &slidingWindow{
+			cache:    cache,
+			requests: opts.Requests,
+			period:   opts.Period,
+			ttl:      2 * opts.Period,
+			now:      time.Now,
+		}, nil
+	default:
+		capacity := opts.Burst
+		if capacity <= 0 {
+			capacity = opts.Requests
+		}
+
+		rate := float64(opts.Requests) / opts.Period.Seconds()
+
+		return &tokenBucket{
+			cache:    cache,
+			capacity: float64(capacity),
+			rate:     rate,
+			ttl:      time.Duration(float64(capacity)/rate*float64(time.Second)) + opts.Period,
+			now:      time.Now,
+		}, nil
+	}
+}
+
+// tokenBucket allows bursts up to the bucket capacity and refills the bucket
+// with the configured rate
+type tokenBucket struct {
+	cache    *ccache.Cache
+	capacity float64
+	rate     float64
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+type bucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func (l *tokenBucket) clear() {
+	l.cache.Clear()
+}
+
+func (l *tokenBucket) Allow(key string) (bool, time.Duration) {
+	now := l.now()
+
+	item, _ := l.cache.Fetch(key, l.ttl, func() (interface{}, error) {
+		return &bucket{tokens: l.capacity, last: now}, nil
+	})
+	item.Extend(l.ttl)
+
+	b := item.Value().(*bucket)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(l.capacity, b.tokens+elapsed*l.rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// slidingWindow limits the number of requests in the sliding window which is
+// estimated by the counters of the current and the previous fixed windows
+type slidingWindow struct {
+	cache    *ccache.Cache
+	requests int
+	period   time.Duration
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+type window struct {
+	mu       sync.Mutex
+	start    time.Time
+	current  int
+	previous int
+}
+
+func (l *slidingWindow) clear() {
+	l.cache.Clear()
+}
+
+func (l *slidingWindow) Allow(key string) (bool, time.Duration) {
+	now := l.now()
+
+	item, _ := l.cache.Fetch(key, l.ttl, func() (interface{}, error) {
+		return &window{start: now}, nil
+	})
+	item.Extend(l.ttl)
+
+	w := item.Value().(*window)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// move to the window the current time belongs to
+	if passed := now.Sub(w.start); passed >= l.period {
+		windows := passed / l.period
+		w.previous = w.current
+		if windows > 1 {
+			w.previous = 0
+		}
+		w.current = 0
+		w.start = w.start.Add(windows * l.period)
+	}
+
+	elapsed := now.Sub(w.start)
+	weight := float64(l.period-elapsed) / float64(l.period)
+
+	if float64(w.previous)*weight+float64(w.current)+1 <= float64(l.requests) {
+		w.current++
+		return true, 0
+	}
+
+	// the request is allowed in the next window if the current one is full
+	if w.current+1 > l.requests || w.previous == 0 {
+		return false, l.period - elapsed
+	}
+
+	// the weight of the previous window should decrease enough
+	allowedWeight := float64(l.requests-w.current-1) / float64(w.previous)
+	retryAfter := time.Duration((1-allowedWeight)*float64(l.period)) - elapsed
+
+	return false, max(retryAfter, time.Millisecond)
+}
+
+// EndpointLimiter is the limiter of the endpoint with the custom limits
+type EndpointLimiter struct {
+	Method  string
+	Path    string
+	Limiter Limiter
+}
+
+// RateLimiter holds the default limiter and the limiters of the endpoints
+// with the custom limits. It is shared between the handlers to keep the
+// limits state on the OpenAPI specification updates
+type RateLimiter struct {
+	Default   Limiter
+	Endpoints []EndpointLimiter
+
+	algorithm  string
+	maxKeys    int64
+	lock       sync.Mutex
+	operations map[string]Limiter
+}
+
+// NewOperationRateLimiter creates the rate limiter without the default and
+// endpoint limits. It is used to limit the operations with the limits set in
+// the OpenAPI specification only
+func NewOperationRateLimiter(cfg *config.RateLimit) *RateLimiter {
+	return &RateLimiter{
+		algorithm: cfg.Algorithm,
+		maxKeys:   cfg.MaxKeys,
+	}
+}
+
+// NewRateLimiter creates the rate limiter. Nil is returned if the rate limits
+// are not configured
+func NewRateLimiter(cfg *config.RateLimit) (*RateLimiter, error) {
+	if cfg.Requests == 0 && len(cfg.Endpoints) == 0 {
+		return nil, nil
+	}
+
+	defaultLimiter, err := New(&Options{
+		Algorithm: cfg.Algorithm,
+		Requests:  cfg.Requests,
+		Period:    cfg.Period,
+		Burst:     cfg.Burst,
+		MaxKeys:   cfg.MaxKeys,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rl := RateLimiter{
+		Default:   defaultLimiter,
+		algorithm: cfg.Algorithm,
+		maxKeys:   cfg.MaxKeys,
+	}
+
+	for _, endpoint := range cfg.Endpoints {
+		limiter, err := New(&Options{
+			Algorithm: cfg.Algorithm,
+			Requests:  endpoint.Requests,
+			Period:    endpoint.Period,
+			Burst:     endpoint.Burst,
+			MaxKeys:   cfg.MaxKeys,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		rl.Endpoints = append(rl.Endpoints, EndpointLimiter{
+			Method:  endpoint.Method,
+			Path:    endpoint.Path,
+			Limiter: limiter,
+		})
+	}
+
+	return &rl, nil
+}
+
+// Find returns the limiter of the endpoint. The first endpoint with the custom
+// limits which matches the method and the route path is used. Nil is returned
+// if the requests to the endpoint are not limited
+func (r *RateLimiter) Find(method, path string) Limiter {
+	if limiter, ok := r.findEndpoint(method, path); ok {
+		return limiter
+	}
+
+	return r.Default
+}
+
+// FindOperation returns the limiter of the endpoint like Find. The operation
+// limiters set in the OpenAPI specification are used if the endpoint has no
+// custom limits configured
+func (r *RateLimiter) FindOperation(method, path string, operations map[string]Limiter) Limiter {
+	if limiter, ok := r.findEndpoint(method, path); ok {
+		return limiter
+	}
+
+	if limiter, ok := operations[OperationKey(method, path)]; ok {
+		return limiter
+	}
+
+	return r.Default
+}
+
+func (r *RateLimiter) findEndpoint(method, path string) (Limiter, bool) {
+	for _, endpoint := range r.Endpoints {
+		if strings.EqualFold(endpoint.Path, path) && (endpoint.Method == "" || strings.EqualFold(endpoint.Method, method)) {
+			return endpoint.Limiter, true
+		}
+	}
+
+	return nil, false
+}
+
+// Operation returns the limiter of the operation with the limits set in the
+// OpenAPI specification. The limiter is reused while the operation limits
+// are not changed to keep its state on the specification updates
+func (r *RateLimiter) Operation(method, path string, requests int, period time.Duration, burst int) (Limiter, error) {
+	key := fmt.Sprintf("%s|%d|%s|%d", OperationKey(method, path), requests, period, burst)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if limiter, ok := r.operations[key]; ok {
+		return limiter, nil
+	}
+
+	maxKeys := r.maxKeys
+	if maxKeys <= 0 {
+		maxKeys = defaultMaxKeys
+	}
+
+	limiter, err := New(&Options{
+		Algorithm: r.algorithm,
+		Requests:  requests,
+		Period:    period,
+		Burst:     burst,
+		MaxKeys:   maxKeys,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if r.operations == nil {
+		r.operations = make(map[string]Limiter)
+	}
+	r.operations[key] = limiter
+
+	return limiter, nil
+}
+
+// RetainOperations drops the operation limiters which are not in the list of
+// the operations. It is called after the handlers are rebuilt on the
+// specification update, so the limiters of the changed and removed operation
+// limits are not kept. The state of the dropped limiters is cleared
+func (r *RateLimiter) RetainOperations(operations map[string]Limiter) {
+	used := make(map[Limiter]struct{}, len(operations))
+	for _, limiter := range operations {
+		used[limiter] = struct{}{}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for key, limiter := range r.operations {
+		if _, ok := used[limiter]; ok {
+			continue
+		}
+
+		delete(r.operations, key)
+
+		// the limiter could be still used by the requests in progress, so
+		// its cache is cleared instead of being stopped
+		if c, ok := limiter.(interface{ clear() }); ok {
+			c.clear()
+		}
+	}
+}
+
+// OperationKey returns the key of the operation limiter
+func OperationKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
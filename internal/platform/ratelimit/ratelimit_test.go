@@ -0,0 +1,243 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestTokenBucket(t *testing.T) {
+	limiter, err := New(&Options{Algorithm: TokenBucket, Requests: 2, Period: time.Second, Burst: 4, MaxKeys: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock := &fakeClock{now: time.Now()}
+	limiter.(*tokenBucket).now = clock.Now
+
+	// burst is allowed
+	for i := 0; i < 4; i++ {
+		if allowed, _ := limiter.Allow("client"); !allowed {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+	}
+
+	allowed, retryAfter := limiter.Allow("client")
+	if allowed {
+		t.Fatal("request over the burst should be rejected")
+	}
+	if retryAfter != 500*time.Millisecond {
+		t.Errorf("expected retry after 500ms, got %s", retryAfter)
+	}
+
+	// other keys are limited separately
+	if allowed, _ := limiter.Allow("other"); !allowed {
+		t.Error("request with another key should be allowed")
+	}
+
+	// the bucket is refilled with the configured rate
+	clock.Advance(500 * time.Millisecond)
+	if allowed, _ := limiter.Allow("client"); !allowed {
+		t.Error("request should be allowed after the refill")
+	}
+	if allowed, _ := limiter.Allow("client"); allowed {
+		t.Error("request should be rejected")
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	limiter, err := New(&Options{Algorithm: SlidingWindow, Requests: 4, Period: time.Second, MaxKeys: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock := &fakeClock{now: time.Now()}
+	limiter.(*slidingWindow).now = clock.Now
+
+	for i := 0; i < 4; i++ {
+		if allowed, _ := limiter.Allow("client"); !allowed {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+	}
+
+	allowed, retryAfter := limiter.Allow("client")
+	if allowed {
+		t.Fatal("request over the limit should be rejected")
+	}
+	if retryAfter != time.Second {
+		t.Errorf("expected retry after 1s, got %s", retryAfter)
+	}
+
+	// the requests of the previous window are counted with the weight
+	clock.Advance(1250 * time.Millisecond)
+
+	// previous window weight is 0.75: 4 * 0.75 = 3 requests
+	if allowed, _ := limiter.Allow("client"); !allowed {
+		t.Fatal("request should be allowed in the next window")
+	}
+
+	allowed, retryAfter = limiter.Allow("client")
+	if allowed {
+		t.Fatal("request should be rejected by the sliding window")
+	}
+	if retryAfter != 250*time.Millisecond {
+		t.Errorf("expected retry after 250ms, got %s", retryAfter)
+	}
+
+	// all requests are allowed after two periods
+	clock.Advance(2 * time.Second)
+	for i := 0; i < 4; i++ {
+		if allowed, _ := limiter.Allow("client"); !allowed {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+	}
+}
+
+func TestNew_Disabled(t *testing.T) {
+	limiter, err := New(&Options{Requests: 0, Period: time.Second})
+	if err != nil || limiter != nil {
+		t.Errorf("expected nil limiter, got %v, %v", limiter, err)
+	}
+
+	if _, err := New(&Options{Requests: 1}); err == nil {
+		t.Error("expected error for zero period")
+	}
+}
+
+func TestRateLimiter_Find(t *testing.T) {
+	rl, err := NewRateLimiter(&config.RateLimit{
+		Requests:  10,
+		Period:    time.Second,
+		Algorithm: TokenBucket,
+		MaxKeys:   100,
+		Endpoints: config.RateLimitEndpointList{
+			{Method: "POST", Path: "/login", Requests: 1, Period: time.Minute},
+			{Path: "/health", Requests: 0, Period: time.Second},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rl.Find("GET", "/users") != rl.Default {
+		t.Error("expected default limiter for the endpoint without custom limits")
+	}
+
+	if rl.Find("post", "/login") != rl.Endpoints[0].Limiter {
+		t.Error("expected custom limiter of the endpoint")
+	}
+
+	if rl.Find("GET", "/login") != rl.Default {
+		t.Error("expected default limiter for another method")
+	}
+
+	if rl.Find("GET", "/health") != nil {
+		t.Error("expected no limiter for the endpoint with disabled limits")
+	}
+
+	disabled, err := NewRateLimiter(&config.RateLimit{})
+	if err != nil || disabled != nil {
+		t.Errorf("expected nil rate limiter, got %v, %v", disabled, err)
+	}
+}
+
+func TestRateLimiter_FindOperation(t *testing.T) {
+	rl, err := NewRateLimiter(&config.RateLimit{
+		Requests:  10,
+		Period:    time.Second,
+		Algorithm: TokenBucket,
+		MaxKeys:   100,
+		Endpoints: config.RateLimitEndpointList{
+			{Method: "POST", Path: "/login", Requests: 1, Period: time.Minute},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	login, err := rl.Operation("POST", "/login", 5, time.Second, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	users, err := rl.Operation("get", "/users", 5, time.Second, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if same, _ := rl.Operation("GET", "/users", 5, time.Second, 0); same != users {
+		t.Error("expected the same limiter of the operation with unchanged limits")
+	}
+
+	if changed, _ := rl.Operation("GET", "/users", 6, time.Second, 0); changed == users {
+		t.Error("expected new limiter of the operation with changed limits")
+	}
+
+	operations := map[string]Limiter{
+		OperationKey("POST", "/login"): login,
+		OperationKey("GET", "/users"):  users,
+	}
+
+	if rl.FindOperation("POST", "/login", operations) != rl.Endpoints[0].Limiter {
+		t.Error("expected custom limiter of the endpoint")
+	}
+
+	if rl.FindOperation("get", "/users", operations) != users {
+		t.Error("expected limiter of the operation")
+	}
+
+	if rl.FindOperation("GET", "/items", operations) != rl.Default {
+		t.Error("expected default limiter for the operation without limits")
+	}
+
+	if NewOperationRateLimiter(&config.RateLimit{}).FindOperation("GET", "/items", operations) != nil {
+		t.Error("expected no limiter without the default limits")
+	}
+}
+
+func TestRateLimiter_RetainOperations(t *testing.T) {
+	rl := NewOperationRateLimiter(&config.RateLimit{Algorithm: TokenBucket, MaxKeys: 100})
+
+	users, err := rl.Operation("GET", "/users", 1, time.Minute, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if allowed, _ := users.Allow("key"); !allowed {
+		t.Fatal("expected the first request to be allowed")
+	}
+
+	// the limits of the operation are changed by the specification update
+	changed, err := rl.Operation("GET", "/users", 2, time.Minute, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rl.RetainOperations(map[string]Limiter{OperationKey("GET", "/users"): changed})
+
+	if len(rl.operations) != 1 {
+		t.Errorf("expected 1 operation limiter, got %d", len(rl.operations))
+	}
+
+	if same, _ := rl.Operation("GET", "/users", 2, time.Minute, 0); same != changed {
+		t.Error("expected the retained limiter to be reused")
+	}
+
+	// the state of the dropped limiter is cleared
+	if allowed, _ := users.Allow("key"); !allowed {
+		t.Error("expected the state of the dropped limiter to be cleared")
+	}
+}
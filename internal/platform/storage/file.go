@@ -6,7 +6,9 @@ import (
 	"errors"
 	"io"
 	"log"
+	"net/url"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -14,6 +16,7 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/savsgio/gotils/strconv"
 
+	"github.com/wallarm/api-firewall/internal/config"
 	"github.com/wallarm/api-firewall/internal/platform/loader"
 )
 
@@ -28,15 +31,18 @@ type File struct {
 	LastUpdate  time.Time
 	OpenAPISpec *openapi3.T
 	lock        *sync.RWMutex
+	refs        *config.SpecRefs
+	refsContent []byte
 }
 
 var _ DBOpenAPILoader = (*File)(nil)
 
-func NewOpenAPIFromFile(OASPath string) (DBOpenAPILoader, error) {
+func NewOpenAPIFromFile(OASPath string, refs *config.SpecRefs) (DBOpenAPILoader, error) {
 
 	fileObj := File{
 		lock:    &sync.RWMutex{},
 		isReady: false,
+		refs:    refs,
 	}
 
 	var err error
@@ -71,7 +77,19 @@ func (f *File) Load(OASPath string) (bool, error) {
 		return isReady, err
 	}
 
-	parsedSpec, err := loader.ParseOAS(rawSpec, "", undefinedSchemaID)
+	absPath, err := filepath.Abs(OASPath)
+	if err != nil {
+		return isReady, err
+	}
+
+	// the external references are resolved relative to the specification file
+	specLocation := &url.URL{Path: filepath.ToSlash(absPath)}
+	refs, err := newRefReader(specLocation, f.refs, nil, nil)
+	if err != nil {
+		return isReady, err
+	}
+
+	parsedSpec, err := loader.ParseOASWithRefs(rawSpec, specLocation, refs.Read, "", undefinedSchemaID)
 	if err != nil {
 		parsingErrs = errors.Join(parsingErrs, err)
 	}
@@ -81,6 +99,7 @@ func (f *File) Load(OASPath string) (bool, error) {
 
 	f.RawSpec = strconv.B2S(rawSpec)
 	f.OpenAPISpec = parsedSpec
+	f.refsContent = refs.Content()
 	isReady = true
 
 	return isReady, parsingErrs
@@ -133,14 +152,23 @@ func (s *File) Version() int {
 	return currentFileVersion
 }
 
+// ReferencedContent returns the content of the external references of the
+// specification
+func (s *File) ReferencedContent() []byte {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.refsContent
+}
+
 func (s *File) AfterLoad(_ string) error {
 	return nil
 }
 
 func (s *File) ShouldUpdate(newStorage DBOpenAPILoader) bool {
 
-	beforeUpdateSpecs := getChecksum(s.SpecificationRawContent(undefinedSchemaID))
-	afterUpdateSpecs := getChecksum(newStorage.SpecificationRawContent(undefinedSchemaID))
+	beforeUpdateSpecs := specChecksum(s)
+	afterUpdateSpecs := specChecksum(newStorage)
 
 	return !bytes.Equal(beforeUpdateSpecs, afterUpdateSpecs)
 }
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/config"
+)
+
+// refReader reads the external references of the specification. The files are
+// read under the base directory only and the URLs are read from the allowed
+// hosts only. The content of all read references is kept to include it into
+// the specification checksum
+type refReader struct {
+	baseDir      string
+	specHost     string
+	allowedHosts []string
+	customHeader *config.CustomHeader
+	client       *fasthttp.Client
+
+	lock *sync.Mutex
+	refs map[string][]byte
+}
+
+// newRefReader function returns the reader of the external references. The
+// directory of the specification file is used if the base directory is not set
+func newRefReader(specLocation *url.URL, refs *config.SpecRefs, customHeader *config.CustomHeader, client *fasthttp.Client) (*refReader, error) {
+
+	r := refReader{
+		specHost:     specLocation.Host,
+		customHeader: customHeader,
+		client:       client,
+		lock:         &sync.Mutex{},
+		refs:         make(map[string][]byte),
+	}
+
+	if refs != nil {
+		r.baseDir = refs.BaseDir
+		r.allowedHosts = refs.AllowedHosts
+	}
+
+	if r.baseDir == "" && specLocation.Host == "" {
+		r.baseDir = filepath.Dir(filepath.FromSlash(specLocation.Path))
+	}
+
+	if r.baseDir != "" {
+		baseDir, err := filepath.Abs(r.baseDir)
+		if err != nil {
+			return nil, err
+		}
+		if baseDir, err = filepath.EvalSymlinks(baseDir); err != nil {
+			return nil, err
+		}
+		r.baseDir = baseDir
+	}
+
+	if r.client == nil {
+		r.client = &fasthttp.Client{
+			ReadTimeout:  readTimeout,
+			WriteTimeout: writeTimeout,
+		}
+	}
+
+	return &r, nil
+}
+
+// Read method reads the content of the external reference
+func (r *refReader) Read(location *url.URL) ([]byte, error) {
+
+	var content []byte
+	var err error
+
+	switch location.Scheme {
+	case "", "file":
+		content, err = r.readFile(location)
+	case "http", "https":
+		content, err = r.readURL(location)
+	default:
+		err = fmt.Errorf("external reference %s: unsupported scheme", location.Redacted())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.lock.Lock()
+	r.refs[location.String()] = content
+	r.lock.Unlock()
+
+	return content, nil
+}
+
+func (r *refReader) readFile(location *url.URL) ([]byte, error) {
+
+	if r.baseDir == "" {
+		return nil, fmt.Errorf("external reference %s: local files are not allowed", location.Path)
+	}
+
+	path, err := filepath.Abs(filepath.FromSlash(location.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	// symlinks are resolved to not allow the files outside the base directory
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return nil, err
+	}
+
+	rel, err := filepath.Rel(r.baseDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("external reference %s: file is outside the base directory %s", location.Path, r.baseDir)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+func (r *refReader) readURL(location *url.URL) ([]byte, error) {
+
+	if !r.isAllowedHost(location) {
+		return nil, fmt.Errorf("external reference %s: host is not allowed", location.Redacted())
+	}
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+
+	req.SetRequestURI(location.String())
+	req.Header.SetMethod(fasthttp.MethodGet)
+	req.Header.SetUserAgent(userAgent)
+
+	// the custom header is sent to the specification host only
+	if r.customHeader != nil && location.Host == r.specHost {
+		customHeaderName := strings.TrimSpace(r.customHeader.Name)
+		customHeaderValue := strings.TrimSpace(r.customHeader.Value)
+		if customHeaderName != "" && customHeaderValue != "" {
+			req.Header.Set(customHeaderName, customHeaderValue)
+		}
+	}
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	if err := r.client.Do(req, resp); err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, fmt.Errorf("external reference %s: unexpected status code: %d", location.Redacted(), resp.StatusCode())
+	}
+
+	return bytes.Clone(resp.Body()), nil
+}
+
+// isAllowedHost method checks if the host of the location is the specification
+// host or is in the list of the allowed hosts. The allowed host may be set
+// with or without port
+func (r *refReader) isAllowedHost(location *url.URL) bool {
+	if r.specHost != "" && location.Host == r.specHost {
+		return true
+	}
+
+	for _, host := range r.allowedHosts {
+		host = strings.TrimSpace(host)
+		if strings.EqualFold(host, location.Host) || strings.EqualFold(host, location.Hostname()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Content method returns the content of all read references sorted by their
+// locations
+func (r *refReader) Content() []byte {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	locations := make([]string, 0, len(r.refs))
+	for location := range r.refs {
+		locations = append(locations, location)
+	}
+	slices.Sort(locations)
+
+	var content bytes.Buffer
+	for _, location := range locations {
+		content.WriteString(location)
+		content.WriteByte('\n')
+		content.Write(r.refs[location])
+		content.WriteByte('\n')
+	}
+
+	return content.Bytes()
+}
+
+// specChecksum function returns the checksum of the specification and the
+// content of its external references if the storage supports them
+func specChecksum(s DBOpenAPILoader) []byte {
+	content := s.SpecificationRawContent(undefinedSchemaID)
+	if r, ok := s.(interface{ ReferencedContent() []byte }); ok {
+		content = append(content, r.ReferencedContent()...)
+	}
+
+	return getChecksum(content)
+}
@@ -58,7 +58,7 @@ func loadOpenAPIDBVersion(dbStoragePath string, version int, execAfterLoad bool)
 }
 
 // NewOpenAPIFromFileOrURL loads OAS specs from the file or URL and returns the struct with the parsed specs
-func NewOpenAPIFromFileOrURL(specPath string, header *config.CustomHeader) (DBOpenAPILoader, error) {
+func NewOpenAPIFromFileOrURL(specPath string, header *config.CustomHeader, refs *config.SpecRefs) (DBOpenAPILoader, error) {
 
 	var specStorage DBOpenAPILoader
 	var err error
@@ -68,7 +68,7 @@ func NewOpenAPIFromFileOrURL(specPath string, header *config.CustomHeader) (DBOp
 
 	// can't parse string as URL. Try to load spec from file
 	if err != nil || apiSpecURL == nil || apiSpecURL.Scheme == "" {
-		specStorage, err = NewOpenAPIFromFile(specPath)
+		specStorage, err = NewOpenAPIFromFile(specPath, refs)
 		if err != nil {
 			return nil, errors.Wrap(err, "loading OpenAPI specification from file")
 		}
@@ -77,7 +77,7 @@ func NewOpenAPIFromFileOrURL(specPath string, header *config.CustomHeader) (DBOp
 	}
 
 	// try to load spec from
-	specStorage, err = NewOpenAPIFromURL(specPath, header)
+	specStorage, err = NewOpenAPIFromURL(specPath, header, refs)
 	if err != nil {
 		return nil, errors.Wrap(err, "loading OpenAPI specification from URL")
 	}
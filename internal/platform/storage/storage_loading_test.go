@@ -2,8 +2,19 @@ package storage
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
+
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/loader"
 )
 
 const (
@@ -99,6 +110,92 @@ paths:
 }`
 )
 
+const (
+	testSwagger2Scheme = `swagger: 2.0
+info:
+  title: Legacy service
+  version: 1.0.0
+basePath: /api
+consumes:
+  - application/json
+paths:
+  /users/{id}:
+    post:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          type: integer
+        - name: body
+          in: body
+          required: true
+          schema:
+            $ref: '#/definitions/User'
+      responses:
+        '200':
+          description: OK
+definitions:
+  User:
+    type: object
+    required:
+      - name
+    properties:
+      name:
+        type: string`
+	testSwagger2InvalidScheme = `{
+  "swagger": "2.0",
+  "info": {"title": "Legacy service", "version": "1.0.0"},
+  "host": "example.com/api",
+  "paths": {}
+}`
+)
+
+func TestSwagger2SpecLoading(t *testing.T) {
+
+	specPath := filepath.Join(t.TempDir(), "swagger.yaml")
+	if err := os.WriteFile(specPath, []byte(testSwagger2Scheme), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	specStorage, err := NewOpenAPIFromFile(specPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := specStorage.Specification(undefinedSchemaID)
+	if spec == nil {
+		t.Fatal("converted specification not found")
+	}
+
+	pathItem := spec.Paths.Find("/users/{id}")
+	if pathItem == nil || pathItem.Post == nil {
+		t.Fatal("converted specification path not found")
+	}
+
+	if pathItem.Post.RequestBody == nil || pathItem.Post.RequestBody.Value.Content.Get("application/json") == nil {
+		t.Fatal("converted specification request body not found")
+	}
+
+	schema := pathItem.Post.RequestBody.Value.Content.Get("application/json").Schema
+	if schema.Value == nil || len(schema.Value.Required) != 1 || schema.Value.Required[0] != "name" {
+		t.Error("converted specification request body schema is not resolved")
+	}
+
+	// the raw specification is kept unchanged
+	if !bytes.Equal(specStorage.SpecificationRawContent(undefinedSchemaID), []byte(testSwagger2Scheme)) {
+		t.Error("loaded and the original specifications are not equal")
+	}
+
+	invalidSpecPath := filepath.Join(t.TempDir(), "swagger.json")
+	if err := os.WriteFile(invalidSpecPath, []byte(testSwagger2InvalidScheme), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewOpenAPIFromFile(invalidSpecPath, nil); !errors.Is(err, loader.ErrOASParsing) || !errors.Is(err, loader.ErrOASConversion) {
+		t.Errorf("expected the conversion error, got %v", err)
+	}
+}
+
 func TestBasicDBSpecsLoading(t *testing.T) {
 
 	dbSpec, err := NewOpenAPIDB("../../../resources/test/database/wallarm_api.db", dbVersion)
@@ -178,3 +275,177 @@ func TestBasicDBSpecsLoadingV2(t *testing.T) {
 		t.Errorf("the DB versions are not equal. Expected %d, got %d", testDBVersion2, dbSpec.Version())
 	}
 }
+
+const (
+	testMultiFileScheme = `openapi: 3.0.1
+info:
+  title: Multi-file service
+  version: 1.0.0
+paths:
+  /users:
+    post:
+      parameters:
+        - $ref: './parameters.yaml#/Limit'
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: './schemas/user.yaml#/User'
+      responses:
+        '200':
+          description: Ok
+`
+	testMultiFileParameters = `Limit:
+  name: limit
+  in: query
+  schema:
+    type: integer
+`
+	testMultiFileUser = `User:
+  type: object
+  required: [name]
+  properties:
+    name:
+      type: string
+    address:
+      $ref: './address.yaml#/Address'
+`
+	testMultiFileAddress = `Address:
+  type: object
+  required: [%s]
+  properties:
+    city:
+      type: string
+    street:
+      type: string
+`
+	testMultiFileOutsideScheme = `openapi: 3.0.1
+info:
+  title: Multi-file service
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      parameters:
+        - $ref: '../parameters.yaml#/Limit'
+      responses:
+        '200':
+          description: Ok
+`
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMultiFileSpecLoading(t *testing.T) {
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"openapi.yaml":         testMultiFileScheme,
+		"parameters.yaml":      testMultiFileParameters,
+		"schemas/user.yaml":    testMultiFileUser,
+		"schemas/address.yaml": fmt.Sprintf(testMultiFileAddress, "city"),
+	})
+
+	specStorage, err := NewOpenAPIFromFile(filepath.Join(dir, "openapi.yaml"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operation := specStorage.Specification(undefinedSchemaID).Paths.Find("/users").Post
+	if len(operation.Parameters) != 1 || operation.Parameters[0].Value.Name != "limit" {
+		t.Error("referenced parameter is not resolved")
+	}
+
+	schema := operation.RequestBody.Value.Content.Get("application/json").Schema.Value
+	address, ok := schema.Properties["address"]
+	if !ok || address.Value == nil || len(address.Value.Required) != 1 || address.Value.Required[0] != "city" {
+		t.Fatal("nested referenced schema is not resolved")
+	}
+
+	// the change of the referenced file updates the specification
+	sameStorage, err := NewOpenAPIFromFile(filepath.Join(dir, "openapi.yaml"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if specStorage.ShouldUpdate(sameStorage) {
+		t.Error("specification is updated without changes")
+	}
+
+	writeTestFiles(t, dir, map[string]string{"schemas/address.yaml": fmt.Sprintf(testMultiFileAddress, "street")})
+
+	updatedStorage, err := NewOpenAPIFromFile(filepath.Join(dir, "openapi.yaml"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !specStorage.ShouldUpdate(updatedStorage) {
+		t.Error("change of the referenced file is not detected")
+	}
+
+	// the files outside the base directory are not allowed
+	writeTestFiles(t, dir, map[string]string{"specs/openapi.yaml": testMultiFileOutsideScheme})
+
+	if _, err := NewOpenAPIFromFile(filepath.Join(dir, "specs", "openapi.yaml"), nil); !errors.Is(err, loader.ErrOASParsing) {
+		t.Errorf("expected the error of the reference outside the base directory, got %v", err)
+	}
+
+	if _, err := NewOpenAPIFromFile(filepath.Join(dir, "specs", "openapi.yaml"), &config.SpecRefs{BaseDir: dir}); err != nil {
+		t.Errorf("reference under the configured base directory is not loaded: %v", err)
+	}
+}
+
+func TestMultiFileSpecLoadingFromURL(t *testing.T) {
+
+	files := map[string]string{
+		"/openapi.yaml":         testMultiFileScheme,
+		"/parameters.yaml":      testMultiFileParameters,
+		"/schemas/user.yaml":    strings.ReplaceAll(testMultiFileUser, "./address.yaml", "http://localhost:%s/schemas/address.yaml"),
+		"/schemas/address.yaml": fmt.Sprintf(testMultiFileAddress, "city"),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["/schemas/user.yaml"] = fmt.Sprintf(files["/schemas/user.yaml"], serverURL.Port())
+
+	specURL := server.URL + "/openapi.yaml"
+
+	// the references to the other hosts are not allowed by default
+	if _, err := NewOpenAPIFromURL(specURL, &config.CustomHeader{}, nil); !errors.Is(err, loader.ErrOASParsing) {
+		t.Errorf("expected the error of the reference to the not allowed host, got %v", err)
+	}
+
+	specStorage, err := NewOpenAPIFromURL(specURL, &config.CustomHeader{}, &config.SpecRefs{AllowedHosts: []string{"localhost"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := specStorage.Specification(undefinedSchemaID).Paths.Find("/users").Post.RequestBody.Value.Content.Get("application/json").Schema.Value
+	if address, ok := schema.Properties["address"]; !ok || address.Value == nil || len(address.Value.Required) != 1 {
+		t.Error("referenced schema from the allowed host is not resolved")
+	}
+}
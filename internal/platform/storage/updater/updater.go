@@ -9,5 +9,6 @@ type Updater interface {
 	Start() error
 	Shutdown() error
 	Load() (storage.DBOpenAPILoader, error)
+	Update() (bool, error)
 	Find(rctx *router.Context, schemaID int, method, path string) (router.Handler, error)
 }
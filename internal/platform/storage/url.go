@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	neturl "net/url"
 	"strings"
 	"sync"
 	"time"
@@ -22,6 +23,8 @@ type URL struct {
 	isReady      bool
 	url          string
 	customHeader *config.CustomHeader
+	refs         *config.SpecRefs
+	refsContent  []byte
 	RawSpec      string
 	LastUpdate   time.Time
 	OpenAPISpec  *openapi3.T
@@ -39,7 +42,7 @@ const (
 
 var _ DBOpenAPILoader = (*URL)(nil)
 
-func NewOpenAPIFromURL(url string, customHeader *config.CustomHeader) (DBOpenAPILoader, error) {
+func NewOpenAPIFromURL(url string, customHeader *config.CustomHeader, refs *config.SpecRefs) (DBOpenAPILoader, error) {
 
 	var err error
 
@@ -56,6 +59,7 @@ func NewOpenAPIFromURL(url string, customHeader *config.CustomHeader) (DBOpenAPI
 		isReady:      false,
 		url:          url,
 		customHeader: customHeader,
+		refs:         refs,
 		client:       &client,
 	}
 
@@ -93,7 +97,18 @@ func (u *URL) Load(url string) (bool, error) {
 
 	rawSpec := resp.Body()
 
-	parsedSpec, err := loader.ParseOAS(rawSpec, "", undefinedSchemaID)
+	// the external references are resolved relative to the specification URL
+	specLocation, err := neturl.Parse(url)
+	if err != nil {
+		return isReady, err
+	}
+
+	refs, err := newRefReader(specLocation, u.refs, u.customHeader, u.client)
+	if err != nil {
+		return isReady, err
+	}
+
+	parsedSpec, err := loader.ParseOASWithRefs(rawSpec, specLocation, refs.Read, "", undefinedSchemaID)
 	if err != nil {
 		parsingErrs = errors.Join(parsingErrs, err)
 	}
@@ -103,6 +118,7 @@ func (u *URL) Load(url string) (bool, error) {
 
 	u.RawSpec = strconv.B2S(rawSpec)
 	u.OpenAPISpec = parsedSpec
+	u.refsContent = refs.Content()
 	isReady = true
 
 	return isReady, parsingErrs
@@ -155,14 +171,23 @@ func (u *URL) Version() int {
 	return currentURLVersion
 }
 
+// ReferencedContent returns the content of the external references of the
+// specification
+func (u *URL) ReferencedContent() []byte {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	return u.refsContent
+}
+
 func (u *URL) AfterLoad(_ string) error {
 	return nil
 }
 
 func (u *URL) ShouldUpdate(newStorage DBOpenAPILoader) bool {
 
-	beforeUpdateSpecs := getChecksum(u.SpecificationRawContent(undefinedSchemaID))
-	afterUpdateSpecs := getChecksum(newStorage.SpecificationRawContent(undefinedSchemaID))
+	beforeUpdateSpecs := specChecksum(u)
+	afterUpdateSpecs := specChecksum(newStorage)
 
 	return !bytes.Equal(beforeUpdateSpecs, afterUpdateSpecs)
 }
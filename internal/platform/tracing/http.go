@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/savsgio/gotils/strconv"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wallarm/api-firewall/internal/platform/router"
+)
+
+// headerCarrier adapts the fasthttp request headers to the propagation.TextMapCarrier
+type headerCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+var _ propagation.TextMapCarrier = (*headerCarrier)(nil)
+
+func (c *headerCarrier) Get(key string) string {
+	return string(c.header.Peek(key))
+}
+
+func (c *headerCarrier) Set(key, value string) {
+	c.header.Set(key, value)
+}
+
+func (c *headerCarrier) Keys() []string {
+	var keys []string
+	c.header.VisitAll(func(k, _ []byte) {
+		keys = append(keys, string(k))
+	})
+	return keys
+}
+
+// Handler returns the handler which starts the server span of the request. The
+// span is the child of the span passed in the W3C traceparent header. The next
+// handler is returned as is if the tracing is disabled
+func Handler(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	if !enabled.Load() {
+		return next
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		method := string(ctx.Method())
+
+		parent := propagator.Extract(context.Background(), &headerCarrier{header: &ctx.Request.Header})
+		spanCtx, span := tracer.Start(parent, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", method),
+				attribute.String("url.path", string(ctx.Path())),
+				attribute.String("server.address", string(ctx.Host())),
+				attribute.String("client.address", ctx.RemoteIP().String()),
+			),
+		)
+		defer span.End()
+
+		ctx.SetUserValue(contextKey{}, spanCtx)
+
+		next(ctx)
+
+		if rctx, ok := ctx.UserValue(router.RouteCtxKey).(*router.Context); ok && rctx.RoutePattern() != "" {
+			span.SetName(method + " " + rctx.RoutePattern())
+			span.SetAttributes(attribute.String("http.route", rctx.RoutePattern()))
+		}
+
+		statusCode := ctx.Response.StatusCode()
+		span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+		if statusCode >= fasthttp.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.B2S(ctx.Response.Header.StatusMessage()))
+		}
+	}
+}
+
+// Inject sets the W3C traceparent header of the request sent to the backend
+// to the context of the current span of the request
+func Inject(ctx *fasthttp.RequestCtx, req *fasthttp.Request) {
+	if !enabled.Load() {
+		return
+	}
+
+	propagator.Inject(spanContext(ctx), &headerCarrier{header: &req.Header})
+}
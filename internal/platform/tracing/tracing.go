@@ -0,0 +1,195 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/version"
+)
+
+const (
+	ExporterOTLP = "OTLP"
+	ExporterFile = "FILE"
+
+	tracerName = "github.com/wallarm/api-firewall"
+)
+
+// contextKey is the key of the request context value which holds the context
+// of the current span of the request
+type contextKey struct{}
+
+var (
+	enabled    atomic.Bool
+	tracer     trace.Tracer = otel.Tracer(tracerName)
+	propagator              = propagation.TraceContext{}
+)
+
+// Enabled returns true if the tracing is initialized
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Init function initializes the OpenTelemetry tracer provider with the
+// configured exporter. The returned function flushes the collected spans and
+// stops the exporter. Nothing is done if the tracing is disabled
+func Init(cfg *config.Tracing, logger zerolog.Logger) (func(context.Context) error, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var exporter sdktrace.SpanExporter
+
+	switch strings.ToUpper(cfg.Exporter) {
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+
+		e, err := otlptracehttp.New(context.Background(), opts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "OTLP exporter init")
+		}
+		exporter = e
+	case ExporterFile:
+		f, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, errors.Wrap(err, "traces file open")
+		}
+
+		e, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, errors.Wrap(err, "file exporter init")
+		}
+		exporter = &fileExporter{SpanExporter: e, file: f}
+	default:
+		return nil, errors.Errorf("unsupported traces exporter %q", cfg.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.ServiceName),
+		attribute.String("service.version", version.Version),
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, "traces resource init")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Error().Err(err).Msg("OpenTelemetry tracing error")
+	}))
+
+	tracer = provider.Tracer(tracerName)
+	enabled.Store(true)
+
+	return func(ctx context.Context) error {
+		enabled.Store(false)
+		return provider.Shutdown(ctx)
+	}, nil
+}
+
+// fileExporter closes the traces file on shutdown
+type fileExporter struct {
+	sdktrace.SpanExporter
+	file *os.File
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	if err := e.SpanExporter.Shutdown(ctx); err != nil {
+		return err
+	}
+	return e.file.Close()
+}
+
+// Span is the span of the request processing stage
+type Span struct {
+	span   trace.Span
+	reqCtx requestCtx
+	parent context.Context
+}
+
+// Start starts the child span of the current span of the request. If ctx is
+// the request context then the new span becomes the current span of the
+// request until it is ended. Nil is returned if the tracing is disabled
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) *Span {
+	if !enabled.Load() {
+		return nil
+	}
+
+	reqCtx, ok := ctx.(requestCtx)
+	if !ok {
+		_, span := tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+		return &Span{span: span}
+	}
+
+	parent := spanContext(reqCtx)
+	spanCtx, span := tracer.Start(parent, name, trace.WithAttributes(attrs...))
+	reqCtx.SetUserValue(contextKey{}, spanCtx)
+
+	return &Span{span: span, reqCtx: reqCtx, parent: parent}
+}
+
+// SetAttributes sets the attributes of the span
+func (s *Span) SetAttributes(attrs ...attribute.KeyValue) {
+	if s == nil {
+		return
+	}
+
+	s.span.SetAttributes(attrs...)
+}
+
+// End ends the span. The span status is set to error if err is not nil
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+
+	s.span.End()
+
+	// restore the parent span as the current span of the request
+	if s.reqCtx != nil {
+		s.reqCtx.SetUserValue(contextKey{}, s.parent)
+	}
+}
+
+// requestCtx is the request context which stores the current span context
+type requestCtx interface {
+	UserValue(key any) any
+	SetUserValue(key, value any)
+}
+
+// spanContext returns the context of the current span of the request
+func spanContext(reqCtx requestCtx) context.Context {
+	if c, ok := reqCtx.UserValue(contextKey{}).(context.Context); ok {
+		return c
+	}
+	return context.Background()
+}
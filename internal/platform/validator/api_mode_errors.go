@@ -2,6 +2,7 @@ package validator
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -106,6 +107,8 @@ func checkRequiredFields(reqErr *openapi3filter.RequestError, schemaError *opena
 				details.Pattern = fmt.Sprintf(">=%0.4f", *schemaError.Schema.Min)
 			case "pattern":
 				details.Pattern = schemaError.Schema.Pattern
+			case "format":
+				details.Format = schemaError.Schema.Format
 			}
 
 			response.FieldsDetails = append(response.FieldsDetails, details)
@@ -172,14 +175,19 @@ func GetErrorResponse(validationError error) ([]*validator.ValidationError, erro
 				}
 				schemaError, ok := err.Err.(*openapi3.SchemaError)
 				if ok {
-					if schemaError.SchemaField == "pattern" {
+					if schemaError.SchemaField == "pattern" || schemaError.SchemaField == "format" {
 						for _, t := range schemaError.Schema.Type.Slice() {
-							response.FieldsDetails = append(response.FieldsDetails, validator.FieldTypeError{
+							details := validator.FieldTypeError{
 								Name:         err.Parameter.Name,
 								ExpectedType: t,
-								Pattern:      schemaError.Schema.Pattern,
 								CurrentValue: fmt.Sprintf("%v", schemaError.Value),
-							})
+							}
+							if schemaError.SchemaField == "pattern" {
+								details.Pattern = schemaError.Schema.Pattern
+							} else {
+								details.Format = schemaError.Schema.Format
+							}
+							response.FieldsDetails = append(response.FieldsDetails, details)
 						}
 					}
 				}
@@ -187,6 +195,41 @@ func GetErrorResponse(validationError error) ([]*validator.ValidationError, erro
 				responseErrors = append(responseErrors, &response)
 			}
 
+			// Validation of the parameter by the JSON schema of the OpenAPI 3.1 specification
+			for _, jsonSchemaError := range jsonSchemaErrors(err.Err) {
+				response := validator.ValidationError{
+					Message: jsonSchemaError.Error(),
+					Fields:  []string{err.Parameter.Name},
+				}
+
+				missed := jsonSchemaError.Keyword == "required"
+				switch err.Parameter.In {
+				case "path":
+					response.Code = validator.ErrCodeRequiredPathParameterInvalidValue
+					if missed {
+						response.Code = validator.ErrCodeRequiredPathParameterMissed
+					}
+				case "query":
+					response.Code = validator.ErrCodeRequiredQueryParameterInvalidValue
+					if missed {
+						response.Code = validator.ErrCodeRequiredQueryParameterMissed
+					}
+				case "cookie":
+					response.Code = validator.ErrCodeRequiredCookieParameterInvalidValue
+					if missed {
+						response.Code = validator.ErrCodeRequiredCookieParameterMissed
+					}
+				case "header":
+					response.Code = validator.ErrCodeRequiredHeaderInvalidValue
+					if missed {
+						response.Code = validator.ErrCodeRequiredHeaderMissed
+					}
+				}
+
+				response.FieldsDetails = jsonSchemaError.FieldDetails(err.Parameter.Name)
+				responseErrors = append(responseErrors, &response)
+			}
+
 			// Validation of the required parameter error
 			switch multiErrors := err.Err.(type) {
 			case openapi3.MultiError:
@@ -274,6 +317,8 @@ func GetErrorResponse(validationError error) ([]*validator.ValidationError, erro
 											details.Pattern = fmt.Sprintf("<=%0.4f", *schemaError.Schema.Max)
 										case "minimum":
 											details.Pattern = fmt.Sprintf(">=%0.4f", *schemaError.Schema.Min)
+										case "format":
+											details.Format = schemaError.Schema.Format
 										}
 
 										response.FieldsDetails = append(response.FieldsDetails, details)
@@ -345,6 +390,8 @@ func GetErrorResponse(validationError error) ([]*validator.ValidationError, erro
 										details.Pattern = fmt.Sprintf("<=%0.4f", *schemaError.Schema.Max)
 									case "minimum":
 										details.Pattern = fmt.Sprintf(">=%0.4f", *schemaError.Schema.Min)
+									case "format":
+										details.Format = schemaError.Schema.Format
 									}
 
 									response.FieldsDetails = append(response.FieldsDetails, details)
@@ -358,6 +405,24 @@ func GetErrorResponse(validationError error) ([]*validator.ValidationError, erro
 			}
 		}
 
+		// Validation of the body by the JSON schema of the OpenAPI 3.1 specification
+		for _, jsonSchemaError := range jsonSchemaErrors(err.Err) {
+			response := validator.ValidationError{
+				Code:    validator.ErrCodeRequiredBodyParameterInvalidValue,
+				Message: jsonSchemaError.Error(),
+			}
+			if jsonSchemaError.Keyword == "required" {
+				response.Code = validator.ErrCodeRequiredBodyParameterMissed
+			}
+
+			if field := jsonSchemaError.Field(); field != "" {
+				response.Fields = []string{field}
+				response.FieldsDetails = jsonSchemaError.FieldDetails(field)
+			}
+
+			responseErrors = append(responseErrors, &response)
+		}
+
 		// Handle request body errors
 		if err.RequestBody != nil {
 
@@ -386,6 +451,9 @@ func GetErrorResponse(validationError error) ([]*validator.ValidationError, erro
 			}
 		}
 
+	case *openapi3filter.ResponseError:
+		responseErrors = getResponseErrorResponse(err)
+
 	case *openapi3filter.SecurityRequirementsError:
 
 		for _, secError := range err.Errors {
@@ -414,3 +482,174 @@ func GetErrorResponse(validationError error) ([]*validator.ValidationError, erro
 
 	return responseErrors, nil
 }
+
+// schemaErrors returns the list of the schema errors found in the validation error
+func schemaErrors(err error) []*openapi3.SchemaError {
+	var result []*openapi3.SchemaError
+
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		for _, multiErr := range e {
+			result = append(result, schemaErrors(multiErr)...)
+		}
+	case *openapi3.SchemaError:
+		result = append(result, e)
+	}
+
+	return result
+}
+
+// schemaFieldDetails returns the details of the field which does not match the schema
+func schemaFieldDetails(name string, schemaError *openapi3.SchemaError) []validator.FieldTypeError {
+	var details []validator.FieldTypeError
+
+	for _, t := range schemaError.Schema.Type.Slice() {
+		d := validator.FieldTypeError{
+			Name:         name,
+			ExpectedType: t,
+			CurrentValue: fmt.Sprintf("%v", schemaError.Value),
+		}
+		switch schemaError.SchemaField {
+		case "pattern":
+			d.Pattern = schemaError.Schema.Pattern
+		case "maximum":
+			d.Pattern = fmt.Sprintf("<=%0.4f", *schemaError.Schema.Max)
+		case "minimum":
+			d.Pattern = fmt.Sprintf(">=%0.4f", *schemaError.Schema.Min)
+		}
+		details = append(details, d)
+	}
+
+	return details
+}
+
+// responseHeaderName returns the name of the header from the response validation error reason
+func responseHeaderName(reason string) string {
+	start := strings.IndexByte(reason, '"')
+	if start < 0 {
+		return ""
+	}
+
+	quoted, err := strconv.QuotedPrefix(reason[start:])
+	if err != nil {
+		return ""
+	}
+
+	name, err := strconv.Unquote(quoted)
+	if err != nil {
+		return ""
+	}
+
+	return name
+}
+
+// getResponseErrorResponse converts the response validation error to the list of the validation errors
+func getResponseErrorResponse(respErr *openapi3filter.ResponseError) []*validator.ValidationError {
+	var responseErrors []*validator.ValidationError
+
+	switch {
+	case respErr.Reason == "status is not supported":
+		responseErrors = append(responseErrors, &validator.ValidationError{
+			Code:    validator.ErrCodeResponseStatusCodeNotFound,
+			Message: validator.ErrResponseStatusNotFound.Error(),
+		})
+
+	case strings.HasPrefix(respErr.Reason, "response header Content-Type has unexpected value"):
+		responseErrors = append(responseErrors, &validator.ValidationError{
+			Code:    validator.ErrCodeResponseContentTypeNotFound,
+			Message: respErr.Error(),
+			Fields:  []string{"Content-Type"},
+		})
+
+	case respErr.Reason == "failed to decode response body" || respErr.Reason == "failed to read response body":
+		responseErrors = append(responseErrors, &validator.ValidationError{
+			Code:    validator.ErrCodeResponseBodyParseError,
+			Message: respErr.Error(),
+		})
+
+	case strings.HasPrefix(respErr.Reason, "response body doesn't match schema"):
+		for _, schemaError := range schemaErrors(respErr.Err) {
+			pointer := schemaError.JSONPointer()
+			field := strings.Join(pointer, ".")
+
+			response := validator.ValidationError{
+				Message: schemaError.Error(),
+			}
+			if field != "" {
+				response.Fields = []string{field}
+			}
+
+			switch schemaError.SchemaField {
+			case "required":
+				response.Code = validator.ErrCodeRequiredResponseBodyParameterMissed
+				if len(pointer) > 0 {
+					if p, lookupErr := schemaError.Schema.Properties.JSONLookup(pointer[len(pointer)-1]); lookupErr == nil {
+						for _, t := range p.(*openapi3.Schema).Type.Slice() {
+							response.FieldsDetails = append(response.FieldsDetails, validator.FieldTypeError{
+								Name:         field,
+								ExpectedType: t,
+							})
+						}
+					}
+				}
+			default:
+				response.Code = validator.ErrCodeRequiredResponseBodyParameterInvalidValue
+				response.FieldsDetails = schemaFieldDetails(field, schemaError)
+			}
+
+			responseErrors = append(responseErrors, &response)
+		}
+
+		for _, jsonSchemaError := range jsonSchemaErrors(respErr.Err) {
+			response := validator.ValidationError{
+				Code:    validator.ErrCodeRequiredResponseBodyParameterInvalidValue,
+				Message: jsonSchemaError.Error(),
+			}
+			if jsonSchemaError.Keyword == "required" {
+				response.Code = validator.ErrCodeRequiredResponseBodyParameterMissed
+			}
+
+			if field := jsonSchemaError.Field(); field != "" {
+				response.Fields = []string{field}
+				response.FieldsDetails = jsonSchemaError.FieldDetails(field)
+			}
+
+			responseErrors = append(responseErrors, &response)
+		}
+
+	case strings.Contains(respErr.Reason, "header"):
+		name := responseHeaderName(respErr.Reason)
+
+		response := validator.ValidationError{
+			Code:    validator.ErrCodeRequiredResponseHeaderInvalidValue,
+			Message: respErr.Error(),
+			Fields:  []string{name},
+		}
+
+		if strings.HasSuffix(respErr.Reason, "missing") {
+			response.Code = validator.ErrCodeRequiredResponseHeaderMissed
+			responseErrors = append(responseErrors, &response)
+			break
+		}
+
+		if parseErr, ok := respErr.Err.(*ParseError); ok {
+			response.FieldsDetails = append(response.FieldsDetails, validator.FieldTypeError{
+				Name:         name,
+				ExpectedType: parseErr.ExpectedType,
+				CurrentValue: parseErr.ValueStr,
+			})
+		}
+
+		for _, schemaError := range schemaErrors(respErr.Err) {
+			response.FieldsDetails = append(response.FieldsDetails, schemaFieldDetails(name, schemaError)...)
+		}
+
+		for _, jsonSchemaError := range jsonSchemaErrors(respErr.Err) {
+			response.FieldsDetails = append(response.FieldsDetails, jsonSchemaError.FieldDetails(name)...)
+		}
+
+		responseErrors = append(responseErrors, &response)
+	}
+
+	return responseErrors
+}
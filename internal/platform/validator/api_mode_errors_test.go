@@ -132,3 +132,45 @@ func TestGetErrorResponse_UnknownError(t *testing.T) {
 		t.Errorf("Expected fallback error code %s, got %s", validator.ErrCodeUnknownValidationError, result[0].Code)
 	}
 }
+
+func TestGetErrorResponse_ResponseHeaderMissed(t *testing.T) {
+	respErr := &openapi3filter.ResponseError{
+		Reason: `response header "X-Rate-Limit" missing`,
+	}
+
+	result, err := GetErrorResponse(respErr)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 validation error, got %d", len(result))
+	}
+
+	if result[0].Code != validator.ErrCodeRequiredResponseHeaderMissed {
+		t.Errorf("Expected error code %s, got %s", validator.ErrCodeRequiredResponseHeaderMissed, result[0].Code)
+	}
+
+	if len(result[0].Fields) != 1 || result[0].Fields[0] != "X-Rate-Limit" {
+		t.Errorf("Expected related field X-Rate-Limit, got %v", result[0].Fields)
+	}
+}
+
+func TestGetErrorResponse_ResponseStatusNotFound(t *testing.T) {
+	respErr := &openapi3filter.ResponseError{
+		Reason: "status is not supported",
+	}
+
+	result, err := GetErrorResponse(respErr)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 validation error, got %d", len(result))
+	}
+
+	if result[0].Code != validator.ErrCodeResponseStatusCodeNotFound {
+		t.Errorf("Expected error code %s, got %s", validator.ErrCodeResponseStatusCodeNotFound, result[0].Code)
+	}
+}
@@ -0,0 +1,109 @@
+package validator
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/pkg/errors"
+	"github.com/savsgio/gotils/strconv"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+	"github.com/valyala/fastjson"
+
+	"github.com/wallarm/api-firewall/internal/platform/loader"
+	"github.com/wallarm/api-firewall/internal/platform/metrics"
+	"github.com/wallarm/api-firewall/internal/platform/router"
+	"github.com/wallarm/api-firewall/internal/platform/web"
+	"github.com/wallarm/api-firewall/pkg/APIMode/validator"
+)
+
+var apiModeResponseValidationOptions = &openapi3filter.Options{
+	MultiError:            true,
+	IncludeResponseStatus: true,
+}
+
+// APIModeValidateResponse validates the response stored in the request context against the operation of the request
+func APIModeValidateResponse(ctx *fasthttp.RequestCtx, metrics metrics.Metrics, schemaID int, jsonParserPool *fastjson.ParserPool, openAPI *loader.CustomRoute) (validationErrs []*validator.ValidationError, err error) {
+
+	// handle panic
+	defer func() {
+		if r := recover(); r != nil {
+
+			switch e := r.(type) {
+			case error:
+				err = e
+			default:
+				metrics.IncErrorTypeCounter("response processing error", schemaID)
+				err = fmt.Errorf("panic: %v", r)
+			}
+
+			return
+		}
+	}()
+
+	// Get path parameters
+	var pathParams map[string]string
+
+	if openAPI.ParametersNumberInPath > 0 {
+		pathParams = router.AllURLParams(ctx)
+	}
+
+	// Convert fasthttp request to net/http request
+	req := http.Request{}
+	if err := fasthttpadaptor.ConvertRequest(ctx, &req, false); err != nil {
+		metrics.IncErrorTypeCounter("request context error", schemaID)
+		return nil, errors.Wrap(err, "request context error")
+	}
+
+	// Prepare response headers
+	respHeader := http.Header{}
+	ctx.Response.Header.VisitAll(func(k, v []byte) {
+		respHeader.Add(strconv.B2S(k), strconv.B2S(v))
+	})
+
+	// Decode response body
+	responseBodyReader, err := web.GetDecompressedResponseBody(&ctx.Response, strconv.B2S(ctx.Response.Header.ContentEncoding()))
+	if err != nil {
+		metrics.IncErrorTypeCounter("response body decompression error", schemaID)
+		return nil, errors.Wrap(err, "response body decompression error")
+	}
+
+	responseValidationInput := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:     &req,
+			PathParams:  pathParams,
+			Route:       openAPI.Route,
+			QueryParams: req.URL.Query(),
+		},
+		Status:  ctx.Response.StatusCode(),
+		Header:  respHeader,
+		Body:    responseBodyReader,
+		Options: apiModeResponseValidationOptions,
+	}
+
+	// Get fastjson parser
+	jsonParser := jsonParserPool.Get()
+	defer jsonParserPool.Put(jsonParser)
+
+	valRespErrors := ValidateResponse(ctx, responseValidationInput, jsonParser)
+	if valRespErrors == nil {
+		return nil, nil
+	}
+
+	var respErrors []*validator.ValidationError
+
+	switch valErr := valRespErrors.(type) {
+	case openapi3.MultiError:
+		for _, currentErr := range valErr {
+			parsedValErrs, _ := GetErrorResponse(currentErr)
+			respErrors = append(respErrors, parsedValErrs...)
+		}
+	default:
+		parsedValErrs, _ := GetErrorResponse(valErr)
+		respErrors = append(respErrors, parsedValErrs...)
+	}
+
+	return respErrors, nil
+}
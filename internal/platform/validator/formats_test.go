@@ -0,0 +1,145 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fastjson"
+
+	"github.com/wallarm/api-firewall/internal/config"
+	"github.com/wallarm/api-firewall/internal/platform/formats"
+	"github.com/wallarm/api-firewall/internal/platform/loader"
+	"github.com/wallarm/api-firewall/pkg/APIMode/validator"
+)
+
+const stringFormatsSpec = `
+openapi: %s
+info:
+  title: 'Validator'
+  version: 0.0.1
+paths:
+  /users:
+    post:
+      parameters:
+        - name: email
+          in: query
+          schema:
+            type: string
+            format: email
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                id:
+                  type: string
+                  format: uuid
+                iban:
+                  type: string
+                  format: iban
+      responses:
+        '200':
+          description: Ok
+`
+
+func TestStringFormatsValidation(t *testing.T) {
+
+	var custom config.StringFormatList
+	require.NoError(t, custom.Set("iban=iban"))
+
+	require.NoError(t, formats.Configure(&config.StringFormats{Validation: true, Custom: custom}))
+	defer formats.Configure(&config.StringFormats{})
+
+	tests := []struct {
+		name    string
+		url     string
+		body    string
+		codes   []string
+		details []validator.FieldTypeError
+	}{
+		{
+			name: "valid request",
+			url:  "/users?email=user@example.com",
+			body: `{"id":"123e4567-e89b-12d3-a456-426614174000","iban":"DE89370400440532013000"}`,
+		},
+		{
+			name:  "invalid standard formats",
+			url:   "/users?email=user",
+			body:  `{"id":"123"}`,
+			codes: []string{validator.ErrCodeRequiredQueryParameterInvalidValue, validator.ErrCodeRequiredBodyParameterInvalidValue},
+			details: []validator.FieldTypeError{
+				{Name: "email", ExpectedType: "string", Format: "email", CurrentValue: "user"},
+				{Name: "id", ExpectedType: "string", Format: "uuid", CurrentValue: "123"},
+			},
+		},
+		{
+			name:  "invalid custom format",
+			url:   "/users",
+			body:  `{"iban":"DE89370400440532013001"}`,
+			codes: []string{validator.ErrCodeRequiredBodyParameterInvalidValue},
+			details: []validator.FieldTypeError{
+				{Name: "iban", ExpectedType: "string", Format: "iban", CurrentValue: "DE89370400440532013001"},
+			},
+		},
+	}
+
+	for _, version := range []string{"3.0.1", "3.1.0"} {
+		doc, err := loader.ParseOAS([]byte(fmt.Sprintf(stringFormatsSpec, version)), "", 0)
+		require.NoError(t, err)
+
+		router, err := gorillamux.NewRouter(doc)
+		require.NoError(t, err)
+
+		for _, tc := range tests {
+			t.Run(version+" "+tc.name, func(t *testing.T) {
+				req, err := http.NewRequest(http.MethodPost, tc.url, bytes.NewReader([]byte(tc.body)))
+				require.NoError(t, err)
+				req.Header.Set("Content-Type", "application/json")
+
+				route, pathParams, err := router.FindRoute(req)
+				require.NoError(t, err)
+
+				input := &openapi3filter.RequestValidationInput{
+					Request:    req,
+					PathParams: pathParams,
+					Route:      route,
+					Options:    &openapi3filter.Options{MultiError: true},
+				}
+
+				err = ValidateRequest(context.Background(), input, &fastjson.Parser{})
+				if len(tc.codes) == 0 {
+					require.NoError(t, err)
+					return
+				}
+				require.Error(t, err)
+
+				multiErr, ok := err.(openapi3.MultiError)
+				require.True(t, ok)
+
+				var codes []string
+				var details []validator.FieldTypeError
+				for _, currentErr := range multiErr {
+					validationErrors, err := GetErrorResponse(currentErr)
+					require.NoError(t, err)
+
+					for _, e := range validationErrors {
+						codes = append(codes, e.Code)
+						details = append(details, e.FieldsDetails...)
+					}
+				}
+				assert.ElementsMatch(t, tc.codes, codes)
+				assert.ElementsMatch(t, tc.details, details)
+			})
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/wallarm/api-firewall/internal/platform/loader"
+	"github.com/wallarm/api-firewall/pkg/APIMode/validator"
+)
+
+// JSONSchemaError is the error of the value validation by the JSON Schema
+// 2020-12 of the OpenAPI 3.1 specification
+type JSONSchemaError struct {
+	Value   any
+	Keyword string
+	Reason  string
+	Path    []string
+	Schema  *jsonschema.Schema
+}
+
+func (e *JSONSchemaError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Reason
+	}
+
+	return fmt.Sprintf("Error at %q: %s", "/"+strings.Join(e.Path, "/"), e.Reason)
+}
+
+// JSONPointer returns the path to the invalid value. For the required keyword
+// the path includes the name of the missing property
+func (e *JSONSchemaError) JSONPointer() []string {
+	return e.Path
+}
+
+// Field returns the path to the invalid value joined by the dot
+func (e *JSONSchemaError) Field() string {
+	return strings.Join(e.Path, ".")
+}
+
+// ExpectedTypes returns the types of the schema of the invalid value
+func (e *JSONSchemaError) ExpectedTypes() []string {
+	if e.Schema == nil {
+		return nil
+	}
+
+	return e.Schema.Types
+}
+
+// FieldDetails returns the details of the invalid value
+func (e *JSONSchemaError) FieldDetails(name string) []validator.FieldTypeError {
+	if e.Schema == nil {
+		return nil
+	}
+
+	var details []validator.FieldTypeError
+	for _, t := range e.Schema.Types {
+		d := validator.FieldTypeError{
+			Name:         name,
+			ExpectedType: t,
+		}
+		if e.Keyword != "required" {
+			d.CurrentValue = fmt.Sprintf("%v", e.Value)
+		}
+
+		switch {
+		case e.Keyword == "pattern" && e.Schema.Pattern != nil:
+			d.Pattern = e.Schema.Pattern.String()
+		case e.Keyword == "maximum" && e.Schema.Maximum != nil:
+			d.Pattern = "<=" + e.Schema.Maximum.FloatString(4)
+		case e.Keyword == "minimum" && e.Schema.Minimum != nil:
+			d.Pattern = ">=" + e.Schema.Minimum.FloatString(4)
+		case e.Keyword == "exclusiveMaximum" && e.Schema.ExclusiveMaximum != nil:
+			d.Pattern = "<" + e.Schema.ExclusiveMaximum.FloatString(4)
+		case e.Keyword == "exclusiveMinimum" && e.Schema.ExclusiveMinimum != nil:
+			d.Pattern = ">" + e.Schema.ExclusiveMinimum.FloatString(4)
+		case e.Keyword == "format":
+			d.Format = e.Schema.Format
+		}
+
+		details = append(details, d)
+	}
+
+	return details
+}
+
+// visitJSON validates the value by the JSON Schema 2020-12 if the schema is
+// loaded from the OpenAPI 3.1 specification. Otherwise, the OpenAPI 3.0
+// schema validation is used
+func visitJSON(schema *openapi3.Schema, value any, multiError bool, opts ...openapi3.SchemaValidationOption) error {
+	jsonSchema := loader.GetJSONSchema(schema)
+	if jsonSchema == nil {
+		return schema.VisitJSON(value, opts...)
+	}
+
+	// the JSON schema validator accepts the JSON values only
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	var jsonValue any
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&jsonValue); err != nil {
+		return err
+	}
+
+	err = jsonSchema.Validate(jsonValue)
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	var result openapi3.MultiError
+	for _, leaf := range leafValidationErrors(validationErr) {
+		result = append(result, newJSONSchemaErrors(jsonSchema, jsonValue, leaf)...)
+	}
+
+	if len(result) == 0 {
+		return err
+	}
+
+	if !multiError {
+		return result[0]
+	}
+
+	return result
+}
+
+// leafValidationErrors returns the validation errors without causes
+func leafValidationErrors(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+
+	var result []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		result = append(result, leafValidationErrors(cause)...)
+	}
+
+	return result
+}
+
+// newJSONSchemaErrors converts the JSON schema validation error to the list
+// of errors. The error is created for each missing property of the required
+// keyword
+func newJSONSchemaErrors(jsonSchema *loader.JSONSchema, root any, err *jsonschema.ValidationError) []error {
+	keyword := err.KeywordLocation[strings.LastIndexByte(err.KeywordLocation, '/')+1:]
+	path := splitPointer(err.InstanceLocation)
+	value := lookupValue(root, path)
+	schema := jsonSchema.Subschema(strings.TrimSuffix(err.AbsoluteKeywordLocation, "/"+keyword))
+
+	if keyword == "required" && schema != nil {
+		object, _ := value.(map[string]any)
+
+		var result []error
+		for _, name := range schema.Required {
+			if _, ok := object[name]; ok {
+				continue
+			}
+
+			var propertySchema *jsonschema.Schema
+			if schema.Properties != nil {
+				propertySchema = schema.Properties[name]
+			}
+
+			result = append(result, &JSONSchemaError{
+				Keyword: keyword,
+				Reason:  fmt.Sprintf("property %q is missing", name),
+				Path:    append(append([]string{}, path...), name),
+				Schema:  propertySchema,
+			})
+		}
+
+		if len(result) > 0 {
+			return result
+		}
+	}
+
+	return []error{&JSONSchemaError{
+		Value:   value,
+		Keyword: keyword,
+		Reason:  err.Message,
+		Path:    path,
+		Schema:  schema,
+	}}
+}
+
+// splitPointer returns the unescaped tokens of the JSON pointer
+func splitPointer(pointer string) []string {
+	pointer = strings.TrimPrefix(pointer, "/")
+	if pointer == "" {
+		return nil
+	}
+
+	tokens := strings.Split(pointer, "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens
+}
+
+// lookupValue returns the value by the path
+func lookupValue(value any, path []string) any {
+	for _, token := range path {
+		switch v := value.(type) {
+		case map[string]any:
+			value = v[token]
+		case []any:
+			var i int
+			if _, err := fmt.Sscanf(token, "%d", &i); err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+
+	return value
+}
+
+// jsonSchemaErrors returns the list of the JSON schema errors found in the
+// validation error
+func jsonSchemaErrors(err error) []*JSONSchemaError {
+	var result []*JSONSchemaError
+
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		for _, multiErr := range e {
+			result = append(result, jsonSchemaErrors(multiErr)...)
+		}
+	case *JSONSchemaError:
+		result = append(result, e)
+	}
+
+	return result
+}
+
+// isKnownProperty checks if the property is defined by the schema. The
+// properties of the subschemas and the pattern properties are checked for
+// the schemas of the OpenAPI 3.1 specification
+func isKnownProperty(schema *openapi3.Schema, name string) bool {
+	jsonSchema := loader.GetJSONSchema(schema)
+	if jsonSchema == nil {
+		_, found := schema.Properties[name]
+		return found
+	}
+
+	names, patterns := jsonSchema.PropertyNames()
+	if Contains(names, name) {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// schemaPropertyNames returns the names of the properties defined by the
+// schema
+func schemaPropertyNames(schema *openapi3.Schema) []string {
+	jsonSchema := loader.GetJSONSchema(schema)
+	if jsonSchema == nil {
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		return names
+	}
+
+	names, _ := jsonSchema.PropertyNames()
+	return names
+}
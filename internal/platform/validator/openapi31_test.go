@@ -0,0 +1,242 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fastjson"
+
+	"github.com/wallarm/api-firewall/internal/platform/loader"
+	"github.com/wallarm/api-firewall/pkg/APIMode/validator"
+)
+
+const openAPI31Spec = `
+openapi: 3.1.0
+info:
+  title: 'Validator'
+  version: 0.0.1
+webhooks:
+  newPet:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        '200':
+          description: Ok
+paths:
+  /pets:
+    post:
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            exclusiveMinimum: 0
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        '200':
+          description: Ok
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id]
+                properties:
+                  id:
+                    type: integer
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name, kind]
+      properties:
+        name:
+          type: string
+        nickname:
+          type: [string, 'null']
+        kind:
+          const: pet
+        tags:
+          $ref: '#/components/schemas/Pet/$defs/Tags'
+        meta:
+          type: object
+          example:
+            type: 'null'
+            exclusiveMinimum: 1
+      patternProperties:
+        '^x-':
+          type: string
+      unevaluatedProperties: false
+      $defs:
+        Tags:
+          type: array
+          prefixItems:
+            - type: string
+            - type: integer
+`
+
+func TestOpenAPI31Validation(t *testing.T) {
+
+	doc, err := loader.ParseOAS([]byte(openAPI31Spec), "", 0)
+	require.NoError(t, err)
+
+	router, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+
+	// the example values are not normalized as schemas
+	meta := doc.Components.Schemas["Pet"].Value.Properties["meta"].Value
+	assert.Equal(t, map[string]any{"type": "null", "exclusiveMinimum": float64(1)}, meta.Example)
+
+	tests := []struct {
+		name     string
+		url      string
+		body     string
+		codes    []string
+		fields   []string
+		unknowns []string
+	}{
+		{
+			name: "valid request",
+			url:  "/pets?limit=1",
+			body: `{"name":"cat","nickname":null,"kind":"pet","tags":["a",1],"x-color":"black"}`,
+		},
+		{
+			name:   "exclusive minimum",
+			url:    "/pets?limit=0",
+			body:   `{"name":"cat","kind":"pet"}`,
+			codes:  []string{validator.ErrCodeRequiredQueryParameterInvalidValue},
+			fields: []string{"limit"},
+		},
+		{
+			name:   "missing required property",
+			url:    "/pets",
+			body:   `{"kind":"pet"}`,
+			codes:  []string{validator.ErrCodeRequiredBodyParameterMissed},
+			fields: []string{"name"},
+		},
+		{
+			name:   "invalid const and nullable type",
+			url:    "/pets",
+			body:   `{"name":"cat","nickname":1,"kind":"dog"}`,
+			codes:  []string{validator.ErrCodeRequiredBodyParameterInvalidValue, validator.ErrCodeRequiredBodyParameterInvalidValue},
+			fields: []string{"kind", "nickname"},
+		},
+		{
+			name:   "invalid prefix item",
+			url:    "/pets",
+			body:   `{"name":"cat","kind":"pet","tags":["a","b"]}`,
+			codes:  []string{validator.ErrCodeRequiredBodyParameterInvalidValue},
+			fields: []string{"tags.1"},
+		},
+		{
+			name:     "unevaluated property",
+			url:      "/pets",
+			body:     `{"name":"cat","kind":"pet","color":"black"}`,
+			codes:    []string{validator.ErrCodeRequiredBodyParameterInvalidValue},
+			unknowns: []string{"color"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, tc.url, bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			route, pathParams, err := router.FindRoute(req)
+			require.NoError(t, err)
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    &openapi3filter.Options{MultiError: true},
+			}
+
+			err = ValidateRequest(context.Background(), input, &fastjson.Parser{})
+			if len(tc.codes) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+
+			multiErr, ok := err.(openapi3.MultiError)
+			require.True(t, ok)
+
+			var codes, fields []string
+			for _, currentErr := range multiErr {
+				validationErrors, err := GetErrorResponse(currentErr)
+				require.NoError(t, err)
+
+				for _, e := range validationErrors {
+					codes = append(codes, e.Code)
+					fields = append(fields, e.Fields...)
+				}
+			}
+			assert.ElementsMatch(t, tc.codes, codes)
+			if tc.fields != nil {
+				assert.ElementsMatch(t, tc.fields, fields)
+			}
+
+			if tc.unknowns != nil {
+				var names []string
+				content := route.Operation.RequestBody.Value.Content.Get("application/json")
+				for name := range map[string]struct{}{"name": {}, "kind": {}, "color": {}, "x-color": {}} {
+					if !isKnownProperty(content.Schema.Value, name) {
+						names = append(names, name)
+					}
+				}
+				assert.ElementsMatch(t, tc.unknowns, names)
+			}
+		})
+	}
+
+	t.Run("response", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/pets", nil)
+		require.NoError(t, err)
+
+		route, pathParams, err := router.FindRoute(req)
+		require.NoError(t, err)
+
+		input := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route:      route,
+			},
+			Status:  http.StatusOK,
+			Header:  http.Header{"Content-Type": []string{"application/json"}},
+			Body:    io.NopCloser(bytes.NewReader([]byte(`{"name":"cat"}`))),
+			Options: &openapi3filter.Options{MultiError: true},
+		}
+
+		err = ValidateResponse(context.Background(), input, &fastjson.Parser{})
+		require.Error(t, err)
+
+		validationErrors, err := GetErrorResponse(err)
+		require.NoError(t, err)
+		require.Len(t, validationErrors, 1)
+		assert.Equal(t, validator.ErrCodeRequiredResponseBodyParameterMissed, validationErrors[0].Code)
+		assert.Equal(t, []string{"id"}, validationErrors[0].Fields)
+	})
+
+	t.Run("webhooks", func(t *testing.T) {
+		assert.Contains(t, doc.Extensions, "webhooks")
+	})
+}
@@ -116,7 +116,7 @@ func findUnknownParamsInJSONBody(decodedBody any, contentType *openapi3.MediaTyp
 	}
 
 	for paramName := range paramList {
-		if _, found := contentType.Schema.Value.Properties[paramName]; !found {
+		if !isKnownProperty(contentType.Schema.Value, paramName) {
 			unknownParameters = append(unknownParameters, RequestParameterDetails{
 				Name:        paramName,
 				Placeholder: "body",
@@ -221,7 +221,7 @@ func ValidateUnknownRequestParameters(ctx *fasthttp.RequestCtx, route *routers.R
 		titleRecord := strings.Split(rows[0], ",")
 
 		for _, rName := range titleRecord {
-			if _, found := contentType.Schema.Value.Properties[rName]; !found {
+			if !isKnownProperty(contentType.Schema.Value, rName) {
 				unknownBodyParams.Message = ErrUnknownBodyParameter.Error()
 				unknownBodyParams.Parameters = append(unknownBodyParams.Parameters, RequestParameterDetails{
 					Name:        rName,
@@ -259,7 +259,7 @@ func ValidateUnknownRequestParameters(ctx *fasthttp.RequestCtx, route *routers.R
 
 	case mType == "application/xml" || suffix == "+xml":
 		var propKeys []string
-		for key := range contentType.Schema.Value.Properties {
+		for _, key := range schemaPropertyNames(contentType.Schema.Value) {
 			propKeys = append(propKeys, strings.ToLower(key))
 		}
 
@@ -14,6 +14,8 @@ import (
 	"github.com/getkin/kin-openapi/openapi3filter"
 	"github.com/pkg/errors"
 	"github.com/valyala/fastjson"
+
+	"github.com/wallarm/api-firewall/internal/platform/tracing"
 )
 
 // ErrAuthenticationServiceMissing is returned when no authentication service
@@ -33,6 +35,14 @@ var ErrInvalidEmptyValue = errors.New("empty value is not allowed")
 // Note: One can tune the behavior of uniqueItems: true verification
 // by registering a custom function with openapi3.RegisterArrayUniqueItemsChecker
 func ValidateRequest(ctx context.Context, input *openapi3filter.RequestValidationInput, jsonParser *fastjson.Parser) error {
+	span := tracing.Start(ctx, "validator.ValidateRequest")
+	err := validateRequest(ctx, input, jsonParser)
+	span.End(err)
+
+	return err
+}
+
+func validateRequest(ctx context.Context, input *openapi3filter.RequestValidationInput, jsonParser *fastjson.Parser) error {
 	var me openapi3.MultiError
 
 	options := input.Options
@@ -232,7 +242,7 @@ func ValidateParameter(ctx context.Context, input *openapi3filter.RequestValidat
 		opts = make([]openapi3.SchemaValidationOption, 0, 1)
 		opts = append(opts, openapi3.MultiErrors())
 	}
-	if err = schema.VisitJSON(value, opts...); err != nil {
+	if err = visitJSON(schema, value, options.MultiError, opts...); err != nil {
 		return &openapi3filter.RequestError{Input: input, Parameter: parameter, Err: err}
 	}
 	return nil
@@ -335,7 +345,7 @@ func ValidateRequestBody(ctx context.Context, input *openapi3filter.RequestValid
 	}
 
 	// Validate JSON with the schema
-	if err := contentType.Schema.Value.VisitJSON(value, opts...); err != nil {
+	if err := visitJSON(contentType.Schema.Value, value, options.MultiError, opts...); err != nil {
 		schemaID := prependSpaceIfNeeded(getSchemaIdentifier(contentType.Schema))
 		return &openapi3filter.RequestError{
 			Input:       input,
@@ -11,6 +11,8 @@ import (
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/openapi3filter"
 	"github.com/valyala/fastjson"
+
+	"github.com/wallarm/api-firewall/internal/platform/tracing"
 )
 
 // ValidateResponse is used to validate the given input according to previous
@@ -20,6 +22,14 @@ import (
 // Note: One can tune the behavior of uniqueItems: true verification
 // by registering a custom function with openapi3.RegisterArrayUniqueItemsChecker
 func ValidateResponse(ctx context.Context, input *openapi3filter.ResponseValidationInput, jsonParser *fastjson.Parser) error {
+	span := tracing.Start(ctx, "validator.ValidateResponse")
+	err := validateResponse(ctx, input, jsonParser)
+	span.End(err)
+
+	return err
+}
+
+func validateResponse(ctx context.Context, input *openapi3filter.ResponseValidationInput, jsonParser *fastjson.Parser) error {
 	req := input.RequestValidationInput.Request
 	switch req.Method {
 	case "HEAD":
@@ -133,7 +143,7 @@ func ValidateResponse(ctx context.Context, input *openapi3filter.ResponseValidat
 	}
 
 	// Validate data with the schema.
-	if err := contentType.Schema.Value.VisitJSON(value, append(opts, openapi3.VisitAsResponse())...); err != nil {
+	if err := visitJSON(contentType.Schema.Value, value, options.MultiError, append(opts, openapi3.VisitAsResponse())...); err != nil {
 		schemaID := prependSpaceIfNeeded(getSchemaIdentifier(contentType.Schema))
 		return &openapi3filter.ResponseError{
 			Input:  input,
@@ -168,7 +178,8 @@ func validateResponseHeader(headerName string, headerRef *openapi3.HeaderRef, in
 	}
 
 	if found {
-		if err = headerRef.Value.Schema.Value.VisitJSON(decodedValue, opts...); err != nil {
+		multiError := input.Options != nil && input.Options.MultiError
+		if err = visitJSON(headerRef.Value.Schema.Value, decodedValue, multiError, opts...); err != nil {
 			return &openapi3filter.ResponseError{
 				Input:  input,
 				Reason: fmt.Sprintf("response header %q doesn't match schema", headerName),
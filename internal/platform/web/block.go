@@ -0,0 +1,127 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"text/template"
+
+	"github.com/valyala/fasthttp"
+)
+
+// BlockViolation is the violation of the blocked request which is passed to
+// the block response body template
+type BlockViolation struct {
+	Code    string
+	Message string
+	Fields  []string
+}
+
+// BlockResponseData is the data passed to the block response body template
+type BlockResponseData struct {
+	RequestID  any
+	StatusCode int
+	Method     string
+	Path       string
+	Detail     string
+	Violations []BlockViolation
+}
+
+// BlockResponse is the response to the blocked request with the body rendered
+// from the Go template
+type BlockResponse struct {
+	StatusCode  int
+	ContentType string
+	Headers     map[string]string
+	body        *template.Template
+}
+
+// blockTemplateFuncs are the functions available in the block response body
+// template
+var blockTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	},
+}
+
+// NewBlockResponse function parses the body template and returns the block
+// response. The response body is empty if the body template is not set
+func NewBlockResponse(statusCode int, contentType string, headers map[string]string, body string) (*BlockResponse, error) {
+	b := BlockResponse{
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		Headers:     headers,
+	}
+
+	if body != "" {
+		tmpl, err := template.New("block_response").Funcs(blockTemplateFuncs).Parse(body)
+		if err != nil {
+			return nil, err
+		}
+		b.body = tmpl
+	}
+
+	return &b, nil
+}
+
+// Respond method sends the block response back to the client. The status code
+// of the data is set to the status code of the block response
+func (b *BlockResponse) Respond(ctx *fasthttp.RequestCtx, statusHeader string, data *BlockResponseData) error {
+
+	var body bytes.Buffer
+
+	if b.body != nil {
+		data.StatusCode = b.StatusCode
+		if err := b.body.Execute(&body, data); err != nil {
+			return err
+		}
+	}
+
+	if err := RespondError(ctx, b.StatusCode, statusHeader); err != nil {
+		return err
+	}
+
+	for name, value := range b.Headers {
+		ctx.Response.Header.Set(name, value)
+	}
+
+	if b.ContentType != "" {
+		ctx.SetContentType(b.ContentType)
+	}
+
+	ctx.SetBody(body.Bytes())
+
+	return nil
+}
+
+// WithStatusCode method returns the copy of the block response with the status
+// code replaced. Nil is returned if the block response is not set
+func (b *BlockResponse) WithStatusCode(statusCode int) *BlockResponse {
+	if b == nil {
+		return nil
+	}
+
+	r := *b
+	r.StatusCode = statusCode
+
+	return &r
+}
+
+// RespondBlocked function sends the block response back to the client. The
+// response with the status code and the empty body is sent if the block
+// response is not set
+func RespondBlocked(ctx *fasthttp.RequestCtx, b *BlockResponse, statusCode int, detail string) error {
+	if b == nil {
+		return RespondError(ctx, statusCode, "")
+	}
+
+	return b.Respond(ctx, "", &BlockResponseData{
+		RequestID: ctx.UserValue(RequestID),
+		Method:    string(ctx.Method()),
+		Path:      string(ctx.Path()),
+		Detail:    detail,
+	})
+}
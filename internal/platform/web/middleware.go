@@ -1,6 +1,15 @@
 package web
 
-import "github.com/wallarm/api-firewall/internal/platform/router"
+import (
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/wallarm/api-firewall/internal/platform/router"
+	"github.com/wallarm/api-firewall/internal/platform/tracing"
+)
 
 // Middleware is a function designed to run some code before and/or after
 // another Handler. It is designed to remove boilerplate or other concerns not
@@ -19,8 +28,44 @@ func WrapMiddleware(mw []Middleware, handler router.Handler) router.Handler {
 		h := mw[i]
 		if h != nil {
 			handler = h(handler)
+
+			// each middleware is traced in the separate span
+			if tracing.Enabled() {
+				handler = traceHandler(middlewareName(h), handler)
+			}
 		}
 	}
 
 	return handler
 }
+
+// traceHandler wraps the handler into the span with the passed name
+func traceHandler(name string, handler router.Handler) router.Handler {
+	return func(ctx *fasthttp.RequestCtx) error {
+		span := tracing.Start(ctx, name)
+		err := handler(ctx)
+		span.End(err)
+
+		return err
+	}
+}
+
+// middlewareName returns the name of the function which created the
+// middleware, e.g. mid.Denylist
+func middlewareName(mw Middleware) string {
+	f := runtime.FuncForPC(reflect.ValueOf(mw).Pointer())
+	if f == nil {
+		return "middleware"
+	}
+
+	name := f.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	if i := strings.Index(name, ".func"); i >= 0 {
+		name = name[:i]
+	}
+
+	return name
+}
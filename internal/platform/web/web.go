@@ -29,15 +29,29 @@ const (
 	PassRequestOPTIONS     = "proxy_request_with_options_method"
 	RequestProxyFailed     = "proxy_failed"
 	RequestProxyNoRoute    = "proxy_no_route"
+	RequestRetrySafe       = "proxy_retry_safe"
 	RequestBlocked         = "request_blocked"
 	ResponseBlocked        = "response_blocked"
 	ResponseStatusNotFound = "response_status_not_found"
 
+	RequestValidationFailed  = "request_validation_failed"
+	ResponseValidationFailed = "response_validation_failed"
+	RequestBlockReason       = "request_block_reason"
+	RequestSpecName          = "request_spec_name"
+
+	BlockReasonDenylist           = "denylist"
+	BlockReasonAllowlist          = "allowlist"
+	BlockReasonRateLimit          = "rate_limit"
+	BlockReasonModSecurity        = "modsecurity"
+	BlockReasonRequestValidation  = "request_validation"
+	BlockReasonResponseValidation = "response_validation"
+
 	APIMode     = "api"
 	ProxyMode   = "proxy"
 	GraphQLMode = "graphql"
 
 	GlobalResponseStatusCodeKey = "global_response_status_code"
+	GlobalResponseRetryAfterKey = "global_response_retry_after"
 
 	RequestSchemaID = "__wallarm_apifw_request_schema_id"
 	RequestID       = "__wallarm_apifw_request_id"
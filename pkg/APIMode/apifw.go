@@ -24,6 +24,8 @@ import (
 type APIFirewall interface {
 	ValidateRequestFromReader(schemaIDs []int, r *bufio.Reader) (*validator.ValidationResponse, error)
 	ValidateRequest(schemaIDs []int, uri, method, body []byte, headers map[string][]string) (*validator.ValidationResponse, error)
+	ValidateResponse(schemaIDs []int, uri, method []byte, statusCode int, body []byte, headers map[string][]string) (*validator.ValidationResponse, error)
+	ValidateRequestResponse(schemaIDs []int, uri, method, reqBody []byte, reqHeaders map[string][]string, statusCode int, respBody []byte, respHeaders map[string][]string) (*validator.ValidationResponse, error)
 	UpdateSpecsStorage() ([]int, bool, error)
 	GetPrometheusCollectors() ([]prometheus.Collector, error)
 }
@@ -205,6 +207,33 @@ func (a *APIFWModeAPI) UpdateSpecsStorage() ([]int, bool, error) {
 
 // ValidateRequest method validates request against the spec with provided schema ID
 func (a *APIFWModeAPI) ValidateRequest(schemaIDs []int, uri, method, body []byte, headers map[string][]string) (*validator.ValidationResponse, error) {
+	return a.validate(schemaIDs, func() *fasthttp.RequestCtx {
+		return newRequestCtx(uri, method, body, headers)
+	})
+}
+
+// ValidateResponse method validates response of the request with provided URI and method against the spec with provided schema ID
+func (a *APIFWModeAPI) ValidateResponse(schemaIDs []int, uri, method []byte, statusCode int, body []byte, headers map[string][]string) (*validator.ValidationResponse, error) {
+	return a.validate(schemaIDs, func() *fasthttp.RequestCtx {
+		ctx := newRequestCtx(uri, method, nil, nil)
+		setResponse(ctx, statusCode, body, headers)
+		ctx.SetUserValue(validator.APIModeValidationTargetKey, validator.TargetResponse)
+		return ctx
+	})
+}
+
+// ValidateRequestResponse method validates request and its response against the spec with provided schema ID
+func (a *APIFWModeAPI) ValidateRequestResponse(schemaIDs []int, uri, method, reqBody []byte, reqHeaders map[string][]string, statusCode int, respBody []byte, respHeaders map[string][]string) (*validator.ValidationResponse, error) {
+	return a.validate(schemaIDs, func() *fasthttp.RequestCtx {
+		ctx := newRequestCtx(uri, method, reqBody, reqHeaders)
+		setResponse(ctx, statusCode, respBody, respHeaders)
+		ctx.SetUserValue(validator.APIModeValidationTargetKey, validator.TargetRequestResponse)
+		return ctx
+	})
+}
+
+// validate method validates the request context built by newCtx against the specs with provided schema IDs
+func (a *APIFWModeAPI) validate(schemaIDs []int, newCtx func() *fasthttp.RequestCtx) (*validator.ValidationResponse, error) {
 
 	resp := validator.ValidationResponse{}
 	var respErr error
@@ -218,17 +247,7 @@ func (a *APIFWModeAPI) ValidateRequest(schemaIDs []int, uri, method, body []byte
 	for _, schemaID := range schemaIDs {
 
 		// build fasthttp RequestCTX
-		ctxReq := new(fasthttp.RequestCtx)
-
-		ctxReq.Request.Header.SetRequestURIBytes(uri)
-		ctxReq.Request.Header.SetMethodBytes(method)
-		ctxReq.Request.SetBody(body)
-
-		for hName, hValues := range headers {
-			for _, hValue := range hValues {
-				ctxReq.Request.Header.Add(hName, hValue)
-			}
-		}
+		ctxReq := newCtx()
 
 		wg.Add(1)
 
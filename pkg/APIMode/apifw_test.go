@@ -295,6 +295,48 @@ func validate200OptionsReq(t *testing.T, apifw APIFirewall, schemaID int) {
 	}
 }
 
+func validateResponseReq(t *testing.T, apifw APIFirewall, schemaID int, statusCode int, expectedStatusCode int, expectedErrCodes []string) {
+	res, err := apifw.ValidateResponse([]int{schemaID}, []byte("/?str=test"), []byte("GET"), statusCode, nil, http.Header{})
+	if err != nil {
+		t.Error(err)
+	}
+
+	checkValidationResult(t, res, schemaID, expectedStatusCode, expectedErrCodes)
+}
+
+func validateRequestResponseReq(t *testing.T, apifw APIFirewall, schemaID int, uri string, statusCode int, expectedStatusCode int, expectedErrCodes []string) {
+	res, err := apifw.ValidateRequestResponse([]int{schemaID}, []byte(uri), []byte("GET"), nil, http.Header{}, statusCode, nil, http.Header{})
+	if err != nil {
+		t.Error(err)
+	}
+
+	checkValidationResult(t, res, schemaID, expectedStatusCode, expectedErrCodes)
+}
+
+func checkValidationResult(t *testing.T, res *validator.ValidationResponse, schemaID int, expectedStatusCode int, expectedErrCodes []string) {
+	if len(res.Summary) != 1 {
+		t.Fatalf("expected response with 1 summary for 1 request. Got %d entries in the summary", len(res.Summary))
+	}
+
+	if *res.Summary[0].SchemaID != schemaID {
+		t.Errorf("expected schema ID value %d. Got schema ID %d", schemaID, *res.Summary[0].SchemaID)
+	}
+
+	if *res.Summary[0].StatusCode != expectedStatusCode {
+		t.Errorf("expected status code %d. Got status code %d", expectedStatusCode, *res.Summary[0].StatusCode)
+	}
+
+	if len(res.Errors) != len(expectedErrCodes) {
+		t.Fatalf("expected %d errors in the list. Got %d errors in the list", len(expectedErrCodes), len(res.Errors))
+	}
+
+	for i, code := range expectedErrCodes {
+		if res.Errors[i].Code != code {
+			t.Errorf("expected error code %s. Got error code %s", code, res.Errors[i].Code)
+		}
+	}
+}
+
 func TestAPIFWBasic(t *testing.T) {
 
 	apifw, err := NewAPIFirewall(
@@ -319,6 +361,14 @@ func TestAPIFWBasic(t *testing.T) {
 
 	validate500UnknownCTReq(t, apifw, defaultIntSchemaID)
 
+	validateResponseReq(t, apifw, defaultIntSchemaID, 200, 200, nil)
+
+	validateResponseReq(t, apifw, defaultIntSchemaID, 500, 403, []string{validator.ErrCodeResponseStatusCodeNotFound})
+
+	validateRequestResponseReq(t, apifw, defaultIntSchemaID, "/?str=test", 200, 200, nil)
+
+	validateRequestResponseReq(t, apifw, defaultIntSchemaID, "/", 500, 403, []string{validator.ErrCodeRequiredQueryParameterMissed, validator.ErrCodeResponseStatusCodeNotFound})
+
 }
 
 func TestAPIFWBasicUpdate(t *testing.T) {
@@ -22,7 +22,7 @@ type RequestValidator struct {
 	Options       *Configuration
 }
 
-// APIModeHandler finds route in the OpenAPI spec and validates request
+// APIModeHandler finds route in the OpenAPI spec and validates request and/or response according to the validation target
 func (rv *RequestValidator) APIModeHandler(ctx *fasthttp.RequestCtx) (err error) {
 
 	// handle panic
@@ -50,9 +50,23 @@ func (rv *RequestValidator) APIModeHandler(ctx *fasthttp.RequestCtx) (err error)
 		return nil
 	}
 
-	validationErrors, err := apiMode.APIModeValidateRequest(ctx, rv.Metrics, rv.SchemaID, rv.ParserPool, rv.CustomRoute, rv.Options.UnknownParametersDetection)
-	if err != nil {
-		return err
+	var validationErrors []*validator.ValidationError
+	target := validator.GetValidationTarget(ctx)
+
+	if target&validator.TargetRequest != 0 {
+		requestErrors, err := apiMode.APIModeValidateRequest(ctx, rv.Metrics, rv.SchemaID, rv.ParserPool, rv.CustomRoute, rv.Options.UnknownParametersDetection)
+		if err != nil {
+			return err
+		}
+		validationErrors = append(validationErrors, requestErrors...)
+	}
+
+	if target&validator.TargetResponse != 0 {
+		responseErrors, err := apiMode.APIModeValidateResponse(ctx, rv.Metrics, rv.SchemaID, rv.ParserPool, rv.CustomRoute)
+		if err != nil {
+			return err
+		}
+		validationErrors = append(validationErrors, responseErrors...)
 	}
 
 	// Respond 403 with errors
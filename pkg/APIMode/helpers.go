@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 
+	"github.com/valyala/fasthttp"
 	"github.com/valyala/fastjson"
 
 	"github.com/wallarm/api-firewall/internal/platform/loader"
@@ -88,3 +89,35 @@ func getRouters(specStorage storage.DBOpenAPILoader, parserPool *fastjson.Parser
 
 	return routers, nil
 }
+
+// newRequestCtx function builds the request context with the provided request data
+func newRequestCtx(uri, method, body []byte, headers map[string][]string) *fasthttp.RequestCtx {
+	ctx := new(fasthttp.RequestCtx)
+
+	ctx.Request.Header.SetRequestURIBytes(uri)
+	ctx.Request.Header.SetMethodBytes(method)
+	ctx.Request.SetBody(body)
+
+	for hName, hValues := range headers {
+		for _, hValue := range hValues {
+			ctx.Request.Header.Add(hName, hValue)
+		}
+	}
+
+	return ctx
+}
+
+// setResponse function sets the provided response data to the request context
+func setResponse(ctx *fasthttp.RequestCtx, statusCode int, body []byte, headers map[string][]string) {
+	ctx.Response.SetStatusCode(statusCode)
+	ctx.Response.SetBody(body)
+
+	// the content type is not set by default
+	ctx.Response.Header.SetNoDefaultContentType(true)
+
+	for hName, hValues := range headers {
+		for _, hValue := range hValues {
+			ctx.Response.Header.Add(hName, hValue)
+		}
+	}
+}
@@ -20,6 +20,16 @@ const (
 	ErrCodeRequiredCookieParameterInvalidValue = "required_cookie_parameter_invalid_value"
 	ErrCodeRequiredHeaderMissed                = "required_header_missed"
 	ErrCodeRequiredHeaderInvalidValue          = "required_header_invalid_value"
+	ErrCodeRequiredBodyLimitExceeded           = "required_body_limit_exceeded"
+	ErrCodeRequiredBodyDuplicateKey            = "required_body_duplicate_key"
+
+	ErrCodeResponseStatusCodeNotFound                = "response_status_code_not_found"
+	ErrCodeResponseContentTypeNotFound               = "response_content_type_not_found"
+	ErrCodeResponseBodyParseError                    = "response_body_parse_error"
+	ErrCodeRequiredResponseBodyParameterMissed       = "required_response_body_parameter_missed"
+	ErrCodeRequiredResponseBodyParameterInvalidValue = "required_response_body_parameter_invalid_value"
+	ErrCodeRequiredResponseHeaderMissed              = "required_response_header_missed"
+	ErrCodeRequiredResponseHeaderInvalidValue        = "required_response_header_invalid_value"
 
 	ErrCodeSecRequirementsFailed = "required_security_requirements_failed"
 
@@ -34,6 +44,7 @@ var (
 	ErrAPITokenMissed           = errors.New("missing API keys for authorization")
 	ErrRequiredBodyIsMissing    = errors.New("required body is missing")
 	ErrMissedRequiredParameters = errors.New("required parameters missed")
+	ErrResponseStatusNotFound   = errors.New("response status code is not found")
 
 	ErrSchemaNotFound = fmt.Errorf("schema not found")
 	ErrRequestParsing = fmt.Errorf("request parsing error")
@@ -6,6 +6,7 @@ type FieldTypeError struct {
 	Name         string `json:"name"`
 	ExpectedType string `json:"expected_type,omitempty"`
 	Pattern      string `json:"pattern,omitempty"`
+	Format       string `json:"format,omitempty"`
 	CurrentValue string `json:"current_value,omitempty"`
 }
 
@@ -15,8 +15,31 @@ import (
 const (
 	APIModePostfixStatusCode       = "_status_code"
 	APIModePostfixValidationErrors = "_validation_errors"
+
+	// APIModeValidationTargetKey is the key of the request context value which
+	// holds the ValidationTarget. The request is validated if it is not set
+	APIModeValidationTargetKey = "api_mode_validation_target"
 )
 
+// ValidationTarget defines the parts of the request/response pair that should
+// be validated against the specification
+type ValidationTarget int
+
+const (
+	TargetRequest ValidationTarget = 1 << iota
+	TargetResponse
+
+	TargetRequestResponse = TargetRequest | TargetResponse
+)
+
+// GetValidationTarget returns the validation target of the request
+func GetValidationTarget(ctx *fasthttp.RequestCtx) ValidationTarget {
+	if target, ok := ctx.UserValue(APIModeValidationTargetKey).(ValidationTarget); ok {
+		return target
+	}
+	return TargetRequest
+}
+
 var (
 	StatusOK                  int = fasthttp.StatusOK
 	StatusForbidden           int = fasthttp.StatusForbidden